@@ -0,0 +1,158 @@
+package achievement
+
+import "github.com/leblancjs/stmoosersburg-api/entity"
+
+// EventType represents something a player did in a game.
+type EventType string
+
+const (
+	// EventPlayed is caused by every player of a finished game.
+	EventPlayed EventType = "played"
+	// EventWon is caused by the winners of a game.
+	EventWon EventType = "won"
+	// EventOwned is caused for every property a player owned when the game
+	// finished, which is its subject.
+	EventOwned EventType = "owned"
+	// EventBankrupted is caused by a player who bankrupted another, who is
+	// its subject.
+	EventBankrupted EventType = "bankrupted"
+)
+
+// Event represents something a player did in a game, such as owning a
+// property, or bankrupting another player.
+type Event struct {
+	Type    EventType
+	UserID  string
+	Subject string
+}
+
+// eventsOf returns the events caused by the players of the finished game.
+func eventsOf(game entity.Game) []Event {
+	events := make([]Event, 0)
+
+	for _, r := range game.Results {
+		events = append(events, Event{Type: EventPlayed, UserID: r.UserID})
+
+		if r.Rank == 1 {
+			events = append(events, Event{Type: EventWon, UserID: r.UserID})
+		}
+
+		for _, property := range r.Properties {
+			events = append(events, Event{Type: EventOwned, UserID: r.UserID, Subject: property})
+		}
+
+		if r.BankruptedBy != "" {
+			events = append(events, Event{Type: EventBankrupted, UserID: r.BankruptedBy, Subject: r.UserID})
+		}
+	}
+
+	return events
+}
+
+// Scope represents over how many games an achievement can be unlocked.
+type Scope string
+
+const (
+	// ScopeGame achievements must be unlocked within a single game.
+	ScopeGame Scope = "game"
+	// ScopeCareer achievements are unlocked over as many games as it takes,
+	// so players progress towards them.
+	ScopeCareer Scope = "career"
+)
+
+// Rule represents what a player must do to unlock an achievement: cause
+// Count events of a type, whose subject is one of Subjects, when there are
+// any, within the Scope.
+type Rule struct {
+	Event    EventType
+	Subjects []string
+	Count    int
+	Scope    Scope
+}
+
+// count returns the number of events the user caused that the rule counts.
+func (r Rule) count(userID string, events []Event) int {
+	count := 0
+
+	for _, e := range events {
+		if e.UserID == userID && e.Type == r.Event && r.matches(e.Subject) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (r Rule) matches(subject string) bool {
+	if len(r.Subjects) == 0 {
+		return true
+	}
+
+	for _, s := range r.Subjects {
+		if s == subject {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Achievement represents something players can be rewarded for, such as
+// owning every waterfront property.
+type Achievement struct {
+	// ID identifies the achievement in storage, so it must never change.
+	ID          string
+	Name        string
+	Description string
+	Rule        Rule
+}
+
+// Waterfront are the properties along the shore of St. Moosersburg, named as
+// they are reported in game results.
+var Waterfront = []string{"Moose Lake", "Elk Harbour", "Beaver Wharf", "Loon Marina"}
+
+// Catalog lists the achievements players can unlock.
+var Catalog = []Achievement{
+	{
+		ID:          "first-game",
+		Name:        "Fresh Tracks",
+		Description: "Finish your first game.",
+		Rule:        Rule{Event: EventPlayed, Count: 1, Scope: ScopeCareer},
+	},
+	{
+		ID:          "first-win",
+		Name:        "Top Moose",
+		Description: "Win a game.",
+		Rule:        Rule{Event: EventWon, Count: 1, Scope: ScopeCareer},
+	},
+	{
+		ID:          "veteran",
+		Name:        "Old Bull",
+		Description: "Finish 100 games.",
+		Rule:        Rule{Event: EventPlayed, Count: 100, Scope: ScopeCareer},
+	},
+	{
+		ID:          "herd-leader",
+		Name:        "Herd Leader",
+		Description: "Win 25 games.",
+		Rule:        Rule{Event: EventWon, Count: 25, Scope: ScopeCareer},
+	},
+	{
+		ID:          "waterfront-baron",
+		Name:        "Waterfront Baron",
+		Description: "Own all waterfront tiles at the end of a game.",
+		Rule:        Rule{Event: EventOwned, Subjects: Waterfront, Count: len(Waterfront), Scope: ScopeGame},
+	},
+	{
+		ID:          "moose-hunter",
+		Name:        "Moose Hunter",
+		Description: "Bankrupt three moose in one game.",
+		Rule:        Rule{Event: EventBankrupted, Count: 3, Scope: ScopeGame},
+	},
+	{
+		ID:          "tycoon",
+		Name:        "Tycoon",
+		Description: "Bankrupt 50 moose.",
+		Rule:        Rule{Event: EventBankrupted, Count: 50, Scope: ScopeCareer},
+	},
+}
@@ -0,0 +1,53 @@
+package achievement
+
+import (
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func TestCatalog(t *testing.T) {
+	t.Run("has unique IDs and achievable rules", func(t *testing.T) {
+		seen := make(map[string]bool)
+
+		for _, a := range Catalog {
+			if a.ID == "" || seen[a.ID] {
+				t.Errorf("expected achievement ID \"%s\" to be unique", a.ID)
+			}
+			if a.Rule.Count < 1 || (len(a.Rule.Subjects) > 0 && a.Rule.Scope == ScopeGame && a.Rule.Count > len(a.Rule.Subjects)) {
+				t.Errorf("expected achievement \"%s\" to be achievable", a.ID)
+			}
+			if a.Rule.Scope != ScopeGame && a.Rule.Scope != ScopeCareer {
+				t.Errorf("expected achievement \"%s\" to have a scope", a.ID)
+			}
+
+			seen[a.ID] = true
+		}
+	})
+}
+
+func TestEvaluatingRule(t *testing.T) {
+	events := eventsOf(entity.Game{
+		Results: []entity.PlayerResult{
+			{UserID: "0", Rank: 1, Properties: []string{"Moose Lake", "Elk Harbour", "Antler Avenue"}},
+			{UserID: "1", Rank: 2, BankruptedBy: "0"},
+			{UserID: "2", Rank: 2, BankruptedBy: "0"},
+		},
+	})
+
+	t.Run("counts the events of the user", func(t *testing.T) {
+		rule := Rule{Event: EventBankrupted, Count: 3, Scope: ScopeGame}
+
+		if rule.count("0", events) != 2 || rule.count("1", events) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("only counts the events whose subject is in the rule", func(t *testing.T) {
+		rule := Rule{Event: EventOwned, Subjects: Waterfront, Count: len(Waterfront), Scope: ScopeGame}
+
+		if rule.count("0", events) != 2 {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package achievement
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type listAchievementsRequest struct {
+	UserID string
+}
+
+type achievementResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Progress    int        `json:"progress"`
+	Goal        int        `json:"goal"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlockedAt,omitempty"`
+}
+
+type listAchievementsResponse struct {
+	Achievements []achievementResponse `json:"achievements"`
+}
+
+func makeListAchievementsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listAchievementsRequest)
+
+		// Players compare achievements with each other, so signed in
+		// players can see everyone's, not only their own.
+		if _, ok := auth.FromContext(ctx); !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		statuses, err := svc.List(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &listAchievementsResponse{
+			Achievements: make([]achievementResponse, 0, len(statuses)),
+		}
+		for _, s := range statuses {
+			resp.Achievements = append(resp.Achievements, achievementResponse{
+				ID:          s.ID,
+				Name:        s.Name,
+				Description: s.Description,
+				Progress:    s.Progress,
+				Goal:        s.Rule.Count,
+				Unlocked:    s.UnlockedAt != nil,
+				UnlockedAt:  s.UnlockedAt,
+			})
+		}
+
+		return resp, nil
+	}
+}
@@ -0,0 +1,114 @@
+package achievement
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inMemoryRepository struct {
+	database *db.InMemory
+}
+
+func NewInMemoryRepository(database *db.InMemory) Repository {
+	return &inMemoryRepository{database}
+}
+
+func (repo *inMemoryRepository) ListUnlocks(userID string) ([]entity.AchievementUnlock, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	unlocks := make([]entity.AchievementUnlock, 0)
+
+	for _, u := range repo.database.AchievementUnlocks {
+		if u.UserID == userID {
+			unlocks = append(unlocks, u)
+		}
+	}
+
+	sort.SliceStable(unlocks, func(i, j int) bool {
+		return unlocks[i].UnlockedAt.Before(unlocks[j].UnlockedAt)
+	})
+
+	return unlocks, nil
+}
+
+func (repo *inMemoryRepository) ListProgress(userID string) ([]entity.AchievementProgress, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	progress := make([]entity.AchievementProgress, 0)
+
+	for _, p := range repo.database.AchievementProgress {
+		if p.UserID == userID {
+			progress = append(progress, p)
+		}
+	}
+
+	return progress, nil
+}
+
+func (repo *inMemoryRepository) IsCounted(userID string, gameID string) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	return repo.isCounted(userID, gameID), nil
+}
+
+func (repo *inMemoryRepository) Save(counted []entity.AchievementGame, progress []entity.AchievementProgress, unlocks []entity.AchievementUnlock) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, g := range counted {
+		if repo.isCounted(g.UserID, g.GameID) {
+			return fmt.Errorf("achievement.InMemoryRepository.Save: game \"%s\" was already counted for user \"%s\"", g.GameID, g.UserID)
+		}
+	}
+	repo.database.AchievementGames = append(repo.database.AchievementGames, counted...)
+
+	for _, progressed := range progress {
+		saved := false
+
+		for i, p := range repo.database.AchievementProgress {
+			if p.UserID == progressed.UserID && p.AchievementID == progressed.AchievementID {
+				repo.database.AchievementProgress[i] = progressed
+				saved = true
+				break
+			}
+		}
+
+		if !saved {
+			repo.database.AchievementProgress = append(repo.database.AchievementProgress, progressed)
+		}
+	}
+
+	for _, unlock := range unlocks {
+		if !repo.isUnlocked(unlock.UserID, unlock.AchievementID) {
+			repo.database.AchievementUnlocks = append(repo.database.AchievementUnlocks, unlock)
+		}
+	}
+
+	return nil
+}
+
+func (repo *inMemoryRepository) isCounted(userID string, gameID string) bool {
+	for _, g := range repo.database.AchievementGames {
+		if g.UserID == userID && g.GameID == gameID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (repo *inMemoryRepository) isUnlocked(userID string, achievementID string) bool {
+	for _, u := range repo.database.AchievementUnlocks {
+		if u.UserID == userID && u.AchievementID == achievementID {
+			return true
+		}
+	}
+
+	return false
+}
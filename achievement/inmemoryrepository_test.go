@@ -0,0 +1,78 @@
+package achievement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func newInMemoryRepository() Repository {
+	database := &db.InMemory{}
+	database.Open()
+
+	return NewInMemoryRepository(database)
+}
+
+func TestInMemoryRepositorySaving(t *testing.T) {
+	t.Run("keeps the time achievements were first unlocked at", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		first := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+		repo.Save(nil, nil, []entity.AchievementUnlock{{UserID: "0", AchievementID: "first-win", UnlockedAt: first}})
+
+		if err := repo.Save(nil, nil, []entity.AchievementUnlock{{UserID: "0", AchievementID: "first-win", UnlockedAt: time.Now()}}); err != nil {
+			t.FailNow()
+		}
+
+		unlocks, _ := repo.ListUnlocks("0")
+		if len(unlocks) != 1 || !unlocks[0].UnlockedAt.Equal(first) {
+			t.Fail()
+		}
+	})
+
+	t.Run("replaces the progress that already exists", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Save(nil, []entity.AchievementProgress{{UserID: "0", AchievementID: "veteran", Count: 1}}, nil)
+
+		if err := repo.Save(nil, []entity.AchievementProgress{{UserID: "0", AchievementID: "veteran", Count: 2}}, nil); err != nil {
+			t.FailNow()
+		}
+
+		progress, _ := repo.ListProgress("0")
+		if len(progress) != 1 || progress[0].Count != 2 {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryCountingGames(t *testing.T) {
+	counted := []entity.AchievementGame{{UserID: "0", GameID: "a.game"}}
+
+	t.Run("records the games that were counted", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if isCounted, _ := repo.IsCounted("0", "a.game"); isCounted {
+			t.FailNow()
+		}
+
+		repo.Save(counted, nil, nil)
+
+		if isCounted, _ := repo.IsCounted("0", "a.game"); !isCounted {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without saving progress when a game was already counted", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Save(counted, nil, nil)
+
+		if err := repo.Save(counted, []entity.AchievementProgress{{UserID: "0", AchievementID: "veteran", Count: 2}}, nil); err == nil {
+			t.FailNow()
+		}
+
+		if progress, _ := repo.ListProgress("0"); len(progress) != 0 {
+			t.Fail()
+		}
+	})
+}
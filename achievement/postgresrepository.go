@@ -0,0 +1,135 @@
+package achievement
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+const (
+	listUnlocksQuery  = "SELECT user_id, achievement_id, unlocked_at FROM achievement_unlocks WHERE user_id = $1 ORDER BY unlocked_at, achievement_id"
+	listProgressQuery = "SELECT user_id, achievement_id, count FROM achievement_progress WHERE user_id = $1"
+	saveProgressQuery = "INSERT INTO achievement_progress(user_id, achievement_id, count) VALUES($1, $2, $3) ON CONFLICT (user_id, achievement_id) DO UPDATE SET count = EXCLUDED.count"
+	unlockQuery       = "INSERT INTO achievement_unlocks(user_id, achievement_id, unlocked_at) VALUES($1, $2, $3) ON CONFLICT DO NOTHING"
+	isCountedQuery    = "SELECT EXISTS(SELECT 1 FROM achievement_games WHERE user_id = $1 AND game_id = $2)"
+	countQuery        = "INSERT INTO achievement_games(user_id, game_id) VALUES($1, $2)"
+)
+
+type postgresRepository struct {
+	database *db.Postgres
+}
+
+func NewPostgresRepository(database *db.Postgres) Repository {
+	return &postgresRepository{database}
+}
+
+func (pr *postgresRepository) ListUnlocks(userID string) ([]entity.AchievementUnlock, error) {
+	rows, err := pr.database.Query(listUnlocksQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"achievement.PostgresRepository.ListUnlocks: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	unlocks := make([]entity.AchievementUnlock, 0)
+	for rows.Next() {
+		var u entity.AchievementUnlock
+		if err := rows.Scan(&u.UserID, &u.AchievementID, &u.UnlockedAt); err != nil {
+			return nil, fmt.Errorf(
+				"achievement.PostgresRepository.ListUnlocks: failed to read unlock (%s)",
+				err,
+			)
+		}
+
+		unlocks = append(unlocks, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"achievement.PostgresRepository.ListUnlocks: failed to read unlocks (%s)",
+			err,
+		)
+	}
+
+	return unlocks, nil
+}
+
+func (pr *postgresRepository) ListProgress(userID string) ([]entity.AchievementProgress, error) {
+	rows, err := pr.database.Query(listProgressQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"achievement.PostgresRepository.ListProgress: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	progress := make([]entity.AchievementProgress, 0)
+	for rows.Next() {
+		var p entity.AchievementProgress
+		if err := rows.Scan(&p.UserID, &p.AchievementID, &p.Count); err != nil {
+			return nil, fmt.Errorf(
+				"achievement.PostgresRepository.ListProgress: failed to read progress (%s)",
+				err,
+			)
+		}
+
+		progress = append(progress, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"achievement.PostgresRepository.ListProgress: failed to read progress (%s)",
+			err,
+		)
+	}
+
+	return progress, nil
+}
+
+func (pr *postgresRepository) IsCounted(userID string, gameID string) (bool, error) {
+	var counted bool
+	if err := pr.database.QueryRow(isCountedQuery, userID, gameID).Scan(&counted); err != nil {
+		return false, fmt.Errorf(
+			"achievement.PostgresRepository.IsCounted: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return counted, nil
+}
+
+func (pr *postgresRepository) Save(counted []entity.AchievementGame, progress []entity.AchievementProgress, unlocks []entity.AchievementUnlock) error {
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		// Games that were already counted violate the primary key, which
+		// rolls back the progress counted again.
+		for _, g := range counted {
+			if _, err := tx.Exec(countQuery, g.UserID, g.GameID); err != nil {
+				return fmt.Errorf("failed to count game (%s)", err)
+			}
+		}
+
+		for _, p := range progress {
+			if _, err := tx.Exec(saveProgressQuery, p.UserID, p.AchievementID, p.Count); err != nil {
+				return fmt.Errorf("failed to save progress (%s)", err)
+			}
+		}
+
+		for _, u := range unlocks {
+			if _, err := tx.Exec(unlockQuery, u.UserID, u.AchievementID, u.UnlockedAt); err != nil {
+				return fmt.Errorf("failed to unlock achievement (%s)", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("achievement.PostgresRepository.Save: %s", err)
+	}
+
+	return nil
+}
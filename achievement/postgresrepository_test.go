@@ -0,0 +1,160 @@
+package achievement
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func TestPostgresRepositoryListing(t *testing.T) {
+	t.Run("returns the unlocks of the user", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listUnlocksQuery).
+			WithArgs("0").
+			WillReturnRows(mock.NewRows([]string{"user_id", "achievement_id", "unlocked_at"}).AddRow("0", "first-win", time.Now()))
+
+		unlocks, err := pr.ListUnlocks("0")
+		if err != nil || len(unlocks) != 1 || unlocks[0].AchievementID != "first-win" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the progress of the user", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listProgressQuery).
+			WithArgs("0").
+			WillReturnRows(mock.NewRows([]string{"user_id", "achievement_id", "count"}).AddRow("0", "veteran", 12))
+
+		progress, err := pr.ListProgress("0")
+		if err != nil || len(progress) != 1 || progress[0].Count != 12 {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositorySaving(t *testing.T) {
+	unlockedAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	progress := []entity.AchievementProgress{{UserID: "0", AchievementID: "veteran", Count: 1}}
+	unlocks := []entity.AchievementUnlock{{UserID: "0", AchievementID: "first-game", UnlockedAt: unlockedAt}}
+	counted := []entity.AchievementGame{{UserID: "0", GameID: "a.game"}}
+
+	t.Run("rolls back when an achievement cannot be unlocked", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(countQuery).
+			WithArgs("0", "a.game").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(saveProgressQuery).
+			WithArgs("0", "veteran", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(unlockQuery).
+			WithArgs("0", "first-game", unlockedAt).
+			WillReturnError(fmt.Errorf("a database error"))
+		mock.ExpectRollback()
+
+		if err := pr.Save(counted, progress, unlocks); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("rolls back when a game was already counted", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(countQuery).
+			WithArgs("0", "a.game").
+			WillReturnError(fmt.Errorf("duplicate key value violates unique constraint"))
+		mock.ExpectRollback()
+
+		if err := pr.Save(counted, progress, unlocks); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("saves the progress and unlocks when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(countQuery).
+			WithArgs("0", "a.game").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(saveProgressQuery).
+			WithArgs("0", "veteran", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(unlockQuery).
+			WithArgs("0", "first-game", unlockedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := pr.Save(counted, progress, unlocks); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryCheckingCountedGames(t *testing.T) {
+	t.Run("tells whether the game was counted for the user", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(isCountedQuery).
+			WithArgs("0", "a.game").
+			WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+
+		if isCounted, err := pr.IsCounted("0", "a.game"); err != nil || !isCounted {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,39 @@
+// Package achievement rewards players with achievements for what they do in
+// the games they finish.
+package achievement
+
+import (
+	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type Repository interface {
+	// ListUnlocks returns the achievements the user unlocked, from the first
+	// unlocked to the last.
+	ListUnlocks(userID string) ([]entity.AchievementUnlock, error)
+	// ListProgress returns the user's progress towards the achievements that
+	// take more than one game.
+	ListProgress(userID string) ([]entity.AchievementProgress, error)
+	// IsCounted tells whether the game was counted towards the user's
+	// achievements.
+	IsCounted(userID string, gameID string) (bool, error)
+
+	// Save records that the games were counted, creates or replaces the
+	// progress, and unlocks the achievements, all at once. It fails without
+	// saving anything when a game was already counted for a player, so that
+	// its progress is never added twice. Achievements that are already
+	// unlocked keep the time they were first unlocked at.
+	Save(counted []entity.AchievementGame, progress []entity.AchievementProgress, unlocks []entity.AchievementUnlock) error
+}
+
+func NewRepository(database db.DB) (Repository, error) {
+	if inmemory, ok := database.(*db.InMemory); ok {
+		return NewInMemoryRepository(inmemory), nil
+	} else if postgres, ok := database.(*db.Postgres); ok {
+		return NewPostgresRepository(postgres), nil
+	}
+
+	return nil, fmt.Errorf("achievement.NewRepository: unsupported database type")
+}
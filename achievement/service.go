@@ -0,0 +1,191 @@
+package achievement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var ErrNotFound = errors.New("user does not exist")
+
+// Status represents a player's progress towards an achievement.
+type Status struct {
+	Achievement
+	// Progress is the number of events the player caused towards the
+	// achievement, which is its rule's count once it is unlocked. It stays
+	// at zero for achievements that must be unlocked within a single game.
+	Progress int
+	// UnlockedAt is when the player unlocked the achievement, or nil if they
+	// have not unlocked it yet.
+	UnlockedAt *time.Time
+}
+
+type Service interface {
+	// List returns the user's status for every achievement of the catalog,
+	// in the order of the catalog.
+	List(ctx context.Context, userID string) ([]Status, error)
+
+	// Update evaluates the achievements of the catalog for the players of
+	// the finished game, provided it is rated, and unlocks those they
+	// earned. Achievements are only ever unlocked once.
+	Update(game entity.Game) error
+}
+
+type service struct {
+	repo    Repository
+	userSvc user.Service
+	catalog []Achievement
+
+	// mu keeps a player's progress from being read by one update while
+	// another is still adding a game to it, since progress is saved as a
+	// total rather than incremented.
+	mu sync.Mutex
+}
+
+func NewService(repo Repository, userSvc user.Service) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("achievement.NewService: repository is required")
+	}
+	if userSvc == nil {
+		return nil, fmt.Errorf("achievement.NewService: user service is required")
+	}
+
+	return &service{
+		repo:    repo,
+		userSvc: userSvc,
+		catalog: Catalog,
+	}, nil
+}
+
+func (svc *service) List(ctx context.Context, userID string) ([]Status, error) {
+	if _, err := svc.userSvc.GetByID(ctx, userID); err == user.ErrNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("achievement.Service.List: %s", err)
+	}
+
+	unlocked, progress, err := svc.get(userID)
+	if err != nil {
+		return nil, fmt.Errorf("achievement.Service.List: %s", err)
+	}
+
+	statuses := make([]Status, 0, len(svc.catalog))
+	for _, a := range svc.catalog {
+		status := Status{
+			Achievement: a,
+			Progress:    progress[a.ID],
+		}
+
+		if unlockedAt, ok := unlocked[a.ID]; ok {
+			status.Progress = a.Rule.Count
+			status.UnlockedAt = &unlockedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (svc *service) Update(game entity.Game) error {
+	if !game.Rated() {
+		return nil
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	events := eventsOf(game)
+
+	var counted []entity.AchievementGame
+	var progressed []entity.AchievementProgress
+	var unlocks []entity.AchievementUnlock
+
+	for _, result := range game.Results {
+		// Games only count once towards each player's progress.
+		isCounted, err := svc.repo.IsCounted(result.UserID, game.ID)
+		if err != nil {
+			return fmt.Errorf("achievement.Service.Update: %s", err)
+		}
+		if isCounted {
+			continue
+		}
+
+		counted = append(counted, entity.AchievementGame{UserID: result.UserID, GameID: game.ID})
+
+		unlocked, progress, err := svc.get(result.UserID)
+		if err != nil {
+			return fmt.Errorf("achievement.Service.Update: %s", err)
+		}
+
+		for _, a := range svc.catalog {
+			if _, ok := unlocked[a.ID]; ok {
+				continue
+			}
+
+			count := a.Rule.count(result.UserID, events)
+			if count == 0 {
+				continue
+			}
+
+			if a.Rule.Scope == ScopeCareer {
+				count += progress[a.ID]
+
+				progressed = append(progressed, entity.AchievementProgress{
+					UserID:        result.UserID,
+					AchievementID: a.ID,
+					Count:         count,
+				})
+			}
+
+			if count >= a.Rule.Count {
+				unlocks = append(unlocks, entity.AchievementUnlock{
+					UserID:        result.UserID,
+					AchievementID: a.ID,
+					UnlockedAt:    game.FinishedAt,
+				})
+			}
+		}
+	}
+
+	if len(counted) == 0 {
+		return nil
+	}
+
+	if err := svc.repo.Save(counted, progressed, unlocks); err != nil {
+		return fmt.Errorf("achievement.Service.Update: %s", err)
+	}
+
+	return nil
+}
+
+// get returns when the user unlocked each achievement, and their progress
+// towards those that take more than one game, by achievement ID.
+func (svc *service) get(userID string) (map[string]time.Time, map[string]int, error) {
+	unlocks, err := svc.repo.ListUnlocks(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unlocked := make(map[string]time.Time, len(unlocks))
+	for _, u := range unlocks {
+		unlocked[u.AchievementID] = u.UnlockedAt
+	}
+
+	progress, err := svc.repo.ListProgress(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[string]int, len(progress))
+	for _, p := range progress {
+		counts[p.AchievementID] = p.Count
+	}
+
+	return unlocked, counts, nil
+}
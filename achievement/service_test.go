@@ -0,0 +1,228 @@
+package achievement
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var finishedAt = time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+// newService creates a service for users whose IDs are numbered from zero.
+func newService(t *testing.T, users int) Service {
+	database := &db.InMemory{}
+	database.Open()
+
+	hashSvc, _ := hash.NewService(hash.NewBCryptProvider())
+
+	userRepo := user.NewInMemoryRepository(database)
+	for i := 0; i < users; i++ {
+		userRepo.Create(context.Background(), "Moose"+strconv.Itoa(i), strconv.Itoa(i)+"@stmoosersburg.com", "a.hashed.password")
+	}
+	userSvc, _ := user.NewService(userRepo, hashSvc)
+
+	svc, err := NewService(NewInMemoryRepository(database), userSvc)
+	if err != nil {
+		t.Fatalf("failed to create service (%s)", err)
+	}
+
+	return svc
+}
+
+// failingUserService fails to get users.
+type failingUserService struct {
+	user.Service
+}
+
+func (mock *failingUserService) GetByID(_ context.Context, _ string) (*entity.User, error) {
+	return nil, fmt.Errorf("failed to get user by ID")
+}
+
+// finishedGame returns a finished matchmade game with the results.
+func finishedGame(results ...entity.PlayerResult) entity.Game {
+	g := entity.Game{
+		ID:         "a.game",
+		Status:     entity.GameStatusFinished,
+		Matchmade:  true,
+		Results:    results,
+		FinishedAt: finishedAt,
+	}
+
+	for _, r := range results {
+		g.PlayerIDs = append(g.PlayerIDs, r.UserID)
+	}
+
+	return g
+}
+
+// statusOf returns the status of the achievement among the statuses.
+func statusOf(statuses []Status, achievementID string) Status {
+	for _, s := range statuses {
+		if s.ID == achievementID {
+			return s
+		}
+	}
+
+	return Status{}
+}
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when dependencies are missing", func(t *testing.T) {
+		if _, err := NewService(nil, nil); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceListingAchievements(t *testing.T) {
+	t.Run("fails when user does not exist", func(t *testing.T) {
+		svc := newService(t, 1)
+
+		if _, err := svc.List(context.Background(), "1"); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without blaming the user when getting them fails", func(t *testing.T) {
+		svc, _ := NewService(NewInMemoryRepository(&db.InMemory{}), &failingUserService{})
+
+		if _, err := svc.List(context.Background(), "0"); err == nil || err == ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("lists the whole catalog when user has unlocked nothing", func(t *testing.T) {
+		svc := newService(t, 1)
+
+		statuses, err := svc.List(context.Background(), "0")
+		if err != nil || len(statuses) != len(Catalog) {
+			t.FailNow()
+		}
+		for _, s := range statuses {
+			if s.UnlockedAt != nil || s.Progress != 0 {
+				t.Fail()
+			}
+		}
+	})
+}
+
+func TestServiceUpdatingAchievements(t *testing.T) {
+	t.Run("ignores games that are not matchmade", func(t *testing.T) {
+		svc := newService(t, 2)
+		g := finishedGame(
+			entity.PlayerResult{UserID: "0", Rank: 1},
+			entity.PlayerResult{UserID: "1", Rank: 2},
+		)
+		g.Matchmade = false
+
+		svc.Update(g)
+
+		statuses, _ := svc.List(context.Background(), "0")
+		if statusOf(statuses, "first-game").UnlockedAt != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("unlocks the achievements earned within the game", func(t *testing.T) {
+		svc := newService(t, 4)
+
+		err := svc.Update(finishedGame(
+			entity.PlayerResult{UserID: "0", Rank: 1, Properties: Waterfront},
+			entity.PlayerResult{UserID: "1", Rank: 2, BankruptedBy: "0"},
+			entity.PlayerResult{UserID: "2", Rank: 2, BankruptedBy: "0"},
+			entity.PlayerResult{UserID: "3", Rank: 2, BankruptedBy: "0"},
+		))
+		if err != nil {
+			t.FailNow()
+		}
+
+		statuses, _ := svc.List(context.Background(), "0")
+		for _, id := range []string{"first-game", "first-win", "waterfront-baron", "moose-hunter"} {
+			if s := statusOf(statuses, id); s.UnlockedAt == nil || !s.UnlockedAt.Equal(finishedAt) {
+				t.Errorf("expected \"%s\" to be unlocked", id)
+			}
+		}
+		if s := statusOf(statuses, "tycoon"); s.UnlockedAt != nil || s.Progress != 3 {
+			t.Fail()
+		}
+
+		statuses, _ = svc.List(context.Background(), "1")
+		if statusOf(statuses, "first-game").UnlockedAt == nil || statusOf(statuses, "first-win").UnlockedAt != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("accumulates progress across games", func(t *testing.T) {
+		svc := newService(t, 2)
+
+		for i := 0; i < 24; i++ {
+			game := finishedGame(
+				entity.PlayerResult{UserID: "0", Rank: 1},
+				entity.PlayerResult{UserID: "1", Rank: 2},
+			)
+			game.ID = strconv.Itoa(i)
+
+			svc.Update(game)
+		}
+
+		statuses, _ := svc.List(context.Background(), "0")
+		if s := statusOf(statuses, "herd-leader"); s.UnlockedAt != nil || s.Progress != 24 {
+			t.FailNow()
+		}
+
+		svc.Update(finishedGame(
+			entity.PlayerResult{UserID: "0", Rank: 1},
+			entity.PlayerResult{UserID: "1", Rank: 2},
+		))
+
+		statuses, _ = svc.List(context.Background(), "0")
+		if s := statusOf(statuses, "herd-leader"); s.UnlockedAt == nil || s.Progress != 25 {
+			t.Fail()
+		}
+	})
+
+	t.Run("only unlocks achievements once", func(t *testing.T) {
+		svc := newService(t, 2)
+		game := finishedGame(
+			entity.PlayerResult{UserID: "0", Rank: 1},
+			entity.PlayerResult{UserID: "1", Rank: 2},
+		)
+		svc.Update(game)
+
+		game.ID = "another.game"
+		game.FinishedAt = finishedAt.Add(time.Hour)
+		svc.Update(game)
+
+		statuses, _ := svc.List(context.Background(), "0")
+		if s := statusOf(statuses, "first-win"); s.UnlockedAt == nil || !s.UnlockedAt.Equal(finishedAt) {
+			t.Fail()
+		}
+	})
+
+	t.Run("counts games towards progress only once", func(t *testing.T) {
+		svc := newService(t, 2)
+		game := finishedGame(
+			entity.PlayerResult{UserID: "0", Rank: 1},
+			entity.PlayerResult{UserID: "1", Rank: 2},
+		)
+
+		if err := svc.Update(game); err != nil {
+			t.FailNow()
+		}
+		if err := svc.Update(game); err != nil {
+			t.FailNow()
+		}
+
+		statuses, _ := svc.List(context.Background(), "0")
+		if s := statusOf(statuses, "herd-leader"); s.Progress != 1 {
+			t.Fail()
+		}
+	})
+}
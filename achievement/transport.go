@@ -0,0 +1,55 @@
+package achievement
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	listAchievementsHandler := stmhttp.NewHandler(
+		wrap("achievement.listAchievements")(makeListAchievementsEndpoint(svc)),
+		decodeListAchievementsRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/users/{id}/achievements", listAchievementsHandler).Methods("GET")
+
+	return r
+}
+
+func decodeListAchievementsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return listAchievementsRequest{
+		UserID: id,
+	}, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	}
+
+	return 0
+})
@@ -0,0 +1,72 @@
+package achievement
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns the achievements of any user to authenticated callers", func(t *testing.T) {
+		svc := newService(t, 2)
+		svc.Update(finishedGame(
+			entity.PlayerResult{UserID: "0", Rank: 1},
+			entity.PlayerResult{UserID: "1", Rank: 2},
+		))
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/v1/users/0/achievements", nil)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "1"})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(ctx))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var body listAchievementsResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Achievements) != len(Catalog) {
+			t.FailNow()
+		}
+		if a := body.Achievements[1]; a.ID != "first-win" || !a.Unlocked || a.UnlockedAt == nil || a.Progress != a.Goal {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails for anonymous callers", func(t *testing.T) {
+		handler := MakeHandler(newService(t, 1))
+
+		r := httptest.NewRequest("GET", "/v1/users/0/achievements", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		ErrNotFound:               http.StatusNotFound,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import "context"
+
+type contextKey int
+
+const identityContextKey contextKey = iota
+
+// Identity represents the authenticated caller of a request.
+type Identity struct {
+	// UserID represents the ID of the authenticated user.
+	UserID string
+}
+
+// NewContext returns a copy of the context that carries the given identity.
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey, identity)
+}
+
+// FromContext returns the identity carried by the context, if any.
+//
+// It returns false when the request was made by an anonymous caller.
+func FromContext(ctx context.Context) (Identity, bool) {
+	if ctx == nil {
+		return Identity{}, false
+	}
+
+	identity, ok := ctx.Value(identityContextKey).(Identity)
+
+	return identity, ok
+}
+
+// RequireUser checks that the caller is authenticated as the user with the
+// given ID.
+//
+// It returns ErrUnauthenticated when the caller is anonymous, and ErrForbidden
+// when they are someone else.
+func RequireUser(ctx context.Context, userID string) error {
+	identity, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if identity.UserID != userID {
+		return ErrForbidden
+	}
+
+	return nil
+}
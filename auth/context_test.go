@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestIdentityContext(t *testing.T) {
+	t.Run("returns false when context has no identity", func(t *testing.T) {
+		if _, ok := FromContext(context.Background()); ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when context is nil", func(t *testing.T) {
+		if _, ok := FromContext(nil); ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the identity stored in the context", func(t *testing.T) {
+		ctx := NewContext(context.Background(), Identity{UserID: "moose"})
+
+		identity, ok := FromContext(ctx)
+		if !ok {
+			t.FailNow()
+		}
+		if strings.Compare("moose", identity.UserID) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestRequiringUser(t *testing.T) {
+	t.Run("fails with unauthenticated when caller is anonymous", func(t *testing.T) {
+		if err := RequireUser(context.Background(), "moose"); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with forbidden when caller is another user", func(t *testing.T) {
+		ctx := NewContext(context.Background(), Identity{UserID: "elk"})
+
+		if err := RequireUser(ctx, "moose"); err != ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when caller is the user", func(t *testing.T) {
+		ctx := NewContext(context.Background(), Identity{UserID: "moose"})
+
+		if err := RequireUser(ctx, "moose"); err != nil {
+			t.Fail()
+		}
+	})
+}
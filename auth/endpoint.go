@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+// UserFunc returns the user with the given ID.
+type UserFunc func(ctx context.Context, id string) (*entity.User, error)
+
+// NewEndpointMiddleware creates a middleware that only lets the request
+// through when the caller's role grants all the permissions.
+//
+// The caller is looked up on every request, rather than trusting their access
+// token, so that changing their role or suspending them takes effect
+// immediately.
+func NewEndpointMiddleware(users UserFunc, permissions ...Permission) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			identity, ok := FromContext(ctx)
+			if !ok {
+				return nil, ErrUnauthenticated
+			}
+
+			u, err := users(ctx, identity.UserID)
+			if err != nil {
+				return nil, ErrUnauthenticated
+			}
+
+			if u.Suspended {
+				return nil, ErrForbidden
+			}
+
+			for _, p := range permissions {
+				if !HasPermission(u.Role, p) {
+					return nil, ErrForbidden
+				}
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func TestEndpointMiddleware(t *testing.T) {
+	ep := func(_ context.Context, request interface{}) (interface{}, error) {
+		return request, nil
+	}
+
+	users := map[string]entity.User{
+		"player":    {ID: "player", Role: entity.RolePlayer},
+		"admin":     {ID: "admin", Role: entity.RoleAdmin},
+		"suspended": {ID: "suspended", Role: entity.RoleAdmin, Suspended: true},
+	}
+	lookup := func(_ context.Context, id string) (*entity.User, error) {
+		u, ok := users[id]
+		if !ok {
+			return nil, fmt.Errorf("no user exists with ID \"%s\"", id)
+		}
+
+		return &u, nil
+	}
+	as := func(id string) context.Context {
+		return NewContext(context.Background(), Identity{UserID: id})
+	}
+
+	authorized := NewEndpointMiddleware(lookup, PermissionListUsers, PermissionSuspendUsers)(ep)
+
+	t.Run("fails with unauthenticated when caller is anonymous", func(t *testing.T) {
+		if _, err := authorized(context.Background(), nil); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with unauthenticated when caller no longer exists", func(t *testing.T) {
+		if _, err := authorized(as("ghost"), nil); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with forbidden when role lacks a permission", func(t *testing.T) {
+		if _, err := authorized(as("player"), nil); err != ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with forbidden when caller is suspended", func(t *testing.T) {
+		if _, err := authorized(as("suspended"), nil); err != ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("calls endpoint when role grants all permissions", func(t *testing.T) {
+		if resp, err := authorized(as("admin"), "request"); err != nil || resp != "request" {
+			t.Fail()
+		}
+	})
+
+	t.Run("calls endpoint for any user when no permissions are required", func(t *testing.T) {
+		authenticated := NewEndpointMiddleware(lookup)(ep)
+
+		if _, err := authenticated(as("player"), nil); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPermissions(t *testing.T) {
+	t.Run("admins can manage users", func(t *testing.T) {
+		for _, p := range []Permission{PermissionListUsers, PermissionChangeRoles, PermissionSuspendUsers, PermissionViewPrivateProfiles} {
+			if !HasPermission(entity.RoleAdmin, p) {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("players and moderators cannot manage users", func(t *testing.T) {
+		for _, role := range []entity.Role{entity.RolePlayer, entity.RoleModerator} {
+			if HasPermission(role, PermissionChangeRoles) {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("unknown roles have no permissions", func(t *testing.T) {
+		if HasPermission("emperor", PermissionListUsers) {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package auth
+
+import "errors"
+
+var (
+	// ErrUnauthenticated is returned when a request requires an authenticated
+	// caller, but it was made anonymously or with an invalid token.
+	ErrUnauthenticated = errors.New("authentication is required")
+
+	// ErrForbidden is returned when the authenticated caller is not allowed to
+	// do what they requested.
+	ErrForbidden = errors.New("access is forbidden")
+)
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
+)
+
+// NewHTTPMiddleware creates a middleware that authenticates the requests that
+// carry an access token in their Authorization header, and stores the
+// caller's identity in their context.
+//
+// Requests without a token are passed along anonymously, and it is up to the
+// endpoints to decide whether they require an authenticated caller. Requests
+// with an invalid token are rejected with HTTP status unauthorized.
+func NewHTTPMiddleware(tokens *Tokens) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			const prefix = "Bearer "
+			if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+				writeUnauthenticated(w, r)
+				return
+			}
+
+			claims, err := tokens.Parse(header[len(prefix):], PurposeAccess)
+			if err != nil {
+				writeUnauthenticated(w, r)
+				return
+			}
+
+			ctx := NewContext(r.Context(), Identity{UserID: claims.Subject})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// writeUnauthenticated responds with the same problem details as endpoints
+// do for ErrUnauthenticated, which it builds itself, since the transport
+// package depends on this one.
+func writeUnauthenticated(w http.ResponseWriter, r *http.Request) {
+	p := problem.New(http.StatusUnauthorized)
+	p.Detail = ErrUnauthenticated.Error()
+	p.Instance = r.URL.Path
+	if id, ok := logging.RequestIDFromContext(r.Context()); ok {
+		p.RequestID = id
+	}
+
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	problem.Write(w, p)
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	tokens, _ := NewTokens(key)
+
+	var identity Identity
+	var authenticated bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, authenticated = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewHTTPMiddleware(tokens)(next)
+
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		identity, authenticated = Identity{}, false
+
+		r := httptest.NewRequest("GET", "/v1/users/0", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr
+	}
+
+	t.Run("passes anonymous requests along", func(t *testing.T) {
+		rr := serve("")
+
+		if rr.Code != http.StatusOK {
+			t.Fail()
+		}
+		if authenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("stores the identity of the caller when access token is valid", func(t *testing.T) {
+		token, _, _ := tokens.Issue("moose", PurposeAccess, time.Hour)
+
+		rr := serve("Bearer " + token)
+
+		if rr.Code != http.StatusOK {
+			t.Fail()
+		}
+		if !authenticated || strings.Compare("moose", identity.UserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with unauthorized when token is invalid", func(t *testing.T) {
+		rr := serve("Bearer not.valid")
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+		if rr.Header().Get("Content-Type") != "application/problem+json" || !strings.Contains(rr.Body.String(), `"status":401`) {
+			t.Errorf("expected problem details, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("responds with unauthorized when token is not an access token", func(t *testing.T) {
+		token, _, _ := tokens.Issue("moose", PurposeMFA, time.Hour)
+
+		if rr := serve("Bearer " + token); rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with unauthorized when scheme is not bearer", func(t *testing.T) {
+		if rr := serve("Basic bW9vc2U6cGFzc3dvcmQ="); rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+}
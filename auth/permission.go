@@ -0,0 +1,44 @@
+package auth
+
+import "github.com/leblancjs/stmoosersburg-api/entity"
+
+// Permission represents something a user must be allowed to do to call an
+// endpoint.
+type Permission string
+
+const (
+	PermissionListUsers    Permission = "users:list"
+	PermissionChangeRoles  Permission = "users:change-roles"
+	PermissionSuspendUsers Permission = "users:suspend"
+
+	// PermissionViewPrivateProfiles allows viewing the private information of
+	// other users, such as their email.
+	PermissionViewPrivateProfiles Permission = "users:view-private"
+)
+
+// rolePermissions lists what each role is allowed to do, on top of what any
+// authenticated user can do.
+//
+// Moderators have no permissions yet, but will be granted the ones needed to
+// moderate players as those features are added.
+var rolePermissions = map[entity.Role][]Permission{
+	entity.RolePlayer:    {},
+	entity.RoleModerator: {},
+	entity.RoleAdmin: {
+		PermissionListUsers,
+		PermissionChangeRoles,
+		PermissionSuspendUsers,
+		PermissionViewPrivateProfiles,
+	},
+}
+
+// HasPermission tells whether the role grants the permission.
+func HasPermission(role entity.Role, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
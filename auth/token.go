@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// PurposeAccess represents tokens that grant access to the API on behalf
+	// of a user.
+	PurposeAccess = "access"
+
+	// PurposeMFA represents tokens that prove that a user entered the right
+	// password, but that they still need to provide a second factor to log
+	// in.
+	PurposeMFA = "mfa"
+
+	// MinKeySize represents the minimum size of the key used to sign tokens,
+	// in bytes.
+	MinKeySize = 32
+)
+
+var tokenEncoding = base64.RawURLEncoding
+
+// Claims represents what a token says about its bearer.
+type Claims struct {
+	// ID represents the unique ID of the token, which lets tokens that can
+	// only be used once, such as MFA tokens, be told apart.
+	ID string `json:"jti"`
+
+	// Subject represents the ID of the user the token was issued to.
+	Subject string `json:"sub"`
+
+	// Purpose represents what the token can be used for.
+	Purpose string `json:"pur"`
+
+	// ExpiresAt represents the time after which the token is no longer valid,
+	// in seconds since the Unix epoch.
+	ExpiresAt int64 `json:"exp"`
+}
+
+// Tokens issues and parses tokens made of claims encoded in JSON, and signed
+// with HMAC-SHA256 so that they cannot be forged.
+type Tokens struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewTokens creates a token issuer that signs tokens with the given key, which
+// must be at least MinKeySize bytes long.
+func NewTokens(key []byte) (*Tokens, error) {
+	if len(key) < MinKeySize {
+		return nil, fmt.Errorf("auth.NewTokens: key must be at least %d bytes long", MinKeySize)
+	}
+
+	return &Tokens{
+		key: key,
+		now: time.Now,
+	}, nil
+}
+
+// Issue issues a token for the given purpose to the user with the given ID,
+// which expires after the given amount of time.
+func (t *Tokens) Issue(subject string, purpose string, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := t.now().Add(ttl).Truncate(time.Second)
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", time.Time{}, fmt.Errorf("auth.Tokens.Issue: failed to generate ID (%s)", err)
+	}
+
+	payload, err := json.Marshal(Claims{
+		ID:        hex.EncodeToString(id),
+		Subject:   subject,
+		Purpose:   purpose,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("auth.Tokens.Issue: failed to encode claims (%s)", err)
+	}
+
+	encodedPayload := tokenEncoding.EncodeToString(payload)
+
+	return encodedPayload + "." + t.sign(encodedPayload), expiresAt, nil
+}
+
+// Parse checks that the token was issued for the given purpose, and that it
+// has not expired, and returns its claims.
+//
+// It returns ErrUnauthenticated when the token is not valid, without telling
+// why.
+func (t *Tokens) Parse(token string, purpose string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrUnauthenticated
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.sign(parts[0])), []byte(parts[1])) != 1 {
+		return nil, ErrUnauthenticated
+	}
+
+	payload, err := tokenEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	if claims.Purpose != purpose {
+		return nil, ErrUnauthenticated
+	}
+
+	if !t.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, ErrUnauthenticated
+	}
+
+	return &claims, nil
+}
+
+func (t *Tokens) sign(encodedPayload string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(encodedPayload))
+
+	return tokenEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var key = bytes.Repeat([]byte{0x42}, MinKeySize)
+
+func TestTokensConstruction(t *testing.T) {
+	t.Run("fails when key is too short", func(t *testing.T) {
+		if _, err := NewTokens([]byte("too.short")); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns tokens with the given key", func(t *testing.T) {
+		tokens, err := NewTokens(key)
+		if err != nil {
+			t.FailNow()
+		}
+		if !bytes.Equal(key, tokens.key) {
+			t.Fail()
+		}
+	})
+}
+
+func TestTokensIssuingAndParsing(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	tokens, _ := NewTokens(key)
+	tokens.now = func() time.Time { return now }
+
+	t.Run("parses the claims of a token it issued", func(t *testing.T) {
+		token, expiresAt, err := tokens.Issue("moose", PurposeAccess, time.Hour)
+		if err != nil {
+			t.FailNow()
+		}
+		if !expiresAt.Equal(now.Add(time.Hour)) {
+			t.Fail()
+		}
+
+		claims, err := tokens.Parse(token, PurposeAccess)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare("moose", claims.Subject) != 0 {
+			t.Fail()
+		}
+		if claims.ExpiresAt != expiresAt.Unix() {
+			t.Fail()
+		}
+	})
+
+	t.Run("gives every token its own ID", func(t *testing.T) {
+		first, _, _ := tokens.Issue("moose", PurposeMFA, time.Hour)
+		second, _, _ := tokens.Issue("moose", PurposeMFA, time.Hour)
+
+		firstClaims, _ := tokens.Parse(first, PurposeMFA)
+		secondClaims, _ := tokens.Parse(second, PurposeMFA)
+		if firstClaims.ID == "" || firstClaims.ID == secondClaims.ID {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when token was issued for another purpose", func(t *testing.T) {
+		token, _, _ := tokens.Issue("moose", PurposeMFA, time.Hour)
+
+		if _, err := tokens.Parse(token, PurposeAccess); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when token has expired", func(t *testing.T) {
+		token, _, _ := tokens.Issue("moose", PurposeAccess, -time.Second)
+
+		if _, err := tokens.Parse(token, PurposeAccess); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when token was signed with another key", func(t *testing.T) {
+		otherTokens, _ := NewTokens(bytes.Repeat([]byte{0x24}, MinKeySize))
+		otherTokens.now = tokens.now
+		token, _, _ := otherTokens.Issue("moose", PurposeAccess, time.Hour)
+
+		if _, err := tokens.Parse(token, PurposeAccess); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when token was tampered with", func(t *testing.T) {
+		token, _, _ := tokens.Issue("moose", PurposeAccess, time.Hour)
+		forged, _, _ := tokens.Issue("elk", PurposeAccess, time.Hour)
+
+		tampered := strings.Split(forged, ".")[0] + "." + strings.Split(token, ".")[1]
+
+		if _, err := tokens.Parse(tampered, PurposeAccess); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when token is malformed", func(t *testing.T) {
+		if _, err := tokens.Parse("not.a.token", PurposeAccess); err != ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+}
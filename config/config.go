@@ -0,0 +1,607 @@
+// Package config loads the configuration of the service from the
+// environment, a .env file, and a YAML file, and validates it.
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/encryption"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/metrics"
+	"github.com/leblancjs/stmoosersburg-api/oidc"
+	"github.com/leblancjs/stmoosersburg-api/server"
+	"github.com/leblancjs/stmoosersburg-api/tracing"
+)
+
+// redacted replaces the value of secrets that are set when the configuration
+// is printed.
+const redacted = "[REDACTED]"
+
+// Config represents the configuration of the service.
+type Config struct {
+	Server   server.Config
+	Metrics  Metrics
+	Logging  logging.Config
+	Tracing  Tracing
+	Database Database
+	Hashing  Hashing
+	Keys     Keys
+
+	// IdentityProviders represents the OpenID Connect providers users can
+	// sign in with, in the order they are listed.
+	IdentityProviders []oidc.Config
+}
+
+// Metrics represents the address metrics are served on, apart from the API,
+// so that they are not exposed publicly. They are not served when the port
+// is 0.
+type Metrics struct {
+	Host string
+	Port int
+}
+
+// Tracing represents where traces are exported, if anywhere, and which are.
+type Tracing struct {
+	Exporter     string
+	OTLPEndpoint string
+	tracing.Config
+}
+
+type Database struct {
+	Type string
+	db.Config
+}
+
+type Hashing struct {
+	Algorithm string
+	hash.Config
+}
+
+// Keys represents the keys used to sign access tokens and to encrypt
+// secrets. Keys that are not set are nil, and must be generated.
+type Keys struct {
+	TokenSigning []byte
+	Encryption   []byte
+}
+
+// ValidationError represents every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(e.Problems, "\n  - "))
+}
+
+// setting represents a setting that can be set by an environment variable,
+// or by a key of the YAML file.
+type setting struct {
+	env    string
+	key    string
+	secret bool
+}
+
+var settings = []setting{
+	{env: "SERVER_HOST", key: "server.host"},
+	{env: "SERVER_PORT", key: "server.port"},
+	{env: "SERVER_READ_TIMEOUT", key: "server.readTimeout"},
+	{env: "SERVER_WRITE_TIMEOUT", key: "server.writeTimeout"},
+	{env: "SERVER_IDLE_TIMEOUT", key: "server.idleTimeout"},
+	{env: "SERVER_SHUTDOWN_TIMEOUT", key: "server.shutdownTimeout"},
+	{env: "SERVER_DRAIN_DELAY", key: "server.drainDelay"},
+	{env: "METRICS_HOST", key: "metrics.host"},
+	{env: "METRICS_PORT", key: "metrics.port"},
+	{env: "LOG_LEVEL", key: "logging.level"},
+	{env: "LOG_FORMAT", key: "logging.format"},
+	{env: "TRACING_EXPORTER", key: "tracing.exporter"},
+	{env: "TRACING_OTLP_ENDPOINT", key: "tracing.otlpEndpoint"},
+	{env: "TRACING_SAMPLE_RATIO", key: "tracing.sampleRatio"},
+	{env: "DB_TYPE", key: "database.type"},
+	{env: "DB_HOST", key: "database.host"},
+	{env: "DB_PORT", key: "database.port"},
+	{env: "DB_USER", key: "database.user"},
+	{env: "DB_PASSWORD", key: "database.password", secret: true},
+	{env: "DB_NAME", key: "database.name"},
+	{env: "DB_SSL_MODE", key: "database.sslMode"},
+	{env: "HASH_ALGORITHM", key: "hashing.algorithm"},
+	{env: "HASH_BCRYPT_COST", key: "hashing.bcryptCost"},
+	{env: "HASH_ARGON2ID_MEMORY", key: "hashing.argon2id.memory"},
+	{env: "HASH_ARGON2ID_ITERATIONS", key: "hashing.argon2id.iterations"},
+	{env: "HASH_ARGON2ID_PARALLELISM", key: "hashing.argon2id.parallelism"},
+	{env: "TOKEN_SIGNING_KEY", key: "keys.tokenSigning", secret: true},
+	{env: "ENCRYPTION_KEY", key: "keys.encryption", secret: true},
+	{env: "OIDC_PROVIDERS", key: "oidc.providers"},
+}
+
+// providerSettings are the settings of each identity provider listed in
+// OIDC_PROVIDERS. Their environment variables are prefixed with the name of
+// the provider, such as OIDC_GOOGLE_CLIENT_ID, and their keys are nested under
+// it, such as oidc.google.clientId.
+var providerSettings = []setting{
+	{env: "ISSUER", key: "issuer"},
+	{env: "CLIENT_ID", key: "clientId"},
+	{env: "CLIENT_SECRET", key: "clientSecret", secret: true},
+	{env: "REDIRECT_URL", key: "redirectUrl"},
+	{env: "SCOPES", key: "scopes"},
+}
+
+// providerNameRegexp matches the names of identity providers, which appear
+// in routes and in the names of environment variables.
+var providerNameRegexp = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// providerPrefix returns the prefix of the environment variables of the
+// identity provider's settings.
+func providerPrefix(name string) string {
+	return "OIDC_" + strings.ToUpper(name) + "_"
+}
+
+// isProviderSetting tells whether the environment variable is a setting of
+// an identity provider, whichever it is.
+func isProviderSetting(env string) bool {
+	if !strings.HasPrefix(env, "OIDC_") {
+		return false
+	}
+
+	for _, s := range providerSettings {
+		if strings.HasSuffix(env, "_"+s.env) && len(env) > len("OIDC_")+len(s.env)+1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load loads the configuration from the YAML file, if a path is given, then
+// from the .env file, if it exists, and then from the environment, each one
+// taking precedence over the ones before it.
+//
+// All the problems found in the configuration are reported at once, in a
+// ValidationError.
+func Load(path string, dotenvPath string) (*Config, error) {
+	return load(os.Environ(), path, dotenvPath)
+}
+
+func load(environ []string, path string, dotenvPath string) (*Config, error) {
+	values := make(map[string]string)
+	var problems []string
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config.Load: failed to read %s (%s)", path, err)
+		}
+
+		fileValues, fileProblems := parseYAML(data)
+		merge(values, fileValues)
+		problems = append(problems, fileProblems...)
+	}
+
+	if dotenvPath != "" {
+		data, err := os.ReadFile(dotenvPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("config.Load: failed to read %s (%s)", dotenvPath, err)
+		}
+
+		dotenvValues, dotenvProblems := parseDotenv(data)
+		merge(values, dotenvValues)
+		problems = append(problems, dotenvProblems...)
+	}
+
+	env := make(map[string]string)
+	for _, variable := range environ {
+		if i := strings.Index(variable, "="); i > 0 {
+			env[variable[:i]] = variable[i+1:]
+		}
+	}
+	merge(values, env)
+
+	conf, parseProblems := parse(values)
+	problems = append(problems, parseProblems...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{problems}
+	}
+
+	return conf, nil
+}
+
+// merge copies the values of known settings, leaving out those that are
+// empty, so that they do not take precedence over values set elsewhere.
+func merge(values map[string]string, from map[string]string) {
+	for _, s := range settings {
+		if v, ok := from[s.env]; ok && v != "" {
+			values[s.env] = v
+		}
+	}
+
+	for env, v := range from {
+		if isProviderSetting(env) && v != "" {
+			values[env] = v
+		}
+	}
+}
+
+// parseYAML flattens the YAML document into the values of the settings it
+// sets, by environment variable.
+func parseYAML(data []byte) (map[string]string, []string) {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, []string{fmt.Sprintf("the configuration file is not valid YAML (%s)", err)}
+	}
+
+	flat := make(map[string]string)
+	flatten("", document, flat)
+
+	values := make(map[string]string)
+	for _, s := range settings {
+		if v, ok := flat[s.key]; ok {
+			values[s.env] = v
+			delete(flat, s.key)
+		}
+	}
+
+	for key, v := range flat {
+		parts := strings.Split(key, ".")
+		if len(parts) != 3 || parts[0] != "oidc" {
+			continue
+		}
+
+		for _, s := range providerSettings {
+			if parts[2] == s.key {
+				values[providerPrefix(parts[1])+s.env] = v
+				delete(flat, key)
+			}
+		}
+	}
+
+	var problems []string
+	for key := range flat {
+		problems = append(problems, fmt.Sprintf("%s is not a known setting of the configuration file", key))
+	}
+	sort.Strings(problems)
+
+	return values, problems
+}
+
+func flatten(prefix string, value interface{}, flat map[string]string) {
+	if m, ok := value.(map[string]interface{}); ok {
+		for k, v := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+
+			flatten(key, v, flat)
+		}
+
+		return
+	}
+
+	if value != nil {
+		flat[prefix] = fmt.Sprint(value)
+	}
+}
+
+// parse converts and validates the values of the settings.
+func parse(values map[string]string) (*Config, []string) {
+	p := &parser{values: values}
+
+	conf := &Config{
+		Server: server.Config{
+			Host:            values["SERVER_HOST"],
+			Port:            p.integer("SERVER_PORT", server.DefaultPort, 1, 65535),
+			ReadTimeout:     p.duration("SERVER_READ_TIMEOUT", server.DefaultReadTimeout),
+			WriteTimeout:    p.duration("SERVER_WRITE_TIMEOUT", server.DefaultWriteTimeout),
+			IdleTimeout:     p.duration("SERVER_IDLE_TIMEOUT", server.DefaultIdleTimeout),
+			ShutdownTimeout: p.duration("SERVER_SHUTDOWN_TIMEOUT", server.DefaultShutdownTimeout),
+			DrainDelay:      p.duration("SERVER_DRAIN_DELAY", 0),
+		},
+		Metrics: Metrics{
+			Host: values["METRICS_HOST"],
+			Port: p.integer("METRICS_PORT", metrics.DefaultPort, 0, 65535),
+		},
+		Logging: logging.Config{
+			Level:  p.level("LOG_LEVEL"),
+			Format: p.oneOf("LOG_FORMAT", logging.FormatJSON, logging.FormatJSON, logging.FormatText),
+		},
+		Tracing: Tracing{
+			Exporter:     p.oneOf("TRACING_EXPORTER", tracing.ExporterNone, tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP),
+			OTLPEndpoint: values["TRACING_OTLP_ENDPOINT"],
+			Config: tracing.Config{
+				SampleRatio: p.ratio("TRACING_SAMPLE_RATIO", tracing.DefaultSampleRatio),
+			},
+		},
+		Database: Database{
+			Type: p.oneOf("DB_TYPE", db.TypeInMemory, db.TypeInMemory, db.TypePostgres),
+			Config: db.Config{
+				Host:     values["DB_HOST"],
+				Port:     values["DB_PORT"],
+				User:     values["DB_USER"],
+				Password: values["DB_PASSWORD"],
+				Name:     values["DB_NAME"],
+				SSLMode:  values["DB_SSL_MODE"],
+			},
+		},
+		Hashing: Hashing{
+			Algorithm: p.oneOf("HASH_ALGORITHM", hash.AlgorithmArgon2id, hash.AlgorithmArgon2id, hash.AlgorithmBCrypt),
+			Config: hash.Config{
+				BCryptCost: p.integer("HASH_BCRYPT_COST", 0, 0, 31),
+				Argon2id: hash.Argon2idParams{
+					Memory:      uint32(p.integer("HASH_ARGON2ID_MEMORY", 0, 0, math.MaxInt32)),
+					Iterations:  uint32(p.integer("HASH_ARGON2ID_ITERATIONS", 0, 0, math.MaxInt32)),
+					Parallelism: uint8(p.integer("HASH_ARGON2ID_PARALLELISM", 0, 0, 255)),
+				},
+			},
+		},
+		Keys: Keys{
+			TokenSigning: p.key("TOKEN_SIGNING_KEY", auth.MinKeySize, false),
+			Encryption:   p.key("ENCRYPTION_KEY", encryption.KeySize, true),
+		},
+		IdentityProviders: p.identityProviders("OIDC_PROVIDERS"),
+	}
+
+	if values["DB_PORT"] != "" {
+		p.integer("DB_PORT", 0, 1, 65535)
+	}
+
+	return conf, p.problems
+}
+
+// parser converts the values of settings, and keeps track of the problems
+// found along the way, rather than stopping at the first one.
+type parser struct {
+	values   map[string]string
+	problems []string
+}
+
+func (p *parser) problem(format string, a ...interface{}) {
+	p.problems = append(p.problems, fmt.Sprintf(format, a...))
+}
+
+func (p *parser) integer(env string, def int, min int, max int) int {
+	value, ok := p.values[env]
+	if !ok {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < min || i > max {
+		p.problem("%s must be an integer between %d and %d", env, min, max)
+		return def
+	}
+
+	return i
+}
+
+// duration parses a positive duration, such as "30s", or "1m30s".
+func (p *parser) duration(env string, def time.Duration) time.Duration {
+	value, ok := p.values[env]
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		p.problem("%s must be a positive duration, such as 30s", env)
+		return def
+	}
+
+	return d
+}
+
+// ratio parses a ratio greater than 0, and at most 1, such as "0.25".
+func (p *parser) ratio(env string, def float64) float64 {
+	value, ok := p.values[env]
+	if !ok {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 || f > 1 {
+		p.problem("%s must be a number greater than 0 and at most 1", env)
+		return def
+	}
+
+	return f
+}
+
+// level parses a level of logs, which is info by default.
+func (p *parser) level(env string) slog.Level {
+	name := p.oneOf(env, "info", "debug", "info", "warn", "error")
+
+	var level slog.Level
+	level.UnmarshalText([]byte(name))
+
+	return level
+}
+
+func (p *parser) oneOf(env string, def string, choices ...string) string {
+	value, ok := p.values[env]
+	if !ok {
+		return def
+	}
+
+	for _, c := range choices {
+		if value == c {
+			return value
+		}
+	}
+
+	p.problem("%s must be one of %s", env, strings.Join(choices, ", "))
+	return def
+}
+
+// key decodes a base 64 key, which must be at least, or exactly, the size.
+func (p *parser) key(env string, size int, exact bool) []byte {
+	value, ok := p.values[env]
+	if !ok {
+		return nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		p.problem("%s must be encoded in base 64", env)
+		return nil
+	}
+
+	if exact && len(decoded) != size {
+		p.problem("%s must be exactly %d bytes", env, size)
+		return nil
+	}
+	if !exact && len(decoded) < size {
+		p.problem("%s must be at least %d bytes", env, size)
+		return nil
+	}
+
+	return decoded
+}
+
+// identityProviders parses the identity providers listed in the setting,
+// separated by commas, along with their own settings.
+func (p *parser) identityProviders(env string) []oidc.Config {
+	var providers []oidc.Config
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(p.values[env], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if !providerNameRegexp.MatchString(name) {
+			p.problem("%s must only list names made of lowercase letters and digits, such as google", env)
+			continue
+		}
+		if seen[name] {
+			p.problem("%s must not list %s more than once", env, name)
+			continue
+		}
+		seen[name] = true
+
+		prefix := providerPrefix(name)
+
+		provider := oidc.Config{
+			Name:         name,
+			Issuer:       p.url(prefix + "ISSUER"),
+			ClientID:     p.values[prefix+"CLIENT_ID"],
+			ClientSecret: p.values[prefix+"CLIENT_SECRET"],
+			RedirectURL:  p.url(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(p.values[prefix+"SCOPES"]),
+		}
+		if provider.ClientID == "" {
+			p.problem("%sCLIENT_ID is required", prefix)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// url parses a required absolute URL, such as "https://accounts.google.com".
+func (p *parser) url(env string) string {
+	value, ok := p.values[env]
+	if !ok {
+		p.problem("%s is required", env)
+		return ""
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		p.problem("%s must be an absolute URL, such as https://accounts.google.com", env)
+		return ""
+	}
+
+	return value
+}
+
+// String returns the settings of the configuration, one per line, with the
+// value of secrets redacted, so that it can safely be logged.
+func (c *Config) String() string {
+	values := map[string]string{
+		"SERVER_HOST":               c.Server.Host,
+		"SERVER_PORT":               strconv.Itoa(c.Server.Port),
+		"SERVER_READ_TIMEOUT":       c.Server.ReadTimeout.String(),
+		"SERVER_WRITE_TIMEOUT":      c.Server.WriteTimeout.String(),
+		"SERVER_IDLE_TIMEOUT":       c.Server.IdleTimeout.String(),
+		"SERVER_SHUTDOWN_TIMEOUT":   c.Server.ShutdownTimeout.String(),
+		"SERVER_DRAIN_DELAY":        c.Server.DrainDelay.String(),
+		"METRICS_HOST":              c.Metrics.Host,
+		"METRICS_PORT":              strconv.Itoa(c.Metrics.Port),
+		"LOG_LEVEL":                 strings.ToLower(c.Logging.Level.String()),
+		"LOG_FORMAT":                c.Logging.Format,
+		"TRACING_EXPORTER":          c.Tracing.Exporter,
+		"TRACING_OTLP_ENDPOINT":     c.Tracing.OTLPEndpoint,
+		"TRACING_SAMPLE_RATIO":      strconv.FormatFloat(c.Tracing.SampleRatio, 'g', -1, 64),
+		"DB_TYPE":                   c.Database.Type,
+		"DB_HOST":                   c.Database.Host,
+		"DB_PORT":                   c.Database.Port,
+		"DB_USER":                   c.Database.User,
+		"DB_PASSWORD":               c.Database.Password,
+		"DB_NAME":                   c.Database.Name,
+		"DB_SSL_MODE":               c.Database.SSLMode,
+		"HASH_ALGORITHM":            c.Hashing.Algorithm,
+		"HASH_BCRYPT_COST":          strconv.Itoa(c.Hashing.BCryptCost),
+		"HASH_ARGON2ID_MEMORY":      strconv.Itoa(int(c.Hashing.Argon2id.Memory)),
+		"HASH_ARGON2ID_ITERATIONS":  strconv.Itoa(int(c.Hashing.Argon2id.Iterations)),
+		"HASH_ARGON2ID_PARALLELISM": strconv.Itoa(int(c.Hashing.Argon2id.Parallelism)),
+		"TOKEN_SIGNING_KEY":         base64.StdEncoding.EncodeToString(c.Keys.TokenSigning),
+		"ENCRYPTION_KEY":            base64.StdEncoding.EncodeToString(c.Keys.Encryption),
+		"OIDC_PROVIDERS":            providerNames(c.IdentityProviders),
+	}
+
+	var b strings.Builder
+	for _, s := range settings {
+		value := values[s.env]
+		if s.secret && value != "" {
+			value = redacted
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", s.env, value)
+	}
+
+	for _, provider := range c.IdentityProviders {
+		providerValues := map[string]string{
+			"ISSUER":        provider.Issuer,
+			"CLIENT_ID":     provider.ClientID,
+			"CLIENT_SECRET": provider.ClientSecret,
+			"REDIRECT_URL":  provider.RedirectURL,
+			"SCOPES":        strings.Join(provider.Scopes, " "),
+		}
+
+		for _, s := range providerSettings {
+			value := providerValues[s.env]
+			if s.secret && value != "" {
+				value = redacted
+			}
+
+			fmt.Fprintf(&b, "%s%s=%s\n", providerPrefix(provider.Name), s.env, value)
+		}
+	}
+
+	return b.String()
+}
+
+func providerNames(providers []oidc.Config) string {
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.Name)
+	}
+
+	return strings.Join(names, ",")
+}
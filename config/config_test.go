@@ -0,0 +1,211 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/metrics"
+	"github.com/leblancjs/stmoosersburg-api/server"
+	"github.com/leblancjs/stmoosersburg-api/tracing"
+)
+
+// A 32 byte key, encoded in base 64.
+const mockKey = "c2lnbmluZy5rZXkuZm9yLmFjY2Vzcy50b2tlbnMuLi4="
+
+// writeFile writes the contents to a file in a temporary directory, and
+// returns its path.
+func writeFile(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("failed to write %s (%s)", name, err)
+	}
+
+	return path
+}
+
+func TestLoadingConfig(t *testing.T) {
+	t.Run("uses the defaults when nothing is set", func(t *testing.T) {
+		conf, err := load(nil, "", filepath.Join(t.TempDir(), ".env"))
+		if err != nil {
+			t.FailNow()
+		}
+
+		if conf.Server.Port != server.DefaultPort || conf.Server.WriteTimeout != server.DefaultWriteTimeout || conf.Database.Type != db.TypeInMemory || conf.Hashing.Algorithm != hash.AlgorithmArgon2id {
+			t.Fail()
+		}
+		if conf.Keys.TokenSigning != nil || conf.Keys.Encryption != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("gives the environment precedence over the .env file, and the .env file over the configuration file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "server:\n  port: 9000\n  shutdownTimeout: 1m\ndatabase:\n  type: postgres\n  host: file.host\n  name: file.name\nhashing:\n  argon2id:\n    memory: 1024\n")
+		dotenv := writeFile(t, ".env", "DB_HOST=dotenv.host\nDB_NAME=dotenv.name\n")
+
+		conf, err := load([]string{"DB_NAME=env.name", "UNRELATED=value"}, path, dotenv)
+		if err != nil {
+			t.Fatalf("failed to load configuration (%s)", err)
+		}
+
+		if conf.Server.Port != 9000 || conf.Server.ShutdownTimeout != time.Minute || conf.Database.Type != db.TypePostgres || conf.Hashing.Argon2id.Memory != 1024 {
+			t.Fail()
+		}
+		if conf.Database.Host != "dotenv.host" || conf.Database.Name != "env.name" {
+			t.Fail()
+		}
+	})
+
+	t.Run("decodes keys", func(t *testing.T) {
+		conf, err := load([]string{"TOKEN_SIGNING_KEY=" + mockKey, "ENCRYPTION_KEY=" + mockKey}, "", "")
+		if err != nil {
+			t.FailNow()
+		}
+
+		if len(conf.Keys.TokenSigning) != 32 || len(conf.Keys.Encryption) != 32 {
+			t.Fail()
+		}
+	})
+
+	t.Run("serves metrics on their own port, unless it is 0", func(t *testing.T) {
+		conf, err := load(nil, "", "")
+		if err != nil || conf.Metrics.Port != metrics.DefaultPort {
+			t.FailNow()
+		}
+
+		conf, err = load([]string{"METRICS_HOST=127.0.0.1", "METRICS_PORT=0"}, "", "")
+		if err != nil || conf.Metrics.Host != "127.0.0.1" || conf.Metrics.Port != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("parses the level and format of logs", func(t *testing.T) {
+		conf, err := load([]string{"LOG_LEVEL=debug", "LOG_FORMAT=text"}, "", "")
+		if err != nil {
+			t.FailNow()
+		}
+
+		if conf.Logging.Level != slog.LevelDebug || conf.Logging.Format != logging.FormatText {
+			t.Fail()
+		}
+	})
+
+	t.Run("parses the exporter and sample ratio of traces", func(t *testing.T) {
+		conf, err := load([]string{"TRACING_EXPORTER=otlp", "TRACING_OTLP_ENDPOINT=http://collector:4318", "TRACING_SAMPLE_RATIO=0.25"}, "", "")
+		if err != nil {
+			t.FailNow()
+		}
+
+		if conf.Tracing.Exporter != tracing.ExporterOTLP || conf.Tracing.OTLPEndpoint != "http://collector:4318" || conf.Tracing.SampleRatio != 0.25 {
+			t.Fail()
+		}
+	})
+
+	t.Run("loads identity providers from the .env file and the configuration file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "oidc:\n  providers: google,moose\n  moose:\n    issuer: https://id.stmoosersburg.com\n    clientId: file.client\n    redirectUrl: http://localhost:8080/v1/auth/moose/callback\n    scopes: openid email\n")
+		dotenv := writeFile(t, ".env", "OIDC_GOOGLE_ISSUER=https://accounts.google.com\nOIDC_GOOGLE_CLIENT_ID=dotenv.client\nOIDC_GOOGLE_CLIENT_SECRET=dotenv.secret\nOIDC_GOOGLE_REDIRECT_URL=http://localhost:8080/v1/auth/google/callback\n")
+
+		conf, err := load([]string{"OIDC_MOOSE_CLIENT_ID=env.client"}, path, dotenv)
+		if err != nil {
+			t.Fatalf("failed to load configuration (%s)", err)
+		}
+
+		if len(conf.IdentityProviders) != 2 {
+			t.FailNow()
+		}
+		google, moose := conf.IdentityProviders[0], conf.IdentityProviders[1]
+		if google.Name != "google" || google.Issuer != "https://accounts.google.com" || google.ClientID != "dotenv.client" || google.ClientSecret != "dotenv.secret" || len(google.Scopes) != 0 {
+			t.Fail()
+		}
+		if moose.Name != "moose" || moose.ClientID != "env.client" || moose.ClientSecret != "" || len(moose.Scopes) != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("reports the problems of identity providers", func(t *testing.T) {
+		_, err := load([]string{
+			"OIDC_PROVIDERS=google,Moose,google",
+			"OIDC_GOOGLE_ISSUER=accounts.google.com",
+		}, "", "")
+
+		e, ok := err.(*ValidationError)
+		if !ok {
+			t.FailNow()
+		}
+		if len(e.Problems) != 5 {
+			t.Errorf("expected 5 problems, got %v", e.Problems)
+		}
+	})
+
+	t.Run("fails when the configuration file does not exist", func(t *testing.T) {
+		if _, err := load(nil, filepath.Join(t.TempDir(), "config.yaml"), ""); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("reports every problem at once", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "server:\n  prot: 9000\n")
+
+		_, err := load([]string{
+			"SERVER_PORT=moose",
+			"SERVER_IDLE_TIMEOUT=forever",
+			"DB_TYPE=mongo",
+			"DB_PORT=99999",
+			"HASH_ALGORITHM=md5",
+			"HASH_BCRYPT_COST=-1",
+			"TOKEN_SIGNING_KEY=c2hvcnQ=",
+			"ENCRYPTION_KEY=not base 64",
+			"LOG_LEVEL=loud",
+			"TRACING_SAMPLE_RATIO=2",
+		}, path, "")
+
+		e, ok := err.(*ValidationError)
+		if !ok {
+			t.FailNow()
+		}
+		if len(e.Problems) != 11 {
+			t.Errorf("expected 11 problems, got %v", e.Problems)
+		}
+	})
+}
+
+func TestPrintingConfig(t *testing.T) {
+	t.Run("redacts secrets that are set", func(t *testing.T) {
+		conf, _ := load([]string{"DB_USER=moose", "DB_PASSWORD=antlers", "TOKEN_SIGNING_KEY=" + mockKey}, "", "")
+
+		s := conf.String()
+		if strings.Contains(s, "antlers") || strings.Contains(s, mockKey) {
+			t.Fail()
+		}
+		if !strings.Contains(s, "DB_USER=moose\n") || !strings.Contains(s, "DB_PASSWORD=[REDACTED]\n") || !strings.Contains(s, "ENCRYPTION_KEY=\n") {
+			t.Fail()
+		}
+	})
+
+	t.Run("redacts the client secrets of identity providers", func(t *testing.T) {
+		conf, err := load([]string{
+			"OIDC_PROVIDERS=google",
+			"OIDC_GOOGLE_ISSUER=https://accounts.google.com",
+			"OIDC_GOOGLE_CLIENT_ID=a.client",
+			"OIDC_GOOGLE_CLIENT_SECRET=antlers",
+			"OIDC_GOOGLE_REDIRECT_URL=http://localhost:8080/v1/auth/google/callback",
+		}, "", "")
+		if err != nil {
+			t.FailNow()
+		}
+
+		s := conf.String()
+		if strings.Contains(s, "antlers") {
+			t.Fail()
+		}
+		if !strings.Contains(s, "OIDC_PROVIDERS=google\n") || !strings.Contains(s, "OIDC_GOOGLE_CLIENT_ID=a.client\n") || !strings.Contains(s, "OIDC_GOOGLE_CLIENT_SECRET=[REDACTED]\n") {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseDotenv parses the variables of a dotenv file, which are assigned one
+// per line (e.g. DB_HOST=localhost), optionally exported, and quoted. Blank
+// lines and lines starting with "#" are ignored.
+func parseDotenv(data []byte) (map[string]string, []string) {
+	values := make(map[string]string)
+	var problems []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		text = strings.TrimPrefix(text, "export ")
+
+		i := strings.Index(text, "=")
+		if i < 1 {
+			problems = append(problems, fmt.Sprintf("line %d of the .env file is not an assignment", line))
+			continue
+		}
+
+		key := strings.TrimSpace(text[:i])
+		value := strings.TrimSpace(text[i+1:])
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		values[key] = value
+	}
+
+	return values, problems
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestParsingDotenv(t *testing.T) {
+	t.Run("parses assignments, ignoring comments and blank lines", func(t *testing.T) {
+		values, problems := parseDotenv([]byte("# Database\n\nDB_TYPE=postgres\nexport DB_HOST = \"db.stmoosersburg.com\"\nDB_PASSWORD='a password'\nDB_NAME=\n"))
+
+		if len(problems) != 0 {
+			t.FailNow()
+		}
+		if values["DB_TYPE"] != "postgres" || values["DB_HOST"] != "db.stmoosersburg.com" || values["DB_PASSWORD"] != "a password" {
+			t.Fail()
+		}
+		if v, ok := values["DB_NAME"]; !ok || v != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("reports lines that are not assignments", func(t *testing.T) {
+		_, problems := parseDotenv([]byte("DB_TYPE=postgres\nmoose\n=elk\n"))
+
+		if len(problems) != 2 {
+			t.Fail()
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -53,6 +54,10 @@ type DB interface {
 
 	// Close closes a database connection.
 	Close() error
+
+	// Check reports whether the database connection is still usable, failing
+	// when it cannot be reached before the context is done.
+	Check(ctx context.Context) error
 }
 
 type db struct {
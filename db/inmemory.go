@@ -1,14 +1,38 @@
 package db
 
-import "github.com/leblancjs/stmoosersburg-api/entity"
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
 
 // InMemory represents an in memory database.
 //
 // All entities are persisted in memory, so they are lost when the service is
 // terminated.
+//
+// Repositories must hold the lock while they read or change the collections,
+// since they are used from concurrent requests, and from background work such
+// as matchmaking.
 type InMemory struct {
 	db
-	Users []entity.User
+	sync.Mutex
+
+	Users            []entity.User
+	LinkedIdentities []entity.LinkedIdentity
+	FriendRequests   []entity.FriendRequest
+	Friendships      []entity.Friendship
+	Blocks           []entity.Block
+	Games            []entity.Game
+	Invites          []entity.Invite
+	Ratings          []entity.Rating
+	Stats            []entity.Stats
+
+	AchievementUnlocks  []entity.AchievementUnlock
+	AchievementProgress []entity.AchievementProgress
+	AchievementGames    []entity.AchievementGame
 }
 
 // NewInMemory creates an in memory database with the given configuration.
@@ -20,7 +44,21 @@ func NewInMemory(conf Config) *InMemory {
 
 // Open opens the in memory database by creating the appropriate collections.
 func (db *InMemory) Open() error {
+	db.Lock()
+	defer db.Unlock()
+
 	db.Users = make([]entity.User, 0)
+	db.LinkedIdentities = make([]entity.LinkedIdentity, 0)
+	db.FriendRequests = make([]entity.FriendRequest, 0)
+	db.Friendships = make([]entity.Friendship, 0)
+	db.Blocks = make([]entity.Block, 0)
+	db.Games = make([]entity.Game, 0)
+	db.Invites = make([]entity.Invite, 0)
+	db.Ratings = make([]entity.Rating, 0)
+	db.Stats = make([]entity.Stats, 0)
+	db.AchievementUnlocks = make([]entity.AchievementUnlock, 0)
+	db.AchievementProgress = make([]entity.AchievementProgress, 0)
+	db.AchievementGames = make([]entity.AchievementGame, 0)
 
 	return nil
 }
@@ -29,3 +67,15 @@ func (db *InMemory) Open() error {
 func (db *InMemory) Close() error {
 	return nil
 }
+
+// Check checks that the in memory database has been opened.
+func (db *InMemory) Check(_ context.Context) error {
+	db.Lock()
+	defer db.Unlock()
+
+	if db.Users == nil {
+		return fmt.Errorf("db.InMemory.Check: database is not open")
+	}
+
+	return nil
+}
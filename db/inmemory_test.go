@@ -1,6 +1,9 @@
 package db
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestInMemoryDatabaseCreation(t *testing.T) {
 	t.Run("returns an in memory database", func(t *testing.T) {
@@ -26,6 +29,150 @@ func TestOpeningInMemoryDatabase(t *testing.T) {
 			t.Fail()
 		}
 	})
+
+	t.Run("creates an empty array of linked identities when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.LinkedIdentities == nil {
+			t.FailNow()
+		}
+
+		if len(db.LinkedIdentities) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of friend requests when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.FriendRequests == nil {
+			t.FailNow()
+		}
+
+		if len(db.FriendRequests) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of friendships when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.Friendships == nil {
+			t.FailNow()
+		}
+
+		if len(db.Friendships) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of blocks when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.Blocks == nil {
+			t.FailNow()
+		}
+
+		if len(db.Blocks) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of games when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.Games == nil {
+			t.FailNow()
+		}
+
+		if len(db.Games) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of invites when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.Invites == nil {
+			t.FailNow()
+		}
+
+		if len(db.Invites) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of ratings when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.Ratings == nil {
+			t.FailNow()
+		}
+
+		if len(db.Ratings) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates an empty array of stats when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.Stats == nil {
+			t.FailNow()
+		}
+
+		if len(db.Stats) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates empty arrays of achievement unlocks, progress, and games when all is well", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Open(); err != nil {
+			t.Fail()
+		}
+
+		if db.AchievementUnlocks == nil || db.AchievementProgress == nil || db.AchievementGames == nil {
+			t.FailNow()
+		}
+
+		if len(db.AchievementUnlocks) != 0 || len(db.AchievementProgress) != 0 || len(db.AchievementGames) != 0 {
+			t.Fail()
+		}
+	})
 }
 
 func TestClosingInMemoryDatabase(t *testing.T) {
@@ -37,3 +184,22 @@ func TestClosingInMemoryDatabase(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckingInMemoryDatabase(t *testing.T) {
+	t.Run("fails when the database was not opened", func(t *testing.T) {
+		db := InMemory{}
+
+		if err := db.Check(context.Background()); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when the database is open", func(t *testing.T) {
+		db := InMemory{}
+		db.Open()
+
+		if err := db.Check(context.Background()); err != nil {
+			t.Fail()
+		}
+	})
+}
@@ -1,8 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/tracing"
 )
 
 const (
@@ -82,6 +85,96 @@ func (db *Postgres) Close() error {
 	return nil
 }
 
+// Check pings the Postgres database to check that the connection is still
+// usable.
+func (db *Postgres) Check(ctx context.Context) error {
+	if db.DB == nil {
+		return fmt.Errorf("db.Postgres.Check: database is not open")
+	}
+
+	if err := db.DB.PingContext(ctx); err != nil {
+		return fmt.Errorf("db.Postgres.Check: failed to ping database (%s)", err)
+	}
+
+	return nil
+}
+
+// QueryRowContext runs a query that returns at most one row, in a span that
+// is a child of the one carried by the context, if any.
+func (db *Postgres) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	span.RecordError(row.Err())
+
+	return row
+}
+
+// QueryContext runs a query that returns rows, in a span that is a child of
+// the one carried by the context, if any. The span ends when the query has
+// run, not when the rows have been read.
+func (db *Postgres) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	span.RecordError(err)
+
+	return rows, err
+}
+
+// ExecContext runs a query that returns no rows, in a span that is a child of
+// the one carried by the context, if any.
+func (db *Postgres) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	span.RecordError(err)
+
+	return result, err
+}
+
+// startQuerySpan starts a span for the query, which carries its statement,
+// but never its arguments, since they can hold passwords.
+func startQuerySpan(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	return tracing.Start(
+		ctx,
+		"db.query",
+		tracing.String("db.system", "postgresql"),
+		tracing.String("db.statement", query),
+	)
+}
+
+// InTransaction runs the function in a transaction, which is committed if the
+// function succeeds, and rolled back otherwise.
+//
+// The function's error is returned as is, so that callers can recognize it.
+func (db *Postgres) InTransaction(fn func(tx *sql.Tx) error) error {
+	return db.InTransactionContext(context.Background(), fn)
+}
+
+// InTransactionContext runs the function in a transaction like InTransaction
+// does, which is rolled back if the context is done before it is committed.
+func (db *Postgres) InTransactionContext(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db.Postgres.InTransaction: failed to begin transaction (%s)", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("db.Postgres.InTransaction: failed to commit transaction (%s)", err)
+	}
+
+	return nil
+}
+
 func (db *Postgres) buildDataSourceName() string {
 	dsName := fmt.Sprintf(
 		"host=%s port=%s dbname=%s sslmode=%s user=%s",
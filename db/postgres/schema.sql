@@ -4,6 +4,154 @@ CREATE TABLE users (
     id uuid default uuid_generate_v4 (),
     username VARCHAR NOT NULL,
     email VARCHAR NOT NULL,
-    "password" CHAR(60) NOT NULL,
+    "password" VARCHAR NOT NULL,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_secret VARCHAR NOT NULL DEFAULT '',
+    recovery_codes VARCHAR[] NOT NULL DEFAULT '{}',
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    role VARCHAR NOT NULL DEFAULT 'player' CHECK (role IN ('player', 'moderator', 'admin')),
+    suspended BOOLEAN NOT NULL DEFAULT FALSE,
+    avatar_url VARCHAR NOT NULL DEFAULT '',
     PRIMARY KEY (id)
-);
\ No newline at end of file
+);
+
+-- Supports searching users by username prefix, ignoring case, in the order of
+-- the index, which is the order in which results are paginated.
+CREATE INDEX users_username_search_idx ON users ((lower(username) COLLATE "C"), id);
+
+CREATE TABLE linked_identities (
+    provider VARCHAR NOT NULL,
+    subject VARCHAR NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX linked_identities_user_id_idx ON linked_identities (user_id);
+
+CREATE TABLE friend_requests (
+    from_user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    to_user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (from_user_id, to_user_id),
+    CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX friend_requests_to_user_id_idx ON friend_requests (to_user_id);
+
+-- Friendships are stored once for each user, so that a user's friends can be
+-- listed with the primary key alone.
+CREATE TABLE friendships (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    friend_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    since TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, friend_id),
+    CHECK (user_id <> friend_id)
+);
+
+CREATE TABLE blocks (
+    blocker_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE games (
+    id uuid default uuid_generate_v4 (),
+    host_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status VARCHAR NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'in_progress', 'finished')),
+    ruleset VARCHAR NOT NULL DEFAULT 'classic' CHECK (ruleset IN ('classic', 'quick')),
+    max_players INTEGER NOT NULL CHECK (max_players > 0),
+    matchmade BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+
+-- The rank and net worth of players are only set once their game is finished.
+CREATE TABLE game_players (
+    game_id uuid NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rank INTEGER CHECK (rank > 0),
+    net_worth INTEGER,
+    properties VARCHAR[],
+    bankrupted_by uuid REFERENCES users (id) ON DELETE SET NULL,
+    PRIMARY KEY (game_id, user_id)
+);
+
+CREATE INDEX game_players_user_id_idx ON game_players (user_id);
+CREATE INDEX games_finished_at_idx ON games (finished_at DESC);
+
+-- Invites are both sent to specific users, who are their recipient, and
+-- shared as join codes, which have no recipient.
+CREATE TABLE invites (
+    code VARCHAR NOT NULL,
+    game_id uuid NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    created_by uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipient_id uuid REFERENCES users (id) ON DELETE CASCADE,
+    max_uses INTEGER NOT NULL CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (code)
+);
+
+CREATE INDEX invites_recipient_id_idx ON invites (recipient_id);
+
+-- Ratings are kept for all time, with the "all" period, and for each month,
+-- with periods such as "2021-03".
+CREATE TABLE ratings (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    period VARCHAR NOT NULL,
+    rating DOUBLE PRECISION NOT NULL,
+    deviation DOUBLE PRECISION NOT NULL,
+    volatility DOUBLE PRECISION NOT NULL,
+    games_played INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, period)
+);
+
+CREATE INDEX ratings_leaderboard_idx ON ratings (period, rating DESC);
+
+CREATE TABLE stats (
+    user_id uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    games_played INTEGER NOT NULL DEFAULT 0,
+    wins INTEGER NOT NULL DEFAULT 0,
+    total_net_worth BIGINT NOT NULL DEFAULT 0,
+    current_streak INTEGER NOT NULL DEFAULT 0,
+    longest_streak INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE stats_properties (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    property VARCHAR NOT NULL,
+    games_owned INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, property)
+);
+
+-- Achievements are defined in code, so only the players who unlocked them,
+-- and their progress towards those that take more than one game, are stored.
+CREATE TABLE achievement_unlocks (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    achievement_id VARCHAR NOT NULL,
+    unlocked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, achievement_id)
+);
+
+CREATE TABLE achievement_progress (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    achievement_id VARCHAR NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, achievement_id)
+);
+
+-- Games are only counted once towards the progress of each of their players.
+CREATE TABLE achievement_games (
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    game_id uuid NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, game_id)
+);
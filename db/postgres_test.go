@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/tracing"
 )
 
 var mConn = &mockConnection{}
@@ -168,6 +172,163 @@ func TestClosingPostgresDatabase(t *testing.T) {
 	})
 }
 
+func TestCheckingPostgresDatabase(t *testing.T) {
+	t.Run("fails when no connection was opened", func(t *testing.T) {
+		db := Postgres{}
+
+		if err := db.Check(context.Background()); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when driver fails to ping", func(t *testing.T) {
+		db := Postgres{}
+		db.Open()
+		defer db.Close()
+
+		mDriver.connector.conn.failOnPing = true
+
+		if err := db.Check(context.Background()); err == nil {
+			t.Fail()
+		}
+
+		mDriver.connector.conn.failOnPing = false
+	})
+
+	t.Run("succeeds when the database can be pinged", func(t *testing.T) {
+		db := Postgres{}
+		db.Open()
+		defer db.Close()
+
+		if err := db.Check(context.Background()); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresTransaction(t *testing.T) {
+	t.Run("fails when transaction cannot begin", func(t *testing.T) {
+		database, mock, _ := sqlmock.New()
+		defer database.Close()
+
+		mock.ExpectBegin().WillReturnError(fmt.Errorf("an error occurred"))
+
+		db := Postgres{DB: database}
+		called := false
+
+		err := db.InTransaction(func(*sql.Tx) error {
+			called = true
+			return nil
+		})
+		if err == nil || called {
+			t.Fail()
+		}
+	})
+
+	t.Run("rolls back and returns the function's error when it fails", func(t *testing.T) {
+		database, mock, _ := sqlmock.New()
+		defer database.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		db := Postgres{DB: database}
+		fnErr := fmt.Errorf("an error occurred")
+
+		if err := db.InTransaction(func(*sql.Tx) error { return fnErr }); err != fnErr {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("commits when the function succeeds", func(t *testing.T) {
+		database, mock, _ := sqlmock.New()
+		defer database.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		db := Postgres{DB: database}
+
+		if err := db.InTransaction(func(*sql.Tx) error { return nil }); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestRunningInTransactionWithContext(t *testing.T) {
+	t.Run("fails without calling the function when the context is done", func(t *testing.T) {
+		database, _, _ := sqlmock.New()
+		defer database.Close()
+
+		db := Postgres{DB: database}
+		called := false
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := db.InTransactionContext(ctx, func(*sql.Tx) error {
+			called = true
+			return nil
+		})
+		if err == nil || called {
+			t.Fail()
+		}
+	})
+}
+
+type mockExporter struct {
+	spans []tracing.SpanData
+}
+
+func (e *mockExporter) Export(_ context.Context, spans []tracing.SpanData) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTracingPostgresQueries(t *testing.T) {
+	t.Run("starts a span with the statement of each query", func(t *testing.T) {
+		database, mock, _ := sqlmock.New()
+		defer database.Close()
+
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"one"}).AddRow(1))
+		mock.ExpectQuery("SELECT 2").WillReturnError(fmt.Errorf("an error occurred"))
+		mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		exporter := &mockExporter{}
+		tracer := tracing.NewTracer(exporter, tracing.Config{})
+		ctx, _ := tracer.Start(context.Background(), "moose", tracing.SpanKindServer)
+
+		db := Postgres{DB: database}
+		var one int
+		db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+		db.QueryContext(ctx, "SELECT 2")
+		db.ExecContext(ctx, "DELETE", "secret")
+
+		stopped, stop := context.WithCancel(context.Background())
+		stop()
+		tracer.Run(stopped)
+
+		if len(exporter.spans) != 3 {
+			t.FailNow()
+		}
+		for i, statement := range []string{"SELECT 1", "SELECT 2", "DELETE"} {
+			span := exporter.spans[i]
+			if span.Name != "db.query" || len(span.Attributes) != 2 || span.Attributes[1].Value != statement {
+				t.Errorf("unexpected span %+v", span)
+			}
+		}
+		if exporter.spans[0].Err != nil || exporter.spans[1].Err == nil {
+			t.Fail()
+		}
+	})
+}
+
 func TestBuildingPostgresDataSourceName(t *testing.T) {
 	conf := Config{
 		Host:     "host",
@@ -0,0 +1,82 @@
+// Package encryption protects secrets that must be stored, but that must also
+// be readable again, such as the secrets shared with authenticator apps.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// KeySize represents the size of the keys used to encrypt secrets, in bytes.
+const KeySize = 32
+
+// A Cipher is an interface representing the ability to encrypt a secret, and
+// to decrypt it again.
+type Cipher interface {
+	// Encrypt encrypts the plain text, and returns it encoded in base 64.
+	Encrypt(plaintext string) (string, error)
+
+	// Decrypt decrypts cipher text that was encrypted by Encrypt.
+	Decrypt(ciphertext string) (string, error)
+}
+
+type aesGCMCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher creates a cipher that uses AES-256 in Galois/Counter Mode,
+// which detects when cipher text has been tampered with.
+//
+// The key must be KeySize bytes long.
+func NewAESGCMCipher(key []byte) (Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption.NewAESGCMCipher: key must be %d bytes long", KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption.NewAESGCMCipher: failed to create block cipher (%s)", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("encryption.NewAESGCMCipher: failed to create GCM cipher (%s)", err)
+	}
+
+	return &aesGCMCipher{aead}, nil
+}
+
+// Encrypt prepends the random nonce to the cipher text, since it is needed to
+// decrypt it.
+func (c *aesGCMCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("encryption.Cipher.Encrypt: failed to generate nonce (%s)", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *aesGCMCipher) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("encryption.Cipher.Decrypt: cipher text is not in base 64 (%s)", err)
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("encryption.Cipher.Decrypt: cipher text is too short")
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("encryption.Cipher.Decrypt: failed to decrypt (%s)", err)
+	}
+
+	return string(plaintext), nil
+}
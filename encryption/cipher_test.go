@@ -0,0 +1,84 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var key = bytes.Repeat([]byte{0x42}, KeySize)
+
+func TestAESGCMCipherCreation(t *testing.T) {
+	t.Run("fails when key has the wrong size", func(t *testing.T) {
+		if _, err := NewAESGCMCipher([]byte("too.short")); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a cipher when all is well", func(t *testing.T) {
+		if c, err := NewAESGCMCipher(key); err != nil || c == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestAESGCMCipherEncryption(t *testing.T) {
+	c, _ := NewAESGCMCipher(key)
+	secret := "a.very.secret.secret"
+
+	t.Run("never returns the plain text", func(t *testing.T) {
+		ciphertext, err := c.Encrypt(secret)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Contains(ciphertext, secret) {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns different cipher text every time", func(t *testing.T) {
+		first, _ := c.Encrypt(secret)
+		second, _ := c.Encrypt(secret)
+
+		if strings.Compare(first, second) == 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("decrypts what it encrypted", func(t *testing.T) {
+		ciphertext, _ := c.Encrypt(secret)
+
+		plaintext, err := c.Decrypt(ciphertext)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(secret, plaintext) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestAESGCMCipherDecryption(t *testing.T) {
+	c, _ := NewAESGCMCipher(key)
+
+	t.Run("fails when cipher text is not in base 64", func(t *testing.T) {
+		if _, err := c.Decrypt("not.base.64!"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when cipher text is too short", func(t *testing.T) {
+		if _, err := c.Decrypt("AAAA"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when cipher text was encrypted with another key", func(t *testing.T) {
+		other, _ := NewAESGCMCipher(bytes.Repeat([]byte{0x24}, KeySize))
+		ciphertext, _ := other.Encrypt("a.very.secret.secret")
+
+		if _, err := c.Decrypt(ciphertext); err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package encryption
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// DeriveKey derives a key of KeySize bytes for the given purpose from the key,
+// so that a single configured key can protect different kinds of secrets,
+// without what is encrypted for one purpose being readable for another.
+func DeriveKey(key []byte, purpose string) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption.DeriveKey: key must be %d bytes long", KeySize)
+	}
+
+	if purpose == "" {
+		return nil, fmt.Errorf("encryption.DeriveKey: purpose is required")
+	}
+
+	derived := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(purpose)), derived); err != nil {
+		return nil, fmt.Errorf("encryption.DeriveKey: %s", err)
+	}
+
+	return derived, nil
+}
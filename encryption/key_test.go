@@ -0,0 +1,56 @@
+package encryption
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	t.Run("fails when key has the wrong size", func(t *testing.T) {
+		if _, err := DeriveKey([]byte("too.short"), "a.purpose"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without a purpose", func(t *testing.T) {
+		if _, err := DeriveKey(key, ""); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("derives the same key for the same purpose", func(t *testing.T) {
+		first, err := DeriveKey(key, "a.purpose")
+		if err != nil {
+			t.FailNow()
+		}
+		second, err := DeriveKey(key, "a.purpose")
+		if err != nil {
+			t.FailNow()
+		}
+		if len(first) != KeySize || !bytes.Equal(first, second) {
+			t.Fail()
+		}
+	})
+
+	t.Run("derives different keys for different purposes", func(t *testing.T) {
+		first, _ := DeriveKey(key, "a.purpose")
+		second, _ := DeriveKey(key, "another.purpose")
+		if bytes.Equal(first, second) || bytes.Equal(first, key) {
+			t.Fail()
+		}
+	})
+
+	t.Run("cannot decrypt what was encrypted with the other key", func(t *testing.T) {
+		derived, _ := DeriveKey(key, "a.purpose")
+		c, _ := NewAESGCMCipher(key)
+		other, _ := NewAESGCMCipher(derived)
+
+		ciphertext, err := c.Encrypt("a.very.secret.secret")
+		if err != nil {
+			t.FailNow()
+		}
+		if _, err := other.Decrypt(ciphertext); err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -5,3 +5,45 @@ import "context"
 type Endpoint func(ctx context.Context, request interface{}) (response interface{}, err error)
 
 type Middleware func(endpoint Endpoint) Endpoint
+
+// Chain composes middlewares into one, in which the first middleware is the
+// outermost, so it is the first to see requests, and the last to see
+// responses.
+func Chain(outer Middleware, others ...Middleware) Middleware {
+	return func(next Endpoint) Endpoint {
+		for i := len(others) - 1; i >= 0; i-- {
+			next = others[i](next)
+		}
+
+		return outer(next)
+	}
+}
+
+// NamedMiddleware creates a middleware for the endpoint with the name, such
+// as "user.register", for middlewares that label what they record with it,
+// like spans, metrics, and logs.
+//
+// Handlers accept named middlewares to apply to all of their endpoints, so
+// that cross-cutting behavior is applied uniformly.
+type NamedMiddleware func(name string) Middleware
+
+// Unnamed adapts a middleware that does not need the name of endpoints.
+func Unnamed(m Middleware) NamedMiddleware {
+	return func(string) Middleware {
+		return m
+	}
+}
+
+// ChainNamed composes named middlewares into one, in the same order as
+// Chain, which does nothing when there are none.
+func ChainNamed(middlewares ...NamedMiddleware) NamedMiddleware {
+	return func(name string) Middleware {
+		return func(next Endpoint) Endpoint {
+			for i := len(middlewares) - 1; i >= 0; i-- {
+				next = middlewares[i](name)(next)
+			}
+
+			return next
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package endpoint
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// recorder creates a middleware that appends its name to the response of
+// the endpoint, to tell in which order middlewares are applied.
+func recorder(name string) Middleware {
+	return func(next Endpoint) Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request.(string)+name)
+			return response.(string) + name, err
+		}
+	}
+}
+
+func echo(_ context.Context, request interface{}) (interface{}, error) {
+	return request.(string) + "|", nil
+}
+
+func TestChain(t *testing.T) {
+	t.Run("applies the first middleware outermost", func(t *testing.T) {
+		e := Chain(recorder("a"), recorder("b"), recorder("c"))(echo)
+
+		response, err := e(context.Background(), "")
+		if err != nil || response != "abc|cba" {
+			t.Errorf("unexpected response %v", response)
+		}
+	})
+
+	t.Run("applies a single middleware", func(t *testing.T) {
+		response, _ := Chain(recorder("a"))(echo)(context.Background(), "")
+
+		if response != "a|a" {
+			t.Errorf("unexpected response %v", response)
+		}
+	})
+}
+
+func TestChainNamed(t *testing.T) {
+	t.Run("gives the name of the endpoint to each middleware", func(t *testing.T) {
+		var names []string
+		named := func(name string) Middleware {
+			names = append(names, name)
+			return recorder(name)
+		}
+
+		e := ChainNamed(named, Unnamed(recorder("b")))("a")(echo)
+
+		response, err := e(context.Background(), "")
+		if err != nil || response != "ab|ba" {
+			t.Errorf("unexpected response %v", response)
+		}
+		if strings.Join(names, ",") != "a" {
+			t.Fail()
+		}
+	})
+
+	t.Run("does nothing when there are no middlewares", func(t *testing.T) {
+		response, _ := ChainNamed()("moose")(echo)(context.Background(), "")
+
+		if response != "|" {
+			t.Errorf("unexpected response %v", response)
+		}
+	})
+}
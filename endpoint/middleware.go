@@ -0,0 +1,87 @@
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrTimeout is returned when an endpoint did not respond in time.
+var ErrTimeout = errors.New("request timed out")
+
+// PanicError is returned when an endpoint panicked, with what it panicked
+// with, and where.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("endpoint panicked: %v", e.Value)
+}
+
+// Recover creates a middleware that recovers from panics in the endpoint,
+// such as an unexpected type of request, and returns them as a PanicError,
+// so that they are handled like any other error.
+func Recover() Middleware {
+	return func(next Endpoint) Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					response = nil
+					err = &PanicError{v, debug.Stack()}
+				}
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// Timeout creates a middleware that cancels the context of the endpoint once
+// the timeout has elapsed, and returns ErrTimeout when it failed because of
+// it.
+//
+// Endpoints must pass the context down to what they call, such as the
+// database, for it to stop them.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Endpoint) Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			response, err := next(timeoutCtx, request)
+			// When the caller went away, the deadline was not the reason.
+			if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+				return nil, ErrTimeout
+			}
+
+			return response, err
+		}
+	}
+}
+
+// Validator is implemented by requests that can tell whether they are valid
+// before they reach the endpoint.
+type Validator interface {
+	Validate() error
+}
+
+// Validate creates a middleware that rejects requests that implement
+// Validator, and are invalid, with the error they returned, without calling
+// the endpoint.
+func Validate() Middleware {
+	return func(next Endpoint) Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if v, ok := request.(Validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, err
+				}
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package endpoint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	t.Run("returns a panic as an error with its stack", func(t *testing.T) {
+		e := Recover()(func(_ context.Context, request interface{}) (interface{}, error) {
+			return request.(int), nil
+		})
+
+		response, err := e(context.Background(), "moose")
+
+		panicErr, ok := err.(*PanicError)
+		if !ok || response != nil {
+			t.FailNow()
+		}
+		if !strings.Contains(panicErr.Error(), "interface conversion") || len(panicErr.Stack) == 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the response when the endpoint does not panic", func(t *testing.T) {
+		response, err := Recover()(echo)(context.Background(), "moose")
+
+		if err != nil || response != "moose|" {
+			t.Fail()
+		}
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	wait := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return "done", nil
+		}
+	}
+
+	t.Run("fails with timeout when the endpoint runs out of time", func(t *testing.T) {
+		if _, err := Timeout(time.Millisecond)(wait)(context.Background(), nil); err != ErrTimeout {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the error of the endpoint when the caller went away", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := Timeout(time.Minute)(wait)(ctx, nil); err != context.Canceled {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the response when the endpoint is in time", func(t *testing.T) {
+		response, err := Timeout(time.Minute)(echo)(context.Background(), "moose")
+
+		if err != nil || response != "moose|" {
+			t.Fail()
+		}
+	})
+}
+
+type mockRequest struct {
+	err error
+}
+
+func (req mockRequest) Validate() error {
+	return req.err
+}
+
+func TestValidate(t *testing.T) {
+	called := false
+	e := Validate()(func(_ context.Context, _ interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	t.Run("rejects invalid requests without calling the endpoint", func(t *testing.T) {
+		called = false
+		invalid := fmt.Errorf("moose is required")
+
+		if _, err := e(context.Background(), mockRequest{invalid}); err != invalid || called {
+			t.Fail()
+		}
+	})
+
+	t.Run("calls the endpoint with valid requests", func(t *testing.T) {
+		called = false
+
+		if _, err := e(context.Background(), mockRequest{}); err != nil || !called {
+			t.Fail()
+		}
+	})
+
+	t.Run("calls the endpoint with requests that cannot be validated", func(t *testing.T) {
+		called = false
+
+		if _, err := e(context.Background(), "moose"); err != nil || !called {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// AchievementUnlock represents an achievement a player unlocked.
+type AchievementUnlock struct {
+	UserID        string
+	AchievementID string
+	UnlockedAt    time.Time
+}
+
+// AchievementGame represents a finished game whose results were counted
+// towards a player's achievements.
+type AchievementGame struct {
+	UserID string
+	GameID string
+}
+
+// AchievementProgress represents how close a player is to unlocking an
+// achievement that takes more than one game.
+type AchievementProgress struct {
+	UserID        string
+	AchievementID string
+	Count         int
+}
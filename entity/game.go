@@ -0,0 +1,121 @@
+package entity
+
+import "time"
+
+// GameStatus represents where a game is in its lifecycle.
+type GameStatus string
+
+const (
+	// GameStatusWaiting indicates that the game is waiting for players, who
+	// can still join it.
+	GameStatusWaiting GameStatus = "waiting"
+
+	// GameStatusInProgress indicates that the game was started, so no one
+	// else can join it, and its results can be reported.
+	GameStatusInProgress GameStatus = "in_progress"
+	GameStatusFinished   GameStatus = "finished"
+)
+
+// Ruleset represents the rules a game is played with.
+type Ruleset string
+
+const (
+	RulesetClassic Ruleset = "classic"
+
+	// RulesetQuick shortens games by dealing properties to players when the
+	// game starts.
+	RulesetQuick Ruleset = "quick"
+)
+
+type Game struct {
+	ID         string
+	HostID     string
+	Status     GameStatus
+	Ruleset    Ruleset
+	MaxPlayers int
+
+	// Matchmade tells whether the players were matched together by
+	// matchmaking, rather than invited by the host.
+	Matchmade bool
+
+	// PlayerIDs are the IDs of the users playing the game, in the order they
+	// joined it, starting with the host.
+	PlayerIDs []string
+
+	// Results are how each player did, in the same order as the players,
+	// once the game is finished.
+	Results    []PlayerResult
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// PlayerResult represents how a player did in a finished game.
+type PlayerResult struct {
+	UserID string
+
+	// Rank is the player's place in the game, starting at 1 for the winner.
+	// Players who tied share the same rank.
+	Rank     int
+	NetWorth int
+
+	// Properties are the names of the properties the player owned when the
+	// game finished.
+	Properties []string
+
+	// BankruptedBy is the ID of the player who bankrupted this one, or empty
+	// if they were not bankrupted by another player.
+	BankruptedBy string
+}
+
+// HasPlayer tells whether the user is playing the game.
+func (g Game) HasPlayer(userID string) bool {
+	for _, id := range g.PlayerIDs {
+		if id == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rated tells whether the game counts towards the ratings, stats and
+// achievements of its players. Only matchmade games that were played to the
+// end do, since the results of games are reported by their host alone, who
+// could otherwise make them up with friends.
+func (g Game) Rated() bool {
+	return g.Matchmade && g.Status == GameStatusFinished
+}
+
+// Result returns how the player did in the game, or nil if they did not play
+// it, or it is not finished.
+func (g Game) Result(userID string) *PlayerResult {
+	for _, r := range g.Results {
+		if r.UserID == userID {
+			return &r
+		}
+	}
+
+	return nil
+}
+
+// Invite represents an invitation to join a game, which is accepted with its
+// code.
+type Invite struct {
+	Code      string
+	GameID    string
+	CreatedBy string
+
+	// RecipientID is the ID of the only user who can accept the invite, or
+	// empty if anyone who has the code can, as is the case for join codes.
+	RecipientID string
+
+	MaxUses   int
+	Uses      int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Usable tells whether the invite can still be accepted at the given time.
+func (i Invite) Usable(now time.Time) bool {
+	return i.Uses < i.MaxUses && now.Before(i.ExpiresAt)
+}
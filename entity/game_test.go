@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGameHasPlayer(t *testing.T) {
+	game := Game{PlayerIDs: []string{"host", "guest"}}
+
+	t.Run("tells that players are playing", func(t *testing.T) {
+		if !game.HasPlayer("guest") {
+			t.Fail()
+		}
+	})
+
+	t.Run("tells that other users are not playing", func(t *testing.T) {
+		if game.HasPlayer("stranger") {
+			t.Fail()
+		}
+	})
+}
+
+func TestGameResult(t *testing.T) {
+	game := Game{
+		PlayerIDs: []string{"host", "guest"},
+		Results: []PlayerResult{
+			{UserID: "host", Rank: 2},
+			{UserID: "guest", Rank: 1},
+		},
+	}
+
+	t.Run("returns the player's result", func(t *testing.T) {
+		if r := game.Result("guest"); r == nil || r.Rank != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns nil for other users", func(t *testing.T) {
+		if game.Result("stranger") != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInviteUsable(t *testing.T) {
+	now := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	invite := Invite{MaxUses: 2, Uses: 1, ExpiresAt: now.Add(time.Hour)}
+
+	t.Run("is usable when it has uses left and has not expired", func(t *testing.T) {
+		if !invite.Usable(now) {
+			t.Fail()
+		}
+	})
+
+	t.Run("is not usable once all uses are used", func(t *testing.T) {
+		i := invite
+		i.Uses = 2
+
+		if i.Usable(now) {
+			t.Fail()
+		}
+	})
+
+	t.Run("is not usable once expired", func(t *testing.T) {
+		if invite.Usable(now.Add(time.Hour)) {
+			t.Fail()
+		}
+	})
+}
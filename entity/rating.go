@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// Rating represents a player's skill, as estimated by the Glicko-2 rating
+// system, over a period, such as all time, or a month.
+type Rating struct {
+	UserID string
+	Period string
+
+	Rating     float64
+	Deviation  float64
+	Volatility float64
+
+	GamesPlayed int
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// FriendRequest represents a request from one user to become friends with
+// another, which is pending until the other user accepts or declines it.
+type FriendRequest struct {
+	FromUserID string
+	ToUserID   string
+	CreatedAt  time.Time
+}
+
+// Friendship represents one side of a friendship between two users.
+//
+// Friendships are symmetric, so there is one for each user.
+type Friendship struct {
+	UserID   string
+	FriendID string
+	Since    time.Time
+}
+
+// Block represents a user who blocked another, so that neither can befriend
+// nor invite the other.
+type Block struct {
+	BlockerID string
+	BlockedID string
+	CreatedAt time.Time
+}
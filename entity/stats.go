@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// Stats represents a player's statistics over all the games they finished.
+//
+// They are updated incrementally as games finish, rather than computed from
+// the whole history of the player every time they are requested.
+type Stats struct {
+	UserID string
+
+	GamesPlayed   int
+	Wins          int
+	TotalNetWorth int64
+
+	// CurrentStreak is the number of games the player won in a row, up to
+	// their last one, and LongestStreak is the most they ever did.
+	CurrentStreak int
+	LongestStreak int
+
+	// PropertyCounts counts the games the player finished owning each
+	// property.
+	PropertyCounts map[string]int
+
+	UpdatedAt time.Time
+}
@@ -5,10 +5,77 @@ import (
 )
 
 type User struct {
-	ID       string
-	Username string
-	Email    string
-	Password string
+	ID        string
+	Username  string
+	Email     string
+	Password  string
+	TwoFactor TwoFactor
+	Role      Role
+
+	// Suspended indicates whether or not the user has been barred from
+	// logging in by an admin.
+	Suspended bool
+
+	// AvatarURL represents the URL of the picture the user chose to appear
+	// with, if any.
+	AvatarURL string
+}
+
+// Role represents what a user is allowed to do.
+type Role string
+
+const (
+	RolePlayer    Role = "player"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Valid tells whether the role is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RolePlayer, RoleModerator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// TwoFactor represents the state of a user's two-factor authentication with
+// time-based one-time passwords (TOTP).
+type TwoFactor struct {
+	// Enabled indicates whether or not a one-time password is required to log
+	// in.
+	Enabled bool
+
+	// Secret represents the encrypted secret shared with the user's
+	// authenticator app.
+	//
+	// It is set as soon as two-factor authentication is set up, but it is only
+	// enabled once the user proves that they can generate one-time passwords.
+	Secret string
+
+	// RecoveryCodes represents the hashes of the codes that can each be used
+	// once to log in when the authenticator app is not available.
+	RecoveryCodes []string
+
+	// LastUsedStep represents the time step of the last one-time password
+	// that was accepted, so that neither it, nor those before it, can be used
+	// again.
+	LastUsedStep uint64
+}
+
+// LinkedIdentity represents an identity from an external identity provider,
+// such as Google, that a user can sign in with.
+type LinkedIdentity struct {
+	// Provider represents the name of the identity provider.
+	Provider string
+
+	// Subject represents the identifier of the user at the identity provider,
+	// which never changes, unlike their email.
+	Subject string
+
+	// UserID represents the ID of the user the identity is linked to.
+	UserID string
 }
 
 func (u User) Validate() error {
@@ -57,4 +57,40 @@ func TestUserStringFormat(t *testing.T) {
 			t.Fail()
 		}
 	})
+
+	t.Run("never prints two-factor secret or recovery codes", func(t *testing.T) {
+		u := user
+		u.TwoFactor = TwoFactor{
+			Enabled:       true,
+			Secret:        "a.very.secret.secret",
+			RecoveryCodes: []string{"a.hashed.recovery.code"},
+		}
+
+		userString := u.String()
+
+		if strings.Contains(userString, u.TwoFactor.Secret) {
+			t.Fail()
+		}
+		if strings.Contains(userString, u.TwoFactor.RecoveryCodes[0]) {
+			t.Fail()
+		}
+	})
+}
+
+func TestRoleValidity(t *testing.T) {
+	t.Run("known roles are valid", func(t *testing.T) {
+		for _, role := range []Role{RolePlayer, RoleModerator, RoleAdmin} {
+			if !role.Valid() {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("unknown and empty roles are invalid", func(t *testing.T) {
+		for _, role := range []Role{"", "emperor"} {
+			if role.Valid() {
+				t.Fail()
+			}
+		}
+	})
 }
@@ -0,0 +1,197 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type gameResponse struct {
+	ID         string            `json:"id"`
+	HostID     string            `json:"hostId"`
+	Status     entity.GameStatus `json:"status"`
+	Ruleset    entity.Ruleset    `json:"ruleset"`
+	MaxPlayers int               `json:"maxPlayers"`
+	Matchmade  bool              `json:"matchmade"`
+	PlayerIDs  []string          `json:"playerIds"`
+	Results    []resultResponse  `json:"results,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+}
+
+type resultResponse struct {
+	UserID     string   `json:"userId"`
+	Rank       int      `json:"rank"`
+	NetWorth   int      `json:"netWorth"`
+	Properties []string `json:"properties"`
+
+	BankruptedBy string `json:"bankruptedBy,omitempty"`
+}
+
+func newGameResponse(g *entity.Game) *gameResponse {
+	response := &gameResponse{
+		ID:         g.ID,
+		HostID:     g.HostID,
+		Status:     g.Status,
+		Ruleset:    g.Ruleset,
+		MaxPlayers: g.MaxPlayers,
+		Matchmade:  g.Matchmade,
+		PlayerIDs:  g.PlayerIDs,
+		CreatedAt:  g.CreatedAt,
+	}
+
+	for _, r := range g.Results {
+		properties := r.Properties
+		if properties == nil {
+			properties = make([]string, 0)
+		}
+
+		response.Results = append(response.Results, resultResponse{
+			UserID:       r.UserID,
+			Rank:         r.Rank,
+			NetWorth:     r.NetWorth,
+			Properties:   properties,
+			BankruptedBy: r.BankruptedBy,
+		})
+	}
+
+	if !g.FinishedAt.IsZero() {
+		response.FinishedAt = &g.FinishedAt
+	}
+
+	return response
+}
+
+type createGameRequest struct {
+	MaxPlayers int
+	Ruleset    entity.Ruleset
+}
+
+func makeCreateGameEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createGameRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		g, err := svc.Create(identity.UserID, req.MaxPlayers, req.Ruleset)
+		if err != nil {
+			return nil, err
+		}
+
+		return newGameResponse(g), nil
+	}
+}
+
+type getGameRequest struct {
+	ID string
+}
+
+func makeGetGameEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getGameRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		g, err := svc.GetByID(req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Games are private, so only their players can see them.
+		if !g.HasPlayer(identity.UserID) {
+			return nil, auth.ErrForbidden
+		}
+
+		return newGameResponse(g), nil
+	}
+}
+
+type startGameRequest struct {
+	ID string
+}
+
+func makeStartGameEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(startGameRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		g, err := svc.Start(identity.UserID, req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return newGameResponse(g), nil
+	}
+}
+
+type finishGameRequest struct {
+	ID      string
+	Results []entity.PlayerResult
+}
+
+func makeFinishGameEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(finishGameRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		g, err := svc.Finish(identity.UserID, req.ID, req.Results)
+		if err != nil {
+			return nil, err
+		}
+
+		return newGameResponse(g), nil
+	}
+}
+
+type listHistoryRequest struct {
+	UserID string
+	Offset int
+	Limit  int
+}
+
+type listHistoryResponse struct {
+	Games []*gameResponse `json:"games"`
+}
+
+func makeListHistoryEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listHistoryRequest)
+
+		// Finished games are part of their players' public record, so any
+		// authenticated user can see them.
+		if _, ok := auth.FromContext(ctx); !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		games, err := svc.ListHistory(req.UserID, req.Offset, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &listHistoryResponse{
+			Games: make([]*gameResponse, 0, len(games)),
+		}
+		for i := range games {
+			resp.Games = append(resp.Games, newGameResponse(&games[i]))
+		}
+
+		return resp, nil
+	}
+}
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inMemoryRepository struct {
+	nextID   int
+	database *db.InMemory
+}
+
+func NewInMemoryRepository(database *db.InMemory) Repository {
+	return &inMemoryRepository{0, database}
+}
+
+func (repo *inMemoryRepository) Create(game entity.Game) (*entity.Game, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	game.ID = strconv.Itoa(repo.nextID)
+	if len(game.PlayerIDs) == 0 {
+		game.PlayerIDs = []string{game.HostID}
+	}
+
+	repo.nextID++
+
+	repo.database.Games = append(repo.database.Games, game)
+
+	return copyGame(game), nil
+}
+
+func (repo *inMemoryRepository) GetByID(id string) (*entity.Game, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, g := range repo.database.Games {
+		if g.ID == id {
+			return copyGame(g), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *inMemoryRepository) AddPlayer(gameID string, userID string, joinedAt time.Time) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, g := range repo.database.Games {
+		if g.ID != gameID {
+			continue
+		}
+
+		if g.HasPlayer(userID) {
+			return errAlreadyJoined
+		}
+		if g.Status != entity.GameStatusWaiting {
+			return ErrStarted
+		}
+		if len(g.PlayerIDs) >= g.MaxPlayers {
+			return ErrFull
+		}
+
+		repo.database.Games[i].PlayerIDs = append(g.PlayerIDs, userID)
+
+		return nil
+	}
+
+	return fmt.Errorf("game.InMemoryRepository.AddPlayer: no game exists with ID \"%s\"", gameID)
+}
+
+func (repo *inMemoryRepository) Start(gameID string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, g := range repo.database.Games {
+		if g.ID != gameID {
+			continue
+		}
+
+		if g.Status != entity.GameStatusWaiting {
+			return ErrStarted
+		}
+
+		repo.database.Games[i].Status = entity.GameStatusInProgress
+
+		return nil
+	}
+
+	return fmt.Errorf("game.InMemoryRepository.Start: no game exists with ID \"%s\"", gameID)
+}
+
+func (repo *inMemoryRepository) Finish(gameID string, results []entity.PlayerResult, finishedAt time.Time) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, g := range repo.database.Games {
+		if g.ID != gameID {
+			continue
+		}
+
+		if g.Status == entity.GameStatusFinished {
+			return ErrFinished
+		}
+		if g.Status != entity.GameStatusInProgress {
+			return ErrNotStarted
+		}
+
+		ordered := make([]entity.PlayerResult, 0, len(g.PlayerIDs))
+		for _, playerID := range g.PlayerIDs {
+			for _, r := range results {
+				if r.UserID == playerID {
+					ordered = append(ordered, r)
+				}
+			}
+		}
+		if len(ordered) != len(results) {
+			return fmt.Errorf("game.InMemoryRepository.Finish: results are not for the game's players")
+		}
+
+		repo.database.Games[i].Status = entity.GameStatusFinished
+		repo.database.Games[i].Results = ordered
+		repo.database.Games[i].FinishedAt = finishedAt
+
+		return nil
+	}
+
+	return fmt.Errorf("game.InMemoryRepository.Finish: no game exists with ID \"%s\"", gameID)
+}
+
+func (repo *inMemoryRepository) ListFinishedByPlayer(userID string, offset int, limit int) ([]entity.Game, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	finished := make([]entity.Game, 0)
+	for _, g := range repo.database.Games {
+		if g.Status == entity.GameStatusFinished && g.HasPlayer(userID) {
+			finished = append(finished, *copyGame(g))
+		}
+	}
+
+	sort.SliceStable(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.After(finished[j].FinishedAt)
+	})
+
+	if offset >= len(finished) {
+		return make([]entity.Game, 0), nil
+	}
+
+	end := offset + limit
+	if end > len(finished) {
+		end = len(finished)
+	}
+
+	return finished[offset:end], nil
+}
+
+// copyGame copies the game, so that its players and results are not shared
+// with the one in the database.
+func copyGame(g entity.Game) *entity.Game {
+	g.PlayerIDs = append([]string(nil), g.PlayerIDs...)
+	if g.Results != nil {
+		g.Results = append([]entity.PlayerResult(nil), g.Results...)
+		for i, r := range g.Results {
+			g.Results[i].Properties = append([]string(nil), r.Properties...)
+		}
+	}
+
+	return &g
+}
@@ -0,0 +1,258 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func newInMemoryRepository() Repository {
+	database := &db.InMemory{}
+	database.Open()
+
+	return NewInMemoryRepository(database)
+}
+
+func TestInMemoryRepositoryCreation(t *testing.T) {
+	t.Run("creates a game with a new ID and the host as its only player", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		first, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+		second, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+
+		if strings.Compare(first.ID, second.ID) == 0 {
+			t.Fail()
+		}
+		if len(first.PlayerIDs) != 1 || first.PlayerIDs[0] != mockHostID {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryGettingGame(t *testing.T) {
+	t.Run("returns nil when no game exists with the ID", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if g, err := repo.GetByID("ghost"); err != nil || g != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a copy of the game", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		created, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+
+		g, _ := repo.GetByID(created.ID)
+		g.PlayerIDs[0] = "someone.else"
+
+		if g, _ := repo.GetByID(created.ID); g.PlayerIDs[0] != mockHostID {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryAddingPlayer(t *testing.T) {
+	t.Run("fails when game is full", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+		repo.AddPlayer(g.ID, mockPlayerID, time.Now())
+
+		if err := repo.AddPlayer(g.ID, "a.third.player", time.Now()); err != ErrFull {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when game has started", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusInProgress, MaxPlayers: 2})
+
+		if err := repo.AddPlayer(g.ID, mockPlayerID, time.Now()); err != ErrStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user already joined", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 4})
+
+		if err := repo.AddPlayer(g.ID, mockHostID, time.Now()); err != errAlreadyJoined {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when game does not exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if err := repo.AddPlayer("ghost", mockPlayerID, time.Now()); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("adds the player after the others when all is well", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+
+		if err := repo.AddPlayer(g.ID, mockPlayerID, time.Now()); err != nil {
+			t.FailNow()
+		}
+
+		g, _ = repo.GetByID(g.ID)
+		if len(g.PlayerIDs) != 2 || g.PlayerIDs[1] != mockPlayerID {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryStartingGame(t *testing.T) {
+	t.Run("fails when game does not exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if err := repo.Start("ghost"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with ErrStarted when game is no longer waiting", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusInProgress, MaxPlayers: 2})
+
+		if err := repo.Start(g.ID); err != ErrStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("marks the game as in progress", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+
+		if err := repo.Start(g.ID); err != nil {
+			t.FailNow()
+		}
+
+		if g, _ := repo.GetByID(g.ID); g.Status != entity.GameStatusInProgress {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryFinishingGame(t *testing.T) {
+	results := []entity.PlayerResult{
+		{UserID: mockPlayerID, Rank: 1},
+		{UserID: mockHostID, Rank: 2},
+	}
+
+	t.Run("records the results in the order of the players", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+		repo.AddPlayer(g.ID, mockPlayerID, time.Now())
+		repo.Start(g.ID)
+
+		if err := repo.Finish(g.ID, results, time.Now()); err != nil {
+			t.FailNow()
+		}
+
+		g, _ = repo.GetByID(g.ID)
+		if g.Status != entity.GameStatusFinished || len(g.Results) != 2 || g.Results[0].UserID != mockHostID {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with ErrFinished when game is already finished", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusFinished, MaxPlayers: 2})
+
+		if err := repo.Finish(g.ID, results, time.Now()); err != ErrFinished {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with ErrNotStarted when game was not started", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+		repo.AddPlayer(g.ID, mockPlayerID, time.Now())
+
+		if err := repo.Finish(g.ID, results, time.Now()); err != ErrNotStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when results are not for the game's players", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusInProgress, MaxPlayers: 2})
+
+		if err := repo.Finish(g.ID, results, time.Now()); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryListingFinishedGames(t *testing.T) {
+	t.Run("lists the player's finished games from the most recent", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		finishedAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+		ids := make([]string, 0)
+		for i := 0; i < 3; i++ {
+			g, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+			repo.AddPlayer(g.ID, mockPlayerID, time.Now())
+			repo.Start(g.ID)
+			repo.Finish(g.ID, []entity.PlayerResult{
+				{UserID: mockHostID, Rank: 1},
+				{UserID: mockPlayerID, Rank: 2},
+			}, finishedAt.Add(time.Duration(i)*time.Hour))
+			ids = append(ids, g.ID)
+		}
+		repo.Create(entity.Game{HostID: mockPlayerID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+
+		games, err := repo.ListFinishedByPlayer(mockPlayerID, 1, 5)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(games) != 2 || games[0].ID != ids[1] || games[1].ID != ids[0] {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryConcurrency(t *testing.T) {
+	t.Run("creates and joins games from concurrent requests", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		shared, _ := repo.Create(entity.Game{HostID: mockHostID, Status: entity.GameStatusWaiting, MaxPlayers: MaxPlayers})
+
+		const requests = 20
+
+		var wg sync.WaitGroup
+		ids := make(chan string, requests)
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func(userID string) {
+				defer wg.Done()
+
+				g, _ := repo.Create(entity.Game{HostID: userID, Status: entity.GameStatusWaiting, MaxPlayers: 2})
+				ids <- g.ID
+
+				repo.AddPlayer(shared.ID, userID, time.Now())
+				repo.GetByID(shared.ID)
+			}(strconv.Itoa(i))
+		}
+		wg.Wait()
+		close(ids)
+
+		seen := make(map[string]bool)
+		for id := range ids {
+			if seen[id] || id == shared.ID {
+				t.Errorf("expected game ID \"%s\" to be unique", id)
+			}
+			seen[id] = true
+		}
+
+		if g, _ := repo.GetByID(shared.ID); len(g.PlayerIDs) != MaxPlayers {
+			t.Errorf("expected the game to be full with %d players, got %d", MaxPlayers, len(g.PlayerIDs))
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+const (
+	createQuery     = "INSERT INTO games(host_id, status, ruleset, max_players, matchmade, created_at) VALUES($1, $2, $3, $4, $5, $6) RETURNING id"
+	getByIDQuery    = "SELECT id, host_id, status, ruleset, max_players, matchmade, created_at, finished_at FROM games WHERE id = $1"
+	getPlayersQuery = "SELECT user_id, rank, net_worth, properties, COALESCE(bankrupted_by::text, '') FROM game_players WHERE game_id = $1 ORDER BY joined_at, user_id"
+	addPlayerQuery  = "INSERT INTO game_players(game_id, user_id, joined_at) VALUES($1, $2, $3)"
+	setResultQuery  = "UPDATE game_players SET rank = $3, net_worth = $4, properties = $5, bankrupted_by = NULLIF($6, '')::uuid WHERE game_id = $1 AND user_id = $2"
+	startQuery      = "UPDATE games SET status = 'in_progress' WHERE id = $1"
+	finishQuery     = "UPDATE games SET status = 'finished', finished_at = $2 WHERE id = $1"
+
+	listFinishedByPlayerQuery = "SELECT g.id FROM games g JOIN game_players p ON p.game_id = g.id WHERE p.user_id = $1 AND g.status = 'finished' ORDER BY g.finished_at DESC, g.id OFFSET $2 LIMIT $3"
+
+	// The game is locked until players are counted and added, so that
+	// players joining at the same time cannot exceed the maximum.
+	lockQuery         = "SELECT status, max_players FROM games WHERE id = $1 FOR UPDATE"
+	countPlayersQuery = "SELECT count(*) FROM game_players WHERE game_id = $1"
+)
+
+type postgresRepository struct {
+	database *db.Postgres
+}
+
+func NewPostgresRepository(database *db.Postgres) Repository {
+	return &postgresRepository{database}
+}
+
+func (pr *postgresRepository) Create(game entity.Game) (*entity.Game, error) {
+	if len(game.PlayerIDs) == 0 {
+		game.PlayerIDs = []string{game.HostID}
+	}
+
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		err := tx.QueryRow(createQuery, game.HostID, game.Status, game.Ruleset, game.MaxPlayers, game.Matchmade, game.CreatedAt).Scan(&game.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create game (%s)", err)
+		}
+
+		for _, playerID := range game.PlayerIDs {
+			if _, err := tx.Exec(addPlayerQuery, game.ID, playerID, game.CreatedAt); err != nil {
+				return fmt.Errorf("failed to add player (%s)", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("game.PostgresRepository.Create: %s", err)
+	}
+
+	return &game, nil
+}
+
+func (pr *postgresRepository) GetByID(id string) (*entity.Game, error) {
+	var game entity.Game
+	var finishedAt sql.NullTime
+
+	err := pr.database.QueryRow(getByIDQuery, id).Scan(
+		&game.ID,
+		&game.HostID,
+		&game.Status,
+		&game.Ruleset,
+		&game.MaxPlayers,
+		&game.Matchmade,
+		&game.CreatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"game.PostgresRepository.GetByID: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rows, err := pr.database.Query(getPlayersQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"game.PostgresRepository.GetByID: failed to execute players query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	game.PlayerIDs = make([]string, 0)
+	for rows.Next() {
+		var playerID string
+		var rank, netWorth sql.NullInt64
+		var properties []string
+		var bankruptedBy string
+		if err := rows.Scan(&playerID, &rank, &netWorth, pq.Array(&properties), &bankruptedBy); err != nil {
+			return nil, fmt.Errorf(
+				"game.PostgresRepository.GetByID: failed to read player (%s)",
+				err,
+			)
+		}
+
+		game.PlayerIDs = append(game.PlayerIDs, playerID)
+
+		if finishedAt.Valid {
+			game.Results = append(game.Results, entity.PlayerResult{
+				UserID:       playerID,
+				Rank:         int(rank.Int64),
+				NetWorth:     int(netWorth.Int64),
+				Properties:   properties,
+				BankruptedBy: bankruptedBy,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"game.PostgresRepository.GetByID: failed to read players (%s)",
+			err,
+		)
+	}
+
+	game.FinishedAt = finishedAt.Time
+
+	return &game, nil
+}
+
+func (pr *postgresRepository) AddPlayer(gameID string, userID string, joinedAt time.Time) error {
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		var status entity.GameStatus
+		var maxPlayers int
+		if err := tx.QueryRow(lockQuery, gameID).Scan(&status, &maxPlayers); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("no game exists with ID \"%s\"", gameID)
+			}
+
+			return fmt.Errorf("failed to lock game (%s)", err)
+		}
+
+		if status != entity.GameStatusWaiting {
+			return ErrStarted
+		}
+
+		var players int
+		if err := tx.QueryRow(countPlayersQuery, gameID).Scan(&players); err != nil {
+			return fmt.Errorf("failed to count players (%s)", err)
+		}
+
+		if players >= maxPlayers {
+			return ErrFull
+		}
+
+		if _, err := tx.Exec(addPlayerQuery, gameID, userID, joinedAt); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+				return errAlreadyJoined
+			}
+
+			return fmt.Errorf("failed to add player (%s)", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if err == ErrStarted || err == ErrFull || err == errAlreadyJoined {
+			return err
+		}
+
+		return fmt.Errorf("game.PostgresRepository.AddPlayer: %s", err)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) Start(gameID string) error {
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		var status entity.GameStatus
+		var maxPlayers int
+		if err := tx.QueryRow(lockQuery, gameID).Scan(&status, &maxPlayers); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("no game exists with ID \"%s\"", gameID)
+			}
+
+			return fmt.Errorf("failed to lock game (%s)", err)
+		}
+
+		if status != entity.GameStatusWaiting {
+			return ErrStarted
+		}
+
+		if _, err := tx.Exec(startQuery, gameID); err != nil {
+			return fmt.Errorf("failed to start game (%s)", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if err == ErrStarted {
+			return err
+		}
+
+		return fmt.Errorf("game.PostgresRepository.Start: %s", err)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) Finish(gameID string, results []entity.PlayerResult, finishedAt time.Time) error {
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		var status entity.GameStatus
+		var maxPlayers int
+		if err := tx.QueryRow(lockQuery, gameID).Scan(&status, &maxPlayers); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("no game exists with ID \"%s\"", gameID)
+			}
+
+			return fmt.Errorf("failed to lock game (%s)", err)
+		}
+
+		if status == entity.GameStatusFinished {
+			return ErrFinished
+		}
+		if status != entity.GameStatusInProgress {
+			return ErrNotStarted
+		}
+
+		for _, r := range results {
+			result, err := tx.Exec(setResultQuery, gameID, r.UserID, r.Rank, r.NetWorth, pq.Array(r.Properties), r.BankruptedBy)
+			if err != nil {
+				return fmt.Errorf("failed to set result (%s)", err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to count updated rows (%s)", err)
+			}
+			if rowsAffected == 0 {
+				return fmt.Errorf("user \"%s\" does not play game \"%s\"", r.UserID, gameID)
+			}
+		}
+
+		if _, err := tx.Exec(finishQuery, gameID, finishedAt); err != nil {
+			return fmt.Errorf("failed to finish game (%s)", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if err == ErrFinished || err == ErrNotStarted {
+			return err
+		}
+
+		return fmt.Errorf("game.PostgresRepository.Finish: %s", err)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) ListFinishedByPlayer(userID string, offset int, limit int) ([]entity.Game, error) {
+	rows, err := pr.database.Query(listFinishedByPlayerQuery, userID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"game.PostgresRepository.ListFinishedByPlayer: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf(
+				"game.PostgresRepository.ListFinishedByPlayer: failed to read game (%s)",
+				err,
+			)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"game.PostgresRepository.ListFinishedByPlayer: failed to read games (%s)",
+			err,
+		)
+	}
+
+	games := make([]entity.Game, 0, len(ids))
+	for _, id := range ids {
+		game, err := pr.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("game.PostgresRepository.ListFinishedByPlayer: %s", err)
+		}
+		if game != nil {
+			games = append(games, *game)
+		}
+	}
+
+	return games, nil
+}
@@ -0,0 +1,473 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func TestPostgresRepositoryCreatingGame(t *testing.T) {
+	game := entity.Game{
+		HostID:     mockHostID,
+		Status:     entity.GameStatusWaiting,
+		Ruleset:    entity.RulesetClassic,
+		MaxPlayers: 4,
+		CreatedAt:  time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+	}
+
+	t.Run("rolls back when host cannot be added", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(createQuery).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(mockGameID))
+		mock.ExpectExec(addPlayerQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+		mock.ExpectRollback()
+
+		if _, err := pr.Create(game); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the game with its new ID and host when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(createQuery).
+			WithArgs(mockHostID, "waiting", "classic", 4, false, game.CreatedAt).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(mockGameID))
+		mock.ExpectExec(addPlayerQuery).
+			WithArgs(mockGameID, mockHostID, game.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		g, err := pr.Create(game)
+		if err != nil {
+			t.FailNow()
+		}
+		if g.ID != mockGameID || len(g.PlayerIDs) != 1 || g.PlayerIDs[0] != mockHostID {
+			t.Fail()
+		}
+	})
+
+	t.Run("adds every player of the game when it has some", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		matched := game
+		matched.PlayerIDs = []string{mockHostID, mockPlayerID}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(createQuery).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(mockGameID))
+		mock.ExpectExec(addPlayerQuery).
+			WithArgs(mockGameID, mockHostID, game.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(addPlayerQuery).
+			WithArgs(mockGameID, mockPlayerID, game.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if _, err := pr.Create(matched); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryGettingGame(t *testing.T) {
+	gameColumns := []string{"id", "host_id", "status", "ruleset", "max_players", "matchmade", "created_at", "finished_at"}
+	playerColumns := []string{"user_id", "rank", "net_worth", "properties", "bankrupted_by"}
+
+	t.Run("returns nil when no game exists with the ID", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getByIDQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows(gameColumns))
+
+		if g, err := pr.GetByID(mockGameID); err != nil || g != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the game with its players when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getByIDQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows(gameColumns).AddRow(mockGameID, mockHostID, "waiting", "classic", 4, false, time.Now(), nil))
+		mock.ExpectQuery(getPlayersQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows(playerColumns).AddRow(mockHostID, nil, nil, nil, "").AddRow(mockPlayerID, nil, nil, nil, ""))
+
+		g, err := pr.GetByID(mockGameID)
+		if err != nil || g == nil {
+			t.FailNow()
+		}
+		if g.Status != entity.GameStatusWaiting || len(g.PlayerIDs) != 2 || g.PlayerIDs[1] != mockPlayerID {
+			t.Fail()
+		}
+		if g.Results != nil || !g.FinishedAt.IsZero() {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the results of a finished game", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getByIDQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows(gameColumns).AddRow(mockGameID, mockHostID, "finished", "classic", 2, true, time.Now(), time.Now()))
+		mock.ExpectQuery(getPlayersQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows(playerColumns).AddRow(mockHostID, 2, 100, "{}", mockPlayerID).AddRow(mockPlayerID, 1, 5000, "{Moose Lake,Elk Harbour}", ""))
+
+		g, err := pr.GetByID(mockGameID)
+		if err != nil || g == nil {
+			t.FailNow()
+		}
+		if len(g.Results) != 2 || g.Results[1].Rank != 1 || g.Results[1].NetWorth != 5000 || len(g.Results[1].Properties) != 2 || g.Results[0].BankruptedBy != mockPlayerID || g.FinishedAt.IsZero() {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryAddingPlayer(t *testing.T) {
+	joinedAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	t.Run("fails with ErrStarted when game has started", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("in_progress", 4))
+		mock.ExpectRollback()
+
+		if err := pr.AddPlayer(mockGameID, mockPlayerID, joinedAt); err != ErrStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with ErrFull when game is full", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("waiting", 2))
+		mock.ExpectQuery(countPlayersQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectRollback()
+
+		if err := pr.AddPlayer(mockGameID, mockPlayerID, joinedAt); err != ErrFull {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with errAlreadyJoined when player joined at the same time", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("waiting", 4))
+		mock.ExpectQuery(countPlayersQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec(addPlayerQuery).
+			WithArgs(mockGameID, mockPlayerID, joinedAt).
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		if err := pr.AddPlayer(mockGameID, mockPlayerID, joinedAt); err != errAlreadyJoined {
+			t.Fail()
+		}
+	})
+
+	t.Run("adds the player when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("waiting", 4))
+		mock.ExpectQuery(countPlayersQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec(addPlayerQuery).
+			WithArgs(mockGameID, mockPlayerID, joinedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := pr.AddPlayer(mockGameID, mockPlayerID, joinedAt); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryStartingGame(t *testing.T) {
+	t.Run("fails with ErrStarted when game is no longer waiting", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("in_progress", 2))
+		mock.ExpectRollback()
+
+		if err := pr.Start(mockGameID); err != ErrStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("marks the game as in progress when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("waiting", 2))
+		mock.ExpectExec(startQuery).
+			WithArgs(mockGameID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := pr.Start(mockGameID); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryFinishingGame(t *testing.T) {
+	finishedAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	results := []entity.PlayerResult{
+		{UserID: mockHostID, Rank: 1, NetWorth: 5000, Properties: []string{"Moose Lake"}},
+		{UserID: mockPlayerID, Rank: 2, NetWorth: 100, BankruptedBy: mockHostID},
+	}
+
+	t.Run("fails with ErrFinished when game is already finished", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("finished", 2))
+		mock.ExpectRollback()
+
+		if err := pr.Finish(mockGameID, results, finishedAt); err != ErrFinished {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with ErrNotStarted when game was not started", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("waiting", 2))
+		mock.ExpectRollback()
+
+		if err := pr.Finish(mockGameID, results, finishedAt); err != ErrNotStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("rolls back when a result is not for a player", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("in_progress", 2))
+		mock.ExpectExec(setResultQuery).
+			WithArgs(mockGameID, mockHostID, 1, 5000, `{"Moose Lake"}`, "").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		if err := pr.Finish(mockGameID, results, finishedAt); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("records the results and finishes the game when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"status", "max_players"}).AddRow("in_progress", 2))
+		mock.ExpectExec(setResultQuery).
+			WithArgs(mockGameID, mockHostID, 1, 5000, `{"Moose Lake"}`, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(setResultQuery).
+			WithArgs(mockGameID, mockPlayerID, 2, 100, nil, mockHostID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(finishQuery).
+			WithArgs(mockGameID, finishedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := pr.Finish(mockGameID, results, finishedAt); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryListingFinishedGames(t *testing.T) {
+	t.Run("returns the games with their results", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listFinishedByPlayerQuery).
+			WithArgs(mockPlayerID, 0, 20).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(mockGameID))
+		mock.ExpectQuery(getByIDQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"id", "host_id", "status", "ruleset", "max_players", "matchmade", "created_at", "finished_at"}).
+				AddRow(mockGameID, mockHostID, "finished", "classic", 2, true, time.Now(), time.Now()))
+		mock.ExpectQuery(getPlayersQuery).
+			WithArgs(mockGameID).
+			WillReturnRows(mock.NewRows([]string{"user_id", "rank", "net_worth", "properties", "bankrupted_by"}).
+				AddRow(mockHostID, 2, 100, "{}", "").
+				AddRow(mockPlayerID, 1, 5000, "{}", ""))
+
+		games, err := pr.ListFinishedByPlayer(mockPlayerID, 0, 20)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(games) != 1 || games[0].ID != mockGameID || len(games[0].Results) != 2 {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
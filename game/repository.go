@@ -0,0 +1,50 @@
+// Package game manages games and the players who take part in them.
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+// errAlreadyJoined is returned when adding a player who already joined the
+// game, which happens when they join from more than one request at once.
+var errAlreadyJoined = errors.New("user already joined the game")
+
+type Repository interface {
+	// Create creates the game with its players, or its host as its only
+	// player when it has none, and returns it with its new ID.
+	Create(game entity.Game) (*entity.Game, error)
+	// GetByID returns the game, or nil if no game exists with the ID.
+	GetByID(id string) (*entity.Game, error)
+	// AddPlayer adds the user to the game's players, and fails with ErrFull
+	// or ErrStarted when the game cannot be joined, which is checked at the
+	// same time. It fails with errAlreadyJoined when the user is already one
+	// of the game's players.
+	AddPlayer(gameID string, userID string, joinedAt time.Time) error
+	// Start marks the game as in progress, unless it is no longer waiting
+	// for players, in which case it fails with ErrStarted.
+	Start(gameID string) error
+	// Finish records the results of the game's players, and marks it as
+	// finished, unless it already is, in which case it fails with
+	// ErrFinished, or it was not started, in which case it fails with
+	// ErrNotStarted.
+	Finish(gameID string, results []entity.PlayerResult, finishedAt time.Time) error
+
+	// ListFinishedByPlayer returns the finished games the user played, from
+	// the most recently finished to the least.
+	ListFinishedByPlayer(userID string, offset int, limit int) ([]entity.Game, error)
+}
+
+func NewRepository(database db.DB) (Repository, error) {
+	if inmemory, ok := database.(*db.InMemory); ok {
+		return NewInMemoryRepository(inmemory), nil
+	} else if postgres, ok := database.(*db.Postgres); ok {
+		return NewPostgresRepository(postgres), nil
+	}
+
+	return nil, fmt.Errorf("game.NewRepository: unsupported database type")
+}
@@ -0,0 +1,355 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
+)
+
+const (
+	MinPlayers        = 2
+	MaxPlayers        = 8
+	DefaultMaxPlayers = 4
+
+	// MaxListLimit is the maximum number of games that can be listed at once.
+	MaxListLimit = 100
+
+	// maxProperties and maxPropertyNameLength prevent clients from storing
+	// arbitrarily many, or long, property names in results.
+	maxProperties         = 64
+	maxPropertyNameLength = 64
+)
+
+var (
+	ErrNotFound          = errors.New("game does not exist")
+	ErrFull              = errors.New("game is full")
+	ErrStarted           = errors.New("game has already started")
+	ErrNotStarted        = errors.New("game has not started yet")
+	ErrFinished          = errors.New("game is already finished")
+	ErrNotHost           = errors.New("only the game's host can do this")
+	ErrTooFewPlayers     = fmt.Errorf("game must have at least %d players", MinPlayers)
+	ErrInvalidResults    = fmt.Errorf("results must rank every player once, starting at 1, with at most %d distinct properties each, and only be bankrupted by other players", maxProperties)
+	ErrInvalidPagination = pagination.NewError(MaxListLimit)
+	ErrInvalidMaxPlayers = fmt.Errorf("max players must be between %d and %d", MinPlayers, MaxPlayers)
+	ErrInvalidRuleset    = errors.New("ruleset must be \"classic\" or \"quick\"")
+)
+
+// FinishListener is notified of games when they are finished.
+type FinishListener func(game entity.Game) error
+
+type Service interface {
+	// Create creates a game hosted by the user, who is its first player. When
+	// max players is zero, DefaultMaxPlayers is used, and when the ruleset is
+	// empty, the classic one is.
+	Create(hostID string, maxPlayers int, ruleset entity.Ruleset) (*entity.Game, error)
+	// CreateMatch creates a game for the players, who were matched together,
+	// which is hosted by the first one, has room for no one else, and is
+	// already started.
+	CreateMatch(playerIDs []string, ruleset entity.Ruleset) (*entity.Game, error)
+	GetByID(id string) (*entity.Game, error)
+
+	// Join adds the user to the game's players, unless they already are one,
+	// and returns the game.
+	Join(gameID string, userID string) (*entity.Game, error)
+
+	// Start starts the game, as requested by its host, after which no one
+	// can join it, and its results can be reported.
+	Start(hostID string, gameID string) (*entity.Game, error)
+
+	// Finish records the results of the game's players, as reported by its
+	// host, once it was started, and notifies the listeners.
+	Finish(reporterID string, gameID string, results []entity.PlayerResult) (*entity.Game, error)
+	// OnFinish adds a listener, which is notified of games when they are
+	// finished. Listeners must be added before games are finished.
+	OnFinish(listener FinishListener)
+
+	// ListHistory returns the finished games the user played, from the most
+	// recently finished to the least.
+	ListHistory(userID string, offset int, limit int) ([]entity.Game, error)
+}
+
+type service struct {
+	repo      Repository
+	now       func() time.Time
+	listeners []FinishListener
+}
+
+func NewService(repo Repository) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("game.NewService: repository is required")
+	}
+
+	return &service{
+		repo: repo,
+		now:  time.Now,
+	}, nil
+}
+
+func (svc *service) Create(hostID string, maxPlayers int, ruleset entity.Ruleset) (*entity.Game, error) {
+	if maxPlayers == 0 {
+		maxPlayers = DefaultMaxPlayers
+	}
+
+	if maxPlayers < MinPlayers || maxPlayers > MaxPlayers {
+		return nil, ErrInvalidMaxPlayers
+	}
+
+	ruleset, err := validRuleset(ruleset)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := svc.repo.Create(entity.Game{
+		HostID:     hostID,
+		Status:     entity.GameStatusWaiting,
+		Ruleset:    ruleset,
+		MaxPlayers: maxPlayers,
+		CreatedAt:  svc.now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.Create: %s", err)
+	}
+
+	return game, nil
+}
+
+func (svc *service) CreateMatch(playerIDs []string, ruleset entity.Ruleset) (*entity.Game, error) {
+	if len(playerIDs) < MinPlayers || len(playerIDs) > MaxPlayers {
+		return nil, ErrInvalidMaxPlayers
+	}
+
+	ruleset, err := validRuleset(ruleset)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := svc.repo.Create(entity.Game{
+		HostID:     playerIDs[0],
+		Status:     entity.GameStatusInProgress,
+		Ruleset:    ruleset,
+		MaxPlayers: len(playerIDs),
+		Matchmade:  true,
+		PlayerIDs:  append([]string(nil), playerIDs...),
+		CreatedAt:  svc.now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.CreateMatch: %s", err)
+	}
+
+	return game, nil
+}
+
+func (svc *service) GetByID(id string) (*entity.Game, error) {
+	game, err := svc.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.GetByID: %s", err)
+	}
+	if game == nil {
+		return nil, ErrNotFound
+	}
+
+	return game, nil
+}
+
+func (svc *service) Join(gameID string, userID string) (*entity.Game, error) {
+	game, err := svc.repo.GetByID(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.Join: %s", err)
+	}
+	if game == nil {
+		return nil, ErrNotFound
+	}
+
+	if game.HasPlayer(userID) {
+		return game, nil
+	}
+
+	if err := svc.repo.AddPlayer(gameID, userID, svc.now()); err != nil {
+		if err == ErrFull || err == ErrStarted {
+			return nil, err
+		}
+
+		// The user joined from another request since the game was read, so
+		// joining again does nothing, like it does when they already had.
+		if err == errAlreadyJoined {
+			return svc.GetByID(gameID)
+		}
+
+		return nil, fmt.Errorf("game.Service.Join: %s", err)
+	}
+
+	game.PlayerIDs = append(game.PlayerIDs, userID)
+
+	return game, nil
+}
+
+func (svc *service) Start(hostID string, gameID string) (*entity.Game, error) {
+	game, err := svc.repo.GetByID(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.Start: %s", err)
+	}
+	if game == nil {
+		return nil, ErrNotFound
+	}
+
+	if game.HostID != hostID {
+		return nil, ErrNotHost
+	}
+	if game.Status == entity.GameStatusFinished {
+		return nil, ErrFinished
+	}
+	if game.Status != entity.GameStatusWaiting {
+		return nil, ErrStarted
+	}
+	if len(game.PlayerIDs) < MinPlayers {
+		return nil, ErrTooFewPlayers
+	}
+
+	if err := svc.repo.Start(gameID); err != nil {
+		if err == ErrStarted {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("game.Service.Start: %s", err)
+	}
+
+	game.Status = entity.GameStatusInProgress
+
+	return game, nil
+}
+
+func (svc *service) Finish(reporterID string, gameID string, results []entity.PlayerResult) (*entity.Game, error) {
+	game, err := svc.repo.GetByID(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.Finish: %s", err)
+	}
+	if game == nil {
+		return nil, ErrNotFound
+	}
+
+	if game.HostID != reporterID {
+		return nil, ErrNotHost
+	}
+	if game.Status == entity.GameStatusFinished {
+		return nil, ErrFinished
+	}
+	if game.Status != entity.GameStatusInProgress {
+		return nil, ErrNotStarted
+	}
+	if !validResults(*game, results) {
+		return nil, ErrInvalidResults
+	}
+
+	finishedAt := svc.now()
+
+	if err := svc.repo.Finish(gameID, results, finishedAt); err != nil {
+		if err == ErrFinished || err == ErrNotStarted {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("game.Service.Finish: %s", err)
+	}
+
+	game.Status = entity.GameStatusFinished
+	game.FinishedAt = finishedAt
+	game.Results = make([]entity.PlayerResult, 0, len(results))
+	for _, playerID := range game.PlayerIDs {
+		for _, r := range results {
+			if r.UserID == playerID {
+				game.Results = append(game.Results, r)
+			}
+		}
+	}
+
+	// The game is finished regardless of what listeners do with it, so their
+	// errors are only logged.
+	for _, listener := range svc.listeners {
+		if err := listener(*game); err != nil {
+			slog.Error("game.Service.Finish: listener failed", slog.String("gameId", game.ID), slog.String("error", err.Error()))
+		}
+	}
+
+	return game, nil
+}
+
+func (svc *service) OnFinish(listener FinishListener) {
+	svc.listeners = append(svc.listeners, listener)
+}
+
+func (svc *service) ListHistory(userID string, offset int, limit int) ([]entity.Game, error) {
+	if !pagination.Valid(offset, limit, MaxListLimit) {
+		return nil, ErrInvalidPagination
+	}
+
+	games, err := svc.repo.ListFinishedByPlayer(userID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("game.Service.ListHistory: %s", err)
+	}
+
+	return games, nil
+}
+
+// validRuleset returns the ruleset, or the classic one when it is empty,
+// unless it does not exist.
+func validRuleset(ruleset entity.Ruleset) (entity.Ruleset, error) {
+	switch ruleset {
+	case "":
+		return entity.RulesetClassic, nil
+	case entity.RulesetClassic, entity.RulesetQuick:
+		return ruleset, nil
+	default:
+		return "", ErrInvalidRuleset
+	}
+}
+
+// validResults tells whether the results rank every player of the game once,
+// with at least one winner.
+func validResults(game entity.Game, results []entity.PlayerResult) bool {
+	if len(results) != len(game.PlayerIDs) {
+		return false
+	}
+
+	seen := make(map[string]bool)
+	winner := false
+
+	for _, r := range results {
+		if !game.HasPlayer(r.UserID) || seen[r.UserID] {
+			return false
+		}
+		if r.Rank < 1 || r.Rank > len(results) {
+			return false
+		}
+		if !validProperties(r.Properties) {
+			return false
+		}
+		if r.BankruptedBy != "" && (r.BankruptedBy == r.UserID || !game.HasPlayer(r.BankruptedBy)) {
+			return false
+		}
+
+		seen[r.UserID] = true
+		winner = winner || r.Rank == 1
+	}
+
+	return winner
+}
+
+func validProperties(properties []string) bool {
+	if len(properties) > maxProperties {
+		return false
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range properties {
+		if p == "" || len(p) > maxPropertyNameLength || seen[p] {
+			return false
+		}
+
+		seen[p] = true
+	}
+
+	return true
+}
@@ -0,0 +1,343 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+const (
+	mockGameID   = "mock.game.id"
+	mockHostID   = "mock.host.id"
+	mockPlayerID = "mock.player.id"
+)
+
+func newService() Service {
+	svc, _ := NewService(newInMemoryRepository())
+
+	return svc
+}
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when repository is missing", func(t *testing.T) {
+		if _, err := NewService(nil); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceCreatingGame(t *testing.T) {
+	t.Run("fails when max players is out of bounds", func(t *testing.T) {
+		svc := newService()
+
+		for _, maxPlayers := range []int{-1, 1, MaxPlayers + 1} {
+			if _, err := svc.Create(mockHostID, maxPlayers, ""); err != ErrInvalidMaxPlayers {
+				t.Errorf("expected %d max players to be invalid", maxPlayers)
+			}
+		}
+	})
+
+	t.Run("fails when ruleset does not exist", func(t *testing.T) {
+		svc := newService()
+
+		if _, err := svc.Create(mockHostID, 0, "calvinball"); err != ErrInvalidRuleset {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates a waiting game with default max players", func(t *testing.T) {
+		svc := newService()
+
+		g, err := svc.Create(mockHostID, 0, "")
+		if err != nil {
+			t.FailNow()
+		}
+		if g.MaxPlayers != DefaultMaxPlayers || g.Status != "waiting" || !g.HasPlayer(mockHostID) {
+			t.Fail()
+		}
+		if g.Ruleset != entity.RulesetClassic {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceCreatingMatch(t *testing.T) {
+	t.Run("fails when there are too few players", func(t *testing.T) {
+		svc := newService()
+
+		if _, err := svc.CreateMatch([]string{mockHostID}, ""); err != ErrInvalidMaxPlayers {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates a full, started game hosted by the first player", func(t *testing.T) {
+		svc := newService()
+
+		g, err := svc.CreateMatch([]string{mockPlayerID, mockHostID}, entity.RulesetQuick)
+		if err != nil {
+			t.FailNow()
+		}
+		if g.HostID != mockPlayerID || g.MaxPlayers != 2 || len(g.PlayerIDs) != 2 || g.Ruleset != entity.RulesetQuick {
+			t.Fail()
+		}
+		if g.Status != entity.GameStatusInProgress || !g.Matchmade {
+			t.Fail()
+		}
+
+		if _, err := svc.Join(g.ID, "a.third.player"); err != ErrStarted {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceJoiningGame(t *testing.T) {
+	t.Run("fails when game does not exist", func(t *testing.T) {
+		svc := newService()
+
+		if _, err := svc.Join("ghost", mockPlayerID); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when game is full", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+
+		if _, err := svc.Join(g.ID, "a.third.player"); err != ErrFull {
+			t.Fail()
+		}
+	})
+
+	t.Run("does nothing when user already plays the game", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+
+		g, err := svc.Join(g.ID, mockHostID)
+		if err != nil || len(g.PlayerIDs) != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("does nothing when user joined from another request at the same time", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		svc, _ := NewService(&joinedMeanwhileRepository{repo, mockPlayerID})
+		g, _ := svc.Create(mockHostID, 4, "")
+
+		g, err := svc.Join(g.ID, mockPlayerID)
+		if err != nil || len(g.PlayerIDs) != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("adds the user to the players when all is well", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+
+		g, err := svc.Join(g.ID, mockPlayerID)
+		if err != nil || !g.HasPlayer(mockPlayerID) {
+			t.FailNow()
+		}
+
+		if g, _ := svc.GetByID(g.ID); !g.HasPlayer(mockPlayerID) {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceStartingGame(t *testing.T) {
+	t.Run("fails when game does not exist", func(t *testing.T) {
+		svc := newService()
+
+		if _, err := svc.Start(mockHostID, "ghost"); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user is not the host", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+
+		if _, err := svc.Start(mockPlayerID, g.ID); err != ErrNotHost {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when host plays alone", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+
+		if _, err := svc.Start(mockHostID, g.ID); err != ErrTooFewPlayers {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when game was already started", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.CreateMatch([]string{mockHostID, mockPlayerID}, "")
+
+		if _, err := svc.Start(mockHostID, g.ID); err != ErrStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("starts the game, which no one else can join", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 4, "")
+		svc.Join(g.ID, mockPlayerID)
+
+		g, err := svc.Start(mockHostID, g.ID)
+		if err != nil || g.Status != entity.GameStatusInProgress {
+			t.FailNow()
+		}
+
+		if _, err := svc.Join(g.ID, "a.third.player"); err != ErrStarted {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceFinishingGame(t *testing.T) {
+	results := []entity.PlayerResult{
+		{UserID: mockHostID, Rank: 2, NetWorth: 100},
+		{UserID: mockPlayerID, Rank: 1, NetWorth: 5000},
+	}
+
+	newGame := func(svc Service) *entity.Game {
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+		g, _ = svc.Start(mockHostID, g.ID)
+		return g
+	}
+
+	t.Run("fails when reporter is not the host", func(t *testing.T) {
+		svc := newService()
+		g := newGame(svc)
+
+		if _, err := svc.Finish(mockPlayerID, g.ID, results); err != ErrNotHost {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when game was not started", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+
+		if _, err := svc.Finish(mockHostID, g.ID, results); err != ErrNotStarted {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when results do not rank every player once", func(t *testing.T) {
+		svc := newService()
+		g := newGame(svc)
+
+		invalid := [][]entity.PlayerResult{
+			results[:1],
+			{results[0], results[0]},
+			{results[0], {UserID: "stranger", Rank: 1}},
+			{{UserID: mockHostID, Rank: 2}, {UserID: mockPlayerID, Rank: 2}},
+			{{UserID: mockHostID, Rank: 1}, {UserID: mockPlayerID, Rank: 3}},
+			{{UserID: mockHostID, Rank: 1, Properties: []string{"Moose Lake", "Moose Lake"}}, results[1]},
+			{{UserID: mockHostID, Rank: 1, Properties: []string{""}}, results[1]},
+			{{UserID: mockHostID, Rank: 1, BankruptedBy: mockHostID}, results[1]},
+			{{UserID: mockHostID, Rank: 1, BankruptedBy: "stranger"}, results[1]},
+		}
+
+		for _, r := range invalid {
+			if _, err := svc.Finish(mockHostID, g.ID, r); err != ErrInvalidResults {
+				t.Errorf("expected results %v to be invalid", r)
+			}
+		}
+	})
+
+	t.Run("fails when game is already finished", func(t *testing.T) {
+		svc := newService()
+		g := newGame(svc)
+		svc.Finish(mockHostID, g.ID, results)
+
+		if _, err := svc.Finish(mockHostID, g.ID, results); err != ErrFinished {
+			t.Fail()
+		}
+	})
+
+	t.Run("finishes the game and notifies listeners when all is well", func(t *testing.T) {
+		svc := newService()
+		g := newGame(svc)
+
+		var notified []entity.Game
+		svc.OnFinish(func(g entity.Game) error {
+			notified = append(notified, g)
+			return nil
+		})
+		svc.OnFinish(func(entity.Game) error {
+			return fmt.Errorf("a listener failed")
+		})
+
+		finished, err := svc.Finish(mockHostID, g.ID, results)
+		if err != nil {
+			t.FailNow()
+		}
+		if finished.Status != entity.GameStatusFinished || finished.Result(mockPlayerID).Rank != 1 {
+			t.Fail()
+		}
+		if len(notified) != 1 || notified[0].ID != g.ID || len(notified[0].Results) != 2 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceListingHistory(t *testing.T) {
+	t.Run("fails when pagination is out of bounds", func(t *testing.T) {
+		svc := newService()
+
+		for _, p := range [][2]int{{-1, 20}, {0, 0}, {0, MaxListLimit + 1}} {
+			if _, err := svc.ListHistory(mockPlayerID, p[0], p[1]); err != ErrInvalidPagination {
+				t.Errorf("expected offset %d and limit %d to be invalid", p[0], p[1])
+			}
+		}
+	})
+
+	t.Run("lists only the finished games the user played", func(t *testing.T) {
+		svc := newService()
+		finished, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(finished.ID, mockPlayerID)
+		svc.Start(mockHostID, finished.ID)
+		svc.Finish(mockHostID, finished.ID, []entity.PlayerResult{
+			{UserID: mockHostID, Rank: 1},
+			{UserID: mockPlayerID, Rank: 2},
+		})
+		waiting, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(waiting.ID, mockPlayerID)
+
+		games, err := svc.ListHistory(mockPlayerID, 0, 20)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(games) != 1 || games[0].ID != finished.ID {
+			t.Fail()
+		}
+	})
+}
+
+// joinedMeanwhileRepository adds the user to the game when it is read, as if
+// they joined from another request right after, but before adding them.
+type joinedMeanwhileRepository struct {
+	Repository
+
+	userID string
+}
+
+func (repo *joinedMeanwhileRepository) GetByID(id string) (*entity.Game, error) {
+	g, err := repo.Repository.GetByID(id)
+	if err != nil || g == nil || g.HasPlayer(repo.userID) {
+		return g, err
+	}
+
+	repo.Repository.AddPlayer(id, repo.userID, g.CreatedAt)
+
+	return g, nil
+}
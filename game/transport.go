@@ -0,0 +1,186 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	createGameHandler := stmhttp.NewHandler(
+		wrap("game.createGame")(makeCreateGameEndpoint(svc)),
+		decodeCreateGameRequest,
+		encodeCreatedResponse,
+		encodeError,
+	)
+
+	getGameHandler := stmhttp.NewHandler(
+		wrap("game.getGame")(makeGetGameEndpoint(svc)),
+		decodeGetGameRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	startGameHandler := stmhttp.NewHandler(
+		wrap("game.startGame")(makeStartGameEndpoint(svc)),
+		decodeStartGameRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	finishGameHandler := stmhttp.NewHandler(
+		wrap("game.finishGame")(makeFinishGameEndpoint(svc)),
+		decodeFinishGameRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	listHistoryHandler := stmhttp.NewHandler(
+		wrap("game.listHistory")(makeListHistoryEndpoint(svc)),
+		decodeListHistoryRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/games", createGameHandler).Methods("POST")
+	r.Handle("/v1/games/{id}", getGameHandler).Methods("GET")
+	r.Handle("/v1/games/{id}/start", startGameHandler).Methods("POST")
+	r.Handle("/v1/games/{id}/results", finishGameHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/games", listHistoryHandler).Methods("GET")
+
+	return r
+}
+
+func decodeCreateGameRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		MaxPlayers int            `json:"maxPlayers"`
+		Ruleset    entity.Ruleset `json:"ruleset"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return createGameRequest{
+		MaxPlayers: body.MaxPlayers,
+		Ruleset:    body.Ruleset,
+	}, nil
+}
+
+func decodeGetGameRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return getGameRequest{
+		ID: id,
+	}, nil
+}
+
+func decodeStartGameRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return startGameRequest{
+		ID: id,
+	}, nil
+}
+
+func decodeFinishGameRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		Results []struct {
+			UserID     string   `json:"userId"`
+			Rank       int      `json:"rank"`
+			NetWorth   int      `json:"netWorth"`
+			Properties []string `json:"properties"`
+
+			BankruptedBy string `json:"bankruptedBy"`
+		} `json:"results"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]entity.PlayerResult, 0, len(body.Results))
+	for _, result := range body.Results {
+		results = append(results, entity.PlayerResult{
+			UserID:       result.UserID,
+			Rank:         result.Rank,
+			NetWorth:     result.NetWorth,
+			Properties:   result.Properties,
+			BankruptedBy: result.BankruptedBy,
+		})
+	}
+
+	return finishGameRequest{
+		ID:      id,
+		Results: results,
+	}, nil
+}
+
+func decodeListHistoryRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	offset, limit, err := pagination.FromQuery(r.URL.Query(), ErrInvalidPagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return listHistoryRequest{
+		UserID: id,
+		Offset: offset,
+		Limit:  limit,
+	}, nil
+}
+
+func encodeCreatedResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrInvalidMaxPlayers, ErrInvalidRuleset, ErrTooFewPlayers, ErrInvalidResults, ErrInvalidPagination:
+		return http.StatusBadRequest
+	case ErrNotHost:
+		return http.StatusForbidden
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrFull, ErrStarted, ErrNotStarted, ErrFinished:
+		return http.StatusConflict
+	}
+
+	return 0
+})
@@ -0,0 +1,192 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+)
+
+func contextOf(userID string) context.Context {
+	return auth.NewContext(context.Background(), auth.Identity{UserID: userID})
+}
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("creates a game hosted by the caller", func(t *testing.T) {
+		handler := MakeHandler(newService())
+
+		r := stmhttptest.NewJSONRequest("POST", "/v1/games", `{"maxPlayers": 3}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockHostID)))
+
+		if rr.Code != http.StatusCreated {
+			t.FailNow()
+		}
+
+		var body gameResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.HostID != mockHostID || body.MaxPlayers != 3 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to create a game for anonymous callers", func(t *testing.T) {
+		handler := MakeHandler(newService())
+
+		r := stmhttptest.NewJSONRequest("POST", "/v1/games", `{}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+
+	t.Run("only shows games to their players", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 0, "")
+		handler := MakeHandler(svc)
+
+		statuses := map[string]int{
+			mockHostID:   http.StatusOK,
+			mockPlayerID: http.StatusForbidden,
+		}
+
+		for userID, status := range statuses {
+			r := httptest.NewRequest("GET", "/v1/games/"+g.ID, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r.WithContext(contextOf(userID)))
+
+			if rr.Code != status {
+				t.Errorf("expected status %d for \"%s\", got %d", status, userID, rr.Code)
+			}
+		}
+	})
+}
+
+func TestStartingGameHandler(t *testing.T) {
+	t.Run("returns the started game", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("POST", "/v1/games/"+g.ID+"/start", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockHostID)))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var response gameResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if response.Status != "in_progress" {
+			t.Fail()
+		}
+	})
+}
+
+func TestFinishingGameHandler(t *testing.T) {
+	t.Run("returns the game with its results", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+		svc.Start(mockHostID, g.ID)
+		handler := MakeHandler(svc)
+
+		body := fmt.Sprintf(
+			`{"results": [{"userId": "%s", "rank": 1, "netWorth": 5000}, {"userId": "%s", "rank": 2, "netWorth": 0}]}`,
+			mockHostID,
+			mockPlayerID,
+		)
+		r := stmhttptest.NewJSONRequest("POST", "/v1/games/"+g.ID+"/results", body)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockHostID)))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var response gameResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if response.Status != "finished" || len(response.Results) != 2 || response.FinishedAt == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestListingHistoryHandler(t *testing.T) {
+	t.Run("lists finished games to any authenticated caller", func(t *testing.T) {
+		svc := newService()
+		g, _ := svc.Create(mockHostID, 2, "")
+		svc.Join(g.ID, mockPlayerID)
+		svc.Start(mockHostID, g.ID)
+		svc.Finish(mockHostID, g.ID, []entity.PlayerResult{
+			{UserID: mockHostID, Rank: 1, Properties: []string{"Moose Lake"}},
+			{UserID: mockPlayerID, Rank: 2},
+		})
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/v1/users/"+mockPlayerID+"/games?limit=5", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf("stranger")))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var response listHistoryResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if len(response.Games) != 1 || response.Games[0].Results[0].Properties[0] != "Moose Lake" {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when pagination is not a number", func(t *testing.T) {
+		handler := MakeHandler(newService())
+
+		r := httptest.NewRequest("GET", "/v1/users/"+mockPlayerID+"/games?offset=moose", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockPlayerID)))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		auth.ErrForbidden:         http.StatusForbidden,
+		ErrInvalidMaxPlayers:      http.StatusBadRequest,
+		ErrInvalidRuleset:         http.StatusBadRequest,
+		ErrTooFewPlayers:          http.StatusBadRequest,
+		ErrInvalidResults:         http.StatusBadRequest,
+		ErrInvalidPagination:      http.StatusBadRequest,
+		ErrNotHost:                http.StatusForbidden,
+		ErrFinished:               http.StatusConflict,
+		ErrNotFound:               http.StatusNotFound,
+		ErrFull:                   http.StatusConflict,
+		ErrStarted:                http.StatusConflict,
+		ErrNotStarted:             http.StatusConflict,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
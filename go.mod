@@ -1,11 +1,13 @@
 module github.com/leblancjs/stmoosersburg-api
 
-go 1.12
+go 1.21
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.3.3
-	github.com/gorilla/handlers v1.4.0
 	github.com/gorilla/mux v1.7.1
 	github.com/lib/pq v1.1.0
 	golang.org/x/crypto v0.0.0-20190411191339-88737f569e3a
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/sys v0.0.0-20190403152447-81d4e9dc473e // indirect
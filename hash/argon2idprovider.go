@@ -0,0 +1,183 @@
+package hash
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idParams represents the cost parameters of the Argon2id algorithm.
+type Argon2idParams struct {
+	// Memory represents the amount of memory to use, in kibibytes.
+	Memory uint32
+
+	// Iterations represents the number of passes over the memory.
+	Iterations uint32
+
+	// Parallelism represents the number of threads to use.
+	Parallelism uint8
+
+	// SaltLength represents the length of the random salt, in bytes.
+	SaltLength uint32
+
+	// KeyLength represents the length of the generated key, in bytes.
+	KeyLength uint32
+}
+
+// DefaultArgon2idParams follows the recommendations of the OWASP password
+// storage cheat sheet.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+type argon2idProvider struct {
+	params Argon2idParams
+}
+
+// NewArgon2idProvider creates a provider that generates hashes with the
+// Argon2id algorithm, encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+//
+// Zero parameters are replaced by their default values.
+func NewArgon2idProvider(params Argon2idParams) (Provider, error) {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2idParams.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2idParams.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2idParams.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2idParams.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2idParams.KeyLength
+	}
+
+	if params.Memory < 8*uint32(params.Parallelism) {
+		return nil, fmt.Errorf(
+			"hash.NewArgon2idProvider: memory must be at least %d KiB for a parallelism of %d",
+			8*uint32(params.Parallelism),
+			params.Parallelism,
+		)
+	}
+
+	return &argon2idProvider{params}, nil
+}
+
+func (p *argon2idProvider) FromPassword(password string) ([]byte, error) {
+	salt := make([]byte, p.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt (%s)", err)
+	}
+
+	key := argon2.IDKey(
+		[]byte(password),
+		salt,
+		p.params.Iterations,
+		p.params.Memory,
+		p.params.Parallelism,
+		p.params.KeyLength,
+	)
+
+	return []byte(fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.params.Memory,
+		p.params.Iterations,
+		p.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)), nil
+}
+
+func (p *argon2idProvider) MatchPassword(hash []byte, password string) error {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey(
+		[]byte(password),
+		salt,
+		params.Iterations,
+		params.Memory,
+		params.Parallelism,
+		params.KeyLength,
+	)
+
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return fmt.Errorf("hash and password do not match")
+	}
+
+	return nil
+}
+
+func (p *argon2idProvider) Identifies(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(argon2idPrefix))
+}
+
+func (p *argon2idProvider) NeedsRehash(hash []byte) bool {
+	params, _, _, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params != p.params
+}
+
+func decodeArgon2idHash(hash []byte) (params Argon2idParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("hash is not in the argon2id format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("hash has a malformed version (%s)", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("hash has unsupported version %d", version)
+	}
+
+	_, err = fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&params.Memory,
+		&params.Iterations,
+		&params.Parallelism,
+	)
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("hash has malformed parameters (%s)", err)
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("hash has a malformed salt (%s)", err)
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("hash has a malformed key (%s)", err)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
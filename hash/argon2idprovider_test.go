@@ -0,0 +1,126 @@
+package hash
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idProviderCreate(t *testing.T) {
+	t.Run("fails when memory is too low for parallelism", func(t *testing.T) {
+		if _, err := NewArgon2idProvider(Argon2idParams{Memory: 8, Parallelism: 4}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses default parameters when they are zero", func(t *testing.T) {
+		provider, _ := NewArgon2idProvider(Argon2idParams{})
+
+		if provider.(*argon2idProvider).params != DefaultArgon2idParams {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses the given parameters", func(t *testing.T) {
+		provider, _ := NewArgon2idProvider(testArgon2idParams)
+
+		if provider.(*argon2idProvider).params != testArgon2idParams {
+			t.Fail()
+		}
+	})
+}
+
+func TestArgon2idProviderHashGeneration(t *testing.T) {
+	provider, _ := NewArgon2idProvider(testArgon2idParams)
+	password := "a.very.strong.password"
+
+	t.Run("generates a hash in the PHC string format", func(t *testing.T) {
+		hash, err := provider.FromPassword(password)
+		if err != nil {
+			t.FailNow()
+		}
+		if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=64,t=1,p=1$") {
+			t.Fail()
+		}
+	})
+
+	t.Run("generates a unique hash every time", func(t *testing.T) {
+		firstHash, _ := provider.FromPassword(password)
+		secondHash, _ := provider.FromPassword(password)
+
+		if bytes.Compare(firstHash, secondHash) == 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestArgon2idProviderHashComparison(t *testing.T) {
+	provider, _ := NewArgon2idProvider(testArgon2idParams)
+	password := "a.very.strong.password"
+	hash, _ := provider.FromPassword(password)
+
+	t.Run("compares a hash with its plain text version", func(t *testing.T) {
+		if err := provider.MatchPassword(hash, password); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when password does not match", func(t *testing.T) {
+		if err := provider.MatchPassword(hash, "not.the.password"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when hash is malformed", func(t *testing.T) {
+		if err := provider.MatchPassword([]byte("$argon2id$v=19$garbage"), password); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses the parameters encoded in the hash", func(t *testing.T) {
+		otherProvider, _ := NewArgon2idProvider(Argon2idParams{Memory: 128, Iterations: 2, Parallelism: 1})
+
+		if err := otherProvider.MatchPassword(hash, password); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestArgon2idProviderHashIdentification(t *testing.T) {
+	provider, _ := NewArgon2idProvider(testArgon2idParams)
+	hash, _ := provider.FromPassword("a.very.strong.password")
+
+	t.Run("identifies argon2id hashes", func(t *testing.T) {
+		if !provider.Identifies(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not identify other hashes", func(t *testing.T) {
+		if provider.Identifies([]byte("$2a$10$abcdefghijklmnopqrstuu")) {
+			t.Fail()
+		}
+	})
+
+	t.Run("needs rehash when parameters differ", func(t *testing.T) {
+		otherProvider, _ := NewArgon2idProvider(Argon2idParams{Memory: 128, Iterations: 1, Parallelism: 1})
+
+		if !otherProvider.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not need rehash when parameters are the same", func(t *testing.T) {
+		if provider.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+}
@@ -1,17 +1,55 @@
 package hash
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"fmt"
 
-type bcryptProvider struct{}
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bcryptProvider struct {
+	cost int
+}
 
 func NewBCryptProvider() Provider {
-	return &bcryptProvider{}
+	return &bcryptProvider{bcrypt.DefaultCost}
+}
+
+// NewBCryptProviderWithCost creates a bcrypt provider that generates hashes
+// with the given cost, or the default cost if it is zero.
+func NewBCryptProviderWithCost(cost int) (Provider, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf(
+			"hash.NewBCryptProviderWithCost: cost must be between %d and %d",
+			bcrypt.MinCost,
+			bcrypt.MaxCost,
+		)
+	}
+
+	return &bcryptProvider{cost}, nil
 }
 
 func (p *bcryptProvider) FromPassword(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return bcrypt.GenerateFromPassword([]byte(password), p.cost)
 }
 
 func (p *bcryptProvider) MatchPassword(hash []byte, password string) error {
 	return bcrypt.CompareHashAndPassword(hash, []byte(password))
 }
+
+func (p *bcryptProvider) Identifies(hash []byte) bool {
+	_, err := bcrypt.Cost(hash)
+	return err == nil
+}
+
+func (p *bcryptProvider) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return true
+	}
+
+	return cost != p.cost
+}
@@ -3,6 +3,8 @@ package hash
 import (
 	"bytes"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestBCryptProviderCreate(t *testing.T) {
@@ -13,6 +15,30 @@ func TestBCryptProviderCreate(t *testing.T) {
 	})
 }
 
+func TestBCryptProviderWithCostCreate(t *testing.T) {
+	t.Run("fails when cost is out of range", func(t *testing.T) {
+		if _, err := NewBCryptProviderWithCost(bcrypt.MaxCost + 1); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses default cost when cost is zero", func(t *testing.T) {
+		provider, _ := NewBCryptProviderWithCost(0)
+
+		if provider.(*bcryptProvider).cost != bcrypt.DefaultCost {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses the given cost", func(t *testing.T) {
+		provider, _ := NewBCryptProviderWithCost(bcrypt.MinCost)
+
+		if provider.(*bcryptProvider).cost != bcrypt.MinCost {
+			t.Fail()
+		}
+	})
+}
+
 func TestBCryptProviderHashGeneration(t *testing.T) {
 	provider := NewBCryptProvider()
 	password := "a.very.strong.password"
@@ -49,3 +75,34 @@ func TestBCryptProviderHashComparison(t *testing.T) {
 		}
 	})
 }
+
+func TestBCryptProviderHashIdentification(t *testing.T) {
+	provider, _ := NewBCryptProviderWithCost(bcrypt.MinCost)
+	hash, _ := provider.FromPassword("a.very.strong.password")
+
+	t.Run("identifies bcrypt hashes", func(t *testing.T) {
+		if !provider.Identifies(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not identify other hashes", func(t *testing.T) {
+		if provider.Identifies([]byte("$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$a2V5")) {
+			t.Fail()
+		}
+	})
+
+	t.Run("needs rehash when cost differs", func(t *testing.T) {
+		otherProvider, _ := NewBCryptProviderWithCost(bcrypt.MinCost + 1)
+
+		if !otherProvider.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not need rehash when cost is the same", func(t *testing.T) {
+		if provider.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+}
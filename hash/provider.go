@@ -1,6 +1,45 @@
 package hash
 
+import "fmt"
+
+const (
+	AlgorithmBCrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
 type Provider interface {
 	FromPassword(password string) ([]byte, error)
 	MatchPassword(hash []byte, password string) error
+
+	// Identifies tells whether the hash was generated with the provider's
+	// algorithm, based on its format.
+	Identifies(hash []byte) bool
+
+	// NeedsRehash tells whether the hash was generated with parameters other
+	// than the provider's, such as a lower cost.
+	NeedsRehash(hash []byte) bool
+}
+
+// Config represents the cost parameters of the supported algorithms.
+//
+// Zero values are replaced by the algorithm's defaults.
+type Config struct {
+	BCryptCost int
+	Argon2id   Argon2idParams
+}
+
+func NewProvider(algorithm string, conf Config) (Provider, error) {
+	switch algorithm {
+	case AlgorithmBCrypt:
+		return NewBCryptProviderWithCost(conf.BCryptCost)
+	case AlgorithmArgon2id:
+		return NewArgon2idProvider(conf.Argon2id)
+	default:
+		return nil, fmt.Errorf(
+			"hash.NewProvider: unknown algorithm \"%s\"; must be %s or %s",
+			algorithm,
+			AlgorithmBCrypt,
+			AlgorithmArgon2id,
+		)
+	}
 }
@@ -0,0 +1,27 @@
+package hash
+
+import "testing"
+
+func TestProviderFactory(t *testing.T) {
+	t.Run("fails when algorithm is not recognized", func(t *testing.T) {
+		if _, err := NewProvider("md5", Config{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a bcrypt provider", func(t *testing.T) {
+		provider, _ := NewProvider(AlgorithmBCrypt, Config{})
+
+		if _, ok := provider.(*bcryptProvider); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns an argon2id provider", func(t *testing.T) {
+		provider, _ := NewProvider(AlgorithmArgon2id, Config{})
+
+		if _, ok := provider.(*argon2idProvider); !ok {
+			t.Fail()
+		}
+	})
+}
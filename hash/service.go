@@ -1,29 +1,46 @@
 package hash
 
 import (
+	"context"
 	"fmt"
 )
 
 type Service interface {
-	GenerateFromPassword(password string) (string, error)
-	MatchPassword(hash string, password string) bool
+	GenerateFromPassword(ctx context.Context, password string) (string, error)
+	MatchPassword(ctx context.Context, hash string, password string) bool
+
+	// NeedsRehash tells whether the hash should be replaced by a new one,
+	// because it was generated with another algorithm or other parameters
+	// than the ones currently used to generate hashes.
+	NeedsRehash(hash string) bool
 }
 
 type service struct {
 	provider Provider
+	others   []Provider
 }
 
-func NewService(provider Provider) (Service, error) {
+// NewService creates a hash service that generates hashes with the given
+// provider, and that can match passwords with hashes generated by it or any
+// of the other providers, such as the ones for previously used algorithms.
+func NewService(provider Provider, others ...Provider) (Service, error) {
 	if provider == nil {
 		return nil, fmt.Errorf("hash.NewService: provider is required")
 	}
 
+	for _, p := range others {
+		if p == nil {
+			return nil, fmt.Errorf("hash.NewService: other providers cannot be nil")
+		}
+	}
+
 	return &service{
 		provider,
+		others,
 	}, nil
 }
 
-func (svc *service) GenerateFromPassword(password string) (string, error) {
+func (svc *service) GenerateFromPassword(_ context.Context, password string) (string, error) {
 	hash, err := svc.provider.FromPassword(password)
 	if err != nil {
 		return "", fmt.Errorf("hash.Service.GenerateFromPassword: failed to generate hash from password (%s)", err)
@@ -32,11 +49,38 @@ func (svc *service) GenerateFromPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
-func (svc *service) MatchPassword(hash string, password string) bool {
-	err := svc.provider.MatchPassword([]byte(hash), password)
+func (svc *service) MatchPassword(_ context.Context, hash string, password string) bool {
+	provider := svc.identify([]byte(hash))
+	if provider == nil {
+		return false
+	}
+
+	err := provider.MatchPassword([]byte(hash), password)
 	if err != nil {
 		return false
 	}
 
 	return true
 }
+
+func (svc *service) NeedsRehash(hash string) bool {
+	if !svc.provider.Identifies([]byte(hash)) {
+		return true
+	}
+
+	return svc.provider.NeedsRehash([]byte(hash))
+}
+
+func (svc *service) identify(hash []byte) Provider {
+	if svc.provider.Identifies(hash) {
+		return svc.provider
+	}
+
+	for _, p := range svc.others {
+		if p.Identifies(hash) {
+			return p
+		}
+	}
+
+	return nil
+}
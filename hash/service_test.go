@@ -1,6 +1,7 @@
 package hash
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -12,6 +13,12 @@ func TestServiceConstruction(t *testing.T) {
 		}
 	})
 
+	t.Run("fails when one of the other providers is missing", func(t *testing.T) {
+		if _, err := NewService(&mockProvider{}, nil); err == nil {
+			t.Fail()
+		}
+	})
+
 	t.Run("returns a service with provider", func(t *testing.T) {
 		provider := &mockProvider{}
 		svc, _ := NewService(provider)
@@ -32,7 +39,7 @@ func TestServiceGenerationFromPassword(t *testing.T) {
 	t.Run("fails when provider fails", func(t *testing.T) {
 		svc, _ := NewService(&mockProvider{failOnGeneration: true})
 
-		if _, err := svc.GenerateFromPassword(password); err == nil {
+		if _, err := svc.GenerateFromPassword(context.Background(), password); err == nil {
 			t.Fail()
 		}
 	})
@@ -40,7 +47,7 @@ func TestServiceGenerationFromPassword(t *testing.T) {
 	t.Run("returns hashed password when all is well", func(t *testing.T) {
 		svc, _ := NewService(&mockProvider{})
 
-		hashedPassword, err := svc.GenerateFromPassword(password)
+		hashedPassword, err := svc.GenerateFromPassword(context.Background(), password)
 		if err != nil {
 			t.Fail()
 		}
@@ -57,7 +64,15 @@ func TestServiceComparisonWithPassword(t *testing.T) {
 	t.Run("fails when provider fails", func(t *testing.T) {
 		svc, _ := NewService(&mockProvider{failOnComparison: true})
 
-		if svc.MatchPassword(hash, password) {
+		if svc.MatchPassword(context.Background(), hash, password) {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no provider identifies the hash", func(t *testing.T) {
+		svc, _ := NewService(&mockProvider{unidentifiable: true})
+
+		if svc.MatchPassword(context.Background(), hash, password) {
 			t.Fail()
 		}
 	})
@@ -65,7 +80,62 @@ func TestServiceComparisonWithPassword(t *testing.T) {
 	t.Run("succeeds when provider succeeds", func(t *testing.T) {
 		svc, _ := NewService(&mockProvider{})
 
-		if !svc.MatchPassword(hash, password) {
+		if !svc.MatchPassword(context.Background(), hash, password) {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when another provider identifies the hash and succeeds", func(t *testing.T) {
+		svc, _ := NewService(&mockProvider{unidentifiable: true}, &mockProvider{})
+
+		if !svc.MatchPassword(context.Background(), hash, password) {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceRehashCheck(t *testing.T) {
+	hash := "a.very.strong.password.hash"
+
+	t.Run("needs rehash when provider does not identify the hash", func(t *testing.T) {
+		svc, _ := NewService(&mockProvider{unidentifiable: true}, &mockProvider{})
+
+		if !svc.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("needs rehash when provider says so", func(t *testing.T) {
+		svc, _ := NewService(&mockProvider{needsRehash: true})
+
+		if !svc.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not need rehash when provider generated the hash with its parameters", func(t *testing.T) {
+		svc, _ := NewService(&mockProvider{})
+
+		if svc.NeedsRehash(hash) {
+			t.Fail()
+		}
+	})
+
+	t.Run("upgrades bcrypt hashes to argon2id", func(t *testing.T) {
+		bcryptProvider, _ := NewBCryptProviderWithCost(4)
+		argon2idProvider, _ := NewArgon2idProvider(Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1})
+		svc, _ := NewService(argon2idProvider, bcryptProvider)
+
+		bcryptHash, _ := bcryptProvider.FromPassword("P@ssw0rd")
+		if !svc.MatchPassword(context.Background(), string(bcryptHash), "P@ssw0rd") {
+			t.Fail()
+		}
+		if !svc.NeedsRehash(string(bcryptHash)) {
+			t.Fail()
+		}
+
+		argon2idHash, _ := svc.GenerateFromPassword(context.Background(), "P@ssw0rd")
+		if svc.NeedsRehash(argon2idHash) {
 			t.Fail()
 		}
 	})
@@ -74,6 +144,8 @@ func TestServiceComparisonWithPassword(t *testing.T) {
 type mockProvider struct {
 	failOnGeneration bool
 	failOnComparison bool
+	unidentifiable   bool
+	needsRehash      bool
 }
 
 func (mock *mockProvider) FromPassword(password string) ([]byte, error) {
@@ -91,3 +163,11 @@ func (mock *mockProvider) MatchPassword(hash []byte, password string) error {
 
 	return nil
 }
+
+func (mock *mockProvider) Identifies(hash []byte) bool {
+	return !mock.unidentifiable
+}
+
+func (mock *mockProvider) NeedsRehash(hash []byte) bool {
+	return mock.needsRehash
+}
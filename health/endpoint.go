@@ -0,0 +1,73 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+)
+
+const (
+	statusOK    = "ok"
+	statusError = "error"
+)
+
+type liveResponse struct {
+	Status string `json:"status"`
+}
+
+// The responses only tell whether checks passed, since the probes do not
+// require authentication, and errors may reveal details about the
+// dependencies. The errors are logged instead.
+
+type checkResponse struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+type readyResponse struct {
+	Status string                   `json:"status"`
+	Checks map[string]checkResponse `json:"checks"`
+}
+
+func makeLiveEndpoint() endpoint.Endpoint {
+	return func(_ context.Context, _ interface{}) (interface{}, error) {
+		// Answering at all means the process is alive.
+		return &liveResponse{Status: statusOK}, nil
+	}
+}
+
+func makeReadyEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		report := svc.Ready(ctx)
+		logger := logging.FromContext(ctx)
+
+		resp := &readyResponse{
+			Status: statusOK,
+			Checks: make(map[string]checkResponse, len(report.Results)),
+		}
+		if !report.Ready {
+			resp.Status = statusError
+		}
+		if report.Err != nil {
+			logger.WarnContext(ctx, "service is not ready", slog.String("error", report.Err.Error()))
+		}
+
+		for _, r := range report.Results {
+			check := checkResponse{
+				Status:    statusOK,
+				LatencyMs: float64(r.Latency) / float64(time.Millisecond),
+			}
+			if r.Err != nil {
+				check.Status = statusError
+				logger.WarnContext(ctx, "dependency is not ready", slog.String("check", r.Name), slog.String("error", r.Err.Error()))
+			}
+
+			resp.Checks[r.Name] = check
+		}
+
+		return resp, nil
+	}
+}
@@ -0,0 +1,176 @@
+// Package health tells orchestrators whether the service is alive, and
+// whether it is ready to serve requests, based on the dependencies it
+// checks.
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is how long a dependency has to answer a check, when none is
+// configured.
+const DefaultTimeout = 2 * time.Second
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// A Checker is a dependency of the service, such as the database, which
+// reports whether it is usable.
+type Checker interface {
+	// Check fails when the dependency is not usable, or when it cannot tell
+	// before the context is done.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc lets ordinary functions be used as checkers.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result represents the outcome of checking a dependency.
+type Result struct {
+	Name    string
+	Latency time.Duration
+
+	// Err is why the dependency is not usable, and is nil when it is.
+	Err error
+}
+
+// Report represents whether the service is ready, with the results of the
+// checks, sorted by name.
+type Report struct {
+	Ready   bool
+	Results []Result
+
+	// Err is why the service is not ready when it is not because of a
+	// dependency, such as it shutting down.
+	Err error
+}
+
+type Config struct {
+	// Timeout is how long each dependency has to answer a check.
+	Timeout time.Duration
+}
+
+type Service interface {
+	// Register adds a dependency to check, under the name, replacing the one
+	// that had the name, if any.
+	Register(name string, checker Checker)
+
+	// Ready checks all dependencies at the same time, and reports the
+	// service as ready when they are all usable and it is not shutting
+	// down.
+	Ready(ctx context.Context) Report
+
+	// Shutdown marks the service as not ready for good, so that it stops
+	// receiving traffic while it drains.
+	Shutdown()
+}
+
+type service struct {
+	conf Config
+
+	mu           sync.RWMutex
+	checkers     map[string]Checker
+	shuttingDown bool
+}
+
+func NewService(conf Config) Service {
+	if conf.Timeout == 0 {
+		conf.Timeout = DefaultTimeout
+	}
+
+	return &service{
+		conf:     conf,
+		checkers: make(map[string]Checker),
+	}
+}
+
+func (svc *service) Register(name string, checker Checker) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.checkers[name] = checker
+}
+
+func (svc *service) Ready(ctx context.Context) Report {
+	svc.mu.RLock()
+	checkers := make(map[string]Checker, len(svc.checkers))
+	for name, checker := range svc.checkers {
+		checkers[name] = checker
+	}
+	shuttingDown := svc.shuttingDown
+	svc.mu.RUnlock()
+
+	results := make([]Result, len(checkers))
+
+	var wg sync.WaitGroup
+	i := 0
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(i int, name string, checker Checker) {
+			defer wg.Done()
+			results[i] = svc.check(ctx, name, checker)
+		}(i, name, checker)
+		i++
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	report := Report{
+		Ready:   !shuttingDown,
+		Results: results,
+	}
+	if shuttingDown {
+		report.Err = ErrShuttingDown
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+func (svc *service) check(ctx context.Context, name string, checker Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, svc.conf.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// A checker that ignores its context must not hold the report back past
+	// the timeout.
+	checked := make(chan error, 1)
+	go func() {
+		checked <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-checked:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return Result{
+		Name:    name,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+}
+
+func (svc *service) Shutdown() {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.shuttingDown = true
+}
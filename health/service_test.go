@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var (
+	healthy = CheckerFunc(func(context.Context) error { return nil })
+	broken  = CheckerFunc(func(context.Context) error { return fmt.Errorf("connection refused") })
+)
+
+func TestServiceCheckingReadiness(t *testing.T) {
+	t.Run("is ready when there is nothing to check", func(t *testing.T) {
+		svc := NewService(Config{})
+
+		if report := svc.Ready(context.Background()); !report.Ready || len(report.Results) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("is ready when all dependencies are usable", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Register("database", healthy)
+		svc.Register("cache", healthy)
+
+		report := svc.Ready(context.Background())
+		if !report.Ready || len(report.Results) != 2 {
+			t.FailNow()
+		}
+		if report.Results[0].Name != "cache" || report.Results[1].Name != "database" {
+			t.Fail()
+		}
+	})
+
+	t.Run("is not ready when a dependency is not usable", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Register("database", broken)
+		svc.Register("cache", healthy)
+
+		report := svc.Ready(context.Background())
+		if report.Ready || report.Results[0].Err != nil || report.Results[1].Err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not wait for a dependency past the timeout", func(t *testing.T) {
+		svc := NewService(Config{Timeout: 20 * time.Millisecond})
+		svc.Register("database", CheckerFunc(func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}))
+
+		start := time.Now()
+		report := svc.Ready(context.Background())
+		if report.Ready || time.Since(start) > 500*time.Millisecond {
+			t.Fail()
+		}
+	})
+
+	t.Run("checks dependencies at the same time", func(t *testing.T) {
+		svc := NewService(Config{})
+		for _, name := range []string{"a", "b", "c"} {
+			svc.Register(name, CheckerFunc(func(context.Context) error {
+				time.Sleep(100 * time.Millisecond)
+				return nil
+			}))
+		}
+
+		start := time.Now()
+		svc.Ready(context.Background())
+		if time.Since(start) > 250*time.Millisecond {
+			t.Fail()
+		}
+	})
+
+	t.Run("is not ready once shutting down", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Register("database", healthy)
+
+		svc.Shutdown()
+
+		if report := svc.Ready(context.Background()); report.Ready || report.Err != ErrShuttingDown {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+// MakeHandler serves the liveness and readiness probes, which are meant for
+// orchestrators, so they do not require authentication.
+func MakeHandler(svc Service) http.Handler {
+	liveHandler := stmhttp.NewHandler(
+		makeLiveEndpoint(),
+		decodeRequest,
+		encodeResponse,
+		encodeError,
+	)
+	readyHandler := stmhttp.NewHandler(
+		makeReadyEndpoint(svc),
+		decodeRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/healthz", liveHandler).Methods("GET")
+	r.Handle("/readyz", readyHandler).Methods("GET")
+
+	return r
+}
+
+func decodeRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if resp, ok := response.(*readyResponse); ok && resp.Status != statusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+// The probes know of no errors of their own, so any error is internal.
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	return 0
+})
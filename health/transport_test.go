@@ -0,0 +1,88 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("reports the process as alive", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Register("database", broken)
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/healthz", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		var body liveResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if rr.Code != http.StatusOK || body.Status != statusOK {
+			t.Fail()
+		}
+	})
+
+	t.Run("reports the status and latency of each check when ready", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Register("database", healthy)
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/readyz", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var body readyResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		check, ok := body.Checks["database"]
+		if body.Status != statusOK || !ok || check.Status != statusOK || check.LatencyMs < 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("reports which checks failed with a 503, without their errors", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Register("database", broken)
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/readyz", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusServiceUnavailable {
+			t.FailNow()
+		}
+
+		if strings.Contains(rr.Body.String(), "connection refused") {
+			t.Fail()
+		}
+
+		var body readyResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.Status != statusError || body.Checks["database"].Status != statusError {
+			t.Fail()
+		}
+	})
+
+	t.Run("is not ready during shutdown", func(t *testing.T) {
+		svc := NewService(Config{})
+		svc.Shutdown()
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/readyz", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		var body readyResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if rr.Code != http.StatusServiceUnavailable || body.Status != statusError {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package invite
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inviteResponse struct {
+	Code        string    `json:"code"`
+	GameID      string    `json:"gameId"`
+	CreatedBy   string    `json:"createdBy"`
+	RecipientID string    `json:"recipientId,omitempty"`
+	MaxUses     int       `json:"maxUses"`
+	Uses        int       `json:"uses"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func newInviteResponse(i entity.Invite) inviteResponse {
+	return inviteResponse{
+		Code:        i.Code,
+		GameID:      i.GameID,
+		CreatedBy:   i.CreatedBy,
+		RecipientID: i.RecipientID,
+		MaxUses:     i.MaxUses,
+		Uses:        i.Uses,
+		ExpiresAt:   i.ExpiresAt,
+		CreatedAt:   i.CreatedAt,
+	}
+}
+
+type listInvitesResponse struct {
+	Invites []inviteResponse `json:"invites"`
+}
+
+func newListInvitesResponse(invites []entity.Invite) *listInvitesResponse {
+	response := &listInvitesResponse{
+		Invites: make([]inviteResponse, 0, len(invites)),
+	}
+
+	for _, i := range invites {
+		response.Invites = append(response.Invites, newInviteResponse(i))
+	}
+
+	return response
+}
+
+type sendInvitesRequest struct {
+	GameID       string
+	RecipientIDs []string
+}
+
+func makeSendInvitesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sendInvitesRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		invites, err := svc.Invite(ctx, identity.UserID, req.GameID, req.RecipientIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		return newListInvitesResponse(invites), nil
+	}
+}
+
+type createJoinCodeRequest struct {
+	GameID    string
+	MaxUses   int
+	ExpiresIn time.Duration
+}
+
+func makeCreateJoinCodeEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createJoinCodeRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		invite, err := svc.CreateJoinCode(ctx, identity.UserID, req.GameID, req.MaxUses, req.ExpiresIn)
+		if err != nil {
+			return nil, err
+		}
+
+		response := newInviteResponse(*invite)
+
+		return &response, nil
+	}
+}
+
+type listPendingRequest struct {
+	UserID string
+}
+
+func makeListPendingEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listPendingRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		invites, err := svc.ListPending(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		return newListInvitesResponse(invites), nil
+	}
+}
+
+// codeRequest represents a request about the invite with a code.
+type codeRequest struct {
+	Code string
+}
+
+type acceptResponse struct {
+	GameID string `json:"gameId"`
+}
+
+func makeAcceptEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(codeRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		g, err := svc.Accept(ctx, identity.UserID, req.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		return &acceptResponse{
+			GameID: g.ID,
+		}, nil
+	}
+}
+
+func makeDeclineEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(codeRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		if err := svc.Decline(ctx, identity.UserID, req.Code); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func makeRevokeEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(codeRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		if err := svc.Revoke(ctx, identity.UserID, req.Code); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
@@ -0,0 +1,114 @@
+package invite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inMemoryRepository struct {
+	database *db.InMemory
+}
+
+func NewInMemoryRepository(database *db.InMemory) Repository {
+	return &inMemoryRepository{database}
+}
+
+func (repo *inMemoryRepository) Create(_ context.Context, invite entity.Invite) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if repo.indexOf(invite.Code) >= 0 {
+		return false, nil
+	}
+
+	repo.database.Invites = append(repo.database.Invites, invite)
+
+	return true, nil
+}
+
+func (repo *inMemoryRepository) GetByCode(_ context.Context, code string) (*entity.Invite, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	i := repo.indexOf(code)
+	if i < 0 {
+		return nil, nil
+	}
+
+	invite := repo.database.Invites[i]
+
+	return &invite, nil
+}
+
+func (repo *inMemoryRepository) ListByRecipient(_ context.Context, userID string, now time.Time) ([]entity.Invite, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	invites := make([]entity.Invite, 0)
+
+	for _, i := range repo.database.Invites {
+		if i.RecipientID == userID && i.Usable(now) {
+			invites = append(invites, i)
+		}
+	}
+
+	return invites, nil
+}
+
+func (repo *inMemoryRepository) Delete(_ context.Context, code string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	i := repo.indexOf(code)
+	if i < 0 {
+		return fmt.Errorf("invite.InMemoryRepository.Delete: no invite exists with code \"%s\"", code)
+	}
+
+	repo.database.Invites = append(repo.database.Invites[:i], repo.database.Invites[i+1:]...)
+
+	return nil
+}
+
+func (repo *inMemoryRepository) Use(_ context.Context, code string, now time.Time) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	i := repo.indexOf(code)
+	if i < 0 || !repo.database.Invites[i].Usable(now) {
+		return false, nil
+	}
+
+	repo.database.Invites[i].Uses++
+
+	return true, nil
+}
+
+func (repo *inMemoryRepository) ReleaseUse(_ context.Context, code string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	i := repo.indexOf(code)
+	if i < 0 {
+		return fmt.Errorf("invite.InMemoryRepository.ReleaseUse: no invite exists with code \"%s\"", code)
+	}
+
+	if repo.database.Invites[i].Uses > 0 {
+		repo.database.Invites[i].Uses--
+	}
+
+	return nil
+}
+
+func (repo *inMemoryRepository) indexOf(code string) int {
+	for i, invite := range repo.database.Invites {
+		if invite.Code == code {
+			return i
+		}
+	}
+
+	return -1
+}
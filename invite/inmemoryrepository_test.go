@@ -0,0 +1,128 @@
+package invite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func newInMemoryRepository() Repository {
+	database := &db.InMemory{}
+	database.Open()
+
+	return NewInMemoryRepository(database)
+}
+
+func TestInMemoryRepositoryCreation(t *testing.T) {
+	t.Run("returns false when code is already taken", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1})
+
+		if created, err := repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1}); err != nil || created {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates the invite when all is well", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if created, err := repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1}); err != nil || !created {
+			t.FailNow()
+		}
+
+		if i, _ := repo.GetByCode(context.Background(), mockCode); i == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryGettingInvite(t *testing.T) {
+	t.Run("returns nil when no invite exists with the code", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if i, err := repo.GetByCode(context.Background(), mockCode); err != nil || i != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryListingInvites(t *testing.T) {
+	t.Run("returns the recipient's usable invites", func(t *testing.T) {
+		now := time.Now()
+
+		repo := newInMemoryRepository()
+		repo.Create(context.Background(), entity.Invite{Code: "A", RecipientID: mockRecipientID, MaxUses: 1, ExpiresAt: now.Add(time.Hour)})
+		repo.Create(context.Background(), entity.Invite{Code: "B", RecipientID: mockRecipientID, MaxUses: 1, Uses: 1, ExpiresAt: now.Add(time.Hour)})
+		repo.Create(context.Background(), entity.Invite{Code: "C", RecipientID: mockRecipientID, MaxUses: 1, ExpiresAt: now.Add(-time.Hour)})
+		repo.Create(context.Background(), entity.Invite{Code: "D", MaxUses: 1, ExpiresAt: now.Add(time.Hour)})
+
+		invites, err := repo.ListByRecipient(context.Background(), mockRecipientID, now)
+		if err != nil || len(invites) != 1 || invites[0].Code != "A" {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryDeletingInvite(t *testing.T) {
+	t.Run("fails when no invite exists with the code", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if err := repo.Delete(context.Background(), mockCode); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("deletes the invite when all is well", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1})
+
+		if err := repo.Delete(context.Background(), mockCode); err != nil {
+			t.FailNow()
+		}
+
+		if i, _ := repo.GetByCode(context.Background(), mockCode); i != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryUsingInvite(t *testing.T) {
+	now := time.Now()
+
+	t.Run("returns false once the invite is used up", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1, ExpiresAt: now.Add(time.Hour)})
+
+		if used, err := repo.Use(context.Background(), mockCode, now); err != nil || !used {
+			t.FailNow()
+		}
+		if used, err := repo.Use(context.Background(), mockCode, now); err != nil || used {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when the invite has expired", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1, ExpiresAt: now})
+
+		if used, err := repo.Use(context.Background(), mockCode, now); err != nil || used {
+			t.Fail()
+		}
+	})
+
+	t.Run("gives back a use when released", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Create(context.Background(), entity.Invite{Code: mockCode, MaxUses: 1, ExpiresAt: now.Add(time.Hour)})
+		repo.Use(context.Background(), mockCode, now)
+
+		if err := repo.ReleaseUse(context.Background(), mockCode); err != nil {
+			t.FailNow()
+		}
+		if used, _ := repo.Use(context.Background(), mockCode, now); !used {
+			t.Fail()
+		}
+	})
+}
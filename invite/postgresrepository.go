@@ -0,0 +1,186 @@
+package invite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+// Join codes have no recipient, which is stored as NULL rather than an empty
+// string, since recipients reference users.
+const (
+	createQuery          = "INSERT INTO invites(code, game_id, created_by, recipient_id, max_uses, uses, expires_at, created_at) VALUES($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8) ON CONFLICT (code) DO NOTHING"
+	getByCodeQuery       = "SELECT code, game_id, created_by, COALESCE(recipient_id::text, ''), max_uses, uses, expires_at, created_at FROM invites WHERE code = $1"
+	listByRecipientQuery = "SELECT code, game_id, created_by, COALESCE(recipient_id::text, ''), max_uses, uses, expires_at, created_at FROM invites WHERE recipient_id = $1 AND uses < max_uses AND expires_at > $2 ORDER BY created_at"
+	deleteQuery          = "DELETE FROM invites WHERE code = $1"
+	useQuery             = "UPDATE invites SET uses = uses + 1 WHERE code = $1 AND uses < max_uses AND expires_at > $2"
+	releaseUseQuery      = "UPDATE invites SET uses = uses - 1 WHERE code = $1 AND uses > 0"
+)
+
+type postgresRepository struct {
+	database *db.Postgres
+}
+
+func NewPostgresRepository(database *db.Postgres) Repository {
+	return &postgresRepository{database}
+}
+
+func (pr *postgresRepository) Create(ctx context.Context, invite entity.Invite) (bool, error) {
+	result, err := pr.database.ExecContext(
+		ctx,
+		createQuery,
+		invite.Code,
+		invite.GameID,
+		invite.CreatedBy,
+		invite.RecipientID,
+		invite.MaxUses,
+		invite.Uses,
+		invite.ExpiresAt,
+		invite.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"invite.PostgresRepository.Create: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf(
+			"invite.PostgresRepository.Create: failed to count created rows (%s)",
+			err,
+		)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (pr *postgresRepository) GetByCode(ctx context.Context, code string) (*entity.Invite, error) {
+	var invite entity.Invite
+
+	err := scanInvite(pr.database.QueryRowContext(ctx, getByCodeQuery, code), &invite)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"invite.PostgresRepository.GetByCode: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return &invite, nil
+}
+
+func (pr *postgresRepository) ListByRecipient(ctx context.Context, userID string, now time.Time) ([]entity.Invite, error) {
+	rows, err := pr.database.QueryContext(ctx, listByRecipientQuery, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invite.PostgresRepository.ListByRecipient: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	invites := make([]entity.Invite, 0)
+	for rows.Next() {
+		var invite entity.Invite
+		if err := scanInvite(rows, &invite); err != nil {
+			return nil, fmt.Errorf(
+				"invite.PostgresRepository.ListByRecipient: failed to read invite (%s)",
+				err,
+			)
+		}
+
+		invites = append(invites, invite)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"invite.PostgresRepository.ListByRecipient: failed to read invites (%s)",
+			err,
+		)
+	}
+
+	return invites, nil
+}
+
+func (pr *postgresRepository) Delete(ctx context.Context, code string) error {
+	result, err := pr.database.ExecContext(ctx, deleteQuery, code)
+	if err != nil {
+		return fmt.Errorf(
+			"invite.PostgresRepository.Delete: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"invite.PostgresRepository.Delete: failed to count deleted rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf(
+			"invite.PostgresRepository.Delete: no invite exists with code \"%s\"",
+			code,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) Use(ctx context.Context, code string, now time.Time) (bool, error) {
+	result, err := pr.database.ExecContext(ctx, useQuery, code, now)
+	if err != nil {
+		return false, fmt.Errorf(
+			"invite.PostgresRepository.Use: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf(
+			"invite.PostgresRepository.Use: failed to count updated rows (%s)",
+			err,
+		)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (pr *postgresRepository) ReleaseUse(ctx context.Context, code string) error {
+	if _, err := pr.database.ExecContext(ctx, releaseUseQuery, code); err != nil {
+		return fmt.Errorf(
+			"invite.PostgresRepository.ReleaseUse: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInvite(row scanner, invite *entity.Invite) error {
+	return row.Scan(
+		&invite.Code,
+		&invite.GameID,
+		&invite.CreatedBy,
+		&invite.RecipientID,
+		&invite.MaxUses,
+		&invite.Uses,
+		&invite.ExpiresAt,
+		&invite.CreatedAt,
+	)
+}
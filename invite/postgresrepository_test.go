@@ -0,0 +1,205 @@
+package invite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+var inviteColumns = []string{"code", "game_id", "created_by", "recipient_id", "max_uses", "uses", "expires_at", "created_at"}
+
+func TestPostgresRepositoryCreatingInvite(t *testing.T) {
+	invite := entity.Invite{
+		Code:      mockCode,
+		GameID:    mockGameID,
+		CreatedBy: mockSenderID,
+		MaxUses:   10,
+		ExpiresAt: time.Date(2021, 3, 15, 15, 9, 26, 0, time.UTC),
+		CreatedAt: time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+	}
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(createQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.Create(context.Background(), invite); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when code is already taken", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(createQuery).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if created, err := pr.Create(context.Background(), invite); err != nil || created {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns true when invite is created", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(createQuery).
+			WithArgs(mockCode, mockGameID, mockSenderID, "", 10, 0, invite.ExpiresAt, invite.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if created, err := pr.Create(context.Background(), invite); err != nil || !created {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryGettingInvite(t *testing.T) {
+	t.Run("returns nil when no invite exists with the code", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getByCodeQuery).
+			WithArgs(mockCode).
+			WillReturnRows(mock.NewRows(inviteColumns))
+
+		if i, err := pr.GetByCode(context.Background(), mockCode); err != nil || i != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the invite when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getByCodeQuery).
+			WithArgs(mockCode).
+			WillReturnRows(mock.NewRows(inviteColumns).
+				AddRow(mockCode, mockGameID, mockSenderID, mockRecipientID, 1, 0, time.Now(), time.Now()))
+
+		i, err := pr.GetByCode(context.Background(), mockCode)
+		if err != nil || i == nil {
+			t.FailNow()
+		}
+		if i.RecipientID != mockRecipientID || i.MaxUses != 1 {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryListingInvites(t *testing.T) {
+	t.Run("returns the recipient's invites", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		now := time.Now()
+
+		mock.ExpectQuery(listByRecipientQuery).
+			WithArgs(mockRecipientID, now).
+			WillReturnRows(mock.NewRows(inviteColumns).
+				AddRow(mockCode, mockGameID, mockSenderID, mockRecipientID, 1, 0, now, now))
+
+		invites, err := pr.ListByRecipient(context.Background(), mockRecipientID, now)
+		if err != nil || len(invites) != 1 || invites[0].Code != mockCode {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryDeletingInvite(t *testing.T) {
+	t.Run("fails when no invite exists with the code", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(deleteQuery).
+			WithArgs(mockCode).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.Delete(context.Background(), mockCode); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUsingInvite(t *testing.T) {
+	now := time.Now()
+
+	t.Run("returns false when invite cannot be used", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(useQuery).
+			WithArgs(mockCode, now).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if used, err := pr.Use(context.Background(), mockCode, now); err != nil || used {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns true when invite is used", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(useQuery).
+			WithArgs(mockCode, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if used, err := pr.Use(context.Background(), mockCode, now); err != nil || !used {
+			t.Fail()
+		}
+	})
+}
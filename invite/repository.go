@@ -0,0 +1,42 @@
+// Package invite manages invitations to join games, which are sent to
+// specific users, or shared with anyone as join codes.
+package invite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type Repository interface {
+	// Create creates the invite, unless its code is already taken, in which
+	// case it returns false.
+	Create(ctx context.Context, invite entity.Invite) (bool, error)
+	// GetByCode returns the invite, or nil if no invite exists with the code.
+	GetByCode(ctx context.Context, code string) (*entity.Invite, error)
+	// ListByRecipient returns the invites sent to the user which can still be
+	// accepted at the given time, from oldest to newest.
+	ListByRecipient(ctx context.Context, userID string, now time.Time) ([]entity.Invite, error)
+	Delete(ctx context.Context, code string) error
+
+	// Use counts a use of the invite, unless it can no longer be accepted at
+	// the given time, in which case it returns false. Both are done at once,
+	// so that an invite cannot be used more than its max uses.
+	Use(ctx context.Context, code string, now time.Time) (bool, error)
+	// ReleaseUse gives back a use of the invite, when the user who used it
+	// could not join its game after all.
+	ReleaseUse(ctx context.Context, code string) error
+}
+
+func NewRepository(database db.DB) (Repository, error) {
+	if inmemory, ok := database.(*db.InMemory); ok {
+		return NewInMemoryRepository(inmemory), nil
+	} else if postgres, ok := database.(*db.Postgres); ok {
+		return NewPostgresRepository(postgres), nil
+	}
+
+	return nil, fmt.Errorf("invite.NewRepository: unsupported database type")
+}
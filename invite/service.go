@@ -0,0 +1,389 @@
+package invite
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	"github.com/leblancjs/stmoosersburg-api/social"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+const (
+	// MaxRecipients is the maximum number of users who can be invited at
+	// once.
+	MaxRecipients = game.MaxPlayers - 1
+
+	// InviteTTL is how long an invite sent to a user can be accepted.
+	InviteTTL = 24 * time.Hour
+
+	DefaultJoinCodeMaxUses = 10
+	MaxJoinCodeMaxUses     = 100
+	DefaultJoinCodeTTL     = 24 * time.Hour
+	MaxJoinCodeTTL         = 7 * 24 * time.Hour
+
+	// codeAttempts is how many codes are generated before giving up, when
+	// they are already taken.
+	codeAttempts = 5
+)
+
+var (
+	ErrNotFound          = errors.New("invite does not exist or has expired")
+	ErrNotPlayer         = errors.New("only the game's players can invite users to it")
+	ErrUserNotFound      = errors.New("user does not exist")
+	ErrSelf              = errors.New("users cannot invite themselves")
+	ErrBlocked           = errors.New("user is blocked")
+	ErrAlreadyPlaying    = errors.New("user is already playing the game")
+	ErrInvalidRecipients = fmt.Errorf("between 1 and %d users must be invited", MaxRecipients)
+	ErrInvalidMaxUses    = fmt.Errorf("max uses must be between 1 and %d", MaxJoinCodeMaxUses)
+	ErrInvalidTTL        = fmt.Errorf("join codes must expire within %s", MaxJoinCodeTTL)
+)
+
+// codeWords are the words join codes start with, followed by four digits
+// (e.g. MOOSE-4821), so that they are easy to read out loud.
+var codeWords = []string{
+	"MOOSE",
+	"ELK",
+	"ANTLER",
+	"CARIBOU",
+	"MAPLE",
+	"SPRUCE",
+	"BIRCH",
+	"PINE",
+	"LAKE",
+	"RIVER",
+	"TUNDRA",
+	"BOREAL",
+	"CANOE",
+	"CABIN",
+	"SNOW",
+	"AURORA",
+}
+
+type Service interface {
+	// Invite sends an invite to join the game to each of the users, which
+	// only they can accept, once.
+	Invite(ctx context.Context, senderID string, gameID string, recipientIDs []string) ([]entity.Invite, error)
+	// CreateJoinCode creates an invite to join the game which anyone who has
+	// its code can accept, up to max uses times. When max uses or the time to
+	// live are zero, their default is used.
+	CreateJoinCode(ctx context.Context, creatorID string, gameID string, maxUses int, ttl time.Duration) (*entity.Invite, error)
+	// ListPending returns the invites sent to the user which can still be
+	// accepted.
+	ListPending(ctx context.Context, userID string) ([]entity.Invite, error)
+
+	// Accept adds the user to the game of the invite with the code, and
+	// returns the game.
+	Accept(ctx context.Context, userID string, code string) (*entity.Game, error)
+	// Decline deletes an invite sent to the user.
+	Decline(ctx context.Context, userID string, code string) error
+	// Revoke deletes an invite created by the user.
+	Revoke(ctx context.Context, userID string, code string) error
+}
+
+type service struct {
+	repo      Repository
+	gameSvc   game.Service
+	socialSvc social.Service
+	userSvc   user.Service
+
+	now          func() time.Time
+	generateCode func() (string, error)
+}
+
+func NewService(repo Repository, gameSvc game.Service, socialSvc social.Service, userSvc user.Service) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("invite.NewService: repository is required")
+	}
+	if gameSvc == nil {
+		return nil, fmt.Errorf("invite.NewService: game service is required")
+	}
+	if socialSvc == nil {
+		return nil, fmt.Errorf("invite.NewService: social service is required")
+	}
+	if userSvc == nil {
+		return nil, fmt.Errorf("invite.NewService: user service is required")
+	}
+
+	return &service{
+		repo:         repo,
+		gameSvc:      gameSvc,
+		socialSvc:    socialSvc,
+		userSvc:      userSvc,
+		now:          time.Now,
+		generateCode: generateCode,
+	}, nil
+}
+
+func (svc *service) Invite(ctx context.Context, senderID string, gameID string, recipientIDs []string) ([]entity.Invite, error) {
+	if len(recipientIDs) == 0 || len(recipientIDs) > MaxRecipients {
+		return nil, ErrInvalidRecipients
+	}
+
+	g, err := svc.joinableGame(senderID, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every recipient is checked before any invite is sent, so that none are
+	// sent when one of them cannot be invited.
+	for _, recipientID := range recipientIDs {
+		if err := svc.checkRecipient(ctx, senderID, recipientID, g); err != nil {
+			return nil, err
+		}
+	}
+
+	now := svc.now()
+	invites := make([]entity.Invite, 0, len(recipientIDs))
+
+	for _, recipientID := range recipientIDs {
+		invite, err := svc.create(ctx, entity.Invite{
+			GameID:      gameID,
+			CreatedBy:   senderID,
+			RecipientID: recipientID,
+			MaxUses:     1,
+			ExpiresAt:   now.Add(InviteTTL),
+			CreatedAt:   now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invite.Service.Invite: %s", err)
+		}
+
+		invites = append(invites, *invite)
+	}
+
+	return invites, nil
+}
+
+func (svc *service) CreateJoinCode(ctx context.Context, creatorID string, gameID string, maxUses int, ttl time.Duration) (*entity.Invite, error) {
+	if maxUses == 0 {
+		maxUses = DefaultJoinCodeMaxUses
+	}
+	if maxUses < 1 || maxUses > MaxJoinCodeMaxUses {
+		return nil, ErrInvalidMaxUses
+	}
+
+	if ttl == 0 {
+		ttl = DefaultJoinCodeTTL
+	}
+	if ttl < 0 || ttl > MaxJoinCodeTTL {
+		return nil, ErrInvalidTTL
+	}
+
+	if _, err := svc.joinableGame(creatorID, gameID); err != nil {
+		return nil, err
+	}
+
+	now := svc.now()
+
+	invite, err := svc.create(ctx, entity.Invite{
+		GameID:    gameID,
+		CreatedBy: creatorID,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invite.Service.CreateJoinCode: %s", err)
+	}
+
+	return invite, nil
+}
+
+func (svc *service) ListPending(ctx context.Context, userID string) ([]entity.Invite, error) {
+	invites, err := svc.repo.ListByRecipient(ctx, userID, svc.now())
+	if err != nil {
+		return nil, fmt.Errorf("invite.Service.ListPending: %s", err)
+	}
+
+	return invites, nil
+}
+
+func (svc *service) Accept(ctx context.Context, userID string, code string) (*entity.Game, error) {
+	invite, err := svc.get(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("invite.Service.Accept: %s", err)
+	}
+
+	// Invites sent to someone else are treated as if they did not exist, so
+	// that their codes cannot be confirmed by guessing them.
+	if invite == nil || !invite.Usable(svc.now()) {
+		return nil, ErrNotFound
+	}
+	if invite.RecipientID != "" && invite.RecipientID != userID {
+		return nil, ErrNotFound
+	}
+
+	g, err := svc.gameSvc.GetByID(invite.GameID)
+	if err != nil {
+		if err == game.ErrNotFound {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("invite.Service.Accept: %s", err)
+	}
+
+	// Accepting an invite to a game the user already plays does not use it
+	// up.
+	if g.HasPlayer(userID) {
+		return g, nil
+	}
+
+	if invite.CreatedBy != userID {
+		blocked, err := svc.socialSvc.IsBlocked(ctx, userID, invite.CreatedBy)
+		if err != nil {
+			return nil, fmt.Errorf("invite.Service.Accept: %s", err)
+		}
+		if blocked {
+			return nil, ErrBlocked
+		}
+	}
+
+	used, err := svc.repo.Use(ctx, invite.Code, svc.now())
+	if err != nil {
+		return nil, fmt.Errorf("invite.Service.Accept: %s", err)
+	}
+	if !used {
+		return nil, ErrNotFound
+	}
+
+	g, err = svc.gameSvc.Join(invite.GameID, userID)
+	if err != nil {
+		if releaseErr := svc.repo.ReleaseUse(ctx, invite.Code); releaseErr != nil {
+			return nil, fmt.Errorf("invite.Service.Accept: %s", releaseErr)
+		}
+
+		if err == game.ErrFull || err == game.ErrStarted {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("invite.Service.Accept: %s", err)
+	}
+
+	return g, nil
+}
+
+func (svc *service) Decline(ctx context.Context, userID string, code string) error {
+	invite, err := svc.get(ctx, code)
+	if err != nil {
+		return fmt.Errorf("invite.Service.Decline: %s", err)
+	}
+	if invite == nil || invite.RecipientID != userID {
+		return ErrNotFound
+	}
+
+	if err := svc.repo.Delete(ctx, invite.Code); err != nil {
+		return fmt.Errorf("invite.Service.Decline: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) Revoke(ctx context.Context, userID string, code string) error {
+	invite, err := svc.get(ctx, code)
+	if err != nil {
+		return fmt.Errorf("invite.Service.Revoke: %s", err)
+	}
+	if invite == nil || invite.CreatedBy != userID {
+		return ErrNotFound
+	}
+
+	if err := svc.repo.Delete(ctx, invite.Code); err != nil {
+		return fmt.Errorf("invite.Service.Revoke: %s", err)
+	}
+
+	return nil
+}
+
+// get returns the invite with the code, which is not case sensitive, since
+// join codes are meant to be typed in by people.
+func (svc *service) get(ctx context.Context, code string) (*entity.Invite, error) {
+	return svc.repo.GetByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// joinableGame returns the game, provided the user plays it and it can still
+// be joined.
+func (svc *service) joinableGame(userID string, gameID string) (*entity.Game, error) {
+	g, err := svc.gameSvc.GetByID(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !g.HasPlayer(userID) {
+		return nil, ErrNotPlayer
+	}
+	if g.Status != entity.GameStatusWaiting {
+		return nil, game.ErrStarted
+	}
+
+	return g, nil
+}
+
+func (svc *service) checkRecipient(ctx context.Context, senderID string, recipientID string, g *entity.Game) error {
+	if recipientID == senderID {
+		return ErrSelf
+	}
+
+	if _, err := svc.userSvc.GetByID(ctx, recipientID); err == user.ErrNotFound {
+		return ErrUserNotFound
+	} else if err != nil {
+		return fmt.Errorf("invite.Service.Invite: %s", err)
+	}
+
+	if g.HasPlayer(recipientID) {
+		return ErrAlreadyPlaying
+	}
+
+	blocked, err := svc.socialSvc.IsBlocked(ctx, senderID, recipientID)
+	if err != nil {
+		return fmt.Errorf("invite.Service.Invite: %s", err)
+	}
+	if blocked {
+		return ErrBlocked
+	}
+
+	return nil
+}
+
+// create creates the invite with a new code, generating another one when it
+// is already taken.
+func (svc *service) create(ctx context.Context, invite entity.Invite) (*entity.Invite, error) {
+	for attempt := 0; attempt < codeAttempts; attempt++ {
+		code, err := svc.generateCode()
+		if err != nil {
+			return nil, err
+		}
+
+		invite.Code = code
+
+		created, err := svc.repo.Create(ctx, invite)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return &invite, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to generate an unused code after %d attempts", codeAttempts)
+}
+
+func generateCode() (string, error) {
+	word, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeWords))))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code (%s)", err)
+	}
+
+	number, err := rand.Int(rand.Reader, big.NewInt(9000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code (%s)", err)
+	}
+
+	return fmt.Sprintf("%s-%d", codeWords[word.Int64()], 1000+number.Int64()), nil
+}
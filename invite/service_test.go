@@ -0,0 +1,362 @@
+package invite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/social"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+const (
+	mockCode   = "MOOSE-4821"
+	mockGameID = "mock.game.id"
+
+	// The users of the fixture are created in this order by the in memory
+	// user repository, which numbers them.
+	mockSenderID    = "0"
+	mockRecipientID = "1"
+	mockOtherUserID = "2"
+)
+
+// fixture wires the service to in memory implementations of the services it
+// depends on, with three users, and a game hosted by the sender.
+type fixture struct {
+	svc       *service
+	gameSvc   game.Service
+	socialSvc social.Service
+	gameID    string
+}
+
+func newFixture(t *testing.T) *fixture {
+	database := &db.InMemory{}
+	database.Open()
+
+	hashSvc, _ := hash.NewService(hash.NewBCryptProvider())
+
+	userRepo := user.NewInMemoryRepository(database)
+	for _, username := range []string{"Sender", "Recipient", "Other"} {
+		userRepo.Create(context.Background(), username, strings.ToLower(username)+"@stmoosersburg.com", "a.hashed.password")
+	}
+	userSvc, _ := user.NewService(userRepo, hashSvc)
+
+	socialSvc, _ := social.NewService(social.NewInMemoryRepository(database), userSvc)
+	gameSvc, _ := game.NewService(game.NewInMemoryRepository(database))
+
+	svc, err := NewService(NewInMemoryRepository(database), gameSvc, socialSvc, userSvc)
+	if err != nil {
+		t.Fatalf("failed to create service (%s)", err)
+	}
+
+	g, _ := gameSvc.Create(mockSenderID, 0, "")
+
+	return &fixture{
+		svc:       svc.(*service),
+		gameSvc:   gameSvc,
+		socialSvc: socialSvc,
+		gameID:    g.ID,
+	}
+}
+
+// failingUserService fails to get users.
+type failingUserService struct {
+	user.Service
+}
+
+func (mock *failingUserService) GetByID(_ context.Context, _ string) (*entity.User, error) {
+	return nil, fmt.Errorf("failed to get user by ID")
+}
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when dependencies are missing", func(t *testing.T) {
+		if _, err := NewService(nil, nil, nil, nil); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceInvitingUsers(t *testing.T) {
+	t.Run("fails when there are no recipients", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.Invite(context.Background(), mockSenderID, f.gameID, nil); err != ErrInvalidRecipients {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when sender does not play the game", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.Invite(context.Background(), mockOtherUserID, f.gameID, []string{mockRecipientID}); err != ErrNotPlayer {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when game does not exist", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.Invite(context.Background(), mockSenderID, "ghost", []string{mockRecipientID}); err != game.ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when sender invites themselves", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockSenderID}); err != ErrSelf {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when recipient does not exist", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{"ghost"}); err != ErrUserNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without blaming the recipient when getting them fails", func(t *testing.T) {
+		f := newFixture(t)
+		f.svc.userSvc = &failingUserService{}
+
+		if _, err := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockRecipientID}); err == nil || err == ErrUserNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without sending any invite when a recipient is blocked", func(t *testing.T) {
+		f := newFixture(t)
+		f.socialSvc.Block(context.Background(), mockOtherUserID, mockSenderID)
+
+		if _, err := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockRecipientID, mockOtherUserID}); err != ErrBlocked {
+			t.FailNow()
+		}
+
+		if invites, _ := f.svc.ListPending(context.Background(), mockRecipientID); len(invites) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("sends a single use invite to each recipient when all is well", func(t *testing.T) {
+		f := newFixture(t)
+
+		invites, err := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockRecipientID, mockOtherUserID})
+		if err != nil || len(invites) != 2 {
+			t.FailNow()
+		}
+
+		pending, _ := f.svc.ListPending(context.Background(), mockRecipientID)
+		if len(pending) != 1 || pending[0].MaxUses != 1 || pending[0].GameID != f.gameID {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceCreatingJoinCode(t *testing.T) {
+	t.Run("fails when max uses or time to live are out of bounds", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, MaxJoinCodeMaxUses+1, 0); err != ErrInvalidMaxUses {
+			t.Fail()
+		}
+		if _, err := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, MaxJoinCodeTTL+time.Second); err != ErrInvalidTTL {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates a join code with defaults when all is well", func(t *testing.T) {
+		f := newFixture(t)
+
+		invite, err := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+		if err != nil {
+			t.FailNow()
+		}
+		if invite.MaxUses != DefaultJoinCodeMaxUses || invite.RecipientID != "" {
+			t.Fail()
+		}
+		if !invite.ExpiresAt.Equal(invite.CreatedAt.Add(DefaultJoinCodeTTL)) {
+			t.Fail()
+		}
+	})
+
+	t.Run("generates another code when it is already taken", func(t *testing.T) {
+		f := newFixture(t)
+
+		codes := []string{mockCode, mockCode, "ELK-1234"}
+		f.svc.generateCode = func() (string, error) {
+			code := codes[0]
+			codes = codes[1:]
+			return code, nil
+		}
+
+		f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+
+		invite, err := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+		if err != nil || invite.Code != "ELK-1234" {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no unused code can be generated", func(t *testing.T) {
+		f := newFixture(t)
+		f.svc.generateCode = func() (string, error) {
+			return mockCode, nil
+		}
+
+		f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+
+		if _, err := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceAcceptingInvite(t *testing.T) {
+	t.Run("fails when no invite exists with the code", func(t *testing.T) {
+		f := newFixture(t)
+
+		if _, err := f.svc.Accept(context.Background(), mockRecipientID, mockCode); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when invite was sent to someone else", func(t *testing.T) {
+		f := newFixture(t)
+		invites, _ := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockRecipientID})
+
+		if _, err := f.svc.Accept(context.Background(), mockOtherUserID, invites[0].Code); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when invite has expired", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, time.Minute)
+		f.svc.now = func() time.Time {
+			return time.Now().Add(time.Hour)
+		}
+
+		if _, err := f.svc.Accept(context.Background(), mockRecipientID, invite.Code); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when acceptor and creator are blocked", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+		f.socialSvc.Block(context.Background(), mockSenderID, mockRecipientID)
+
+		if _, err := f.svc.Accept(context.Background(), mockRecipientID, invite.Code); err != ErrBlocked {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when join code is used up", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 1, 0)
+		f.svc.Accept(context.Background(), mockRecipientID, invite.Code)
+
+		if _, err := f.svc.Accept(context.Background(), mockOtherUserID, invite.Code); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("gives back the use when game is full", func(t *testing.T) {
+		f := newFixture(t)
+		g, _ := f.gameSvc.Create(mockSenderID, 2, "")
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, g.ID, 0, 0)
+		f.gameSvc.Join(g.ID, mockOtherUserID)
+
+		if _, err := f.svc.Accept(context.Background(), mockRecipientID, invite.Code); err != game.ErrFull {
+			t.FailNow()
+		}
+
+		if i, _ := f.svc.repo.GetByCode(context.Background(), invite.Code); i.Uses != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not use the invite when user already plays the game", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 1, 0)
+
+		if _, err := f.svc.Accept(context.Background(), mockSenderID, invite.Code); err != nil {
+			t.FailNow()
+		}
+
+		if i, _ := f.svc.repo.GetByCode(context.Background(), invite.Code); i.Uses != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("adds the user to the game, ignoring the case of the code, when all is well", func(t *testing.T) {
+		f := newFixture(t)
+		invites, _ := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockRecipientID})
+
+		g, err := f.svc.Accept(context.Background(), mockRecipientID, strings.ToLower(invites[0].Code))
+		if err != nil || !g.HasPlayer(mockRecipientID) {
+			t.FailNow()
+		}
+
+		if pending, _ := f.svc.ListPending(context.Background(), mockRecipientID); len(pending) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceDecliningAndRevokingInvite(t *testing.T) {
+	t.Run("only lets the recipient decline an invite", func(t *testing.T) {
+		f := newFixture(t)
+		invites, _ := f.svc.Invite(context.Background(), mockSenderID, f.gameID, []string{mockRecipientID})
+
+		if err := f.svc.Decline(context.Background(), mockOtherUserID, invites[0].Code); err != ErrNotFound {
+			t.Fail()
+		}
+		if err := f.svc.Decline(context.Background(), mockRecipientID, invites[0].Code); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("only lets the creator revoke an invite", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+
+		if err := f.svc.Revoke(context.Background(), mockRecipientID, invite.Code); err != ErrNotFound {
+			t.Fail()
+		}
+		if err := f.svc.Revoke(context.Background(), mockSenderID, invite.Code); err != nil {
+			t.Fail()
+		}
+		if _, err := f.svc.Accept(context.Background(), mockRecipientID, invite.Code); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+}
+
+func TestGeneratingCode(t *testing.T) {
+	t.Run("generates a word followed by four digits", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			code, err := generateCode()
+			if err != nil {
+				t.FailNow()
+			}
+
+			var word string
+			var number int
+			if n, _ := fmt.Sscanf(strings.Replace(code, "-", " ", 1), "%s %d", &word, &number); n != 2 {
+				t.Fatalf("unexpected code \"%s\"", code)
+			}
+			if number < 1000 || number > 9999 || strings.ToUpper(word) != word {
+				t.Fatalf("unexpected code \"%s\"", code)
+			}
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package invite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	sendInvitesHandler := stmhttp.NewHandler(
+		wrap("invite.sendInvites")(makeSendInvitesEndpoint(svc)),
+		decodeSendInvitesRequest,
+		encodeCreatedResponse,
+		encodeError,
+	)
+
+	createJoinCodeHandler := stmhttp.NewHandler(
+		wrap("invite.createJoinCode")(makeCreateJoinCodeEndpoint(svc)),
+		decodeCreateJoinCodeRequest,
+		encodeCreatedResponse,
+		encodeError,
+	)
+
+	listPendingHandler := stmhttp.NewHandler(
+		wrap("invite.listPending")(makeListPendingEndpoint(svc)),
+		decodeListPendingRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	acceptHandler := stmhttp.NewHandler(
+		wrap("invite.accept")(makeAcceptEndpoint(svc)),
+		decodeCodeRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	declineHandler := stmhttp.NewHandler(
+		wrap("invite.decline")(makeDeclineEndpoint(svc)),
+		decodeCodeRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	revokeHandler := stmhttp.NewHandler(
+		wrap("invite.revoke")(makeRevokeEndpoint(svc)),
+		decodeCodeRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/games/{id}/invites", sendInvitesHandler).Methods("POST")
+	r.Handle("/v1/games/{id}/join-codes", createJoinCodeHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/invites", listPendingHandler).Methods("GET")
+	r.Handle("/v1/invites/{code}/accept", acceptHandler).Methods("POST")
+	r.Handle("/v1/invites/{code}/decline", declineHandler).Methods("POST")
+	r.Handle("/v1/invites/{code}", revokeHandler).Methods("DELETE")
+
+	return r
+}
+
+func decodeSendInvitesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		UserIDs []string `json:"userIds"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return sendInvitesRequest{
+		GameID:       id,
+		RecipientIDs: body.UserIDs,
+	}, nil
+}
+
+func decodeCreateJoinCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	// The time to live is in seconds.
+	var body struct {
+		MaxUses   int `json:"maxUses"`
+		ExpiresIn int `json:"expiresIn"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return createJoinCodeRequest{
+		GameID:    id,
+		MaxUses:   body.MaxUses,
+		ExpiresIn: time.Duration(body.ExpiresIn) * time.Second,
+	}, nil
+}
+
+func decodeListPendingRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return listPendingRequest{
+		UserID: id,
+	}, nil
+}
+
+func decodeCodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	code, ok := mux.Vars(r)["code"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return codeRequest{
+		Code: code,
+	}, nil
+}
+
+func encodeCreatedResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeNoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrSelf, ErrInvalidRecipients, ErrInvalidMaxUses, ErrInvalidTTL:
+		return http.StatusBadRequest
+	case ErrNotPlayer, ErrBlocked:
+		return http.StatusForbidden
+	case ErrNotFound, ErrUserNotFound, game.ErrNotFound:
+		return http.StatusNotFound
+	case ErrAlreadyPlaying, game.ErrFull, game.ErrStarted:
+		return http.StatusConflict
+	}
+
+	return 0
+})
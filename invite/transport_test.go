@@ -0,0 +1,148 @@
+package invite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+)
+
+func contextOf(userID string) context.Context {
+	return auth.NewContext(context.Background(), auth.Identity{UserID: userID})
+}
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("invites users to a game", func(t *testing.T) {
+		f := newFixture(t)
+		handler := MakeHandler(f.svc)
+
+		body := fmt.Sprintf(`{"userIds": ["%s"]}`, mockRecipientID)
+		r := stmhttptest.NewJSONRequest("POST", "/v1/games/"+f.gameID+"/invites", body)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockSenderID)))
+
+		if rr.Code != http.StatusCreated {
+			t.FailNow()
+		}
+
+		var response listInvitesResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if len(response.Invites) != 1 || response.Invites[0].RecipientID != mockRecipientID {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates a join code which expires in the given number of seconds", func(t *testing.T) {
+		f := newFixture(t)
+		handler := MakeHandler(f.svc)
+
+		r := stmhttptest.NewJSONRequest("POST", "/v1/games/"+f.gameID+"/join-codes", `{"maxUses": 3, "expiresIn": 3600}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockSenderID)))
+
+		if rr.Code != http.StatusCreated {
+			t.FailNow()
+		}
+
+		var response inviteResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if response.MaxUses != 3 || response.ExpiresAt.Sub(response.CreatedAt).Hours() != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("only lists a user's invites to themselves", func(t *testing.T) {
+		f := newFixture(t)
+		handler := MakeHandler(f.svc)
+
+		r := httptest.NewRequest("GET", "/v1/users/"+mockRecipientID+"/invites", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockSenderID)))
+
+		if rr.Code != http.StatusForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts an invite and returns the game's ID", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+		handler := MakeHandler(f.svc)
+
+		r := httptest.NewRequest("POST", "/v1/invites/"+invite.Code+"/accept", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockRecipientID)))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var response acceptResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if response.GameID != f.gameID {
+			t.Fail()
+		}
+	})
+
+	t.Run("revokes an invite", func(t *testing.T) {
+		f := newFixture(t)
+		invite, _ := f.svc.CreateJoinCode(context.Background(), mockSenderID, f.gameID, 0, 0)
+		handler := MakeHandler(f.svc)
+
+		r := httptest.NewRequest("DELETE", "/v1/invites/"+invite.Code, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf(mockSenderID)))
+
+		if rr.Code != http.StatusNoContent {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to accept an invite for anonymous callers", func(t *testing.T) {
+		handler := MakeHandler(newFixture(t).svc)
+
+		r := httptest.NewRequest("POST", "/v1/invites/"+mockCode+"/accept", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		ErrSelf:                   http.StatusBadRequest,
+		ErrInvalidRecipients:      http.StatusBadRequest,
+		ErrInvalidMaxUses:         http.StatusBadRequest,
+		ErrInvalidTTL:             http.StatusBadRequest,
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		auth.ErrForbidden:         http.StatusForbidden,
+		ErrNotPlayer:              http.StatusForbidden,
+		ErrBlocked:                http.StatusForbidden,
+		ErrNotFound:               http.StatusNotFound,
+		ErrUserNotFound:           http.StatusNotFound,
+		game.ErrNotFound:          http.StatusNotFound,
+		ErrAlreadyPlaying:         http.StatusConflict,
+		game.ErrFull:              http.StatusConflict,
+		game.ErrStarted:           http.StatusConflict,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
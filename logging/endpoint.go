@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+// NewEndpointMiddleware creates a middleware that logs each call to the
+// endpoint with the name, such as "user.register", with how long it took,
+// using the logger of the request.
+//
+// Calls are logged at the debug level, since failures are already logged by
+// the HTTP transport, and requests and responses are never logged, since they
+// can hold passwords.
+func NewEndpointMiddleware(name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			start := time.Now()
+
+			response, err := next(ctx, request)
+
+			attrs := []slog.Attr{
+				slog.String("endpoint", name),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			FromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "called endpoint", attrs...)
+
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestEndpointMiddleware(t *testing.T) {
+	t.Run("logs calls with the name of the endpoint at the debug level", func(t *testing.T) {
+		var b bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		ctx := NewContext(context.Background(), logger)
+
+		e := NewEndpointMiddleware("user.register")(func(_ context.Context, _ interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("moose is loose")
+		})
+		e(ctx, nil)
+
+		got := b.String()
+		for _, want := range []string{`"level":"DEBUG"`, `"endpoint":"user.register"`, `"error":"moose is loose"`, `"duration"`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected %s in %s", want, got)
+			}
+		}
+	})
+}
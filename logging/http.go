@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/tracing"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/middleware"
+)
+
+// RequestIDHeader is the header that carries the ID of a request, from
+// clients or proxies that already assigned one, and back to clients in the
+// response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength prevents clients from making every log line about their
+// request arbitrarily long.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID of the request the context is for.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// NewRequestIDMiddleware creates a middleware that identifies requests with
+// the ID in their X-Request-ID header, when it is valid, or with a new one,
+// which it stores in their context and sends back in the response.
+func NewRequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// validRequestID accepts the characters of UUIDs, and of the IDs generated by
+// common proxies, but nothing that could forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// IDs only need to be unique enough to tell requests apart in the
+		// logs, so the time will do when the system cannot provide
+		// randomness.
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// NewHTTPMiddleware creates a middleware that gives requests a logger with
+// their ID, and the ID of their trace, which handlers can get with FromContext, and that logs a line for
+// each request once it has been served.
+//
+// Only the path of requests is logged, since query strings can hold secrets,
+// such as authorization codes.
+func NewHTTPMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestLogger := logger
+			if id, ok := RequestIDFromContext(r.Context()); ok {
+				requestLogger = requestLogger.With(slog.String("requestId", id))
+			}
+			// Logs are correlated with the trace of the request, when it
+			// is traced.
+			if span, ok := tracing.SpanFromContext(r.Context()); ok {
+				requestLogger = requestLogger.With(slog.String("traceId", span.SpanContext().TraceID.String()))
+			}
+
+			rw := middleware.NewResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(NewContext(r.Context(), requestLogger)))
+
+			level := slog.LevelInfo
+			if rw.Status() >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			requestLogger.LogAttrs(
+				r.Context(),
+				level,
+				"served request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rw.Status()),
+				slog.Int64("bytes", rw.Bytes()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remoteAddr", r.RemoteAddr),
+				slog.String("userAgent", r.UserAgent()),
+			)
+		})
+	}
+}
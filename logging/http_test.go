@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	serve := func(header string) (string, string) {
+		var fromContext string
+		handler := NewRequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fromContext, _ = RequestIDFromContext(r.Context())
+		}))
+
+		r := httptest.NewRequest("GET", "/v1/users", nil)
+		if header != "" {
+			r.Header.Set(RequestIDHeader, header)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		return fromContext, rr.Header().Get(RequestIDHeader)
+	}
+
+	t.Run("accepts the ID of the request", func(t *testing.T) {
+		id := "4b0f6d4c-8f0e-4bf6-9d3a-5e0c1a3b7f21"
+
+		if fromContext, sent := serve(id); fromContext != id || sent != id {
+			t.Fail()
+		}
+	})
+
+	t.Run("generates an ID when the request has none", func(t *testing.T) {
+		fromContext, sent := serve("")
+
+		if fromContext == "" || fromContext != sent {
+			t.Fail()
+		}
+	})
+
+	t.Run("replaces IDs that are invalid", func(t *testing.T) {
+		for _, id := range []string{"moose\nlevel=ERROR", strings.Repeat("m", maxRequestIDLength+1)} {
+			if fromContext, _ := serve(id); fromContext == id {
+				t.Errorf("expected ID %q to be replaced", id)
+			}
+		}
+	})
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	t.Run("logs requests once served, with their ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		handler := NewRequestIDMiddleware()(NewHTTPMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Info("handling request")
+			w.WriteHeader(http.StatusTeapot)
+		})))
+
+		r := httptest.NewRequest("GET", "/v1/auth/moose/callback?code=secret", nil)
+		r.Header.Set(RequestIDHeader, "mock.request.id")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 || strings.Contains(buf.String(), "secret") {
+			t.Fatalf("expected two lines without the query, got:\n%s", buf.String())
+		}
+
+		for _, line := range lines {
+			var record map[string]interface{}
+			json.Unmarshal([]byte(line), &record)
+			if record["requestId"] != "mock.request.id" {
+				t.Errorf("expected the request ID in %s", line)
+			}
+		}
+
+		var access map[string]interface{}
+		json.Unmarshal([]byte(lines[1]), &access)
+		if access["status"] != float64(http.StatusTeapot) || access["path"] != "/v1/auth/moose/callback" {
+			t.Fail()
+		}
+	})
+
+	t.Run("lets streamed responses be flushed", func(t *testing.T) {
+		flushed := false
+		handler := NewHTTPMiddleware(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, flushed = w.(http.Flusher)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		if !flushed {
+			t.Fail()
+		}
+	})
+}
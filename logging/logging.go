@@ -0,0 +1,68 @@
+// Package logging writes structured logs, and identifies the requests they
+// are about, so that everything logged about a request can be found with its
+// ID.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+const (
+	// FormatJSON writes a JSON object per line, for log collectors.
+	FormatJSON = "json"
+
+	// FormatText writes key=value pairs, which are easier to read in a
+	// terminal.
+	FormatText = "text"
+)
+
+// Config represents how logs are written.
+type Config struct {
+	// Level is the minimum level of the records that are written, which is
+	// info by default.
+	Level slog.Level
+
+	// Format is FormatJSON, which is the default, or FormatText.
+	Format string
+}
+
+// New creates a logger that writes records to the writer in the configured
+// format.
+func New(w io.Writer, conf Config) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: conf.Level}
+
+	switch conf.Format {
+	case FormatJSON, "":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf(
+			"logging.New: unknown format \"%s\"; must be %s or %s",
+			conf.Format,
+			FormatJSON,
+			FormatText,
+		)
+	}
+}
+
+type loggerKey struct{}
+
+// NewContext returns a context carrying the logger, which is meant to have
+// the attributes of the request the context is for, such as its ID.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by the context, or the default
+// logger when there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggerCreation(t *testing.T) {
+	t.Run("fails when the format is unknown", func(t *testing.T) {
+		if _, err := New(&bytes.Buffer{}, Config{Format: "xml"}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("writes JSON records at or above the level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := New(&buf, Config{Level: slog.LevelWarn})
+		if err != nil {
+			t.FailNow()
+		}
+
+		logger.Info("ignored")
+		logger.Warn("moose on the loose", slog.String("where", "Moose Lake"))
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("expected a single JSON record, got %s", buf.String())
+		}
+		if record["msg"] != "moose on the loose" || record["level"] != "WARN" || record["where"] != "Moose Lake" {
+			t.Fail()
+		}
+	})
+}
+
+func TestLoggerContext(t *testing.T) {
+	t.Run("returns the default logger when the context has none", func(t *testing.T) {
+		if FromContext(context.Background()) != slog.Default() {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the logger carried by the context", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+		if FromContext(NewContext(context.Background(), logger)) != logger {
+			t.Fail()
+		}
+	})
+}
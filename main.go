@@ -1,73 +1,495 @@
 package main
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
-	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
 
 	_ "github.com/lib/pq"
 
+	"github.com/leblancjs/stmoosersburg-api/achievement"
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/config"
 	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/encryption"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/game"
 	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/health"
+	"github.com/leblancjs/stmoosersburg-api/invite"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/matchmaking"
+	"github.com/leblancjs/stmoosersburg-api/metrics"
+	"github.com/leblancjs/stmoosersburg-api/oidc"
+	"github.com/leblancjs/stmoosersburg-api/presence"
+	"github.com/leblancjs/stmoosersburg-api/ratelimit"
+	"github.com/leblancjs/stmoosersburg-api/rating"
+	"github.com/leblancjs/stmoosersburg-api/server"
+	"github.com/leblancjs/stmoosersburg-api/session"
+	"github.com/leblancjs/stmoosersburg-api/social"
+	"github.com/leblancjs/stmoosersburg-api/stats"
+	"github.com/leblancjs/stmoosersburg-api/tracing"
+	"github.com/leblancjs/stmoosersburg-api/twofactor"
 	"github.com/leblancjs/stmoosersburg-api/user"
 )
 
+const totpIssuer = "St-Moosersburg"
+
+// presenceExpiryInterval is how often devices that stopped sending heartbeats
+// are disconnected, which is also how late friends can be notified of it.
+const presenceExpiryInterval = 10 * time.Second
+
+// matchmakingInterval is how often players looking for a game are matched.
+const matchmakingInterval = 2 * time.Second
+
+// endpointTimeout is how long endpoints have to respond, which is shorter
+// than the default write timeout of the server, so that they can still
+// respond when they run out of time.
+const endpointTimeout = 20 * time.Second
+
 func main() {
-	database, err := configureDatabase()
+	conf, err := config.Load(os.Getenv("CONFIG_FILE"), ".env")
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+
+	logger, err := logging.New(os.Stdout, conf.Logging)
+	if err != nil {
+		fatal(err)
+	}
+	slog.SetDefault(logger)
+	slog.Info("loaded configuration", slog.String("config", conf.String()))
+
+	database, err := configureDatabase(conf.Database)
+	if err != nil {
+		fatal(err)
 	}
 	err = database.Open()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+
+	registry := metrics.NewRegistry()
+	if pg, ok := database.(*db.Postgres); ok {
+		metrics.RegisterDBStats(registry, pg.Stats)
 	}
-	defer database.Close()
 
-	hashProvider := hash.NewBCryptProvider()
-	hashSvc, err := hash.NewService(hashProvider)
+	hashSvc, err := configureHashing(conf.Hashing)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+	hashSvc = metrics.InstrumentHashing(registry, hashSvc, conf.Hashing.Algorithm)
+	hashSvc = tracing.InstrumentHashing(hashSvc)
+
+	// Every endpoint is traced, measured, and logged, including those that
+	// panic, time out, or are given invalid requests.
+	middlewares := []endpoint.NamedMiddleware{
+		tracing.NewEndpointMiddleware,
+		metrics.NewEndpoints(registry).Middleware,
+		logging.NewEndpointMiddleware,
+		endpoint.Unnamed(endpoint.Recover()),
+		endpoint.Unnamed(endpoint.Timeout(endpointTimeout)),
+		endpoint.Unnamed(endpoint.Validate()),
 	}
 
 	userRepo, err := user.NewRepository(database)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
+	userRepo = user.NewTracedRepository(userRepo)
 	userSvc, err := user.NewService(userRepo, hashSvc)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
+	}
+
+	tokens, err := configureTokens(conf.Keys.TokenSigning)
+	if err != nil {
+		fatal(err)
+	}
+
+	cipher, stateCipher, err := configureEncryption(conf.Keys.Encryption)
+	if err != nil {
+		fatal(err)
+	}
+
+	twoFactorSvc, err := twofactor.NewService(userRepo, hashSvc, cipher, totpIssuer)
+	if err != nil {
+		fatal(err)
+	}
+	twoFactorHandler := twofactor.MakeHandler(twoFactorSvc, middlewares...)
+
+	sessionSvc, err := session.NewService(userSvc, twoFactorSvc, tokens, session.Config{})
+	if err != nil {
+		fatal(err)
+	}
+	sessionHandler := session.MakeHandler(sessionSvc, middlewares...)
+
+	providers, err := configureIdentityProviders(conf.IdentityProviders)
+	if err != nil {
+		fatal(err)
+	}
+	oidcSvc, err := oidc.NewService(providers, sessionSvc, stateCipher)
+	if err != nil {
+		fatal(err)
+	}
+	oidcHandler := oidc.MakeHandler(oidcSvc, middlewares...)
+
+	socialRepo, err := social.NewRepository(database)
+	if err != nil {
+		fatal(err)
+	}
+	socialSvc, err := social.NewService(socialRepo, userSvc)
+	if err != nil {
+		fatal(err)
+	}
+	socialHandler := social.MakeHandler(socialSvc, middlewares...)
+
+	presenceSvc, err := presence.NewService(socialSvc, presence.Config{})
+	if err != nil {
+		fatal(err)
+	}
+	presenceHandler := presence.MakeHandler(presenceSvc, middlewares...)
+
+	gameRepo, err := game.NewRepository(database)
+	if err != nil {
+		fatal(err)
+	}
+	gameSvc, err := game.NewService(gameRepo)
+	if err != nil {
+		fatal(err)
+	}
+	gameHandler := game.MakeHandler(gameSvc, middlewares...)
+
+	ratingRepo, err := rating.NewRepository(database)
+	if err != nil {
+		fatal(err)
+	}
+	ratingSvc, err := rating.NewService(ratingRepo, userSvc)
+	if err != nil {
+		fatal(err)
+	}
+	gameSvc.OnFinish(ratingSvc.Update)
+	ratingHandler := rating.MakeHandler(ratingSvc, middlewares...)
+
+	statsRepo, err := stats.NewRepository(database)
+	if err != nil {
+		fatal(err)
+	}
+	statsSvc, err := stats.NewService(statsRepo, userSvc)
+	if err != nil {
+		fatal(err)
+	}
+	gameSvc.OnFinish(statsSvc.Update)
+	statsHandler := stats.MakeHandler(statsSvc, middlewares...)
+
+	// Profiles are served by the user handler, which gets the stats shown on
+	// them from the stats service, since it cannot depend on it.
+	userHandler := user.MakeHandler(userSvc, stats.NewProfileStats(statsSvc), middlewares...)
+
+	achievementRepo, err := achievement.NewRepository(database)
+	if err != nil {
+		fatal(err)
+	}
+	achievementSvc, err := achievement.NewService(achievementRepo, userSvc)
+	if err != nil {
+		fatal(err)
+	}
+	gameSvc.OnFinish(achievementSvc.Update)
+	achievementHandler := achievement.MakeHandler(achievementSvc, middlewares...)
+
+	inviteRepo, err := invite.NewRepository(database)
+	if err != nil {
+		fatal(err)
+	}
+	inviteSvc, err := invite.NewService(inviteRepo, gameSvc, socialSvc, userSvc)
+	if err != nil {
+		fatal(err)
+	}
+	inviteHandler := invite.MakeHandler(inviteSvc, middlewares...)
+
+	matchmakingSvc, err := matchmaking.NewService(gameSvc, socialSvc, ratingSvc, matchmaking.Config{})
+	if err != nil {
+		fatal(err)
+	}
+	matchmakingHandler := matchmaking.MakeHandler(matchmakingSvc, middlewares...)
+
+	// Routes are matched in the order they are added, so sub-resources must
+	// come before the resources they belong to.
+	router := mux.NewRouter()
+	router.PathPrefix("/v1/sessions").Handler(sessionHandler)
+	router.PathPrefix("/v1/auth").Handler(oidcHandler)
+	router.PathPrefix("/v1/users/{id}/totp").Handler(twoFactorHandler)
+	router.PathPrefix("/v1/users/{id}/friends").Handler(socialHandler)
+	router.PathPrefix("/v1/users/{id}/presence").Handler(presenceHandler)
+	router.PathPrefix("/v1/users/{id}/invites").Handler(inviteHandler)
+	router.PathPrefix("/v1/users/{id}/games").Handler(gameHandler)
+	router.PathPrefix("/v1/users/{id}/stats").Handler(statsHandler)
+	router.PathPrefix("/v1/users/{id}/achievements").Handler(achievementHandler)
+	router.PathPrefix("/v1/users").Handler(userHandler)
+	router.PathPrefix("/v1/admin/users").Handler(userHandler)
+	router.PathPrefix("/v1/games/{id}/invites").Handler(inviteHandler)
+	router.PathPrefix("/v1/games/{id}/join-codes").Handler(inviteHandler)
+	router.PathPrefix("/v1/games").Handler(gameHandler)
+	router.PathPrefix("/v1/invites").Handler(inviteHandler)
+	router.PathPrefix("/v1/matchmaking").Handler(matchmakingHandler)
+	router.PathPrefix("/v1/leaderboards").Handler(ratingHandler)
+
+	limitClients, limitUsers, err := configureRateLimiting()
+	if err != nil {
+		fatal(err)
 	}
-	userHandler := user.MakeHandler(userSvc)
 
-	mux := http.NewServeMux()
-	mux.Handle("/v1/", userHandler)
+	authenticate := auth.NewHTTPMiddleware(tokens)
 
-	http.Handle("/", handlers.LoggingHandler(os.Stdout, mux))
+	healthSvc := health.NewService(health.Config{})
+	healthSvc.Register("database", database)
 
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	tracer := configureTracing(conf.Tracing)
+	trace := tracing.NewHTTPMiddleware(tracer, router)
+	instrument := metrics.NewHTTPMiddleware(registry, router)
+	requestID := logging.NewRequestIDMiddleware()
+	logRequests := logging.NewHTTPMiddleware(logger)
+
+	// Probes are answered before anything else, so that orchestrators are
+	// neither logged, authenticated, nor rate limited.
+	root := mux.NewRouter()
+	root.Path("/healthz").Handler(health.MakeHandler(healthSvc))
+	root.Path("/readyz").Handler(health.MakeHandler(healthSvc))
+	root.PathPrefix("/").Handler(trace(requestID(logRequests(instrument(limitClients(authenticate(limitUsers(router))))))))
+
+	srv := server.New(conf.Server, root)
+	srv.OnShutdown(healthSvc.Shutdown)
+	srv.RunInBackground(tracer.Run)
+	srv.RunInBackground(func(ctx context.Context) {
+		presenceSvc.Run(ctx, presenceExpiryInterval)
+	})
+	srv.RunInBackground(func(ctx context.Context) {
+		matchmakingSvc.Run(ctx, matchmakingInterval)
+	})
+	srv.CloseOnShutdown(database)
+
+	if conf.Metrics.Port != 0 {
+		serveMetrics, err := configureMetricsServer(conf.Metrics, registry)
+		if err != nil {
+			fatal(err)
+		}
+		srv.RunInBackground(serveMetrics)
+	}
+
+	// The server shuts down gracefully when the service is interrupted, or
+	// terminated.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		fatal(err)
+	}
+}
+
+// fatal logs the error that prevents the service from running, and exits.
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
 }
 
-func configureDatabase() (db.DB, error) {
-	databaseType := os.Getenv("DB_TYPE")
-	if databaseType == "" {
-		databaseType = db.TypeInMemory
+// configureMetricsServer listens on the address metrics are served on, apart
+// from the API, since they do not require authentication. Listening right
+// away fails early when the address is taken.
+func configureMetricsServer(conf config.Metrics, registry *metrics.Registry) (func(ctx context.Context), error) {
+	router := mux.NewRouter()
+	router.Path("/metrics").Handler(registry.Handler())
+
+	srv := server.New(server.Config{Host: conf.Host, Port: conf.Port}, router)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on the metrics address (%s)", err)
 	}
 
-	databaseConfig := db.Config{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		Name:     os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SSL_MODE"),
+	return func(ctx context.Context) {
+		if err := srv.Serve(ctx, listener); err != nil {
+			slog.Error(err.Error())
+		}
+	}, nil
+}
+
+func configureDatabase(conf config.Database) (db.DB, error) {
+	return db.New(conf.Type, conf.Config)
+}
+
+func configureHashing(conf config.Hashing) (hash.Service, error) {
+	provider, err := hash.NewProvider(conf.Algorithm, conf.Config)
+	if err != nil {
+		return nil, err
 	}
 
-	database, err := db.New(databaseType, databaseConfig)
+	// Hashes generated with the other algorithms can still be matched, and
+	// are replaced when their users log in.
+	bcryptProvider := hash.NewBCryptProvider()
+	argon2idProvider, err := hash.NewArgon2idProvider(hash.DefaultArgon2idParams)
 	if err != nil {
 		return nil, err
 	}
 
-	return database, nil
+	return hash.NewService(provider, argon2idProvider, bcryptProvider)
+}
+
+func configureTracing(conf config.Tracing) *tracing.Tracer {
+	switch conf.Exporter {
+	case tracing.ExporterStdout:
+		return tracing.NewTracer(tracing.NewStdoutExporter(os.Stdout), conf.Config)
+	case tracing.ExporterOTLP:
+		return tracing.NewTracer(tracing.NewOTLPExporter(conf.OTLPEndpoint), conf.Config)
+	default:
+		return tracing.NewTracer(nil, conf.Config)
+	}
+}
+
+// configureRateLimiting returns the middleware that limits requests by client
+// IP address, which goes before authentication, so that requests with invalid
+// tokens are limited too, and the one that limits them by user.
+//
+// Clients are allowed more than users, since users behind the same address,
+// such as on the same network, share its bucket.
+func configureRateLimiting() (func(http.Handler) http.Handler, func(http.Handler) http.Handler, error) {
+	limitClients, err := ratelimit.NewHTTPMiddleware(
+		ratelimit.ByClientIP,
+		ratelimit.PerSecond(50, 100),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limitUsers, err := ratelimit.NewHTTPMiddleware(
+		ratelimit.ByUser,
+		ratelimit.PerSecond(10, 20),
+		// Registering and logging in are expensive, since passwords are
+		// hashed, and logging in is the target of brute force attacks, as
+		// are one-time passwords.
+		ratelimit.Route{
+			Method: "POST",
+			Path:   "/v1/users",
+			Rate:   ratelimit.PerMinute(5, 5),
+		},
+		ratelimit.Route{
+			Method: "POST",
+			Path:   "/v1/sessions",
+			Rate:   ratelimit.PerMinute(5, 5),
+		},
+		ratelimit.Route{
+			Method: "POST",
+			Path:   "/v1/sessions/mfa",
+			Rate:   ratelimit.PerMinute(5, 5),
+		},
+		ratelimit.Route{
+			Method: "POST",
+			Path:   "/v1/users/{id}/totp/{action}",
+			Rate:   ratelimit.PerMinute(5, 5),
+		},
+		ratelimit.Route{
+			Method: "GET",
+			Path:   "/v1/auth/{provider}/callback",
+			Rate:   ratelimit.PerMinute(5, 5),
+		},
+		// Friend requests notify their recipients, so they must not be
+		// usable to spam them.
+		ratelimit.Route{
+			Method: "POST",
+			Path:   "/v1/users/{id}/friends/requests",
+			Rate:   ratelimit.PerMinute(10, 10),
+		},
+		// Join codes are short, so accepting invites must not be usable to
+		// guess them.
+		ratelimit.Route{
+			Method: "POST",
+			Path:   "/v1/invites/{code}/accept",
+			Rate:   ratelimit.PerMinute(10, 10),
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return limitClients, limitUsers, nil
+}
+
+func configureTokens(key []byte) (*auth.Tokens, error) {
+	key, err := keyOrRandom("TOKEN_SIGNING_KEY", key, auth.MinKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewTokens(key)
+}
+
+// configureEncryption returns the cipher that protects the secrets shared with
+// authenticator apps, and the one that seals the state of sign-ins with
+// identity providers, whose key is derived from the encryption key.
+func configureEncryption(key []byte) (encryption.Cipher, encryption.Cipher, error) {
+	key, err := keyOrRandom("ENCRYPTION_KEY", key, encryption.KeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cipher, err := encryption.NewAESGCMCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stateKey, err := encryption.DeriveKey(key, "oidc.state")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stateCipher, err := encryption.NewAESGCMCipher(stateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cipher, stateCipher, nil
+}
+
+// configureIdentityProviders configures the identity providers users can
+// sign in with.
+func configureIdentityProviders(confs []oidc.Config) ([]*oidc.Provider, error) {
+	providers := make([]*oidc.Provider, 0, len(confs))
+
+	for _, conf := range confs {
+		provider, err := oidc.NewProvider(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// keyOrRandom returns the key, or generates a random one when it is not set,
+// which is only suitable for development, since the key is lost when the
+// service is shutdown.
+func keyOrRandom(name string, key []byte, size int) ([]byte, error) {
+	if key != nil {
+		return key, nil
+	}
+
+	slog.Warn("key is not set; using a random key that will be lost on shutdown", slog.String("key", name))
+
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate %s (%s)", name, err)
+	}
+
+	return random, nil
 }
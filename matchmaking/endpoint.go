@@ -0,0 +1,94 @@
+package matchmaking
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type statusResponse struct {
+	State       State          `json:"state"`
+	PlayerCount int            `json:"playerCount,omitempty"`
+	Ruleset     entity.Ruleset `json:"ruleset,omitempty"`
+	Rating      float64        `json:"rating,omitempty"`
+	EnqueuedAt  *time.Time     `json:"enqueuedAt,omitempty"`
+	Tolerance   float64        `json:"tolerance,omitempty"`
+	GameID      string         `json:"gameId,omitempty"`
+}
+
+func newStatusResponse(s *Status) *statusResponse {
+	response := &statusResponse{
+		State:       s.State,
+		PlayerCount: s.Preferences.PlayerCount,
+		Ruleset:     s.Preferences.Ruleset,
+		Rating:      s.Rating,
+		Tolerance:   s.Tolerance,
+		GameID:      s.GameID,
+	}
+
+	if !s.EnqueuedAt.IsZero() {
+		response.EnqueuedAt = &s.EnqueuedAt
+	}
+
+	return response
+}
+
+func makeGetStatusEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		status, err := svc.Status(identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		return newStatusResponse(status), nil
+	}
+}
+
+type enqueueRequest struct {
+	PlayerCount int
+	Ruleset     entity.Ruleset
+}
+
+func makeEnqueueEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enqueueRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		status, err := svc.Enqueue(identity.UserID, Preferences{
+			PlayerCount: req.PlayerCount,
+			Ruleset:     req.Ruleset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return newStatusResponse(status), nil
+	}
+}
+
+func makeLeaveEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		if err := svc.Leave(identity.UserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
@@ -0,0 +1,478 @@
+// Package matchmaking groups players who are looking for a game with others of
+// a similar skill, and places them into a new game together.
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	"github.com/leblancjs/stmoosersburg-api/social"
+)
+
+const (
+	// DefaultRating is the rating of players who have not been rated yet.
+	DefaultRating = 1500
+
+	DefaultInitialTolerance = 100
+	DefaultToleranceGrowth  = 10
+	DefaultMaxTolerance     = 500
+
+	// DefaultMatchTTL is how long a player can see the game they were
+	// matched into, when none is configured.
+	DefaultMatchTTL = 5 * time.Minute
+)
+
+var (
+	ErrInvalidPlayerCount = fmt.Errorf("player count must be between %d and %d", game.MinPlayers, game.MaxPlayers)
+	ErrAlreadyQueued      = errors.New("user is already looking for a game")
+	ErrNotQueued          = errors.New("user is not looking for a game")
+)
+
+// State represents where a user is in matchmaking.
+type State string
+
+const (
+	StateIdle      State = "idle"
+	StateSearching State = "searching"
+	StateMatched   State = "matched"
+)
+
+// Preferences represent the games a user is willing to be matched into.
+type Preferences struct {
+	PlayerCount int
+	Ruleset     entity.Ruleset
+}
+
+// Status represents a user's place in matchmaking.
+type Status struct {
+	State       State
+	Preferences Preferences
+	Rating      float64
+
+	// EnqueuedAt is when the user started looking for a game, and Tolerance
+	// is how far from theirs the rating of the players they can be matched
+	// with currently is, both while they are searching.
+	EnqueuedAt time.Time
+	Tolerance  float64
+
+	// GameID is the ID of the game the user was matched into.
+	GameID string
+}
+
+// Ratings provides the ratings players are matched by.
+type Ratings interface {
+	Rating(userID string) (float64, error)
+}
+
+type constantRatings float64
+
+// ConstantRatings gives every player the same rating, so that players are
+// matched in the order they started looking for a game.
+func ConstantRatings(rating float64) Ratings {
+	return constantRatings(rating)
+}
+
+func (r constantRatings) Rating(string) (float64, error) {
+	return float64(r), nil
+}
+
+type Config struct {
+	// InitialTolerance is how far from theirs the rating of the players a
+	// player can be matched with is when they start looking for a game. It
+	// grows by ToleranceGrowth every second they wait, up to MaxTolerance.
+	InitialTolerance float64
+	ToleranceGrowth  float64
+	MaxTolerance     float64
+
+	// MatchTTL is how long a player can see the game they were matched into.
+	MatchTTL time.Duration
+}
+
+type Service interface {
+	// Enqueue starts looking for a game matching the preferences for the
+	// user. When the ruleset is empty, the classic one is used.
+	Enqueue(userID string, prefs Preferences) (*Status, error)
+	// Leave stops looking for a game for the user, or forgets the game they
+	// were matched into.
+	Leave(userID string) error
+	Status(userID string) (*Status, error)
+
+	// Match places the players who can be matched together into new games.
+	Match(ctx context.Context) error
+	// Run matches players at the interval until the context is done.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type ticket struct {
+	userID     string
+	prefs      Preferences
+	rating     float64
+	enqueuedAt time.Time
+}
+
+type match struct {
+	ticket
+	gameID    string
+	matchedAt time.Time
+}
+
+type service struct {
+	gameSvc   game.Service
+	socialSvc social.Service
+	ratings   Ratings
+	conf      Config
+	now       func() time.Time
+
+	// mu guards the tickets and matches, and is never held while calling
+	// other services, so that players can look for a game, leave, and check
+	// their status while games are being created.
+	mu      sync.Mutex
+	tickets map[string]*ticket
+	matches map[string]match
+
+	// matching serializes runs of Match, so that overlapping runs cannot
+	// place the same players into two games.
+	matching sync.Mutex
+}
+
+func NewService(gameSvc game.Service, socialSvc social.Service, ratings Ratings, conf Config) (Service, error) {
+	if gameSvc == nil {
+		return nil, fmt.Errorf("matchmaking.NewService: game service is required")
+	}
+	if socialSvc == nil {
+		return nil, fmt.Errorf("matchmaking.NewService: social service is required")
+	}
+	if ratings == nil {
+		return nil, fmt.Errorf("matchmaking.NewService: ratings are required")
+	}
+
+	if conf.InitialTolerance == 0 {
+		conf.InitialTolerance = DefaultInitialTolerance
+	}
+	if conf.ToleranceGrowth == 0 {
+		conf.ToleranceGrowth = DefaultToleranceGrowth
+	}
+	if conf.MaxTolerance == 0 {
+		conf.MaxTolerance = DefaultMaxTolerance
+	}
+	if conf.MatchTTL == 0 {
+		conf.MatchTTL = DefaultMatchTTL
+	}
+
+	return &service{
+		gameSvc:   gameSvc,
+		socialSvc: socialSvc,
+		ratings:   ratings,
+		conf:      conf,
+		now:       time.Now,
+		tickets:   make(map[string]*ticket),
+		matches:   make(map[string]match),
+	}, nil
+}
+
+func (svc *service) Enqueue(userID string, prefs Preferences) (*Status, error) {
+	if prefs.PlayerCount < game.MinPlayers || prefs.PlayerCount > game.MaxPlayers {
+		return nil, ErrInvalidPlayerCount
+	}
+
+	switch prefs.Ruleset {
+	case "":
+		prefs.Ruleset = entity.RulesetClassic
+	case entity.RulesetClassic, entity.RulesetQuick:
+	default:
+		return nil, game.ErrInvalidRuleset
+	}
+
+	rating, err := svc.ratings.Rating(userID)
+	if err != nil {
+		return nil, fmt.Errorf("matchmaking.Service.Enqueue: %s", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if _, ok := svc.tickets[userID]; ok {
+		return nil, ErrAlreadyQueued
+	}
+
+	// Looking for another game forgets the last one the user was matched
+	// into.
+	delete(svc.matches, userID)
+
+	t := &ticket{
+		userID:     userID,
+		prefs:      prefs,
+		rating:     rating,
+		enqueuedAt: svc.now(),
+	}
+	svc.tickets[userID] = t
+
+	return svc.searching(t, t.enqueuedAt), nil
+}
+
+func (svc *service) Leave(userID string) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	_, queued := svc.tickets[userID]
+	_, matched := svc.matches[userID]
+	if !queued && !matched {
+		return ErrNotQueued
+	}
+
+	delete(svc.tickets, userID)
+	delete(svc.matches, userID)
+
+	return nil
+}
+
+func (svc *service) Status(userID string) (*Status, error) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	now := svc.now()
+
+	if t, ok := svc.tickets[userID]; ok {
+		return svc.searching(t, now), nil
+	}
+
+	if m, ok := svc.matches[userID]; ok && now.Sub(m.matchedAt) < svc.conf.MatchTTL {
+		return &Status{
+			State:       StateMatched,
+			Preferences: m.prefs,
+			Rating:      m.rating,
+			GameID:      m.gameID,
+		}, nil
+	}
+
+	return &Status{
+		State: StateIdle,
+	}, nil
+}
+
+func (svc *service) Match(ctx context.Context) error {
+	svc.matching.Lock()
+	defer svc.matching.Unlock()
+
+	now, queue := svc.snapshot()
+
+	// Players who could not be matched because another service failed are
+	// left in the queue, to be matched again on the next run, while the
+	// other players are matched as usual.
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("matchmaking.Service.Match: %s", err)
+		}
+	}
+
+	matched := make(map[string]bool)
+
+	for _, anchor := range queue {
+		if matched[anchor.userID] {
+			continue
+		}
+
+		group, err := svc.group(ctx, anchor, queue, matched, now)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if group == nil {
+			continue
+		}
+
+		for _, t := range group {
+			matched[t.userID] = true
+		}
+
+		if !svc.queued(group) {
+			continue
+		}
+
+		playerIDs := make([]string, 0, len(group))
+		for _, t := range group {
+			playerIDs = append(playerIDs, t.userID)
+		}
+
+		g, err := svc.gameSvc.CreateMatch(playerIDs, anchor.prefs.Ruleset)
+		if err != nil {
+			fail(err)
+			continue
+		}
+
+		svc.record(group, g.ID, now)
+	}
+
+	return firstErr
+}
+
+// snapshot forgets the matches whose TTL elapsed, and returns the players
+// looking for a game, from those who waited the longest to those who waited
+// the least, which are matched first, with the players whose rating is the
+// closest to theirs.
+func (svc *service) snapshot() (time.Time, []*ticket) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	now := svc.now()
+
+	for userID, m := range svc.matches {
+		if now.Sub(m.matchedAt) >= svc.conf.MatchTTL {
+			delete(svc.matches, userID)
+		}
+	}
+
+	queue := make([]*ticket, 0, len(svc.tickets))
+	for _, t := range svc.tickets {
+		queue = append(queue, t)
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if queue[i].enqueuedAt.Equal(queue[j].enqueuedAt) {
+			return queue[i].userID < queue[j].userID
+		}
+		return queue[i].enqueuedAt.Before(queue[j].enqueuedAt)
+	})
+
+	return now, queue
+}
+
+// queued tells whether every player of the group is still looking for the
+// game they were grouped for, since they could have left since the snapshot.
+func (svc *service) queued(group []*ticket) bool {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for _, t := range group {
+		if svc.tickets[t.userID] != t {
+			return false
+		}
+	}
+
+	return true
+}
+
+// record stops looking for a game for the players of the group, and remembers
+// the game they were matched into, except for those who left while it was
+// being created.
+func (svc *service) record(group []*ticket, gameID string, now time.Time) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for _, t := range group {
+		if svc.tickets[t.userID] != t {
+			continue
+		}
+
+		delete(svc.tickets, t.userID)
+		svc.matches[t.userID] = match{
+			ticket:    *t,
+			gameID:    gameID,
+			matchedAt: now,
+		}
+	}
+}
+
+func (svc *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := svc.Match(ctx); err != nil {
+				slog.Error("matchmaking.Service.Run: failed to match players", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// group returns the anchor with the players it can be matched with, or nil if
+// there are not enough of them yet. Players must have the same preferences,
+// be within each other's tolerance, and not have blocked one another.
+func (svc *service) group(ctx context.Context, anchor *ticket, queue []*ticket, matched map[string]bool, now time.Time) ([]*ticket, error) {
+	candidates := make([]*ticket, 0)
+	for _, t := range queue {
+		if t == anchor || matched[t.userID] || t.prefs != anchor.prefs {
+			continue
+		}
+
+		candidates = append(candidates, t)
+	}
+
+	if len(candidates) < anchor.prefs.PlayerCount-1 {
+		return nil, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return math.Abs(candidates[i].rating-anchor.rating) < math.Abs(candidates[j].rating-anchor.rating)
+	})
+
+	group := []*ticket{anchor}
+
+	for _, candidate := range candidates {
+		compatible, err := svc.compatible(ctx, candidate, group, now)
+		if err != nil {
+			return nil, err
+		}
+		if !compatible {
+			continue
+		}
+
+		group = append(group, candidate)
+		if len(group) == anchor.prefs.PlayerCount {
+			return group, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// compatible tells whether the candidate can be matched with every player of
+// the group.
+func (svc *service) compatible(ctx context.Context, candidate *ticket, group []*ticket, now time.Time) (bool, error) {
+	for _, t := range group {
+		diff := math.Abs(candidate.rating - t.rating)
+		if diff > svc.tolerance(candidate, now) || diff > svc.tolerance(t, now) {
+			return false, nil
+		}
+
+		blocked, err := svc.socialSvc.IsBlocked(ctx, candidate.userID, t.userID)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// tolerance returns how far from the player's the rating of the players they
+// can be matched with is, which grows as they wait.
+func (svc *service) tolerance(t *ticket, now time.Time) float64 {
+	waited := now.Sub(t.enqueuedAt).Seconds()
+
+	return math.Min(svc.conf.InitialTolerance+svc.conf.ToleranceGrowth*waited, svc.conf.MaxTolerance)
+}
+
+func (svc *service) searching(t *ticket, now time.Time) *Status {
+	return &Status{
+		State:       StateSearching,
+		Preferences: t.prefs,
+		Rating:      t.rating,
+		EnqueuedAt:  t.enqueuedAt,
+		Tolerance:   svc.tolerance(t, now),
+	}
+}
@@ -0,0 +1,359 @@
+package matchmaking
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/social"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+// mockRatings rates players by their ID, which the in memory user repository
+// numbers from zero.
+type mockRatings map[string]float64
+
+func (mock mockRatings) Rating(userID string) (float64, error) {
+	rating, ok := mock[userID]
+	if !ok {
+		return 0, fmt.Errorf("no rating for user \"%s\"", userID)
+	}
+
+	return rating, nil
+}
+
+// failingGameService fails to create the matches of a player.
+type failingGameService struct {
+	game.Service
+
+	failFor string
+}
+
+func (mock *failingGameService) CreateMatch(playerIDs []string, ruleset entity.Ruleset) (*entity.Game, error) {
+	for _, playerID := range playerIDs {
+		if playerID == mock.failFor {
+			return nil, fmt.Errorf("failed to create match")
+		}
+	}
+
+	return mock.Service.CreateMatch(playerIDs, ruleset)
+}
+
+// callbackGameService calls back before creating matches.
+type callbackGameService struct {
+	game.Service
+
+	callback func()
+}
+
+func (mock *callbackGameService) CreateMatch(playerIDs []string, ruleset entity.Ruleset) (*entity.Game, error) {
+	mock.callback()
+
+	return mock.Service.CreateMatch(playerIDs, ruleset)
+}
+
+type fixture struct {
+	svc       *service
+	gameSvc   game.Service
+	socialSvc social.Service
+	now       time.Time
+}
+
+// newFixture creates a service for users with the ratings, whose IDs are
+// their index.
+func newFixture(t *testing.T, ratings ...float64) *fixture {
+	database := &db.InMemory{}
+	database.Open()
+
+	hashSvc, _ := hash.NewService(hash.NewBCryptProvider())
+
+	userRepo := user.NewInMemoryRepository(database)
+	mock := make(mockRatings)
+	for i, rating := range ratings {
+		u, _ := userRepo.Create(context.Background(), "Moose"+strconv.Itoa(i), strconv.Itoa(i)+"@stmoosersburg.com", "a.hashed.password")
+		mock[u.ID] = rating
+	}
+	userSvc, _ := user.NewService(userRepo, hashSvc)
+
+	socialSvc, _ := social.NewService(social.NewInMemoryRepository(database), userSvc)
+	gameSvc, _ := game.NewService(game.NewInMemoryRepository(database))
+
+	svc, err := NewService(gameSvc, socialSvc, mock, Config{})
+	if err != nil {
+		t.Fatalf("failed to create service (%s)", err)
+	}
+
+	f := &fixture{
+		svc:       svc.(*service),
+		gameSvc:   gameSvc,
+		socialSvc: socialSvc,
+		now:       time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+	}
+	f.svc.now = func() time.Time {
+		return f.now
+	}
+
+	return f
+}
+
+var duel = Preferences{PlayerCount: 2, Ruleset: entity.RulesetClassic}
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when dependencies are missing", func(t *testing.T) {
+		if _, err := NewService(nil, nil, nil, Config{}); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceEnqueuing(t *testing.T) {
+	t.Run("fails when player count is out of bounds", func(t *testing.T) {
+		f := newFixture(t, 1500)
+
+		if _, err := f.svc.Enqueue("0", Preferences{PlayerCount: 1}); err != ErrInvalidPlayerCount {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when ruleset does not exist", func(t *testing.T) {
+		f := newFixture(t, 1500)
+
+		if _, err := f.svc.Enqueue("0", Preferences{PlayerCount: 2, Ruleset: "calvinball"}); err != game.ErrInvalidRuleset {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user is already queued", func(t *testing.T) {
+		f := newFixture(t, 1500)
+		f.svc.Enqueue("0", duel)
+
+		if _, err := f.svc.Enqueue("0", duel); err != ErrAlreadyQueued {
+			t.Fail()
+		}
+	})
+
+	t.Run("searches with the user's rating and the classic ruleset by default", func(t *testing.T) {
+		f := newFixture(t, 1234)
+
+		status, err := f.svc.Enqueue("0", Preferences{PlayerCount: 2})
+		if err != nil {
+			t.FailNow()
+		}
+		if status.State != StateSearching || status.Rating != 1234 || status.Preferences.Ruleset != entity.RulesetClassic {
+			t.Fail()
+		}
+		if status.Tolerance != DefaultInitialTolerance {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceLeaving(t *testing.T) {
+	t.Run("fails when user is not queued", func(t *testing.T) {
+		f := newFixture(t, 1500)
+
+		if err := f.svc.Leave("0"); err != ErrNotQueued {
+			t.Fail()
+		}
+	})
+
+	t.Run("stops searching when all is well", func(t *testing.T) {
+		f := newFixture(t, 1500)
+		f.svc.Enqueue("0", duel)
+
+		if err := f.svc.Leave("0"); err != nil {
+			t.FailNow()
+		}
+
+		if status, _ := f.svc.Status("0"); status.State != StateIdle {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceMatching(t *testing.T) {
+	t.Run("matches players with close ratings into a new game", func(t *testing.T) {
+		f := newFixture(t, 1500, 1550)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+
+		if err := f.svc.Match(context.Background()); err != nil {
+			t.FailNow()
+		}
+
+		status, _ := f.svc.Status("1")
+		if status.State != StateMatched {
+			t.FailNow()
+		}
+
+		g, err := f.gameSvc.GetByID(status.GameID)
+		if err != nil || !g.HasPlayer("0") || !g.HasPlayer("1") || g.MaxPlayers != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not match players with different preferences", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", Preferences{PlayerCount: 2, Ruleset: entity.RulesetQuick})
+
+		f.svc.Match(context.Background())
+
+		if status, _ := f.svc.Status("0"); status.State != StateSearching {
+			t.Fail()
+		}
+	})
+
+	t.Run("widens the tolerance as players wait", func(t *testing.T) {
+		f := newFixture(t, 1500, 1800)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+
+		f.svc.Match(context.Background())
+		if status, _ := f.svc.Status("0"); status.State != StateSearching {
+			t.FailNow()
+		}
+
+		f.now = f.now.Add(20 * time.Second)
+
+		f.svc.Match(context.Background())
+		if status, _ := f.svc.Status("0"); status.State != StateMatched {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not widen the tolerance past its maximum", func(t *testing.T) {
+		f := newFixture(t, 1000, 2000)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+
+		f.now = f.now.Add(time.Hour)
+
+		f.svc.Match(context.Background())
+		if status, _ := f.svc.Status("0"); status.State != StateSearching || status.Tolerance != DefaultMaxTolerance {
+			t.Fail()
+		}
+	})
+
+	t.Run("matches the players whose rating is the closest", func(t *testing.T) {
+		f := newFixture(t, 1500, 1590, 1510)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+		f.svc.Enqueue("2", duel)
+
+		f.svc.Match(context.Background())
+
+		if status, _ := f.svc.Status("1"); status.State != StateSearching {
+			t.Fail()
+		}
+		if status, _ := f.svc.Status("2"); status.State != StateMatched {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not match players who blocked each other", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500)
+		f.socialSvc.Block(context.Background(), "1", "0")
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+
+		f.svc.Match(context.Background())
+
+		if status, _ := f.svc.Status("0"); status.State != StateSearching {
+			t.Fail()
+		}
+	})
+
+	t.Run("waits until there are enough players", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500, 1500)
+		prefs := Preferences{PlayerCount: 3}
+		f.svc.Enqueue("0", prefs)
+		f.svc.Enqueue("1", prefs)
+
+		f.svc.Match(context.Background())
+		if status, _ := f.svc.Status("0"); status.State != StateSearching {
+			t.FailNow()
+		}
+
+		f.svc.Enqueue("2", prefs)
+
+		f.svc.Match(context.Background())
+		if status, _ := f.svc.Status("0"); status.State != StateMatched {
+			t.Fail()
+		}
+	})
+
+	t.Run("leaves only the players whose game could not be created queued", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500, 2000, 2000)
+		f.svc.gameSvc = &failingGameService{Service: f.gameSvc, failFor: "0"}
+		for _, userID := range []string{"0", "1", "2", "3"} {
+			f.svc.Enqueue(userID, duel)
+		}
+
+		if err := f.svc.Match(context.Background()); err == nil {
+			t.Fail()
+		}
+
+		for userID, state := range map[string]State{"0": StateSearching, "1": StateSearching, "2": StateMatched, "3": StateMatched} {
+			if status, _ := f.svc.Status(userID); status.State != state {
+				t.Errorf("expected user \"%s\" to be %s, got %s", userID, state, status.State)
+			}
+		}
+	})
+
+	t.Run("does not hold the lock while creating games", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500, 1500)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+
+		f.svc.gameSvc = &callbackGameService{Service: f.gameSvc, callback: func() {
+			f.svc.Enqueue("2", duel)
+			f.svc.Leave("1")
+		}}
+
+		done := make(chan error)
+		go func() {
+			done <- f.svc.Match(context.Background())
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.FailNow()
+		}
+
+		if status, _ := f.svc.Status("1"); status.State != StateIdle {
+			t.Fail()
+		}
+		if status, _ := f.svc.Status("2"); status.State != StateSearching {
+			t.Fail()
+		}
+	})
+
+	t.Run("forgets matches once their TTL elapses", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+		f.svc.Match(context.Background())
+
+		f.now = f.now.Add(DefaultMatchTTL)
+
+		if status, _ := f.svc.Status("0"); status.State != StateIdle {
+			t.Fail()
+		}
+	})
+}
+
+func TestConstantRatings(t *testing.T) {
+	t.Run("rates every player the same", func(t *testing.T) {
+		if rating, err := ConstantRatings(DefaultRating).Rating("anyone"); err != nil || rating != DefaultRating {
+			t.Fail()
+		}
+	})
+}
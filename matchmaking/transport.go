@@ -0,0 +1,91 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	getStatusHandler := stmhttp.NewHandler(
+		wrap("matchmaking.getStatus")(makeGetStatusEndpoint(svc)),
+		decodeEmptyRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	enqueueHandler := stmhttp.NewHandler(
+		wrap("matchmaking.enqueue")(makeEnqueueEndpoint(svc)),
+		decodeEnqueueRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	leaveHandler := stmhttp.NewHandler(
+		wrap("matchmaking.leave")(makeLeaveEndpoint(svc)),
+		decodeEmptyRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/matchmaking", getStatusHandler).Methods("GET")
+	r.Handle("/v1/matchmaking", enqueueHandler).Methods("POST")
+	r.Handle("/v1/matchmaking", leaveHandler).Methods("DELETE")
+
+	return r
+}
+
+func decodeEmptyRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeEnqueueRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		PlayerCount int            `json:"playerCount"`
+		Ruleset     entity.Ruleset `json:"ruleset"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return enqueueRequest{
+		PlayerCount: body.PlayerCount,
+		Ruleset:     body.Ruleset,
+	}, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeNoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrInvalidPlayerCount, game.ErrInvalidRuleset:
+		return http.StatusBadRequest
+	case ErrNotQueued:
+		return http.StatusNotFound
+	case ErrAlreadyQueued:
+		return http.StatusConflict
+	}
+
+	return 0
+})
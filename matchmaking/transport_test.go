@@ -0,0 +1,104 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/game"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+)
+
+func contextOf(userID string) context.Context {
+	return auth.NewContext(context.Background(), auth.Identity{UserID: userID})
+}
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("enqueues the caller and returns their status", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500)
+		handler := MakeHandler(f.svc)
+
+		r := stmhttptest.NewJSONRequest("POST", "/v1/matchmaking", `{"playerCount": 2, "ruleset": "quick"}`)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf("0")))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var body statusResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.State != StateSearching || body.PlayerCount != 2 || body.Ruleset != "quick" || body.EnqueuedAt == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the game the caller was matched into", func(t *testing.T) {
+		f := newFixture(t, 1500, 1500)
+		f.svc.Enqueue("0", duel)
+		f.svc.Enqueue("1", duel)
+		f.svc.Match(context.Background())
+		handler := MakeHandler(f.svc)
+
+		r := httptest.NewRequest("GET", "/v1/matchmaking", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf("0")))
+
+		var body statusResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.State != StateMatched || body.GameID == "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("stops searching for the caller", func(t *testing.T) {
+		f := newFixture(t, 1500)
+		f.svc.Enqueue("0", duel)
+		handler := MakeHandler(f.svc)
+
+		r := httptest.NewRequest("DELETE", "/v1/matchmaking", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(contextOf("0")))
+
+		if rr.Code != http.StatusNoContent {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails for anonymous callers", func(t *testing.T) {
+		handler := MakeHandler(newFixture(t).svc)
+
+		r := httptest.NewRequest("GET", "/v1/matchmaking", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		ErrInvalidPlayerCount:     http.StatusBadRequest,
+		game.ErrInvalidRuleset:    http.StatusBadRequest,
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		ErrNotQueued:              http.StatusNotFound,
+		ErrAlreadyQueued:          http.StatusConflict,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import "database/sql"
+
+// RegisterDBStats registers gauges and counters reporting the state of a
+// pool of database connections, which are read from the function whenever
+// the metrics are scraped.
+func RegisterDBStats(reg *Registry, stats func() sql.DBStats) {
+	reg.NewGaugeFunc(
+		"db_max_open_connections",
+		"Maximum number of open connections to the database, or 0 when unlimited.",
+		func() float64 { return float64(stats().MaxOpenConnections) },
+	)
+	reg.NewGaugeFunc(
+		"db_open_connections",
+		"Number of open connections to the database, in use or idle.",
+		func() float64 { return float64(stats().OpenConnections) },
+	)
+	reg.NewGaugeFunc(
+		"db_in_use_connections",
+		"Number of connections to the database that are in use.",
+		func() float64 { return float64(stats().InUse) },
+	)
+	reg.NewGaugeFunc(
+		"db_idle_connections",
+		"Number of idle connections to the database.",
+		func() float64 { return float64(stats().Idle) },
+	)
+	reg.NewCounterFunc(
+		"db_wait_count_total",
+		"Number of times a connection to the database had to be waited for.",
+		func() float64 { return float64(stats().WaitCount) },
+	)
+	reg.NewCounterFunc(
+		"db_wait_duration_seconds_total",
+		"How long was spent waiting for connections to the database.",
+		func() float64 { return stats().WaitDuration.Seconds() },
+	)
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisteringDBStats(t *testing.T) {
+	t.Run("reports the state of the connection pool", func(t *testing.T) {
+		reg := NewRegistry()
+		RegisterDBStats(reg, func() sql.DBStats {
+			return sql.DBStats{OpenConnections: 5, InUse: 3, Idle: 2, WaitCount: 7, WaitDuration: 1500 * time.Millisecond}
+		})
+
+		got := scrape(reg)
+		for _, sample := range []string{
+			"db_open_connections 5",
+			"db_in_use_connections 3",
+			"db_idle_connections 2",
+			"db_wait_count_total 7",
+			"db_wait_duration_seconds_total 1.5",
+		} {
+			if !strings.Contains(got, "\n"+sample+"\n") {
+				t.Errorf("expected sample %s in:\n%s", sample, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+)
+
+// Endpoints measures calls to endpoints, which are named when they are
+// instrumented, since they are plain functions.
+type Endpoints struct {
+	calls     *Counter
+	durations *Histogram
+}
+
+func NewEndpoints(reg *Registry) *Endpoints {
+	return &Endpoints{
+		calls: reg.NewCounter(
+			"endpoint_calls_total",
+			"Number of calls to endpoints, by endpoint and outcome.",
+			"endpoint", "outcome",
+		),
+		durations: reg.NewHistogram(
+			"endpoint_call_duration_seconds",
+			"How long calls to endpoints took, by endpoint.",
+			DefaultBuckets,
+			"endpoint",
+		),
+	}
+}
+
+// Middleware creates a middleware that counts calls to the endpoint with the
+// name, such as "user.register", by whether they failed, and measures how
+// long they take.
+func (e *Endpoints) Middleware(name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			start := time.Now()
+
+			response, err := next(ctx, request)
+
+			outcome := outcomeSuccess
+			if err != nil {
+				outcome = outcomeFailure
+			}
+
+			e.calls.Inc(name, outcome)
+			e.durations.Observe(time.Since(start).Seconds(), name)
+
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEndpointMiddleware(t *testing.T) {
+	t.Run("records calls by endpoint and outcome", func(t *testing.T) {
+		reg := NewRegistry()
+		instrument := NewEndpoints(reg).Middleware("user.register")
+
+		succeed := instrument(func(context.Context, interface{}) (interface{}, error) {
+			return "registered", nil
+		})
+		fail := instrument(func(context.Context, interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("an error occurred")
+		})
+
+		if response, err := succeed(context.Background(), nil); response != "registered" || err != nil {
+			t.Fail()
+		}
+		if _, err := fail(context.Background(), nil); err == nil {
+			t.Fail()
+		}
+
+		got := scrape(reg)
+		for _, sample := range []string{
+			`endpoint_calls_total{endpoint="user.register",outcome="failure"} 1`,
+			`endpoint_calls_total{endpoint="user.register",outcome="success"} 1`,
+			`endpoint_call_duration_seconds_count{endpoint="user.register"} 2`,
+		} {
+			if !strings.Contains(got, sample) {
+				t.Errorf("expected sample %s in:\n%s", sample, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/hash"
+)
+
+// hashBuckets go from 10ms to 5s, since hashing passwords is meant to be
+// slow.
+var hashBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+type hashService struct {
+	hash.Service
+	algorithm string
+	durations *Histogram
+}
+
+// InstrumentHashing wraps the hash service to measure how long it takes to
+// generate hashes from passwords, and to match passwords with hashes, by
+// operation and by the algorithm used to generate hashes.
+func InstrumentHashing(reg *Registry, svc hash.Service, algorithm string) hash.Service {
+	return &hashService{
+		Service:   svc,
+		algorithm: algorithm,
+		durations: reg.NewHistogram(
+			"password_hashing_duration_seconds",
+			"How long hashing passwords took, by operation and algorithm.",
+			hashBuckets,
+			"operation", "algorithm",
+		),
+	}
+}
+
+func (svc *hashService) GenerateFromPassword(ctx context.Context, password string) (string, error) {
+	defer svc.observe("generate", time.Now())
+
+	return svc.Service.GenerateFromPassword(ctx, password)
+}
+
+func (svc *hashService) MatchPassword(ctx context.Context, hash string, password string) bool {
+	defer svc.observe("match", time.Now())
+
+	return svc.Service.MatchPassword(ctx, hash, password)
+}
+
+func (svc *hashService) observe(operation string, start time.Time) {
+	svc.durations.Observe(time.Since(start).Seconds(), operation, svc.algorithm)
+}
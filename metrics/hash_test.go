@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type mockHashService struct{}
+
+func (mockHashService) GenerateFromPassword(_ context.Context, password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func (mockHashService) MatchPassword(_ context.Context, hash string, password string) bool {
+	return hash == "hashed:"+password
+}
+
+func (mockHashService) NeedsRehash(hash string) bool {
+	return false
+}
+
+func TestInstrumentingHashing(t *testing.T) {
+	t.Run("measures generating and matching hashes", func(t *testing.T) {
+		reg := NewRegistry()
+		svc := InstrumentHashing(reg, mockHashService{}, "bcrypt")
+
+		hash, err := svc.GenerateFromPassword(context.Background(), "moose")
+		if err != nil || !svc.MatchPassword(context.Background(), hash, "moose") {
+			t.FailNow()
+		}
+
+		got := scrape(reg)
+		for _, sample := range []string{
+			`password_hashing_duration_seconds_count{operation="generate",algorithm="bcrypt"} 1`,
+			`password_hashing_duration_seconds_count{operation="match",algorithm="bcrypt"} 1`,
+		} {
+			if !strings.Contains(got, sample) {
+				t.Errorf("expected sample %s in:\n%s", sample, got)
+			}
+		}
+	})
+}
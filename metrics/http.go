@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/transport/http/middleware"
+)
+
+// unmatchedRoute labels requests that do not match any route, so that
+// clients cannot create series by requesting arbitrary paths.
+const unmatchedRoute = "unmatched"
+
+// NewHTTPMiddleware creates a middleware that counts requests, and measures
+// how long they take, by method, route, and class of status code, such as
+// 4xx for client errors and 5xx for server errors.
+//
+// Routes are the path templates of the routes of the router that requests
+// match, such as "/v1/users/{id}/stats", rather than their paths, so that
+// there is a series per route instead of one per user.
+func NewHTTPMiddleware(reg *Registry, router *mux.Router) func(http.Handler) http.Handler {
+	requests := reg.NewCounter(
+		"http_requests_total",
+		"Number of HTTP requests served, by method, route, and class of status code.",
+		"method", "route", "class",
+	)
+	durations := reg.NewHistogram(
+		"http_request_duration_seconds",
+		"How long HTTP requests took to serve, by method and route.",
+		DefaultBuckets,
+		"method", "route",
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			route, ok := middleware.Route(router, r)
+			if !ok {
+				route = unmatchedRoute
+			}
+
+			rw := middleware.NewResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			requests.Inc(r.Method, route, strconv.Itoa(rw.Status()/100)+"xx")
+			durations.Observe(time.Since(start).Seconds(), r.Method, route)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/v1/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	router.Handle("/v1/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+
+	t.Run("records requests by route template and class of status code", func(t *testing.T) {
+		reg := NewRegistry()
+		handler := NewHTTPMiddleware(reg, router)(router)
+
+		for _, path := range []string{"/v1/users/1", "/v1/users/2", "/v1/users", "/moose"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+
+		got := scrape(reg)
+		for _, sample := range []string{
+			`http_requests_total{method="GET",route="/v1/users/{id}",class="4xx"} 2`,
+			`http_requests_total{method="GET",route="/v1/users",class="2xx"} 1`,
+			`http_requests_total{method="GET",route="unmatched",class="4xx"} 1`,
+			`http_request_duration_seconds_count{method="GET",route="/v1/users/{id}"} 2`,
+		} {
+			if !strings.Contains(got, sample) {
+				t.Errorf("expected sample %s in:\n%s", sample, got)
+			}
+		}
+	})
+
+	t.Run("lets streamed responses be flushed", func(t *testing.T) {
+		flushed := false
+		handler := NewHTTPMiddleware(NewRegistry(), router)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, flushed = w.(http.Flusher)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/users", nil))
+
+		if !flushed {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,330 @@
+// Package metrics records measurements of the service, such as how many
+// requests it serves and how long they take, and exposes them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPort is the port metrics are served on, apart from the API, when
+// none is configured.
+const DefaultPort = 9090
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of
+// histograms measuring latencies, which go from 5ms to 10s.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry represents a set of metrics, which are written out together when
+// they are scraped.
+//
+// Registering two metrics with the same name panics, like using a metric with
+// the wrong number of label values, since both are programming errors.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+// family represents a metric along with all of its series, one per set of
+// label values.
+type family interface {
+	write(w io.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		names: make(map[string]bool),
+	}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics.Registry: metric \"%s\" is already registered", name))
+	}
+
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// NewCounter registers a counter, a value that only goes up, such as the
+// number of requests served, with a series for each set of label values.
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{
+		desc:   desc{name, help, "counter", labels},
+		values: make(map[string]*counterSeries),
+	}
+	r.register(name, c)
+
+	return c
+}
+
+// NewHistogram registers a histogram, which counts observations, such as
+// latencies, in buckets with the given upper bounds, with a series for each
+// set of label values.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	h := &Histogram{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: sorted,
+		values:  make(map[string]*histogramSeries),
+	}
+	r.register(name, h)
+
+	return h
+}
+
+// NewGaugeFunc registers a gauge, a value that goes up and down, which is
+// read from the function whenever the metrics are scraped.
+func (r *Registry) NewGaugeFunc(name string, help string, value func() float64) {
+	r.register(name, &valueFunc{desc{name, help, "gauge", nil}, value})
+}
+
+// NewCounterFunc registers a counter that is read from the function whenever
+// the metrics are scraped, for values that are already counted elsewhere.
+func (r *Registry) NewCounterFunc(name string, help string, value func() float64) {
+	r.register(name, &valueFunc{desc{name, help, "counter", nil}, value})
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format, in the
+// order they were registered.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, fmt.Errorf("metrics.Registry.WriteTo: failed to write metrics (%s)", err)
+	}
+
+	return cw.n, nil
+}
+
+// Handler serves the metrics to scrapers, such as Prometheus.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// Counter represents a value that only goes up.
+type Counter struct {
+	desc
+
+	mu     sync.Mutex
+	values map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// Inc adds one to the series with the label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a positive value to the series with the label values.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.values[key]
+	if !ok {
+		s = &counterSeries{labelValues: labelValues}
+		c.values[key] = s
+	}
+
+	s.value += value
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	c.writeHeader(w)
+	for _, key := range keys {
+		s := c.values[key]
+		writeSample(w, c.name, c.labels, s.labelValues, "", "", s.value)
+	}
+}
+
+// Histogram represents observations counted in buckets.
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+
+	// counts are the number of observations in each bucket, not including
+	// the ones in the buckets before it.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe counts the value in the series with the label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: labelValues,
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = s
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h.writeHeader(w)
+	for _, key := range keys {
+		s := h.values[key]
+
+		// Buckets are cumulative when they are exposed.
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", formatFloat(upperBound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+type valueFunc struct {
+	desc
+	value func() float64
+}
+
+func (v *valueFunc) write(w io.Writer) {
+	v.writeHeader(w)
+	writeSample(w, v.name, nil, nil, "", "", v.value())
+}
+
+// desc describes a metric.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf(
+			"metrics: metric \"%s\" has %d labels, but got %d values",
+			d.name,
+			len(d.labels),
+			len(labelValues),
+		))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, helpEscaper.Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// writeSample writes a line with the value of a series, with an extra label,
+// such as the upper bound of a histogram bucket, when its name is not empty.
+func writeSample(w io.Writer, name string, labels []string, labelValues []string, extraLabel string, extraValue string, value float64) {
+	pairs := make([]string, 0, len(labels)+1)
+	for i, l := range labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l, labelEscaper.Replace(labelValues[i])))
+	}
+	if extraLabel != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraLabel, extraValue))
+	}
+
+	if len(pairs) == 0 {
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+		return
+	}
+
+	fmt.Fprintf(w, "%s{%s} %s\n", name, strings.Join(pairs, ","), formatFloat(value))
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(reg *Registry) string {
+	var buf bytes.Buffer
+	reg.WriteTo(&buf)
+
+	return buf.String()
+}
+
+func TestRegistryRegistration(t *testing.T) {
+	t.Run("panics when a metric is registered twice", func(t *testing.T) {
+		reg := NewRegistry()
+		reg.NewCounter("requests_total", "Requests.")
+
+		defer func() {
+			if recover() == nil {
+				t.Fail()
+			}
+		}()
+
+		reg.NewGaugeFunc("requests_total", "Requests.", func() float64 { return 0 })
+	})
+
+	t.Run("panics when label values do not match the labels", func(t *testing.T) {
+		c := NewRegistry().NewCounter("requests_total", "Requests.", "method")
+
+		defer func() {
+			if recover() == nil {
+				t.Fail()
+			}
+		}()
+
+		c.Inc("GET", "/v1/users")
+	})
+}
+
+func TestRegistryWriting(t *testing.T) {
+	t.Run("writes counters with a sample per set of label values", func(t *testing.T) {
+		reg := NewRegistry()
+		c := reg.NewCounter("requests_total", "Number of requests.", "method", "path")
+		c.Inc("POST", "/v1/users")
+		c.Add(2, "GET", `/v1/"moose"`)
+
+		expected := "# HELP requests_total Number of requests.\n" +
+			"# TYPE requests_total counter\n" +
+			`requests_total{method="GET",path="/v1/\"moose\""} 2` + "\n" +
+			`requests_total{method="POST",path="/v1/users"} 1` + "\n"
+		if got := scrape(reg); got != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("writes histograms with cumulative buckets", func(t *testing.T) {
+		reg := NewRegistry()
+		h := reg.NewHistogram("duration_seconds", "Duration.", []float64{1, 0.1})
+		h.Observe(0.05)
+		h.Observe(0.5)
+		h.Observe(3)
+
+		expected := "# HELP duration_seconds Duration.\n" +
+			"# TYPE duration_seconds histogram\n" +
+			`duration_seconds_bucket{le="0.1"} 1` + "\n" +
+			`duration_seconds_bucket{le="1"} 2` + "\n" +
+			`duration_seconds_bucket{le="+Inf"} 3` + "\n" +
+			"duration_seconds_sum 3.55\n" +
+			"duration_seconds_count 3\n"
+		if got := scrape(reg); got != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+
+	t.Run("reads functions when scraped", func(t *testing.T) {
+		reg := NewRegistry()
+		value := 1.0
+		reg.NewGaugeFunc("connections", "Connections.", func() float64 { return value })
+
+		value = 4
+		if !strings.Contains(scrape(reg), "\nconnections 4\n") {
+			t.Fail()
+		}
+	})
+
+	t.Run("serves metrics in the text exposition format", func(t *testing.T) {
+		reg := NewRegistry()
+		reg.NewCounter("requests_total", "Requests.").Inc()
+
+		rr := httptest.NewRecorder()
+		reg.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+		if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") || !strings.Contains(rr.Body.String(), "requests_total 1") {
+			t.Fail()
+		}
+	})
+}
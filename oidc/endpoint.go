@@ -0,0 +1,81 @@
+package oidc
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type beginRequest struct {
+	Provider string
+}
+
+type beginResponse struct {
+	Provider    string
+	URL         string
+	SealedState string
+}
+
+func makeBeginEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(beginRequest)
+
+		authorization, err := svc.Begin(ctx, req.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		return &beginResponse{
+			Provider:    req.Provider,
+			URL:         authorization.URL,
+			SealedState: authorization.SealedState,
+		}, nil
+	}
+}
+
+type completeRequest struct {
+	Provider    string
+	SealedState string
+	State       string
+	Code        string
+}
+
+type completeResponse struct {
+	provider string
+
+	UserID      string     `json:"userId,omitempty"`
+	AccessToken string     `json:"accessToken,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+
+	MFARequired bool       `json:"mfaRequired"`
+	MFAToken    string     `json:"mfaToken,omitempty"`
+	MFAExpires  *time.Time `json:"mfaExpiresAt,omitempty"`
+}
+
+func makeCompleteEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(completeRequest)
+
+		result, err := svc.Complete(ctx, req.Provider, req.SealedState, req.State, req.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Challenge != nil {
+			return &completeResponse{
+				provider:    req.Provider,
+				MFARequired: true,
+				MFAToken:    result.Challenge.MFAToken,
+				MFAExpires:  &result.Challenge.ExpiresAt,
+			}, nil
+		}
+
+		return &completeResponse{
+			provider:    req.Provider,
+			UserID:      result.Session.UserID,
+			AccessToken: result.Session.AccessToken,
+			ExpiresAt:   &result.Session.ExpiresAt,
+		}, nil
+	}
+}
@@ -0,0 +1,86 @@
+package oidc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/oidc/oidctest"
+)
+
+func TestBeginEndpoint(t *testing.T) {
+	server := oidctest.NewServer(mockUser)
+	defer server.Close()
+
+	svc, _ := NewService([]*Provider{newMockProvider(t, server)}, &mockSessionService{}, cipher)
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		if _, err := makeBeginEndpoint(svc)(context.Background(), beginRequest{Provider: "facemoose"}); err != ErrUnknownProvider {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns authorization URL and sealed state when all is well", func(t *testing.T) {
+		resp, err := makeBeginEndpoint(svc)(context.Background(), beginRequest{Provider: mockProviderName})
+		if err != nil {
+			t.FailNow()
+		}
+
+		beginResp := resp.(*beginResponse)
+		if !strings.HasPrefix(beginResp.URL, server.URL) || beginResp.SealedState == "" {
+			t.Fail()
+		}
+	})
+}
+
+func TestCompleteEndpoint(t *testing.T) {
+	server := oidctest.NewServer(mockUser)
+	defer server.Close()
+
+	complete := func(sessionSvc *mockSessionService) (interface{}, error) {
+		svc, _ := NewService([]*Provider{newMockProvider(t, server)}, sessionSvc, cipher)
+
+		authorization, _ := svc.Begin(context.Background(), mockProviderName)
+		code, state, _ := server.Authorize(authorization.URL)
+
+		return makeCompleteEndpoint(svc)(context.Background(), completeRequest{
+			Provider:    mockProviderName,
+			SealedState: authorization.SealedState,
+			State:       state,
+			Code:        code,
+		})
+	}
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		if _, err := complete(&mockSessionService{failOnLogin: true}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns access token when no second factor is required", func(t *testing.T) {
+		resp, err := complete(&mockSessionService{})
+		if err != nil {
+			t.FailNow()
+		}
+
+		completeResp := resp.(*completeResponse)
+		if completeResp.MFARequired || completeResp.AccessToken == "" {
+			t.Fail()
+		}
+		if strings.Compare(mockUserID, completeResp.UserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns MFA token when a second factor is required", func(t *testing.T) {
+		resp, err := complete(&mockSessionService{twoFactorEnabled: true})
+		if err != nil {
+			t.FailNow()
+		}
+
+		completeResp := resp.(*completeResponse)
+		if !completeResp.MFARequired || completeResp.MFAToken == "" || completeResp.AccessToken != "" {
+			t.Fail()
+		}
+	})
+}
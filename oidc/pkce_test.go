@@ -0,0 +1,33 @@
+package oidc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	t.Run("matches example from RFC 7636", func(t *testing.T) {
+		challenge := codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+
+		if strings.Compare("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", challenge) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestRandomString(t *testing.T) {
+	t.Run("returns different URL safe strings", func(t *testing.T) {
+		first, err := randomString(32)
+		if err != nil {
+			t.FailNow()
+		}
+		second, _ := randomString(32)
+
+		if first == second {
+			t.Fail()
+		}
+		if strings.ContainsAny(first, "+/=") {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,433 @@
+// Package oidc lets users sign in with an external OpenID Connect identity
+// provider, using the authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// clockSkew is how much the clocks of the identity provider and of the
+	// service may differ when checking when ID tokens expire.
+	clockSkew = time.Minute
+
+	// minKeysRefreshInterval prevents tokens with unknown key IDs from making
+	// the provider fetch its keys on every request.
+	minKeysRefreshInterval = time.Minute
+
+	maxResponseSize = 1 << 20
+)
+
+// DefaultScopes are the scopes requested when none are configured.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Config represents how the service is registered with an identity provider.
+type Config struct {
+	// Name identifies the provider in routes and linked identities, and must
+	// never change once users have signed in with it.
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Claims represents the claims of an ID token that the service relies on.
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+}
+
+// audience is either a single string or an array of strings in ID tokens.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to an identity provider, whose endpoints and keys are
+// discovered the first time they are needed, rather than at startup, so that
+// the service can start when the provider is unreachable.
+type Provider struct {
+	conf   Config
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewProvider(conf Config) (*Provider, error) {
+	if conf.Name == "" {
+		return nil, fmt.Errorf("oidc.NewProvider: name is required")
+	}
+
+	if _, err := url.ParseRequestURI(conf.Issuer); err != nil {
+		return nil, fmt.Errorf("oidc.NewProvider: issuer must be a URL (%s)", err)
+	}
+
+	if conf.ClientID == "" {
+		return nil, fmt.Errorf("oidc.NewProvider: client ID is required")
+	}
+
+	if _, err := url.ParseRequestURI(conf.RedirectURL); err != nil {
+		return nil, fmt.Errorf("oidc.NewProvider: redirect URL must be a URL (%s)", err)
+	}
+
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = DefaultScopes
+	}
+
+	return &Provider{
+		conf:   conf,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+func (p *Provider) Name() string {
+	return p.conf.Name
+}
+
+// AuthCodeURL returns the URL of the provider's authorization endpoint, where
+// users are sent to sign in.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", fmt.Errorf("oidc.Provider.AuthCodeURL: %s", err)
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.conf.ClientID},
+		"redirect_uri":          {p.conf.RedirectURL},
+		"scope":                 {strings.Join(p.conf.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return md.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades the authorization code for an ID token, and returns its
+// claims once it is verified.
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Claims, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Provider.Exchange: %s", err)
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.conf.RedirectURL},
+		"client_id":     {p.conf.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	if p.conf.ClientSecret != "" {
+		form.Set("client_secret", p.conf.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Provider.Exchange: failed to create token request (%s)", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Provider.Exchange: failed to call token endpoint (%s)", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oidc.Provider.Exchange: failed to decode token response (%s)", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"oidc.Provider.Exchange: token endpoint refused code (%s: %s)",
+			body.Error,
+			body.ErrorDescription,
+		)
+	}
+
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("oidc.Provider.Exchange: token response has no ID token")
+	}
+
+	claims, err := p.verify(ctx, body.IDToken, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Provider.Exchange: %s", err)
+	}
+
+	return claims, nil
+}
+
+// verify checks the signature and claims of a compact serialized ID token,
+// which must be signed with RS256, the only algorithm providers must support.
+func (p *Provider) verify(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("ID token is malformed")
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("ID token header is malformed (%s)", err)
+	}
+
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("ID token is signed with unsupported algorithm \"%s\"", header.Algorithm)
+	}
+
+	key, err := p.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("ID token signature is malformed (%s)", err)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("ID token signature is invalid")
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("ID token claims are malformed (%s)", err)
+	}
+
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Issuer != md.Issuer {
+		return nil, fmt.Errorf("ID token was issued by \"%s\"", claims.Issuer)
+	}
+
+	if !claims.Audience.contains(p.conf.ClientID) {
+		return nil, fmt.Errorf("ID token was not issued for this client")
+	}
+
+	if p.now().Add(-clockSkew).Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("ID token has expired")
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("ID token nonce does not match")
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("ID token has no subject")
+	}
+
+	return &claims, nil
+}
+
+// discover fetches the provider's metadata without holding the lock, so that a
+// slow provider does not hold up requests that only need what is cached.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	md := p.metadata
+	p.mu.Unlock()
+
+	if md != nil {
+		return md, nil
+	}
+
+	md = &metadata{}
+	if err := p.get(ctx, strings.TrimSuffix(p.conf.Issuer, "/")+discoveryPath, md); err != nil {
+		return nil, fmt.Errorf("failed to discover provider (%s)", err)
+	}
+
+	if md.Issuer != p.conf.Issuer {
+		return nil, fmt.Errorf("provider has issuer \"%s\" instead of \"%s\"", md.Issuer, p.conf.Issuer)
+	}
+
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, fmt.Errorf("provider metadata is incomplete")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Another request may have discovered the provider in the meantime, and
+	// keeping the first metadata means every caller sees the same.
+	if p.metadata == nil {
+		p.metadata = md
+	}
+
+	return p.metadata, nil
+}
+
+// key returns the provider's public key with the given ID, fetching the keys
+// again when it is unknown, since providers rotate them. Like discover, it
+// does not hold the lock while fetching them.
+func (p *Provider) key(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	key, ok := p.keys[keyID]
+	recentlyFetched := p.keys != nil && p.now().Sub(p.keysFetchedAt) < minKeysRefreshInterval
+	p.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if recentlyFetched {
+		return nil, fmt.Errorf("ID token is signed with unknown key \"%s\"", keyID)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.get(ctx, md.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch provider keys (%s)", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.KeyType != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+
+		keys[k.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+	p.mu.Unlock()
+
+	key, ok = keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("ID token is signed with unknown key \"%s\"", keyID)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+		return fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decoded, v)
+}
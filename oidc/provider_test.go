@@ -0,0 +1,280 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/oidc/oidctest"
+)
+
+const (
+	mockClientID     = "a.client.id"
+	mockRedirectURL  = "http://localhost:8080/v1/auth/moosebook/callback"
+	mockProviderName = "moosebook"
+	mockNonce        = "a.nonce"
+	mockCodeVerifier = "a.code.verifier.that.is.long.enough.to.be.valid"
+)
+
+var mockUser = oidctest.User{
+	Subject:           "a.moosebook.subject",
+	Email:             "moose@stmoosersburg.com",
+	EmailVerified:     true,
+	PreferredUsername: "Moose",
+}
+
+func newMockProvider(t *testing.T, server *oidctest.Server) *Provider {
+	p, err := NewProvider(Config{
+		Name:        mockProviderName,
+		Issuer:      server.Issuer(),
+		ClientID:    mockClientID,
+		RedirectURL: mockRedirectURL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create provider (%s)", err)
+	}
+
+	return p
+}
+
+func TestProviderConstructor(t *testing.T) {
+	conf := Config{
+		Name:        mockProviderName,
+		Issuer:      "http://localhost:9999",
+		ClientID:    mockClientID,
+		RedirectURL: mockRedirectURL,
+	}
+
+	t.Run("fails when name is missing", func(t *testing.T) {
+		c := conf
+		c.Name = ""
+
+		if _, err := NewProvider(c); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when issuer is not a URL", func(t *testing.T) {
+		c := conf
+		c.Issuer = "moosebook"
+
+		if _, err := NewProvider(c); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when client ID is missing", func(t *testing.T) {
+		c := conf
+		c.ClientID = ""
+
+		if _, err := NewProvider(c); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when redirect URL is not a URL", func(t *testing.T) {
+		c := conf
+		c.RedirectURL = ""
+
+		if _, err := NewProvider(c); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses default scopes when none are given", func(t *testing.T) {
+		p, err := NewProvider(conf)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(p.conf.Scopes) != len(DefaultScopes) {
+			t.Fail()
+		}
+	})
+}
+
+func TestProviderAuthCodeURL(t *testing.T) {
+	t.Run("fails when provider cannot be discovered", func(t *testing.T) {
+		server := oidctest.NewServer(mockUser)
+		p := newMockProvider(t, server)
+		server.Close()
+
+		if _, err := p.AuthCodeURL(context.Background(), "a.state", mockNonce, mockCodeVerifier); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns URL of authorization endpoint with PKCE challenge", func(t *testing.T) {
+		server := oidctest.NewServer(mockUser)
+		defer server.Close()
+		p := newMockProvider(t, server)
+
+		authURL, err := p.AuthCodeURL(context.Background(), "a.state", mockNonce, mockCodeVerifier)
+		if err != nil {
+			t.FailNow()
+		}
+
+		u, _ := url.Parse(authURL)
+		if !strings.HasPrefix(authURL, server.URL+"/authorize?") {
+			t.Fail()
+		}
+		if strings.Compare(codeChallenge(mockCodeVerifier), u.Query().Get("code_challenge")) != 0 {
+			t.Fail()
+		}
+		if strings.Compare("S256", u.Query().Get("code_challenge_method")) != 0 {
+			t.Fail()
+		}
+		if strings.Compare("openid email profile", u.Query().Get("scope")) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestProviderExchange(t *testing.T) {
+	server := oidctest.NewServer(mockUser)
+	defer server.Close()
+
+	authorize := func(p *Provider) string {
+		authURL, _ := p.AuthCodeURL(context.Background(), "a.state", mockNonce, mockCodeVerifier)
+		code, _, err := server.Authorize(authURL)
+		if err != nil {
+			t.Fatalf("failed to authorize (%s)", err)
+		}
+
+		return code
+	}
+
+	t.Run("fails when code is unknown", func(t *testing.T) {
+		p := newMockProvider(t, server)
+
+		if _, err := p.Exchange(context.Background(), "an.unknown.code", mockCodeVerifier, mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when context is cancelled", func(t *testing.T) {
+		p := newMockProvider(t, server)
+		code := authorize(p)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := p.Exchange(ctx, code, mockCodeVerifier, mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when code verifier does not match challenge", func(t *testing.T) {
+		p := newMockProvider(t, server)
+		code := authorize(p)
+
+		if _, err := p.Exchange(context.Background(), code, "another.code.verifier", mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when nonce does not match", func(t *testing.T) {
+		p := newMockProvider(t, server)
+		code := authorize(p)
+
+		if _, err := p.Exchange(context.Background(), code, mockCodeVerifier, "another.nonce"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns claims of ID token when all is well", func(t *testing.T) {
+		p := newMockProvider(t, server)
+		code := authorize(p)
+
+		claims, err := p.Exchange(context.Background(), code, mockCodeVerifier, mockNonce)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(mockUser.Subject, claims.Subject) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(mockUser.Email, claims.Email) != 0 || !claims.EmailVerified {
+			t.Fail()
+		}
+		if strings.Compare(mockUser.PreferredUsername, claims.PreferredUsername) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when code is used twice", func(t *testing.T) {
+		p := newMockProvider(t, server)
+		code := authorize(p)
+
+		if _, err := p.Exchange(context.Background(), code, mockCodeVerifier, mockNonce); err != nil {
+			t.FailNow()
+		}
+		if _, err := p.Exchange(context.Background(), code, mockCodeVerifier, mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestProviderVerification(t *testing.T) {
+	server := oidctest.NewServer(mockUser)
+	defer server.Close()
+
+	p := newMockProvider(t, server)
+
+	t.Run("fails when ID token is malformed", func(t *testing.T) {
+		if _, err := p.verify(context.Background(), "not.an.id.token", mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when signature is invalid", func(t *testing.T) {
+		idToken := server.Sign(server.Claims(mockClientID, mockNonce))
+		tampered := idToken[:len(idToken)-4] + "AAAA"
+
+		if _, err := p.verify(context.Background(), tampered, mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when ID token was signed by another provider", func(t *testing.T) {
+		other := oidctest.NewServer(mockUser)
+		defer other.Close()
+
+		if _, err := p.verify(context.Background(), other.Sign(server.Claims(mockClientID, mockNonce)), mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when issuer does not match", func(t *testing.T) {
+		claims := server.Claims(mockClientID, mockNonce)
+		claims["iss"] = "http://moosebook.example"
+
+		if _, err := p.verify(context.Background(), server.Sign(claims), mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when ID token was issued for another client", func(t *testing.T) {
+		if _, err := p.verify(context.Background(), server.Sign(server.Claims("another.client.id", mockNonce)), mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when ID token has expired", func(t *testing.T) {
+		claims := server.Claims(mockClientID, mockNonce)
+		claims["exp"] = time.Now().Add(-2 * clockSkew).Unix()
+
+		if _, err := p.verify(context.Background(), server.Sign(claims), mockNonce); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts audience given as an array", func(t *testing.T) {
+		claims := server.Claims(mockClientID, mockNonce)
+		claims["aud"] = []string{"another.client.id", mockClientID}
+
+		if _, err := p.verify(context.Background(), server.Sign(claims), mockNonce); err != nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/encryption"
+	"github.com/leblancjs/stmoosersburg-api/session"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+// StateTTL is how long users have to sign in with the identity provider.
+const StateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidState         = errors.New("sign-in request is invalid or has expired")
+	ErrAuthenticationFailed = errors.New("identity provider did not authenticate the user")
+)
+
+// Authorization represents a sign-in that has been started, but not completed
+// yet.
+type Authorization struct {
+	// URL is where the user must be sent to sign in with the provider.
+	URL string
+
+	// SealedState must be handed back to complete the sign-in. It is
+	// encrypted, so that it can be kept by the user's browser, in a cookie,
+	// without revealing the PKCE code verifier.
+	SealedState string
+}
+
+type Service interface {
+	Begin(ctx context.Context, provider string) (*Authorization, error)
+
+	// Complete exchanges the code returned by the provider along with the
+	// state for a login result, provided the state matches the sealed one.
+	Complete(ctx context.Context, provider string, sealedState string, state string, code string) (*session.Result, error)
+}
+
+// pendingAuthorization represents what must be remembered between the start
+// and the end of a sign-in.
+type pendingAuthorization struct {
+	Provider     string `json:"p"`
+	State        string `json:"s"`
+	Nonce        string `json:"n"`
+	CodeVerifier string `json:"v"`
+	ExpiresAt    int64  `json:"e"`
+}
+
+type service struct {
+	providers  map[string]*Provider
+	sessionSvc session.Service
+	cipher     encryption.Cipher
+	now        func() time.Time
+}
+
+// NewService creates a service that seals the state of sign-ins with the
+// cipher, which must use a key of its own, such as one derived for the purpose
+// with encryption.DeriveKey, rather than the key that protects other secrets.
+func NewService(providers []*Provider, sessionSvc session.Service, cipher encryption.Cipher) (Service, error) {
+	if sessionSvc == nil {
+		return nil, fmt.Errorf("oidc.NewService: session service is required")
+	}
+
+	if cipher == nil {
+		return nil, fmt.Errorf("oidc.NewService: cipher is required")
+	}
+
+	byName := make(map[string]*Provider, len(providers))
+	for _, p := range providers {
+		if p == nil {
+			return nil, fmt.Errorf("oidc.NewService: providers cannot be nil")
+		}
+
+		if _, ok := byName[p.Name()]; ok {
+			return nil, fmt.Errorf("oidc.NewService: provider \"%s\" is configured more than once", p.Name())
+		}
+
+		byName[p.Name()] = p
+	}
+
+	return &service{
+		providers:  byName,
+		sessionSvc: sessionSvc,
+		cipher:     cipher,
+		now:        time.Now,
+	}, nil
+}
+
+func (svc *service) Begin(ctx context.Context, provider string) (*Authorization, error) {
+	p, ok := svc.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	pending := pendingAuthorization{
+		Provider:  provider,
+		ExpiresAt: svc.now().Add(StateTTL).Unix(),
+	}
+
+	var err error
+	if pending.State, err = randomString(32); err != nil {
+		return nil, fmt.Errorf("oidc.Service.Begin: %s", err)
+	}
+	if pending.Nonce, err = randomString(32); err != nil {
+		return nil, fmt.Errorf("oidc.Service.Begin: %s", err)
+	}
+	if pending.CodeVerifier, err = randomString(32); err != nil {
+		return nil, fmt.Errorf("oidc.Service.Begin: %s", err)
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, pending.State, pending.Nonce, pending.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Service.Begin: %s", err)
+	}
+
+	encoded, err := json.Marshal(pending)
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Service.Begin: failed to encode state (%s)", err)
+	}
+
+	sealed, err := svc.cipher.Encrypt(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("oidc.Service.Begin: failed to seal state (%s)", err)
+	}
+
+	return &Authorization{
+		URL:         authURL,
+		SealedState: sealed,
+	}, nil
+}
+
+func (svc *service) Complete(ctx context.Context, provider string, sealedState string, state string, code string) (*session.Result, error) {
+	p, ok := svc.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	decrypted, err := svc.cipher.Decrypt(sealedState)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+
+	var pending pendingAuthorization
+	if err := json.Unmarshal([]byte(decrypted), &pending); err != nil {
+		return nil, ErrInvalidState
+	}
+
+	if pending.Provider != provider ||
+		subtle.ConstantTimeCompare([]byte(pending.State), []byte(state)) != 1 ||
+		svc.now().Unix() >= pending.ExpiresAt {
+		return nil, ErrInvalidState
+	}
+
+	if code == "" {
+		return nil, ErrAuthenticationFailed
+	}
+
+	claims, err := p.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Name
+	}
+
+	result, err := svc.sessionSvc.LoginWithIdentity(ctx, user.ExternalIdentity{
+		Provider:      provider,
+		Subject:       claims.Subject,
+		Username:      username,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	})
+	if err != nil {
+		if err == user.ErrIdentityConflict || err == user.ErrSuspended {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("oidc.Service.Complete: %s", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,205 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/encryption"
+	"github.com/leblancjs/stmoosersburg-api/oidc/oidctest"
+	"github.com/leblancjs/stmoosersburg-api/session"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var cipher, _ = encryption.NewAESGCMCipher(bytes.Repeat([]byte{0x42}, encryption.KeySize))
+
+func TestServiceConstructor(t *testing.T) {
+	sessionSvc := &mockSessionService{}
+
+	t.Run("fails when session service is missing", func(t *testing.T) {
+		if _, err := NewService(nil, nil, cipher); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when cipher is missing", func(t *testing.T) {
+		if _, err := NewService(nil, sessionSvc, nil); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when a provider is configured more than once", func(t *testing.T) {
+		p, _ := NewProvider(Config{Name: mockProviderName, Issuer: "http://localhost", ClientID: mockClientID, RedirectURL: mockRedirectURL})
+
+		if _, err := NewService([]*Provider{p, p}, sessionSvc, cipher); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a service when all is well", func(t *testing.T) {
+		if svc, err := NewService(nil, sessionSvc, cipher); err != nil || svc == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceSignIn(t *testing.T) {
+	server := oidctest.NewServer(mockUser)
+	defer server.Close()
+
+	newService := func(sessionSvc session.Service) Service {
+		svc, err := NewService([]*Provider{newMockProvider(t, server)}, sessionSvc, cipher)
+		if err != nil {
+			t.Fatalf("failed to create service (%s)", err)
+		}
+
+		return svc
+	}
+
+	begin := func(svc Service) (*Authorization, string, string) {
+		authorization, err := svc.Begin(context.Background(), mockProviderName)
+		if err != nil {
+			t.Fatalf("failed to begin sign-in (%s)", err)
+		}
+
+		code, state, err := server.Authorize(authorization.URL)
+		if err != nil {
+			t.Fatalf("failed to authorize (%s)", err)
+		}
+
+		return authorization, code, state
+	}
+
+	t.Run("fails to begin with unknown provider", func(t *testing.T) {
+		svc := newService(&mockSessionService{})
+
+		if _, err := svc.Begin(context.Background(), "facemoose"); err != ErrUnknownProvider {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to complete with unknown provider", func(t *testing.T) {
+		svc := newService(&mockSessionService{})
+		authorization, code, state := begin(svc)
+
+		if _, err := svc.Complete(context.Background(), "facemoose", authorization.SealedState, state, code); err != ErrUnknownProvider {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with invalid state when sealed state was tampered with", func(t *testing.T) {
+		svc := newService(&mockSessionService{})
+		_, code, state := begin(svc)
+
+		if _, err := svc.Complete(context.Background(), mockProviderName, "tampered", state, code); err != ErrInvalidState {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with invalid state when state does not match", func(t *testing.T) {
+		svc := newService(&mockSessionService{})
+		authorization, code, _ := begin(svc)
+
+		if _, err := svc.Complete(context.Background(), mockProviderName, authorization.SealedState, "another.state", code); err != ErrInvalidState {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with invalid state when it has expired", func(t *testing.T) {
+		svc := newService(&mockSessionService{})
+		authorization, code, state := begin(svc)
+		svc.(*service).now = func() time.Time { return time.Now().Add(StateTTL) }
+
+		if _, err := svc.Complete(context.Background(), mockProviderName, authorization.SealedState, state, code); err != ErrInvalidState {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with authentication failed when code is invalid", func(t *testing.T) {
+		svc := newService(&mockSessionService{})
+		authorization, _, state := begin(svc)
+
+		if _, err := svc.Complete(context.Background(), mockProviderName, authorization.SealedState, state, "an.invalid.code"); err != ErrAuthenticationFailed {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with identity conflict when session service reports one", func(t *testing.T) {
+		svc := newService(&mockSessionService{failOnLogin: true})
+		authorization, code, state := begin(svc)
+
+		if _, err := svc.Complete(context.Background(), mockProviderName, authorization.SealedState, state, code); err != user.ErrIdentityConflict {
+			t.Fail()
+		}
+	})
+
+	t.Run("logs in with the identity of the user when all is well", func(t *testing.T) {
+		sessionSvc := &mockSessionService{}
+		svc := newService(sessionSvc)
+		authorization, code, state := begin(svc)
+
+		result, err := svc.Complete(context.Background(), mockProviderName, authorization.SealedState, state, code)
+		if err != nil || result.Session == nil {
+			t.FailNow()
+		}
+
+		identity := sessionSvc.identity
+		if strings.Compare(mockProviderName, identity.Provider) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(mockUser.Subject, identity.Subject) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(mockUser.PreferredUsername, identity.Username) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(mockUser.Email, identity.Email) != 0 || !identity.EmailVerified {
+			t.Fail()
+		}
+	})
+}
+
+const mockUserID = "mock.user.id"
+
+type mockSessionService struct {
+	failOnLogin      bool
+	twoFactorEnabled bool
+
+	identity user.ExternalIdentity
+}
+
+func (mock *mockSessionService) Login(_ context.Context, email string, password string) (*session.Result, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockSessionService) LoginWithIdentity(_ context.Context, identity user.ExternalIdentity) (*session.Result, error) {
+	if mock.failOnLogin {
+		return nil, user.ErrIdentityConflict
+	}
+
+	mock.identity = identity
+
+	if mock.twoFactorEnabled {
+		return &session.Result{
+			Challenge: &session.Challenge{
+				MFAToken:  "an.mfa.token",
+				ExpiresAt: time.Now().Add(time.Minute),
+			},
+		}, nil
+	}
+
+	return &session.Result{
+		Session: &session.Session{
+			UserID:      mockUserID,
+			AccessToken: "an.access.token",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	}, nil
+}
+
+func (mock *mockSessionService) CompleteChallenge(_ context.Context, mfaToken string, code string) (*session.Session, error) {
+	return nil, fmt.Errorf("not implemented")
+}
@@ -0,0 +1,142 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+// stateCookieName is the name of the cookie that keeps the sealed state in
+// the user's browser while they sign in with the provider.
+const stateCookieName = "oidc_state"
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	beginHandler := stmhttp.NewHandler(
+		wrap("oidc.begin")(makeBeginEndpoint(svc)),
+		decodeBeginRequest,
+		encodeBeginResponse,
+		encodeError,
+	)
+
+	completeHandler := stmhttp.NewHandler(
+		wrap("oidc.complete")(makeCompleteEndpoint(svc)),
+		decodeCompleteRequest,
+		encodeCompleteResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/auth/{provider}/login", beginHandler).Methods("GET")
+	r.Handle("/v1/auth/{provider}/callback", completeHandler).Methods("GET")
+
+	return r
+}
+
+func decodeBeginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	provider, ok := mux.Vars(r)["provider"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return beginRequest{
+		Provider: provider,
+	}, nil
+}
+
+func encodeBeginResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(*beginResponse)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    resp.SealedState,
+		Path:     callbackPath(resp.Provider),
+		MaxAge:   int(StateTTL.Seconds()),
+		HttpOnly: true,
+		// The provider redirects the user back with a top-level navigation,
+		// which carries lax cookies.
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.Header().Set("Location", resp.URL)
+	w.WriteHeader(http.StatusFound)
+
+	return nil
+}
+
+func decodeCompleteRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	provider, ok := mux.Vars(r)["provider"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	query := r.URL.Query()
+
+	// The provider reports that the user refused to sign in, or that it could
+	// not authenticate them, with an error instead of a code.
+	if query.Get("error") != "" {
+		return nil, ErrAuthenticationFailed
+	}
+
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+
+	return completeRequest{
+		Provider:    provider,
+		SealedState: cookie.Value,
+		State:       query.Get("state"),
+		Code:        query.Get("code"),
+	}, nil
+}
+
+func encodeCompleteResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(*completeResponse)
+
+	// The state can only be used once.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Path:     callbackPath(resp.provider),
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return encodeResponse(ctx, w, response)
+}
+
+func callbackPath(provider string) string {
+	return fmt.Sprintf("/v1/auth/%s/callback", provider)
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrUnknownProvider:
+		return http.StatusNotFound
+	case ErrInvalidState:
+		return http.StatusBadRequest
+	case ErrAuthenticationFailed:
+		return http.StatusUnauthorized
+	case user.ErrSuspended:
+		return http.StatusForbidden
+	case user.ErrIdentityConflict:
+		return http.StatusConflict
+	}
+
+	return 0
+})
@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/oidc/oidctest"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns a handler when all is well", func(t *testing.T) {
+		svc, _ := NewService(nil, &mockSessionService{}, cipher)
+
+		if handler := MakeHandler(svc); handler == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestSigningInThroughHandler(t *testing.T) {
+	server := oidctest.NewServer(mockUser)
+	defer server.Close()
+
+	svc, _ := NewService([]*Provider{newMockProvider(t, server)}, &mockSessionService{}, cipher)
+	handler := MakeHandler(svc)
+
+	t.Run("responds with not found when provider is unknown", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/auth/facemoose/login", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("redirects to provider and back, and responds with a session", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/auth/moosebook/login", nil))
+
+		if rr.Code != http.StatusFound {
+			t.FailNow()
+		}
+
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 || !cookies[0].HttpOnly {
+			t.FailNow()
+		}
+		if strings.Compare("/v1/auth/moosebook/callback", cookies[0].Path) != 0 {
+			t.Fail()
+		}
+
+		code, state, err := server.Authorize(rr.Header().Get("Location"))
+		if err != nil {
+			t.FailNow()
+		}
+
+		callback := httptest.NewRequest("GET", fmt.Sprintf("/v1/auth/moosebook/callback?code=%s&state=%s", code, state), nil)
+		callback.AddCookie(cookies[0])
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, callback)
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+		if !strings.Contains(rr.Body.String(), "an.access.token") {
+			t.Fail()
+		}
+
+		cleared := rr.Result().Cookies()
+		if len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with bad request when state cookie is missing", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/auth/moosebook/callback?code=a.code&state=a.state", nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with unauthorized when provider reports an error", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/auth/moosebook/callback?error=access_denied", nil))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		ErrUnknownProvider:        http.StatusNotFound,
+		ErrInvalidState:           http.StatusBadRequest,
+		ErrAuthenticationFailed:   http.StatusUnauthorized,
+		user.ErrSuspended:         http.StatusForbidden,
+		user.ErrIdentityConflict:  http.StatusConflict,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Package pagination reads and checks the offset and limit of the pages of
+// listings, which every package that lists things does the same way.
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// DefaultLimit is the number of items listed when no limit is given.
+const DefaultLimit = 20
+
+// NewError returns the error listings fail with when their offset is negative,
+// or their limit is not between 1 and the maximum.
+func NewError(maxLimit int) error {
+	return fmt.Errorf("limit must be between 1 and %d, and offset cannot be negative", maxLimit)
+}
+
+// Valid tells whether the offset is not negative, and whether the limit is
+// between 1 and the maximum.
+func Valid(offset int, limit int, maxLimit int) bool {
+	return offset >= 0 && limit >= 1 && limit <= maxLimit
+}
+
+// FromQuery reads the offset and limit query parameters, which default to
+// zero and DefaultLimit, and fails with the given error when either is not a
+// number. Their bounds are left for services to check.
+func FromQuery(query url.Values, invalid error) (offset int, limit int, err error) {
+	offset, limit = 0, DefaultLimit
+
+	if value := query.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, invalid
+		}
+	}
+
+	return offset, limit, nil
+}
@@ -0,0 +1,45 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+var errMockInvalid = errors.New("pagination is invalid")
+
+func TestReadingFromQuery(t *testing.T) {
+	t.Run("defaults to the first page", func(t *testing.T) {
+		offset, limit, err := FromQuery(url.Values{}, errMockInvalid)
+		if err != nil || offset != 0 || limit != DefaultLimit {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with the given error when offset or limit is not a number", func(t *testing.T) {
+		for _, query := range []url.Values{{"offset": {"first"}}, {"limit": {"ten"}}} {
+			if _, _, err := FromQuery(query, errMockInvalid); err != errMockInvalid {
+				t.Errorf("expected error for %v", query)
+			}
+		}
+	})
+
+	t.Run("reads offset and limit, without checking their bounds", func(t *testing.T) {
+		offset, limit, err := FromQuery(url.Values{"offset": {"-1"}, "limit": {"1000"}}, errMockInvalid)
+		if err != nil || offset != -1 || limit != 1000 {
+			t.Fail()
+		}
+	})
+}
+
+func TestValidating(t *testing.T) {
+	for _, p := range [][2]int{{-1, 20}, {0, 0}, {0, 101}} {
+		if Valid(p[0], p[1], 100) {
+			t.Errorf("expected offset %d and limit %d to be invalid", p[0], p[1])
+		}
+	}
+
+	if !Valid(0, 100, 100) {
+		t.Fail()
+	}
+}
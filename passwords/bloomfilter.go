@@ -0,0 +1,68 @@
+package passwords
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomFilter is a compact set that can tell for sure that it does not contain
+// a value, but may be wrong, at the given false positive rate, when it says
+// that it does.
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// newBloomFilter creates a bloom filter sized to hold the given number of
+// values with the given false positive rate.
+func newBloomFilter(capacity int, falsePositiveRate float64) *bloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	n := float64(capacity)
+	size := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/n*math.Ln2)))
+
+	return &bloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+func (bf *bloomFilter) Add(value string) {
+	h1, h2 := bf.hash(value)
+
+	for i := uint64(0); i < bf.hashes; i++ {
+		bit := (h1 + i*h2) % bf.size
+		bf.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (bf *bloomFilter) Contains(value string) bool {
+	h1, h2 := bf.hash(value)
+
+	for i := uint64(0); i < bf.hashes; i++ {
+		bit := (h1 + i*h2) % bf.size
+		if bf.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hash derives the two hashes used to simulate the filter's k hash functions,
+// as described by Kirsch and Mitzenmacher in "Less Hashing, Same Performance".
+func (bf *bloomFilter) hash(value string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	h1 := h.Sum64()
+
+	h.Write([]byte{0})
+	h2 := h.Sum64() | 1
+
+	return h1, h2
+}
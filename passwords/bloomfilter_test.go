@@ -0,0 +1,55 @@
+package passwords
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBloomFilterCreation(t *testing.T) {
+	t.Run("sizes the filter for the capacity and false positive rate", func(t *testing.T) {
+		bf := newBloomFilter(1000, 0.01)
+
+		// About 9.6 bits per value and 7 hash functions are needed for a 1%
+		// false positive rate.
+		if bf.size != 9586 {
+			t.Fail()
+		}
+		if bf.hashes != 7 {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses a capacity of one when capacity is zero", func(t *testing.T) {
+		if bf := newBloomFilter(0, 0.01); bf.size == 0 || bf.hashes == 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBloomFilterMembership(t *testing.T) {
+	bf := newBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		bf.Add("value" + strconv.Itoa(i))
+	}
+
+	t.Run("contains the values that were added", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			if !bf.Contains("value" + strconv.Itoa(i)) {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("rarely contains values that were not added", func(t *testing.T) {
+		falsePositives := 0
+		for i := 0; i < 10000; i++ {
+			if bf.Contains("other" + strconv.Itoa(i)) {
+				falsePositives++
+			}
+		}
+
+		if falsePositives > 200 {
+			t.Fail()
+		}
+	})
+}
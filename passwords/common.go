@@ -0,0 +1,63 @@
+// Package passwords knows about passwords that are too common to be used, since
+// they are the first ones attackers try.
+package passwords
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The list of common passwords comes from the zxcvbn project
+// (https://github.com/dropbox/zxcvbn), which compiled it from passwords
+// exposed in data breaches. It is lower case, and has one password per line.
+//
+//go:embed common.txt
+var commonPasswordList string
+
+const falsePositiveRate = 0.001
+
+var (
+	commonPasswords     *bloomFilter
+	commonPasswordsOnce sync.Once
+)
+
+// IsCommon tells whether the password, or the word it is built around, is a
+// common password.
+//
+// The comparison ignores case, as well as the digits and special characters
+// at the start and end of the password, so that variations such as
+// "Password1!" are caught.
+//
+// It may wrongly report a password as common, but only about once in a
+// thousand times.
+func IsCommon(password string) bool {
+	commonPasswordsOnce.Do(loadCommonPasswords)
+
+	normalized := strings.ToLower(password)
+	if commonPasswords.Contains(normalized) {
+		return true
+	}
+
+	word := strings.TrimFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if word != "" && word != normalized && commonPasswords.Contains(word) {
+		return true
+	}
+
+	return false
+}
+
+func loadCommonPasswords() {
+	commonPasswords = newBloomFilter(strings.Count(commonPasswordList, "\n"), falsePositiveRate)
+
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordList))
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			commonPasswords.Add(password)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package passwords
+
+import "testing"
+
+func TestCommonPasswordDetection(t *testing.T) {
+	t.Run("detects common passwords", func(t *testing.T) {
+		for _, password := range []string{"password", "letmein", "trustno1"} {
+			if !IsCommon(password) {
+				t.Errorf("expected %q to be common", password)
+			}
+		}
+	})
+
+	t.Run("ignores case", func(t *testing.T) {
+		if !IsCommon("LetMeIn") {
+			t.Fail()
+		}
+	})
+
+	t.Run("ignores digits and special characters around common passwords", func(t *testing.T) {
+		for _, password := range []string{"Password1!", "!Password", "Football2019"} {
+			if !IsCommon(password) {
+				t.Errorf("expected %q to be common", password)
+			}
+		}
+	})
+
+	t.Run("does not detect uncommon passwords", func(t *testing.T) {
+		for _, password := range []string{"P@ssw0rd", "Ant1ers&Hooves!", "wealthy.influential.moose"} {
+			if IsCommon(password) {
+				t.Errorf("expected %q not to be common", password)
+			}
+		}
+	})
+}
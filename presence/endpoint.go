@@ -0,0 +1,129 @@
+package presence
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type presenceResponse struct {
+	UserID     string     `json:"userId"`
+	Status     Status     `json:"status"`
+	Devices    int        `json:"devices"`
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+}
+
+func newPresenceResponse(p Presence) presenceResponse {
+	resp := presenceResponse{
+		UserID:  p.UserID,
+		Status:  p.Status,
+		Devices: p.Devices,
+	}
+
+	if !p.LastSeenAt.IsZero() {
+		resp.LastSeenAt = &p.LastSeenAt
+	}
+
+	return resp
+}
+
+type getPresenceRequest struct {
+	UserID string
+}
+
+func makeGetPresenceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getPresenceRequest)
+
+		identity, ok := auth.FromContext(ctx)
+		if !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		p, err := svc.Get(ctx, identity.UserID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := newPresenceResponse(*p)
+
+		return &resp, nil
+	}
+}
+
+type listFriendsRequest struct {
+	UserID string
+}
+
+type listFriendsResponse struct {
+	Friends []presenceResponse `json:"friends"`
+}
+
+func makeListFriendsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listFriendsRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		presences, err := svc.ListFriends(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		friends := make([]presenceResponse, 0, len(presences))
+		for _, p := range presences {
+			friends = append(friends, newPresenceResponse(p))
+		}
+
+		return &listFriendsResponse{
+			Friends: friends,
+		}, nil
+	}
+}
+
+type heartbeatRequest struct {
+	UserID   string
+	DeviceID string
+	Status   Status
+}
+
+func makeHeartbeatEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(heartbeatRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.Heartbeat(ctx, req.UserID, req.DeviceID, req.Status); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+type disconnectRequest struct {
+	UserID   string
+	DeviceID string
+}
+
+func makeDisconnectEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(disconnectRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.Disconnect(ctx, req.UserID, req.DeviceID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
@@ -0,0 +1,92 @@
+package presence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func userContext() context.Context {
+	return auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
+}
+
+func TestGetPresenceEndpoint(t *testing.T) {
+	t.Run("fails when caller is anonymous", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		endpoint := makeGetPresenceEndpoint(svc)
+
+		if _, err := endpoint(context.Background(), getPresenceRequest{UserID: mockFriendID}); err != auth.ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when caller cannot see the user's presence", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		endpoint := makeGetPresenceEndpoint(svc)
+
+		if _, err := endpoint(userContext(), getPresenceRequest{UserID: "a.stranger"}); err != ErrHidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("omits last seen time when user has not been seen", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		endpoint := makeGetPresenceEndpoint(svc)
+
+		resp, err := endpoint(userContext(), getPresenceRequest{UserID: mockFriendID})
+		if err != nil {
+			t.FailNow()
+		}
+
+		presence := resp.(*presenceResponse)
+		if presence.Status != StatusOffline || presence.LastSeenAt != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestHeartbeatEndpoint(t *testing.T) {
+	req := heartbeatRequest{UserID: mockUserID, DeviceID: mockDeviceID, Status: StatusOnline}
+
+	t.Run("fails when caller is not the user", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		endpoint := makeHeartbeatEndpoint(svc)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockFriendID})
+
+		if _, err := endpoint(ctx, req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("makes the user online when all is well", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		endpoint := makeHeartbeatEndpoint(svc)
+
+		if _, err := endpoint(userContext(), req); err != nil {
+			t.FailNow()
+		}
+
+		if p, _ := svc.Get(context.Background(), mockUserID, mockUserID); p.Status != StatusOnline {
+			t.Fail()
+		}
+	})
+}
+
+func TestListFriendsEndpoint(t *testing.T) {
+	t.Run("returns the friends' presence when all is well", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusInGame)
+		endpoint := makeListFriendsEndpoint(svc)
+
+		resp, err := endpoint(userContext(), listFriendsRequest{UserID: mockUserID})
+		if err != nil {
+			t.FailNow()
+		}
+
+		friends := resp.(*listFriendsResponse).Friends
+		if len(friends) != 1 || friends[0].Status != StatusInGame {
+			t.Fail()
+		}
+	})
+}
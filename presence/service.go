@@ -0,0 +1,400 @@
+// Package presence tracks which users are connected, and what they are doing,
+// so that their friends can see it.
+package presence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/social"
+)
+
+const (
+	// DefaultTTL is how long a device is considered connected after its last
+	// heartbeat, when none is configured.
+	DefaultTTL = time.Minute
+
+	// maxDeviceIDLength prevents clients from making the service remember
+	// arbitrarily long device IDs.
+	maxDeviceIDLength = 64
+
+	// subscriptionBufferSize is how many changes can be waiting to be sent to
+	// a subscriber before new ones are dropped.
+	subscriptionBufferSize = 32
+)
+
+var (
+	ErrInvalidStatus   = errors.New("status must be \"online\", \"in_lobby\" or \"in_game\"")
+	ErrInvalidDeviceID = errors.New("device ID must be between 1 and 64 characters long")
+	ErrHidden          = errors.New("presence is only visible to the user and their friends")
+)
+
+// Status represents what a user is doing.
+type Status string
+
+const (
+	StatusOffline Status = "offline"
+	StatusOnline  Status = "online"
+	StatusInLobby Status = "in_lobby"
+	StatusInGame  Status = "in_game"
+)
+
+// priority ranks statuses, so that a user connected from several devices has
+// the status of the one that is the most engaged.
+var priority = map[Status]int{
+	StatusOffline: 0,
+	StatusOnline:  1,
+	StatusInLobby: 2,
+	StatusInGame:  3,
+}
+
+// Presence represents the status of a user across all of their devices.
+type Presence struct {
+	UserID  string
+	Status  Status
+	Devices int
+
+	// LastSeenAt is when the user last sent a heartbeat from any device, and
+	// is zero if they have not been seen since the service started.
+	LastSeenAt time.Time
+}
+
+// Change represents a user's status changing.
+type Change struct {
+	UserID string
+	Status Status
+	At     time.Time
+}
+
+type Config struct {
+	// TTL is how long a device is considered connected after its last
+	// heartbeat.
+	TTL time.Duration
+}
+
+type Service interface {
+	// Heartbeat records that the user is connected from the device with the
+	// status, until the TTL elapses without another heartbeat.
+	Heartbeat(ctx context.Context, userID string, deviceID string, status Status) error
+
+	// Disconnect forgets the device right away, rather than waiting for the
+	// TTL to elapse.
+	Disconnect(ctx context.Context, userID string, deviceID string) error
+
+	// Get returns the presence of the user, as seen by the viewer, who must
+	// be the user or one of their friends.
+	Get(ctx context.Context, viewerID string, userID string) (*Presence, error)
+
+	// ListFriends returns the presence of the user's friends.
+	ListFriends(ctx context.Context, userID string) ([]Presence, error)
+
+	// Subscribe returns the changes to the status of the user's friends, as
+	// they happen, until the subscription is cancelled.
+	//
+	// Changes are dropped when the subscriber is too slow to receive them.
+	Subscribe(userID string) (<-chan Change, func())
+
+	// Expire disconnects the devices whose TTL elapsed, and notifies friends
+	// of their users' new status.
+	Expire(ctx context.Context)
+
+	// Run expires devices at the interval until the context is done.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type device struct {
+	status    Status
+	expiresAt time.Time
+}
+
+type subscription struct {
+	userID  string
+	changes chan Change
+}
+
+type service struct {
+	socialSvc social.Service
+	conf      Config
+	now       func() time.Time
+
+	mu      sync.Mutex
+	devices map[string]map[string]device
+	// statuses are the statuses friends were last notified of, for users who
+	// are not offline.
+	statuses      map[string]Status
+	lastSeen      map[string]time.Time
+	subscriptions map[string]map[*subscription]struct{}
+}
+
+func NewService(socialSvc social.Service, conf Config) (Service, error) {
+	if socialSvc == nil {
+		return nil, fmt.Errorf("presence.NewService: social service is required")
+	}
+
+	if conf.TTL == 0 {
+		conf.TTL = DefaultTTL
+	}
+
+	return &service{
+		socialSvc:     socialSvc,
+		conf:          conf,
+		now:           time.Now,
+		devices:       make(map[string]map[string]device),
+		statuses:      make(map[string]Status),
+		lastSeen:      make(map[string]time.Time),
+		subscriptions: make(map[string]map[*subscription]struct{}),
+	}, nil
+}
+
+func (svc *service) Heartbeat(ctx context.Context, userID string, deviceID string, status Status) error {
+	if status != StatusOnline && status != StatusInLobby && status != StatusInGame {
+		return ErrInvalidStatus
+	}
+
+	if deviceID == "" || len(deviceID) > maxDeviceIDLength {
+		return ErrInvalidDeviceID
+	}
+
+	now := svc.now()
+
+	svc.mu.Lock()
+	devices, ok := svc.devices[userID]
+	if !ok {
+		devices = make(map[string]device)
+		svc.devices[userID] = devices
+	}
+	devices[deviceID] = device{
+		status:    status,
+		expiresAt: now.Add(svc.conf.TTL),
+	}
+	svc.lastSeen[userID] = now
+
+	change := svc.refresh(userID, now)
+	svc.mu.Unlock()
+
+	if change != nil {
+		if err := svc.notify(ctx, *change); err != nil {
+			return fmt.Errorf("presence.Service.Heartbeat: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (svc *service) Disconnect(ctx context.Context, userID string, deviceID string) error {
+	now := svc.now()
+
+	svc.mu.Lock()
+	delete(svc.devices[userID], deviceID)
+	if len(svc.devices[userID]) == 0 {
+		delete(svc.devices, userID)
+	}
+
+	change := svc.refresh(userID, now)
+	svc.mu.Unlock()
+
+	if change != nil {
+		if err := svc.notify(ctx, *change); err != nil {
+			return fmt.Errorf("presence.Service.Disconnect: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (svc *service) Get(ctx context.Context, viewerID string, userID string) (*Presence, error) {
+	if viewerID != userID {
+		friends, err := svc.socialSvc.AreFriends(ctx, viewerID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("presence.Service.Get: %s", err)
+		}
+		if !friends {
+			return nil, ErrHidden
+		}
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	p := svc.presence(userID, svc.now())
+
+	return &p, nil
+}
+
+func (svc *service) ListFriends(ctx context.Context, userID string) ([]Presence, error) {
+	friends, err := svc.socialSvc.ListFriends(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("presence.Service.ListFriends: %s", err)
+	}
+
+	now := svc.now()
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	presences := make([]Presence, 0, len(friends))
+	for _, f := range friends {
+		presences = append(presences, svc.presence(f.UserID, now))
+	}
+
+	return presences, nil
+}
+
+func (svc *service) Subscribe(userID string) (<-chan Change, func()) {
+	sub := &subscription{
+		userID:  userID,
+		changes: make(chan Change, subscriptionBufferSize),
+	}
+
+	svc.mu.Lock()
+	if _, ok := svc.subscriptions[userID]; !ok {
+		svc.subscriptions[userID] = make(map[*subscription]struct{})
+	}
+	svc.subscriptions[userID][sub] = struct{}{}
+	svc.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			svc.mu.Lock()
+			defer svc.mu.Unlock()
+
+			delete(svc.subscriptions[userID], sub)
+			if len(svc.subscriptions[userID]) == 0 {
+				delete(svc.subscriptions, userID)
+			}
+
+			close(sub.changes)
+		})
+	}
+
+	return sub.changes, cancel
+}
+
+func (svc *service) Expire(ctx context.Context) {
+	now := svc.now()
+
+	var changes []Change
+
+	svc.mu.Lock()
+	for userID, devices := range svc.devices {
+		for deviceID, d := range devices {
+			if !now.Before(d.expiresAt) {
+				delete(devices, deviceID)
+			}
+		}
+		if len(devices) == 0 {
+			delete(svc.devices, userID)
+		}
+	}
+
+	for userID := range svc.statuses {
+		if change := svc.refresh(userID, now); change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	svc.mu.Unlock()
+
+	// Friends who miss a change because it could not be sent see the new
+	// status the next time they get the user's presence.
+	for _, c := range changes {
+		svc.notify(ctx, c)
+	}
+}
+
+func (svc *service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			svc.Expire(ctx)
+		}
+	}
+}
+
+// status returns the status of the user's most engaged device that has not
+// expired. It must be called with the lock held.
+func (svc *service) status(userID string, now time.Time) Status {
+	status := StatusOffline
+
+	for _, d := range svc.devices[userID] {
+		if now.Before(d.expiresAt) && priority[d.status] > priority[status] {
+			status = d.status
+		}
+	}
+
+	return status
+}
+
+// refresh records the user's status, and returns the change friends must be
+// notified of, if it changed since they were last notified. It must be called
+// with the lock held.
+func (svc *service) refresh(userID string, now time.Time) *Change {
+	last, ok := svc.statuses[userID]
+	if !ok {
+		last = StatusOffline
+	}
+
+	status := svc.status(userID, now)
+	if status == last {
+		return nil
+	}
+
+	if status == StatusOffline {
+		delete(svc.statuses, userID)
+	} else {
+		svc.statuses[userID] = status
+	}
+
+	return &Change{
+		UserID: userID,
+		Status: status,
+		At:     now,
+	}
+}
+
+// presence must be called with the lock held.
+func (svc *service) presence(userID string, now time.Time) Presence {
+	p := Presence{
+		UserID:     userID,
+		Status:     svc.status(userID, now),
+		LastSeenAt: svc.lastSeen[userID],
+	}
+
+	for _, d := range svc.devices[userID] {
+		if now.Before(d.expiresAt) {
+			p.Devices++
+		}
+	}
+
+	return p
+}
+
+// notify sends the change to the user's friends who subscribed to changes.
+func (svc *service) notify(ctx context.Context, change Change) error {
+	friends, err := svc.socialSvc.ListFriends(ctx, change.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list friends to notify (%s)", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for _, f := range friends {
+		for sub := range svc.subscriptions[f.UserID] {
+			select {
+			case sub.changes <- change:
+			default:
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package presence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/social"
+)
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when social service is missing", func(t *testing.T) {
+		if _, err := NewService(nil, Config{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses default TTL when it is zero", func(t *testing.T) {
+		svc, _ := NewService(&mockSocialService{}, Config{})
+
+		if svc.(*service).conf.TTL != DefaultTTL {
+			t.Fail()
+		}
+	})
+}
+
+// newService returns a service whose clock only moves when the returned
+// function is called.
+func newService(socialSvc social.Service) (*service, func(time.Duration)) {
+	svc, _ := NewService(socialSvc, Config{TTL: time.Minute})
+
+	now := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	svc.(*service).now = func() time.Time { return now }
+
+	return svc.(*service), func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestServiceHeartbeat(t *testing.T) {
+	t.Run("fails when status is invalid", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+
+		if err := svc.Heartbeat(context.Background(), mockUserID, mockDeviceID, StatusOffline); err != ErrInvalidStatus {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when device ID is too long", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+
+		if err := svc.Heartbeat(context.Background(), mockUserID, strings.Repeat("d", 65), StatusOnline); err != ErrInvalidDeviceID {
+			t.Fail()
+		}
+	})
+
+	t.Run("makes the user online until the TTL elapses", func(t *testing.T) {
+		svc, advance := newService(&mockSocialService{})
+
+		svc.Heartbeat(context.Background(), mockUserID, mockDeviceID, StatusOnline)
+
+		if p, _ := svc.Get(context.Background(), mockUserID, mockUserID); p.Status != StatusOnline || p.Devices != 1 {
+			t.Fail()
+		}
+
+		advance(time.Minute)
+
+		p, _ := svc.Get(context.Background(), mockUserID, mockUserID)
+		if p.Status != StatusOffline || p.Devices != 0 {
+			t.Fail()
+		}
+		if p.LastSeenAt.IsZero() {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses the status of the most engaged device", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+
+		svc.Heartbeat(context.Background(), mockUserID, "phone", StatusInGame)
+		svc.Heartbeat(context.Background(), mockUserID, "desktop", StatusOnline)
+
+		if p, _ := svc.Get(context.Background(), mockUserID, mockUserID); p.Status != StatusInGame || p.Devices != 2 {
+			t.Fail()
+		}
+
+		svc.Disconnect(context.Background(), mockUserID, "phone")
+
+		if p, _ := svc.Get(context.Background(), mockUserID, mockUserID); p.Status != StatusOnline || p.Devices != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when friends cannot be listed to notify them", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{fail: true})
+
+		if err := svc.Heartbeat(context.Background(), mockUserID, mockDeviceID, StatusOnline); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceGet(t *testing.T) {
+	t.Run("fails when viewer is not a friend", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+
+		if _, err := svc.Get(context.Background(), "a.stranger", mockUserID); err != ErrHidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns presence to friends", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusInLobby)
+
+		p, err := svc.Get(context.Background(), mockUserID, mockFriendID)
+		if err != nil || p.Status != StatusInLobby {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceNotifications(t *testing.T) {
+	receive := func(changes <-chan Change) *Change {
+		select {
+		case c := <-changes:
+			return &c
+		default:
+			return nil
+		}
+	}
+
+	t.Run("notifies friends when status changes", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		changes, cancel := svc.Subscribe(mockUserID)
+		defer cancel()
+
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusOnline)
+
+		c := receive(changes)
+		if c == nil || c.UserID != mockFriendID || c.Status != StatusOnline {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not notify friends when status stays the same", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		changes, cancel := svc.Subscribe(mockUserID)
+		defer cancel()
+
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusOnline)
+		receive(changes)
+		svc.Heartbeat(context.Background(), mockFriendID, "another.device", StatusOnline)
+
+		if receive(changes) != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not notify users who are not friends", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		changes, cancel := svc.Subscribe("a.stranger")
+		defer cancel()
+
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusOnline)
+
+		if receive(changes) != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("notifies friends when devices expire", func(t *testing.T) {
+		svc, advance := newService(&mockSocialService{})
+		changes, cancel := svc.Subscribe(mockUserID)
+		defer cancel()
+
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusInGame)
+		receive(changes)
+
+		advance(time.Minute)
+		svc.Expire(context.Background())
+
+		c := receive(changes)
+		if c == nil || c.Status != StatusOffline {
+			t.FailNow()
+		}
+		if len(svc.devices) != 0 || len(svc.statuses) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("closes changes when subscription is cancelled", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		changes, cancel := svc.Subscribe(mockUserID)
+
+		cancel()
+		cancel()
+
+		if _, ok := <-changes; ok {
+			t.Fail()
+		}
+		if len(svc.subscriptions) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+const (
+	mockUserID   = "mock.user.id"
+	mockFriendID = "mock.friend.id"
+	mockDeviceID = "mock.device.id"
+)
+
+// mockSocialService considers that the mock user and the mock friend are
+// friends, and that no one else is.
+type mockSocialService struct {
+	fail bool
+}
+
+func (mock *mockSocialService) friendOf(userID string) string {
+	switch userID {
+	case mockUserID:
+		return mockFriendID
+	case mockFriendID:
+		return mockUserID
+	default:
+		return ""
+	}
+}
+
+func (mock *mockSocialService) SendRequest(_ context.Context, fromUserID string, toUserID string) (bool, error) {
+	return false, fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) AcceptRequest(_ context.Context, userID string, fromUserID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) DeclineRequest(_ context.Context, userID string, fromUserID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) CancelRequest(_ context.Context, userID string, toUserID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) ListRequests(_ context.Context, userID string) (*social.Requests, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) ListFriends(_ context.Context, userID string) ([]social.Peer, error) {
+	if mock.fail {
+		return nil, fmt.Errorf("failed to list friends")
+	}
+
+	friendID := mock.friendOf(userID)
+	if friendID == "" {
+		return []social.Peer{}, nil
+	}
+
+	return []social.Peer{{UserID: friendID}}, nil
+}
+
+func (mock *mockSocialService) AreFriends(_ context.Context, userID string, otherUserID string) (bool, error) {
+	if mock.fail {
+		return false, fmt.Errorf("failed to check friendship")
+	}
+
+	return mock.friendOf(userID) == otherUserID && otherUserID != "", nil
+}
+
+func (mock *mockSocialService) RemoveFriend(_ context.Context, userID string, friendID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) Block(_ context.Context, userID string, blockedID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) Unblock(_ context.Context, userID string, blockedID string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) ListBlocked(_ context.Context, userID string) ([]social.Peer, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockSocialService) IsBlocked(_ context.Context, userID string, otherUserID string) (bool, error) {
+	return false, fmt.Errorf("not implemented")
+}
@@ -0,0 +1,236 @@
+package presence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/server"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+// keepAliveInterval is how often a comment is sent to subscribers when there
+// are no changes, so that proxies do not close idle connections.
+const keepAliveInterval = 30 * time.Second
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	getPresenceHandler := stmhttp.NewHandler(
+		wrap("presence.getPresence")(makeGetPresenceEndpoint(svc)),
+		decodeGetPresenceRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	listFriendsHandler := stmhttp.NewHandler(
+		wrap("presence.listFriends")(makeListFriendsEndpoint(svc)),
+		decodeListFriendsRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	heartbeatHandler := stmhttp.NewHandler(
+		wrap("presence.heartbeat")(makeHeartbeatEndpoint(svc)),
+		decodeHeartbeatRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	disconnectHandler := stmhttp.NewHandler(
+		wrap("presence.disconnect")(makeDisconnectEndpoint(svc)),
+		decodeDisconnectRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/users/{id}/presence", getPresenceHandler).Methods("GET")
+	r.Handle("/v1/users/{id}/presence/friends", listFriendsHandler).Methods("GET")
+	r.Handle("/v1/users/{id}/presence/events", &eventsHandler{svc}).Methods("GET")
+	r.Handle("/v1/users/{id}/presence/devices/{deviceId}", heartbeatHandler).Methods("PUT")
+	r.Handle("/v1/users/{id}/presence/devices/{deviceId}", disconnectHandler).Methods("DELETE")
+
+	return r
+}
+
+func decodeGetPresenceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return getPresenceRequest{
+		UserID: id,
+	}, nil
+}
+
+func decodeListFriendsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return listFriendsRequest{
+		UserID: id,
+	}, nil
+}
+
+func decodeHeartbeatRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+
+	id, ok := vars["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	deviceID, ok := vars["deviceId"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		Status Status `json:"status"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return heartbeatRequest{
+		UserID:   id,
+		DeviceID: deviceID,
+		Status:   body.Status,
+	}, nil
+}
+
+func decodeDisconnectRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+
+	id, ok := vars["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	deviceID, ok := vars["deviceId"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return disconnectRequest{
+		UserID:   id,
+		DeviceID: deviceID,
+	}, nil
+}
+
+// eventsHandler streams the changes to the status of a user's friends as
+// server-sent events, starting with their current status.
+//
+// It does not fit the request and response model of endpoints, so it is a
+// plain handler.
+type eventsHandler struct {
+	svc Service
+}
+
+type changeEvent struct {
+	UserID string    `json:"userId"`
+	Status Status    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := mux.Vars(r)["id"]
+
+	if err := auth.RequireUser(ctx, userID); err != nil {
+		encodeError(ctx, w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		encodeError(ctx, w, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	// Subscribing before listing friends ensures that no change is missed in
+	// between, at the cost of possibly sending a status twice.
+	changes, cancel := h.svc.Subscribe(userID)
+	defer cancel()
+
+	friends, err := h.svc.ListFriends(ctx, userID)
+	if err != nil {
+		encodeError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	now := time.Now()
+	for _, f := range friends {
+		writeEvent(w, changeEvent{UserID: f.UserID, Status: f.Status, At: now})
+	}
+	flusher.Flush()
+
+	// The stream ends when the server shuts down, or before its connection
+	// times out, and clients reconnect.
+	ctx, cancelStream := server.StreamContext(ctx)
+	defer cancelStream()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+
+			writeEvent(w, changeEvent{UserID: change.UserID, Status: change.Status, At: change.At})
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event changeEvent) {
+	data, _ := json.Marshal(event)
+
+	fmt.Fprintf(w, "event: presence\ndata: %s\n\n", data)
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeNoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrInvalidStatus, ErrInvalidDeviceID:
+		return http.StatusBadRequest
+	case ErrHidden:
+		return http.StatusForbidden
+	}
+
+	return 0
+})
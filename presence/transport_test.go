@@ -0,0 +1,127 @@
+package presence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+)
+
+func TestMakingHandler(t *testing.T) {
+	routes := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/v1/users/" + mockUserID + "/presence", "", http.StatusOK},
+		{"GET", "/v1/users/" + mockUserID + "/presence/friends", "", http.StatusOK},
+		{"PUT", "/v1/users/" + mockUserID + "/presence/devices/" + mockDeviceID, `{"status": "in_lobby"}`, http.StatusNoContent},
+		{"PUT", "/v1/users/" + mockUserID + "/presence/devices/" + mockDeviceID, `{"status": "away"}`, http.StatusBadRequest},
+		{"DELETE", "/v1/users/" + mockUserID + "/presence/devices/" + mockDeviceID, "", http.StatusNoContent},
+	}
+
+	for _, route := range routes {
+		t.Run("routes "+route.method+" "+route.path, func(t *testing.T) {
+			svc, _ := newService(&mockSocialService{})
+			handler := MakeHandler(svc)
+
+			r := stmhttptest.NewJSONRequest(route.method, route.path, route.body)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r.WithContext(userContext()))
+
+			if rr.Code != route.status {
+				t.Errorf("expected status %d, got %d", route.status, rr.Code)
+			}
+		})
+	}
+}
+
+func TestStreamingEvents(t *testing.T) {
+	t.Run("fails when caller is not the user", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/v1/users/"+mockFriendID+"/presence/events", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(userContext()))
+
+		if rr.Code != http.StatusForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("streams the friends' current status, then their changes", func(t *testing.T) {
+		svc, _ := newService(&mockSocialService{})
+		handler := MakeHandler(svc)
+
+		ctx, cancel := context.WithCancel(userContext())
+		r := httptest.NewRequest("GET", "/v1/users/"+mockUserID+"/presence/events", nil)
+		rr := httptest.NewRecorder()
+
+		done := make(chan struct{})
+		go func() {
+			handler.ServeHTTP(rr, r.WithContext(ctx))
+			close(done)
+		}()
+
+		// The handler subscribes before anything is streamed, so waiting for
+		// the subscription ensures that the change is not missed.
+		for {
+			svc.mu.Lock()
+			subscribed := len(svc.subscriptions) == 1
+			svc.mu.Unlock()
+			if subscribed {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		svc.Heartbeat(context.Background(), mockFriendID, mockDeviceID, StatusInGame)
+
+		// Changes are streamed asynchronously, so the only way to know that
+		// it was written is to wait a little before stopping the stream.
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		<-done
+
+		if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/event-stream") {
+			t.Fail()
+		}
+
+		body := rr.Body.String()
+		if !strings.Contains(body, `"status":"offline"`) {
+			t.Errorf("expected the friend's current status, got %s", body)
+		}
+		if !strings.Contains(body, `"status":"in_game"`) {
+			t.Errorf("expected the friend's new status, got %s", body)
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		auth.ErrForbidden:         http.StatusForbidden,
+		ErrHidden:                 http.StatusForbidden,
+		ErrInvalidStatus:          http.StatusBadRequest,
+		ErrInvalidDeviceID:        http.StatusBadRequest,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
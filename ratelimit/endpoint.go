@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+// Error is returned when a request is rejected because its caller has run out
+// of tokens.
+type Error struct {
+	Result
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rate limit exceeded; retry in %s", e.RetryAfter)
+}
+
+// ContextKeyFunc returns the key of the bucket to take a token from for the
+// request carried by the context.
+//
+// If it returns false, the request is not limited.
+type ContextKeyFunc func(ctx context.Context) (string, bool)
+
+// ByAuthenticatedUser keys requests by the ID of the authenticated user, and
+// leaves anonymous requests alone.
+func ByAuthenticatedUser(ctx context.Context) (string, bool) {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	return identity.UserID, true
+}
+
+// NewEndpointMiddleware creates a middleware that takes a token from the
+// limiter before calling the endpoint, and returns an *Error instead when
+// there are none left.
+func NewEndpointMiddleware(limiter *Limiter, key ContextKeyFunc) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			k, ok := key(ctx)
+			if !ok {
+				return next(ctx, request)
+			}
+
+			result := limiter.Allow(k)
+			if !result.Allowed {
+				return nil, &Error{result}
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
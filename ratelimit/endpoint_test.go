@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestEndpointMiddleware(t *testing.T) {
+	ep := func(_ context.Context, request interface{}) (interface{}, error) {
+		return request, nil
+	}
+	ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "moose"})
+
+	t.Run("calls endpoint when a token is available", func(t *testing.T) {
+		limiter, _ := NewLimiter(PerMinute(1, 1))
+		limited := NewEndpointMiddleware(limiter, ByAuthenticatedUser)(ep)
+
+		if resp, err := limited(ctx, "request"); err != nil || resp != "request" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a rate limit error when no tokens are left", func(t *testing.T) {
+		limiter, _ := NewLimiter(PerMinute(1, 1))
+		limited := NewEndpointMiddleware(limiter, ByAuthenticatedUser)(ep)
+
+		limited(ctx, nil)
+
+		_, err := limited(ctx, nil)
+		if _, ok := err.(*Error); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not limit requests without a key", func(t *testing.T) {
+		limiter, _ := NewLimiter(PerMinute(1, 1))
+		limited := NewEndpointMiddleware(limiter, ByAuthenticatedUser)(ep)
+
+		for i := 0; i < 3; i++ {
+			if _, err := limited(context.Background(), nil); err != nil {
+				t.Fail()
+			}
+		}
+	})
+}
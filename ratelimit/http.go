@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
+)
+
+// KeyFunc returns the key of the bucket to take a token from for the request.
+type KeyFunc func(r *http.Request) string
+
+// ByClientIP keys requests by the IP address of the client that sent them.
+func ByClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ByUser keys requests by the ID of the authenticated user, or by the IP
+// address of the client for anonymous requests.
+func ByUser(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return "user:" + identity.UserID
+	}
+
+	return "ip:" + ByClientIP(r)
+}
+
+// Route represents a rate that applies to the requests made to a route instead
+// of the default one.
+type Route struct {
+	// Method represents the HTTP method of the route.
+	//
+	// If it is empty, the route matches all methods.
+	Method string
+
+	// Path represents the path of the route.
+	//
+	// Segments enclosed in braces, such as "{id}" in "/v1/users/{id}", match
+	// any value.
+	Path string
+
+	// Rate represents the rate at which requests made to the route are
+	// allowed.
+	Rate Rate
+}
+
+func (route Route) matches(r *http.Request) bool {
+	if route.Method != "" && !strings.EqualFold(route.Method, r.Method) {
+		return false
+	}
+
+	patternSegments := strings.Split(strings.Trim(route.Path, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type routeLimiter struct {
+	route   Route
+	limiter *Limiter
+}
+
+// NewHTTPMiddleware creates a middleware that takes a token from the bucket of
+// the request's key before calling the next handler, and responds with HTTP
+// status too many requests instead when there are none left.
+//
+// Requests made to one of the given routes are limited at the route's rate,
+// and all others are limited at the default rate. Each route has its own
+// buckets.
+//
+// The rate limit headers are set on every response.
+func NewHTTPMiddleware(key KeyFunc, defaultRate Rate, routes ...Route) (func(http.Handler) http.Handler, error) {
+	defaultLimiter, err := NewLimiter(defaultRate)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit.NewHTTPMiddleware: invalid default rate (%s)", err)
+	}
+
+	routeLimiters := make([]routeLimiter, 0, len(routes))
+	for _, route := range routes {
+		limiter, err := NewLimiter(route.Rate)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"ratelimit.NewHTTPMiddleware: invalid rate for route \"%s %s\" (%s)",
+				route.Method,
+				route.Path,
+				err,
+			)
+		}
+
+		routeLimiters = append(routeLimiters, routeLimiter{route, limiter})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limiter := defaultLimiter
+			for _, rl := range routeLimiters {
+				if rl.route.matches(r) {
+					limiter = rl.limiter
+					break
+				}
+			}
+
+			result := limiter.Allow(key(r))
+
+			SetHeaders(w, result)
+
+			if !result.Allowed {
+				p := stmhttp.NewProblem(r.Context(), http.StatusTooManyRequests)
+				p.Detail = (&Error{result}).Error()
+				p.Instance = r.URL.Path
+				problem.Write(w, p)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// SetHeaders sets the rate limit headers describing the result on the
+// response.
+//
+// The Retry-After header is only set when the request was not allowed.
+func SetHeaders(w http.ResponseWriter, result Result) {
+	setHeaders(w.Header(), result)
+}
+
+func setHeaders(h http.Header, result Result) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+	if !result.Allowed {
+		h.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+	}
+}
+
+// StatusCode returns 429 Too Many Requests, which is the status of responses
+// to requests that were not allowed.
+func (e *Error) StatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+// Headers returns the rate limit headers describing the result, so that
+// responses tell callers when to retry.
+func (e *Error) Headers() http.Header {
+	h := http.Header{}
+	setHeaders(h, e.Result)
+	return h
+}
+
+// seconds rounds the duration up to the nearest second, so that clients never
+// retry too early.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestKeyFuncs(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/users/0", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+
+	t.Run("keys by client IP without port", func(t *testing.T) {
+		if strings.Compare("192.0.2.1", ByClientIP(r)) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("keys by client IP when caller is anonymous", func(t *testing.T) {
+		if strings.Compare("ip:192.0.2.1", ByUser(r)) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("keys by user ID when caller is authenticated", func(t *testing.T) {
+		ctx := auth.NewContext(r.Context(), auth.Identity{UserID: "moose"})
+
+		if strings.Compare("user:moose", ByUser(r.WithContext(ctx))) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestRouteMatching(t *testing.T) {
+	route := Route{Method: "GET", Path: "/v1/users/{id}"}
+
+	t.Run("matches path with placeholder", func(t *testing.T) {
+		if !route.matches(httptest.NewRequest("GET", "/v1/users/42", nil)) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not match other methods", func(t *testing.T) {
+		if route.matches(httptest.NewRequest("POST", "/v1/users/42", nil)) {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not match other paths", func(t *testing.T) {
+		if route.matches(httptest.NewRequest("GET", "/v1/users", nil)) {
+			t.Fail()
+		}
+	})
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(handler http.Handler, method string, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	t.Run("fails when default rate is invalid", func(t *testing.T) {
+		if _, err := NewHTTPMiddleware(ByClientIP, Rate{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when a route's rate is invalid", func(t *testing.T) {
+		if _, err := NewHTTPMiddleware(ByClientIP, PerSecond(1, 1), Route{Path: "/"}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("sets rate limit headers when request is allowed", func(t *testing.T) {
+		middleware, _ := NewHTTPMiddleware(ByClientIP, PerSecond(1, 5))
+
+		rr := serve(middleware(next), "GET", "/v1/users/0")
+
+		if rr.Code != http.StatusOK {
+			t.Fail()
+		}
+		if strings.Compare("5", rr.Header().Get("X-RateLimit-Limit")) != 0 {
+			t.Fail()
+		}
+		if strings.Compare("4", rr.Header().Get("X-RateLimit-Remaining")) != 0 {
+			t.Fail()
+		}
+		if strings.Compare("1", rr.Header().Get("X-RateLimit-Reset")) != 0 {
+			t.Fail()
+		}
+		if rr.Header().Get("Retry-After") != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with too many requests and retry after when no tokens are left", func(t *testing.T) {
+		middleware, _ := NewHTTPMiddleware(ByClientIP, PerMinute(1, 1))
+		handler := middleware(next)
+
+		serve(handler, "GET", "/v1/users/0")
+		rr := serve(handler, "GET", "/v1/users/0")
+
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fail()
+		}
+		if strings.Compare("60", rr.Header().Get("Retry-After")) != 0 {
+			t.Fail()
+		}
+		if rr.Header().Get("Content-Type") != "application/problem+json" || !strings.Contains(rr.Body.String(), `"instance":"/v1/users/0"`) {
+			t.Errorf("expected problem details, got %s", rr.Body.String())
+		}
+	})
+
+	t.Run("limits routes at their own rate", func(t *testing.T) {
+		middleware, _ := NewHTTPMiddleware(
+			ByClientIP,
+			PerSecond(10, 10),
+			Route{Method: "POST", Path: "/v1/users", Rate: PerMinute(1, 1)},
+		)
+		handler := middleware(next)
+
+		serve(handler, "POST", "/v1/users")
+
+		if rr := serve(handler, "POST", "/v1/users"); rr.Code != http.StatusTooManyRequests {
+			t.Fail()
+		}
+		if rr := serve(handler, "GET", "/v1/users/0"); rr.Code != http.StatusOK {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Rate represents how fast the tokens of a bucket are replenished, and how
+// many of them it can hold.
+type Rate struct {
+	// Limit represents the number of tokens added to a bucket every period.
+	Limit int
+
+	// Period represents the amount of time it takes to add Limit tokens to a
+	// bucket.
+	Period time.Duration
+
+	// Burst represents the maximum number of tokens a bucket can hold, which
+	// is the number of requests that can be made at once.
+	//
+	// If it is zero, Limit is used instead.
+	Burst int
+}
+
+// PerSecond creates a rate that allows the given number of requests every
+// second.
+func PerSecond(limit int, burst int) Rate {
+	return Rate{Limit: limit, Period: time.Second, Burst: burst}
+}
+
+// PerMinute creates a rate that allows the given number of requests every
+// minute.
+func PerMinute(limit int, burst int) Rate {
+	return Rate{Limit: limit, Period: time.Minute, Burst: burst}
+}
+
+// Validate checks that the rate can be used to create a limiter.
+func (r Rate) Validate() error {
+	if r.Limit <= 0 {
+		return fmt.Errorf("ratelimit.Rate.Validate: limit must be greater than zero")
+	}
+
+	if r.Period <= 0 {
+		return fmt.Errorf("ratelimit.Rate.Validate: period must be greater than zero")
+	}
+
+	if r.Burst < 0 {
+		return fmt.Errorf("ratelimit.Rate.Validate: burst cannot be negative")
+	}
+
+	return nil
+}
+
+func (r Rate) capacity() float64 {
+	if r.Burst == 0 {
+		return float64(r.Limit)
+	}
+
+	return float64(r.Burst)
+}
+
+// interval returns the amount of time it takes to add one token to a bucket.
+func (r Rate) interval() time.Duration {
+	return r.Period / time.Duration(r.Limit)
+}
+
+// Result represents the outcome of taking a token from a bucket.
+type Result struct {
+	// Allowed indicates whether or not a token was available.
+	Allowed bool
+
+	// Limit represents the maximum number of tokens the bucket can hold.
+	Limit int
+
+	// Remaining represents the number of tokens left in the bucket.
+	Remaining int
+
+	// Reset represents the amount of time until the bucket is full again.
+	Reset time.Duration
+
+	// RetryAfter represents the amount of time until a token is available,
+	// when none were.
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket rate limiter that keeps one bucket per key, such
+// as a client IP address or a user ID.
+//
+// It is safe for concurrent use.
+type Limiter struct {
+	rate Rate
+	now  func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter creates a limiter that fills its buckets at the given rate.
+func NewLimiter(rate Rate) (*Limiter, error) {
+	if err := rate.Validate(); err != nil {
+		return nil, fmt.Errorf("ratelimit.NewLimiter: %s", err)
+	}
+
+	return &Limiter{
+		rate:    rate,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}, nil
+}
+
+// Allow takes a token from the bucket identified by the key, creating a full
+// bucket if it does not exist yet.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := l.rate.capacity()
+	interval := l.rate.interval()
+
+	l.sweep(now, capacity, interval)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last)
+	if elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(interval))
+		b.last = now
+	}
+
+	result := Result{Limit: int(capacity)}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) * float64(interval))
+
+	return result
+}
+
+// sweep forgets about the buckets that have been refilled completely, since
+// they are indistinguishable from new ones, to keep memory usage in check.
+func (l *Limiter) sweep(now time.Time, capacity float64, interval time.Duration) {
+	fillTime := time.Duration(capacity * float64(interval))
+	if now.Sub(l.lastSweep) < fillTime {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= fillTime {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastSweep = now
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateValidation(t *testing.T) {
+	t.Run("fails when limit is not positive", func(t *testing.T) {
+		if err := (Rate{Limit: 0, Period: time.Second}).Validate(); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when period is not positive", func(t *testing.T) {
+		if err := (Rate{Limit: 1}).Validate(); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when burst is negative", func(t *testing.T) {
+		if err := (Rate{Limit: 1, Period: time.Second, Burst: -1}).Validate(); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when all is well", func(t *testing.T) {
+		if err := PerMinute(10, 5).Validate(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestLimiterConstruction(t *testing.T) {
+	t.Run("fails when rate is invalid", func(t *testing.T) {
+		if _, err := NewLimiter(Rate{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a limiter with the given rate", func(t *testing.T) {
+		rate := PerSecond(1, 2)
+
+		limiter, err := NewLimiter(rate)
+		if err != nil {
+			t.FailNow()
+		}
+		if limiter.rate != rate {
+			t.Fail()
+		}
+	})
+}
+
+func TestLimiterAllowing(t *testing.T) {
+	newLimiter := func(rate Rate) (*Limiter, *time.Time) {
+		now := time.Unix(0, 0)
+		limiter, _ := NewLimiter(rate)
+		limiter.now = func() time.Time { return now }
+		return limiter, &now
+	}
+
+	t.Run("allows bursts of up to the bucket's capacity", func(t *testing.T) {
+		limiter, _ := newLimiter(PerSecond(1, 3))
+
+		for i := 0; i < 3; i++ {
+			if !limiter.Allow("moose").Allowed {
+				t.Fail()
+			}
+		}
+		if limiter.Allow("moose").Allowed {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses limit as capacity when burst is zero", func(t *testing.T) {
+		limiter, _ := newLimiter(PerSecond(2, 0))
+
+		if result := limiter.Allow("moose"); result.Limit != 2 || result.Remaining != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("keeps a bucket per key", func(t *testing.T) {
+		limiter, _ := newLimiter(PerSecond(1, 1))
+
+		limiter.Allow("moose")
+
+		if !limiter.Allow("elk").Allowed {
+			t.Fail()
+		}
+	})
+
+	t.Run("refills buckets over time", func(t *testing.T) {
+		limiter, now := newLimiter(PerSecond(1, 1))
+
+		limiter.Allow("moose")
+		*now = now.Add(time.Second)
+
+		if !limiter.Allow("moose").Allowed {
+			t.Fail()
+		}
+	})
+
+	t.Run("tells when to retry when no tokens are left", func(t *testing.T) {
+		limiter, now := newLimiter(PerMinute(2, 1))
+
+		limiter.Allow("moose")
+		*now = now.Add(10 * time.Second)
+
+		result := limiter.Allow("moose")
+		if result.Allowed {
+			t.Fail()
+		}
+		if result.RetryAfter != 20*time.Second {
+			t.Fail()
+		}
+		if result.Reset != 20*time.Second {
+			t.Fail()
+		}
+	})
+
+	t.Run("forgets about buckets that are full", func(t *testing.T) {
+		limiter, now := newLimiter(PerSecond(1, 1))
+
+		limiter.Allow("moose")
+		*now = now.Add(time.Minute)
+		limiter.Allow("elk")
+
+		if _, ok := limiter.buckets["moose"]; ok {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package rating
+
+import (
+	"context"
+	"math"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type leaderboardRequest struct {
+	Leaderboard Leaderboard
+	Offset      int
+	Limit       int
+}
+
+// entryResponse rounds ratings, since their decimals are meaningless to
+// players.
+type entryResponse struct {
+	Rank        int    `json:"rank"`
+	UserID      string `json:"userId"`
+	Username    string `json:"username"`
+	Rating      int    `json:"rating"`
+	Deviation   int    `json:"deviation"`
+	GamesPlayed int    `json:"gamesPlayed"`
+}
+
+func newEntryResponse(e Entry) entryResponse {
+	return entryResponse{
+		Rank:        e.Rank,
+		UserID:      e.UserID,
+		Username:    e.Username,
+		Rating:      int(math.Round(e.Rating.Rating)),
+		Deviation:   int(math.Round(e.Deviation)),
+		GamesPlayed: e.GamesPlayed,
+	}
+}
+
+type leaderboardResponse struct {
+	Period  string          `json:"period"`
+	Entries []entryResponse `json:"entries"`
+	Own     *entryResponse  `json:"own,omitempty"`
+}
+
+func makeLeaderboardEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(leaderboardRequest)
+
+		// Anyone can see leaderboards, but only authenticated users see
+		// their own standing.
+		var viewerID string
+		if identity, ok := auth.FromContext(ctx); ok {
+			viewerID = identity.UserID
+		}
+
+		page, err := svc.Leaderboard(ctx, viewerID, req.Leaderboard, req.Offset, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &leaderboardResponse{
+			Period:  page.Period,
+			Entries: make([]entryResponse, 0, len(page.Entries)),
+		}
+		for _, e := range page.Entries {
+			resp.Entries = append(resp.Entries, newEntryResponse(e))
+		}
+		if page.Own != nil {
+			own := newEntryResponse(*page.Own)
+			resp.Own = &own
+		}
+
+		return resp, nil
+	}
+}
@@ -0,0 +1,143 @@
+package rating
+
+import "math"
+
+// The Glicko-2 rating system is described by its author, Mark Glickman, in
+// http://www.glicko.net/glicko/glicko2.pdf, whose steps are followed here.
+const (
+	DefaultRating     = 1500
+	DefaultDeviation  = 350
+	DefaultVolatility = 0.06
+
+	// tau constrains how much volatility can change between periods.
+	tau = 0.5
+
+	// scale converts ratings and deviations to the Glicko-2 scale.
+	scale = 173.7178
+
+	// convergenceTolerance is when the volatility is considered found.
+	convergenceTolerance = 0.000001
+)
+
+// glicko2 represents a player's rating, deviation and volatility.
+type glicko2 struct {
+	rating     float64
+	deviation  float64
+	volatility float64
+}
+
+// outcome represents the result of a player against an opponent, who won
+// (1), tied (0.5), or lost (0).
+type outcome struct {
+	opponent glicko2
+	score    float64
+}
+
+// update returns the player's rating after the outcomes, which happened
+// during the same rating period.
+func (p glicko2) update(outcomes []outcome) glicko2 {
+	if len(outcomes) == 0 {
+		return p
+	}
+
+	mu := (p.rating - DefaultRating) / scale
+	phi := p.deviation / scale
+
+	var vInverse, deltaSum float64
+	for _, o := range outcomes {
+		muJ := (o.opponent.rating - DefaultRating) / scale
+		phiJ := o.opponent.deviation / scale
+
+		g := g(phiJ)
+		e := 1 / (1 + math.Exp(-g*(mu-muJ)))
+
+		vInverse += g * g * e * (1 - e)
+		deltaSum += g * (o.score - e)
+	}
+
+	v := 1 / vInverse
+	delta := v * deltaSum
+
+	sigma := volatility(phi, p.volatility, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phiPrime := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	muPrime := mu + phiPrime*phiPrime*deltaSum
+
+	return glicko2{
+		rating:     scale*muPrime + DefaultRating,
+		deviation:  scale * phiPrime,
+		volatility: sigma,
+	}
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// volatility finds the player's new volatility with the Illinois algorithm.
+func volatility(phi float64, sigma float64, v float64, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > convergenceTolerance {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA = fA / 2
+		}
+
+		B, fB = C, fC
+	}
+
+	return math.Exp(A / 2)
+}
+
+// pairwiseOutcomes decomposes the ranking of a multiplayer game into the
+// outcomes of each player against every other player, who they beat when
+// they ranked better, and tied when they ranked the same.
+func pairwiseOutcomes(player int, ranks []int, ratings []glicko2) []outcome {
+	outcomes := make([]outcome, 0, len(ranks)-1)
+
+	for opponent := range ranks {
+		if opponent == player {
+			continue
+		}
+
+		score := 0.5
+		if ranks[player] < ranks[opponent] {
+			score = 1
+		} else if ranks[player] > ranks[opponent] {
+			score = 0
+		}
+
+		outcomes = append(outcomes, outcome{
+			opponent: ratings[opponent],
+			score:    score,
+		})
+	}
+
+	return outcomes
+}
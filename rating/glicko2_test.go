@@ -0,0 +1,54 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGlicko2Update(t *testing.T) {
+	t.Run("matches the example of the Glicko-2 paper", func(t *testing.T) {
+		player := glicko2{rating: 1500, deviation: 200, volatility: DefaultVolatility}
+
+		updated := player.update([]outcome{
+			{opponent: glicko2{rating: 1400, deviation: 30}, score: 1},
+			{opponent: glicko2{rating: 1550, deviation: 100}, score: 0},
+			{opponent: glicko2{rating: 1700, deviation: 300}, score: 0},
+		})
+
+		if math.Abs(updated.rating-1464.06) > 0.01 {
+			t.Errorf("expected rating 1464.06, got %f", updated.rating)
+		}
+		if math.Abs(updated.deviation-151.52) > 0.01 {
+			t.Errorf("expected deviation 151.52, got %f", updated.deviation)
+		}
+		if math.Abs(updated.volatility-0.05999) > 0.00001 {
+			t.Errorf("expected volatility 0.05999, got %f", updated.volatility)
+		}
+	})
+
+	t.Run("does not change without outcomes", func(t *testing.T) {
+		player := glicko2{rating: 1500, deviation: 200, volatility: DefaultVolatility}
+
+		if player.update(nil) != player {
+			t.Fail()
+		}
+	})
+}
+
+func TestPairwiseOutcomes(t *testing.T) {
+	ratings := []glicko2{{rating: 1500}, {rating: 1600}, {rating: 1700}}
+
+	t.Run("beats worse ranked players, ties equally ranked ones, and loses to the others", func(t *testing.T) {
+		outcomes := pairwiseOutcomes(1, []int{1, 2, 2}, ratings)
+
+		if len(outcomes) != 2 {
+			t.FailNow()
+		}
+		if outcomes[0].score != 0 || outcomes[0].opponent.rating != 1500 {
+			t.Fail()
+		}
+		if outcomes[1].score != 0.5 || outcomes[1].opponent.rating != 1700 {
+			t.Fail()
+		}
+	})
+}
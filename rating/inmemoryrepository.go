@@ -0,0 +1,111 @@
+package rating
+
+import (
+	"sort"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inMemoryRepository struct {
+	database *db.InMemory
+}
+
+func NewInMemoryRepository(database *db.InMemory) Repository {
+	return &inMemoryRepository{database}
+}
+
+func (repo *inMemoryRepository) Get(userID string, period string) (*entity.Rating, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, r := range repo.database.Ratings {
+		if r.UserID == userID && r.Period == period {
+			return &r, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *inMemoryRepository) Save(ratings []entity.Rating) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, rating := range ratings {
+		saved := false
+
+		for i, r := range repo.database.Ratings {
+			if r.UserID == rating.UserID && r.Period == rating.Period {
+				repo.database.Ratings[i] = rating
+				saved = true
+				break
+			}
+		}
+
+		if !saved {
+			repo.database.Ratings = append(repo.database.Ratings, rating)
+		}
+	}
+
+	return nil
+}
+
+func (repo *inMemoryRepository) List(period string, offset int, limit int) ([]Standing, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	standings := repo.standings(period)
+
+	if offset >= len(standings) {
+		return make([]Standing, 0), nil
+	}
+
+	end := offset + limit
+	if end > len(standings) {
+		end = len(standings)
+	}
+
+	return standings[offset:end], nil
+}
+
+func (repo *inMemoryRepository) Rank(userID string, period string) (int, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, s := range repo.standings(period) {
+		if s.UserID == userID {
+			return s.Rank, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// standings returns the ranked ratings over the period, ordered like they are
+// in Postgres.
+func (repo *inMemoryRepository) standings(period string) []Standing {
+	standings := make([]Standing, 0)
+	for _, r := range repo.database.Ratings {
+		if r.Period == period {
+			standings = append(standings, Standing{Rating: r})
+		}
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Rating.Rating == standings[j].Rating.Rating {
+			return standings[i].UserID < standings[j].UserID
+		}
+		return standings[i].Rating.Rating > standings[j].Rating.Rating
+	})
+
+	for i := range standings {
+		if i > 0 && standings[i].Rating.Rating == standings[i-1].Rating.Rating {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+
+	return standings
+}
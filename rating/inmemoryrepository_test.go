@@ -0,0 +1,88 @@
+package rating
+
+import (
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func newInMemoryRepository() Repository {
+	database := &db.InMemory{}
+	database.Open()
+
+	return NewInMemoryRepository(database)
+}
+
+func TestInMemoryRepositorySavingRatings(t *testing.T) {
+	t.Run("returns nil when user has not been rated over the period", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Save([]entity.Rating{{UserID: "0", Period: PeriodAll, Rating: 1500}})
+
+		if r, err := repo.Get("0", "2021-03"); err != nil || r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("replaces the ratings that already exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Save([]entity.Rating{{UserID: "0", Period: PeriodAll, Rating: 1500}})
+
+		if err := repo.Save([]entity.Rating{{UserID: "0", Period: PeriodAll, Rating: 1600}}); err != nil {
+			t.FailNow()
+		}
+
+		if r, _ := repo.Get("0", PeriodAll); r == nil || r.Rating != 1600 {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryRanking(t *testing.T) {
+	repo := newInMemoryRepository()
+	repo.Save([]entity.Rating{
+		{UserID: "0", Period: PeriodAll, Rating: 1500},
+		{UserID: "1", Period: PeriodAll, Rating: 1700},
+		{UserID: "2", Period: PeriodAll, Rating: 1500},
+		{UserID: "3", Period: PeriodAll, Rating: 1400},
+		{UserID: "4", Period: "2021-03", Rating: 2000},
+	})
+
+	t.Run("lists the period's ratings from highest to lowest, sharing ranks on ties", func(t *testing.T) {
+		standings, err := repo.List(PeriodAll, 0, 10)
+		if err != nil || len(standings) != 4 {
+			t.FailNow()
+		}
+
+		expected := []struct {
+			userID string
+			rank   int
+		}{{"1", 1}, {"0", 2}, {"2", 2}, {"3", 4}}
+
+		for i, e := range expected {
+			if standings[i].UserID != e.userID || standings[i].Rank != e.rank {
+				t.Errorf("expected user \"%s\" at rank %d, got user \"%s\" at rank %d", e.userID, e.rank, standings[i].UserID, standings[i].Rank)
+			}
+		}
+	})
+
+	t.Run("lists the requested page", func(t *testing.T) {
+		standings, err := repo.List(PeriodAll, 3, 10)
+		if err != nil || len(standings) != 1 || standings[0].UserID != "3" {
+			t.Fail()
+		}
+
+		if standings, _ := repo.List(PeriodAll, 10, 10); standings == nil || len(standings) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the user's rank, or zero when they are not rated", func(t *testing.T) {
+		if rank, err := repo.Rank("2", PeriodAll); err != nil || rank != 2 {
+			t.Fail()
+		}
+		if rank, err := repo.Rank("4", PeriodAll); err != nil || rank != 0 {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package rating
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+const (
+	getQuery  = "SELECT user_id, period, rating, deviation, volatility, games_played, updated_at FROM ratings WHERE user_id = $1 AND period = $2"
+	saveQuery = "INSERT INTO ratings(user_id, period, rating, deviation, volatility, games_played, updated_at) VALUES($1, $2, $3, $4, $5, $6, $7) " +
+		"ON CONFLICT (user_id, period) DO UPDATE SET rating = EXCLUDED.rating, deviation = EXCLUDED.deviation, volatility = EXCLUDED.volatility, games_played = EXCLUDED.games_played, updated_at = EXCLUDED.updated_at"
+	listQuery = "SELECT user_id, period, rating, deviation, volatility, games_played, updated_at, RANK() OVER (ORDER BY rating DESC) FROM ratings WHERE period = $1 ORDER BY rating DESC, user_id OFFSET $2 LIMIT $3"
+	rankQuery = "SELECT (SELECT count(*) FROM ratings r WHERE r.period = own.period AND r.rating > own.rating) + 1 FROM ratings own WHERE own.user_id = $1 AND own.period = $2"
+)
+
+type postgresRepository struct {
+	database *db.Postgres
+}
+
+func NewPostgresRepository(database *db.Postgres) Repository {
+	return &postgresRepository{database}
+}
+
+func (pr *postgresRepository) Get(userID string, period string) (*entity.Rating, error) {
+	var r entity.Rating
+
+	err := pr.database.QueryRow(getQuery, userID, period).Scan(
+		&r.UserID,
+		&r.Period,
+		&r.Rating,
+		&r.Deviation,
+		&r.Volatility,
+		&r.GamesPlayed,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"rating.PostgresRepository.Get: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return &r, nil
+}
+
+func (pr *postgresRepository) Save(ratings []entity.Rating) error {
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		for _, r := range ratings {
+			_, err := tx.Exec(saveQuery, r.UserID, r.Period, r.Rating, r.Deviation, r.Volatility, r.GamesPlayed, r.UpdatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to save rating (%s)", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("rating.PostgresRepository.Save: %s", err)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) List(period string, offset int, limit int) ([]Standing, error) {
+	rows, err := pr.database.Query(listQuery, period, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"rating.PostgresRepository.List: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	standings := make([]Standing, 0)
+	for rows.Next() {
+		var s Standing
+		err := rows.Scan(
+			&s.UserID,
+			&s.Period,
+			&s.Rating.Rating,
+			&s.Deviation,
+			&s.Volatility,
+			&s.GamesPlayed,
+			&s.UpdatedAt,
+			&s.Rank,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"rating.PostgresRepository.List: failed to read rating (%s)",
+				err,
+			)
+		}
+
+		standings = append(standings, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"rating.PostgresRepository.List: failed to read ratings (%s)",
+			err,
+		)
+	}
+
+	return standings, nil
+}
+
+func (pr *postgresRepository) Rank(userID string, period string) (int, error) {
+	var rank int
+	if err := pr.database.QueryRow(rankQuery, userID, period).Scan(&rank); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf(
+			"rating.PostgresRepository.Rank: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return rank, nil
+}
@@ -0,0 +1,172 @@
+package rating
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+var ratingColumns = []string{"user_id", "period", "rating", "deviation", "volatility", "games_played", "updated_at"}
+
+func TestPostgresRepositoryGettingRating(t *testing.T) {
+	t.Run("returns nil when user has not been rated over the period", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getQuery).
+			WithArgs("0", PeriodAll).
+			WillReturnRows(mock.NewRows(ratingColumns))
+
+		if r, err := pr.Get("0", PeriodAll); err != nil || r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the rating when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getQuery).
+			WithArgs("0", PeriodAll).
+			WillReturnRows(mock.NewRows(ratingColumns).AddRow("0", PeriodAll, 1612.5, 80.1, 0.06, 12, time.Now()))
+
+		r, err := pr.Get("0", PeriodAll)
+		if err != nil || r == nil {
+			t.FailNow()
+		}
+		if r.Rating != 1612.5 || r.GamesPlayed != 12 {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositorySavingRatings(t *testing.T) {
+	updatedAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	ratings := []entity.Rating{
+		{UserID: "0", Period: PeriodAll, Rating: 1600, Deviation: 300, Volatility: 0.06, GamesPlayed: 1, UpdatedAt: updatedAt},
+		{UserID: "1", Period: PeriodAll, Rating: 1400, Deviation: 300, Volatility: 0.06, GamesPlayed: 1, UpdatedAt: updatedAt},
+	}
+
+	t.Run("rolls back when a rating cannot be saved", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(saveQuery).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(saveQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+		mock.ExpectRollback()
+
+		if err := pr.Save(ratings); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("saves every rating when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		for _, r := range ratings {
+			mock.ExpectExec(saveQuery).
+				WithArgs(r.UserID, r.Period, r.Rating, r.Deviation, r.Volatility, r.GamesPlayed, r.UpdatedAt).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		if err := pr.Save(ratings); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryRanking(t *testing.T) {
+	t.Run("lists the standings of the page", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listQuery).
+			WithArgs(PeriodAll, 20, 10).
+			WillReturnRows(mock.NewRows(append(ratingColumns, "rank")).
+				AddRow("0", PeriodAll, 1600, 300, 0.06, 1, time.Now(), 21).
+				AddRow("1", PeriodAll, 1600, 300, 0.06, 1, time.Now(), 21))
+
+		standings, err := pr.List(PeriodAll, 20, 10)
+		if err != nil || len(standings) != 2 {
+			t.FailNow()
+		}
+		if standings[1].UserID != "1" || standings[1].Rank != 21 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns zero when user has not been rated over the period", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(rankQuery).
+			WithArgs("0", PeriodAll).
+			WillReturnRows(mock.NewRows([]string{"rank"}))
+
+		if rank, err := pr.Rank("0", PeriodAll); err != nil || rank != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the user's rank when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(rankQuery).
+			WithArgs("0", PeriodAll).
+			WillReturnRows(mock.NewRows([]string{"rank"}).AddRow(42))
+
+		if rank, err := pr.Rank("0", PeriodAll); err != nil || rank != 42 {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,42 @@
+// Package rating rates players by how they do in the games they finish, and
+// ranks them on leaderboards.
+package rating
+
+import (
+	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+// Standing represents a player's rating, and their rank among the players
+// rated over the same period.
+type Standing struct {
+	entity.Rating
+	Rank int
+}
+
+type Repository interface {
+	// Get returns the user's rating over the period, or nil if they have not
+	// been rated over it.
+	Get(userID string, period string) (*entity.Rating, error)
+	// Save creates or replaces the ratings, all at once.
+	Save(ratings []entity.Rating) error
+
+	// List returns the ratings over the period, from highest to lowest.
+	// Players with the same rating share the same rank.
+	List(period string, offset int, limit int) ([]Standing, error)
+	// Rank returns the user's rank over the period, or zero if they have not
+	// been rated over it.
+	Rank(userID string, period string) (int, error)
+}
+
+func NewRepository(database db.DB) (Repository, error) {
+	if inmemory, ok := database.(*db.InMemory); ok {
+		return NewInMemoryRepository(inmemory), nil
+	} else if postgres, ok := database.(*db.Postgres); ok {
+		return NewPostgresRepository(postgres), nil
+	}
+
+	return nil, fmt.Errorf("rating.NewRepository: unsupported database type")
+}
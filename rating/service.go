@@ -0,0 +1,259 @@
+package rating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+// MaxListLimit is the maximum number of standings that can be listed at once.
+const MaxListLimit = 100
+
+// PeriodAll is the period of all time ratings. Monthly ratings have periods
+// such as "2021-03".
+const PeriodAll = "all"
+
+var (
+	ErrInvalidPeriod     = errors.New("period must be \"all\" or \"monthly\"")
+	ErrInvalidPagination = pagination.NewError(MaxListLimit)
+)
+
+// Leaderboard represents which leaderboard is requested.
+type Leaderboard string
+
+const (
+	LeaderboardAll     Leaderboard = "all"
+	LeaderboardMonthly Leaderboard = "monthly"
+)
+
+// Entry represents a player's standing on a leaderboard.
+type Entry struct {
+	Standing
+	Username string
+}
+
+// Page represents a page of a leaderboard, and the standing of the user who
+// requested it, who may not be on the page.
+type Page struct {
+	Period  string
+	Entries []Entry
+	// Own is the standing of the user who requested the page, or nil if they
+	// have not been rated over the period.
+	Own *Entry
+}
+
+type Service interface {
+	// Get returns the user's all time rating, which is the default one if
+	// they have not been rated yet.
+	Get(userID string) (*entity.Rating, error)
+	// Rating returns the user's all time rating, by which players are
+	// matched.
+	Rating(userID string) (float64, error)
+
+	// Update rates the players of the finished game by their results, both
+	// for all time and for the month the game finished in, provided it is
+	// rated.
+	Update(game entity.Game) error
+
+	// Leaderboard returns a page of the leaderboard, with the standing of
+	// the viewer, unless they are anonymous, in which case the viewer ID is
+	// empty.
+	Leaderboard(ctx context.Context, viewerID string, leaderboard Leaderboard, offset int, limit int) (*Page, error)
+}
+
+type service struct {
+	repo    Repository
+	userSvc user.Service
+	now     func() time.Time
+
+	// mu serializes updates, since every new rating is computed from the
+	// ratings of all of the game's players, which must not change while a
+	// game is being rated.
+	mu sync.Mutex
+}
+
+func NewService(repo Repository, userSvc user.Service) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("rating.NewService: repository is required")
+	}
+	if userSvc == nil {
+		return nil, fmt.Errorf("rating.NewService: user service is required")
+	}
+
+	return &service{
+		repo:    repo,
+		userSvc: userSvc,
+		now:     time.Now,
+	}, nil
+}
+
+func (svc *service) Get(userID string) (*entity.Rating, error) {
+	r, err := svc.get(userID, PeriodAll)
+	if err != nil {
+		return nil, fmt.Errorf("rating.Service.Get: %s", err)
+	}
+
+	return r, nil
+}
+
+func (svc *service) Rating(userID string) (float64, error) {
+	r, err := svc.get(userID, PeriodAll)
+	if err != nil {
+		return 0, fmt.Errorf("rating.Service.Rating: %s", err)
+	}
+
+	return r.Rating, nil
+}
+
+func (svc *service) Update(game entity.Game) error {
+	if !game.Rated() || len(game.Results) < 2 {
+		return nil
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	updated := make([]entity.Rating, 0, 2*len(game.Results))
+
+	for _, period := range []string{PeriodAll, monthOf(game.FinishedAt)} {
+		ratings := make([]glicko2, 0, len(game.Results))
+		ranks := make([]int, 0, len(game.Results))
+		current := make([]*entity.Rating, 0, len(game.Results))
+
+		for _, result := range game.Results {
+			r, err := svc.get(result.UserID, period)
+			if err != nil {
+				return fmt.Errorf("rating.Service.Update: %s", err)
+			}
+
+			current = append(current, r)
+			ratings = append(ratings, glicko2{r.Rating, r.Deviation, r.Volatility})
+			ranks = append(ranks, result.Rank)
+		}
+
+		// Every player is rated against the others' ratings from before the
+		// game.
+		for i, r := range current {
+			rated := ratings[i].update(pairwiseOutcomes(i, ranks, ratings))
+
+			updated = append(updated, entity.Rating{
+				UserID:      r.UserID,
+				Period:      period,
+				Rating:      rated.rating,
+				Deviation:   rated.deviation,
+				Volatility:  rated.volatility,
+				GamesPlayed: r.GamesPlayed + 1,
+				UpdatedAt:   game.FinishedAt,
+			})
+		}
+	}
+
+	if err := svc.repo.Save(updated); err != nil {
+		return fmt.Errorf("rating.Service.Update: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) Leaderboard(ctx context.Context, viewerID string, leaderboard Leaderboard, offset int, limit int) (*Page, error) {
+	var period string
+	switch leaderboard {
+	case LeaderboardAll:
+		period = PeriodAll
+	case LeaderboardMonthly:
+		period = monthOf(svc.now())
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	if !pagination.Valid(offset, limit, MaxListLimit) {
+		return nil, ErrInvalidPagination
+	}
+
+	standings, err := svc.repo.List(period, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("rating.Service.Leaderboard: %s", err)
+	}
+
+	page := &Page{
+		Period:  period,
+		Entries: make([]Entry, 0, len(standings)),
+	}
+
+	for _, s := range standings {
+		entry, err := svc.entry(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("rating.Service.Leaderboard: %s", err)
+		}
+
+		page.Entries = append(page.Entries, *entry)
+	}
+
+	// Anonymous viewers have no standing, and looking theirs up would fail
+	// in databases that require user IDs to be UUIDs.
+	if viewerID == "" {
+		return page, nil
+	}
+
+	own, err := svc.repo.Get(viewerID, period)
+	if err != nil {
+		return nil, fmt.Errorf("rating.Service.Leaderboard: %s", err)
+	}
+	if own != nil {
+		rank, err := svc.repo.Rank(viewerID, period)
+		if err != nil {
+			return nil, fmt.Errorf("rating.Service.Leaderboard: %s", err)
+		}
+
+		page.Own, err = svc.entry(ctx, Standing{Rating: *own, Rank: rank})
+		if err != nil {
+			return nil, fmt.Errorf("rating.Service.Leaderboard: %s", err)
+		}
+	}
+
+	return page, nil
+}
+
+// get returns the user's rating over the period, or the default one if they
+// have not been rated over it.
+func (svc *service) get(userID string, period string) (*entity.Rating, error) {
+	r, err := svc.repo.Get(userID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	if r == nil {
+		r = &entity.Rating{
+			UserID:     userID,
+			Period:     period,
+			Rating:     DefaultRating,
+			Deviation:  DefaultDeviation,
+			Volatility: DefaultVolatility,
+		}
+	}
+
+	return r, nil
+}
+
+func (svc *service) entry(ctx context.Context, s Standing) (*Entry, error) {
+	u, err := svc.userSvc.GetByID(ctx, s.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entry{
+		Standing: s,
+		Username: u.Username,
+	}, nil
+}
+
+// monthOf returns the period of the monthly ratings at the time, in UTC.
+func monthOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
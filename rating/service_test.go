@@ -0,0 +1,212 @@
+package rating
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var finishedAt = time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+// newService creates a service for users whose IDs are numbered from zero.
+func newService(t *testing.T, users int) *service {
+	database := &db.InMemory{}
+	database.Open()
+
+	hashSvc, _ := hash.NewService(hash.NewBCryptProvider())
+
+	userRepo := user.NewInMemoryRepository(database)
+	for i := 0; i < users; i++ {
+		userRepo.Create(context.Background(), "Moose"+strconv.Itoa(i), strconv.Itoa(i)+"@stmoosersburg.com", "a.hashed.password")
+	}
+	userSvc, _ := user.NewService(userRepo, hashSvc)
+
+	svc, err := NewService(NewInMemoryRepository(database), userSvc)
+	if err != nil {
+		t.Fatalf("failed to create service (%s)", err)
+	}
+
+	s := svc.(*service)
+	s.now = func() time.Time {
+		return finishedAt
+	}
+
+	return s
+}
+
+// finishedGame returns a finished matchmade game whose players are ranked in
+// the order of their IDs.
+func finishedGame(ranks ...int) entity.Game {
+	g := entity.Game{
+		ID:         "a.game",
+		Status:     entity.GameStatusFinished,
+		Matchmade:  true,
+		FinishedAt: finishedAt,
+	}
+
+	for i, rank := range ranks {
+		g.PlayerIDs = append(g.PlayerIDs, strconv.Itoa(i))
+		g.Results = append(g.Results, entity.PlayerResult{UserID: strconv.Itoa(i), Rank: rank})
+	}
+
+	return g
+}
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when dependencies are missing", func(t *testing.T) {
+		if _, err := NewService(nil, nil); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceGettingRating(t *testing.T) {
+	t.Run("returns the default rating for players who have not been rated", func(t *testing.T) {
+		svc := newService(t, 1)
+
+		r, err := svc.Get("0")
+		if err != nil || r.Rating != DefaultRating || r.Deviation != DefaultDeviation || r.GamesPlayed != 0 {
+			t.Fail()
+		}
+
+		if rating, err := svc.Rating("0"); err != nil || rating != DefaultRating {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceUpdatingRatings(t *testing.T) {
+	t.Run("ignores games that are not finished", func(t *testing.T) {
+		svc := newService(t, 2)
+		g := finishedGame(1, 2)
+		g.Status = entity.GameStatusInProgress
+
+		svc.Update(g)
+
+		if r, _ := svc.repo.Get("0", PeriodAll); r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("ignores games that are not matchmade", func(t *testing.T) {
+		svc := newService(t, 2)
+		g := finishedGame(1, 2)
+		g.Matchmade = false
+
+		svc.Update(g)
+
+		if r, _ := svc.repo.Get("0", PeriodAll); r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("rates winners up and losers down, for all time and for the month", func(t *testing.T) {
+		svc := newService(t, 3)
+
+		if err := svc.Update(finishedGame(1, 2, 3)); err != nil {
+			t.FailNow()
+		}
+
+		for _, period := range []string{PeriodAll, "2021-03"} {
+			first, _ := svc.repo.Get("0", period)
+			second, _ := svc.repo.Get("1", period)
+			third, _ := svc.repo.Get("2", period)
+
+			if first == nil || second == nil || third == nil {
+				t.FailNow()
+			}
+			if first.Rating <= DefaultRating || third.Rating >= DefaultRating {
+				t.Fail()
+			}
+			if second.Rating != DefaultRating {
+				t.Errorf("expected the middle player to keep their rating, got %f", second.Rating)
+			}
+			if first.GamesPlayed != 1 || first.Deviation >= DefaultDeviation {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("keeps the ratings of players who tied", func(t *testing.T) {
+		svc := newService(t, 2)
+
+		svc.Update(finishedGame(1, 1))
+
+		if r, _ := svc.repo.Get("0", PeriodAll); r.Rating != DefaultRating {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceListingLeaderboard(t *testing.T) {
+	t.Run("fails when period or pagination are invalid", func(t *testing.T) {
+		svc := newService(t, 1)
+
+		if _, err := svc.Leaderboard(context.Background(), "0", "weekly", 0, 10); err != ErrInvalidPeriod {
+			t.Fail()
+		}
+		if _, err := svc.Leaderboard(context.Background(), "0", LeaderboardAll, -1, 10); err != ErrInvalidPagination {
+			t.Fail()
+		}
+		if _, err := svc.Leaderboard(context.Background(), "0", LeaderboardAll, 0, MaxListLimit+1); err != ErrInvalidPagination {
+			t.Fail()
+		}
+	})
+
+	t.Run("lists standings with usernames, and the viewer's own", func(t *testing.T) {
+		svc := newService(t, 3)
+		svc.Update(finishedGame(1, 2, 3))
+
+		page, err := svc.Leaderboard(context.Background(), "2", LeaderboardMonthly, 0, 1)
+		if err != nil {
+			t.FailNow()
+		}
+		if page.Period != "2021-03" || len(page.Entries) != 1 || page.Entries[0].Username != "Moose0" {
+			t.Fail()
+		}
+		if page.Own == nil || page.Own.Rank != 3 || page.Own.Username != "Moose2" {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not look up the standing of anonymous viewers", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		svc := newService(t, 1)
+		svc.repo = NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listQuery).
+			WithArgs(PeriodAll, 0, 10).
+			WillReturnRows(mock.NewRows(append(ratingColumns, "rank")).
+				AddRow("0", PeriodAll, 1600, 300, 0.06, 1, finishedAt, 1))
+
+		page, err := svc.Leaderboard(context.Background(), "", LeaderboardAll, 0, 10)
+		if err != nil || len(page.Entries) != 1 || page.Own != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("omits the viewer's standing when they are not rated", func(t *testing.T) {
+		svc := newService(t, 3)
+		svc.Update(finishedGame(1, 2))
+
+		if page, err := svc.Leaderboard(context.Background(), "2", LeaderboardAll, 0, 10); err != nil || page.Own != nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package rating
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	leaderboardHandler := stmhttp.NewHandler(
+		wrap("rating.leaderboard")(makeLeaderboardEndpoint(svc)),
+		decodeLeaderboardRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/leaderboards", leaderboardHandler).Methods("GET")
+
+	return r
+}
+
+func decodeLeaderboardRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	offset, limit, err := pagination.FromQuery(query, ErrInvalidPagination)
+	if err != nil {
+		return nil, err
+	}
+
+	req := leaderboardRequest{
+		Leaderboard: LeaderboardAll,
+		Offset:      offset,
+		Limit:       limit,
+	}
+
+	if period := query.Get("period"); period != "" {
+		req.Leaderboard = Leaderboard(period)
+	}
+
+	return req, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrInvalidPeriod, ErrInvalidPagination:
+		return http.StatusBadRequest
+	}
+
+	return 0
+})
@@ -0,0 +1,84 @@
+package rating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns the leaderboard with the caller's standing", func(t *testing.T) {
+		svc := newService(t, 2)
+		svc.Update(finishedGame(2, 1))
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/v1/leaderboards?period=all&limit=1", nil)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "0"})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(ctx))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var body leaderboardResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Entries) != 1 || body.Entries[0].UserID != "1" || body.Entries[0].Rank != 1 {
+			t.Fail()
+		}
+		if body.Own == nil || body.Own.Rank != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the leaderboard without a standing to anonymous callers", func(t *testing.T) {
+		svc := newService(t, 2)
+		svc.Update(finishedGame(2, 1))
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/v1/leaderboards", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		var body leaderboardResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if rr.Code != http.StatusOK || len(body.Entries) != 2 || body.Own != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when pagination is not a number", func(t *testing.T) {
+		handler := MakeHandler(newService(t, 0))
+
+		r := httptest.NewRequest("GET", "/v1/leaderboards?offset=first", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		ErrInvalidPeriod:          http.StatusBadRequest,
+		ErrInvalidPagination:      http.StatusBadRequest,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
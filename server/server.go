@@ -0,0 +1,253 @@
+// Package server serves the API over HTTP, and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults of the configuration.
+const (
+	DefaultPort            = 8080
+	DefaultReadTimeout     = 10 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 2 * time.Minute
+	DefaultShutdownTimeout = 15 * time.Second
+)
+
+// Config represents the configuration of a server. Fields that are not set
+// take their default values.
+type Config struct {
+	// Host is the address the server listens on, which is every address by
+	// default.
+	Host string
+	Port int
+
+	// ReadTimeout is how long clients have to send a request, including its
+	// body, WriteTimeout is how long the server has to send the response,
+	// and IdleTimeout is how long connections are kept open between
+	// requests.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// ShutdownTimeout is how long requests in flight have to complete when
+	// the server shuts down, before their connections are closed.
+	ShutdownTimeout time.Duration
+
+	// DrainDelay is how long the server keeps accepting requests after it
+	// starts shutting down, so that load balancers notice it is no longer
+	// ready and stop sending it traffic. There is no delay by default.
+	DrainDelay time.Duration
+}
+
+// Server represents an HTTP server, which closes the resources it was given
+// once it has shut down.
+type Server struct {
+	conf    Config
+	http    *http.Server
+	workers []func(ctx context.Context)
+	closers []io.Closer
+	hooks   []func()
+
+	// shutdown is closed when the server starts shutting down, to end
+	// streams.
+	shutdown chan struct{}
+}
+
+func New(conf Config, handler http.Handler) *Server {
+	if conf.Port == 0 {
+		conf.Port = DefaultPort
+	}
+	if conf.ReadTimeout == 0 {
+		conf.ReadTimeout = DefaultReadTimeout
+	}
+	if conf.WriteTimeout == 0 {
+		conf.WriteTimeout = DefaultWriteTimeout
+	}
+	if conf.IdleTimeout == 0 {
+		conf.IdleTimeout = DefaultIdleTimeout
+	}
+	if conf.ShutdownTimeout == 0 {
+		conf.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	s := &Server{
+		conf:     conf,
+		shutdown: make(chan struct{}),
+	}
+
+	s.http = &http.Server{
+		Addr:         net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port)),
+		Handler:      s.withStreamInfo(handler),
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+		IdleTimeout:  conf.IdleTimeout,
+	}
+
+	return s
+}
+
+// RunInBackground registers work to run alongside the server, such as
+// periodic jobs, which is started when the server starts serving, and must
+// return once its context is done. The server waits for it to return before
+// closing resources.
+func (s *Server) RunInBackground(work func(ctx context.Context)) {
+	s.workers = append(s.workers, work)
+}
+
+// CloseOnShutdown registers resources, such as the database, to close once
+// the server has shut down, in the reverse order they were registered in.
+func (s *Server) CloseOnShutdown(closer io.Closer) {
+	s.closers = append(s.closers, closer)
+}
+
+// OnShutdown registers a function to call as soon as the server starts
+// shutting down, before it stops accepting requests, such as one that marks
+// the service as not ready.
+func (s *Server) OnShutdown(hook func()) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// Run listens on the configured address and serves requests until the
+// context is done, then shuts the server down.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		s.close()
+		return fmt.Errorf("server.Server.Run: failed to listen on %s (%s)", s.http.Addr, err)
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves requests from the listener until the context is done, then
+// shuts the server down: it calls the OnShutdown hooks, keeps serving for the
+// drain delay, stops accepting connections, ends streams, waits
+// for requests in flight to complete, up to the shutdown timeout, waits for
+// the background work to return, and closes the resources registered with
+// CloseOnShutdown.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	// Background work keeps running until requests in flight complete, since
+	// they may depend on it.
+	workCtx, stopWork := context.WithCancel(context.Background())
+	defer stopWork()
+
+	var working sync.WaitGroup
+	for _, work := range s.workers {
+		working.Add(1)
+		go func(work func(context.Context)) {
+			defer working.Done()
+			work(workCtx)
+		}(work)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.http.Serve(listener)
+	}()
+
+	slog.Info("listening", slog.String("addr", listener.Addr().String()))
+
+	select {
+	case err := <-served:
+		stopWork()
+		working.Wait()
+		s.close()
+		return fmt.Errorf("server.Server.Serve: %s", err)
+	case <-ctx.Done():
+	}
+
+	for _, hook := range s.hooks {
+		hook()
+	}
+
+	if s.conf.DrainDelay > 0 {
+		slog.Info("draining before shutting down", slog.Duration("delay", s.conf.DrainDelay))
+		time.Sleep(s.conf.DrainDelay)
+	}
+
+	slog.Info("shutting down, waiting for requests to complete", slog.Duration("timeout", s.conf.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.conf.ShutdownTimeout)
+	defer cancel()
+
+	close(s.shutdown)
+
+	err := s.http.Shutdown(shutdownCtx)
+	if err != nil {
+		// The requests that are still in flight are cut short.
+		s.http.Close()
+		err = fmt.Errorf("server.Server.Serve: requests did not complete in time (%s)", err)
+	}
+
+	stopWork()
+	working.Wait()
+	s.close()
+
+	return err
+}
+
+func (s *Server) close() {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i].Close(); err != nil {
+			slog.Error("server.Server: failed to close resource", slog.String("error", err.Error()))
+		}
+	}
+}
+
+type streamInfoKey struct{}
+
+// streamInfo tells streams when they must end.
+type streamInfo struct {
+	shutdown <-chan struct{}
+	deadline time.Time
+}
+
+// withStreamInfo adds what streams need to know to end in time to the
+// context of requests.
+func (s *Server) withStreamInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Streams end a little before the write timeout, when the connection
+		// would be cut, so that they can end cleanly.
+		info := streamInfo{
+			shutdown: s.shutdown,
+			deadline: time.Now().Add(s.conf.WriteTimeout - s.conf.WriteTimeout/10),
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), streamInfoKey{}, info)))
+	})
+}
+
+// StreamContext returns a context for a response streamed to the client,
+// such as server-sent events, which is done when the server shuts down, or
+// shortly before the write timeout would cut the connection. Clients are
+// expected to reconnect when a stream ends.
+//
+// Outside of a Server, the context is only done when the parent is, or when
+// it is canceled.
+func StreamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	info, ok := parent.Value(streamInfoKey{}).(streamInfo)
+	if !ok {
+		return context.WithCancel(parent)
+	}
+
+	ctx, cancel := context.WithDeadline(parent, info.deadline)
+
+	go func() {
+		select {
+		case <-info.shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockCloser records when it is closed, among other events.
+type mockCloser struct {
+	name   string
+	events *events
+}
+
+func (c *mockCloser) Close() error {
+	c.events.add("closed " + c.name)
+	return nil
+}
+
+// events records the order things happen in.
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.list = append(e.list, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]string(nil), e.list...)
+}
+
+// serve serves the handler on a random port, and returns its address, with a
+// channel on which the result of serving is sent once the server shut down.
+func serve(t *testing.T, s *Server, ctx context.Context) (string, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen (%s)", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.Serve(ctx, listener)
+	}()
+
+	return "http://" + listener.Addr().String(), result
+}
+
+func TestServerConstruction(t *testing.T) {
+	t.Run("uses the defaults for what is not configured", func(t *testing.T) {
+		s := New(Config{Host: "127.0.0.1"}, http.NotFoundHandler())
+
+		if s.http.Addr != "127.0.0.1:8080" || s.http.ReadTimeout != DefaultReadTimeout || s.http.WriteTimeout != DefaultWriteTimeout || s.http.IdleTimeout != DefaultIdleTimeout {
+			t.Fail()
+		}
+	})
+}
+
+func TestServerShutdown(t *testing.T) {
+	t.Run("drains requests in flight, then stops background work, then closes resources in reverse order", func(t *testing.T) {
+		e := &events{}
+		started := make(chan struct{})
+
+		s := New(Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			e.add("responded")
+		}))
+		s.RunInBackground(func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			e.add("stopped work")
+		})
+		s.CloseOnShutdown(&mockCloser{"database", e})
+		s.CloseOnShutdown(&mockCloser{"cache", e})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		addr, result := serve(t, s, ctx)
+
+		responded := make(chan int, 1)
+		go func() {
+			resp, err := http.Get(addr)
+			if err != nil {
+				responded <- 0
+				return
+			}
+			resp.Body.Close()
+			responded <- resp.StatusCode
+		}()
+
+		<-started
+		cancel()
+
+		if err := <-result; err != nil {
+			t.Fatalf("expected shutdown to succeed (%s)", err)
+		}
+		if status := <-responded; status != http.StatusOK {
+			t.Errorf("expected the request in flight to complete, got %d", status)
+		}
+
+		expected := []string{"responded", "stopped work", "closed cache", "closed database"}
+		if got := e.get(); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("calls shutdown hooks, then keeps serving for the drain delay", func(t *testing.T) {
+		e := &events{}
+
+		s := New(Config{DrainDelay: 200 * time.Millisecond}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e.add("responded")
+		}))
+		notified := make(chan struct{})
+		s.OnShutdown(func() {
+			e.add("notified")
+			close(notified)
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		addr, result := serve(t, s, ctx)
+
+		cancel()
+		<-notified
+
+		resp, err := http.Get(addr)
+		if err != nil {
+			t.Fatalf("expected requests to be served while draining (%s)", err)
+		}
+		resp.Body.Close()
+
+		if err := <-result; err != nil {
+			t.Fail()
+		}
+
+		expected := []string{"notified", "responded"}
+		if got := e.get(); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("ends streams", func(t *testing.T) {
+		started := make(chan struct{})
+
+		s := New(Config{ShutdownTimeout: 5 * time.Second}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := StreamContext(r.Context())
+			defer cancel()
+
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(started)
+
+			<-ctx.Done()
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		addr, result := serve(t, s, ctx)
+
+		go func() {
+			if resp, err := http.Get(addr); err == nil {
+				ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+		}()
+
+		<-started
+		shutdownStarted := time.Now()
+		cancel()
+
+		if err := <-result; err != nil || time.Since(shutdownStarted) > time.Second {
+			t.Fail()
+		}
+	})
+
+	t.Run("cuts requests short when they do not complete in time", func(t *testing.T) {
+		e := &events{}
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		s := New(Config{ShutdownTimeout: 50 * time.Millisecond}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}))
+		s.CloseOnShutdown(&mockCloser{"database", e})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		addr, result := serve(t, s, ctx)
+
+		go func() {
+			if resp, err := http.Get(addr); err == nil {
+				resp.Body.Close()
+			}
+		}()
+
+		<-started
+		cancel()
+
+		if err := <-result; err == nil {
+			t.Fail()
+		}
+		if got := e.get(); len(got) != 1 || got[0] != "closed database" {
+			t.Fail()
+		}
+	})
+
+	t.Run("closes resources when it fails to listen", func(t *testing.T) {
+		e := &events{}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen (%s)", err)
+		}
+		defer listener.Close()
+
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		s := New(Config{Host: "127.0.0.1"}, http.NotFoundHandler())
+		s.http.Addr = net.JoinHostPort("127.0.0.1", port)
+		s.CloseOnShutdown(&mockCloser{"database", e})
+
+		if err := s.Run(context.Background()); err == nil {
+			t.Fail()
+		}
+		if got := e.get(); len(got) != 1 {
+			t.Fail()
+		}
+	})
+}
+
+func TestStreamContext(t *testing.T) {
+	t.Run("ends before the write timeout", func(t *testing.T) {
+		var deadline time.Time
+		var ok bool
+
+		s := New(Config{WriteTimeout: 10 * time.Second}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := StreamContext(r.Context())
+			defer cancel()
+
+			deadline, ok = ctx.Deadline()
+		}))
+
+		before := time.Now()
+		s.http.Handler.ServeHTTP(nil, (&http.Request{}).WithContext(context.Background()))
+
+		if !ok || !deadline.After(before) || !deadline.Before(before.Add(10*time.Second)) {
+			t.Fail()
+		}
+	})
+
+	t.Run("has no deadline outside of a server", func(t *testing.T) {
+		ctx, cancel := StreamContext(context.Background())
+		defer cancel()
+
+		if _, ok := ctx.Deadline(); ok {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type loginRequest struct {
+	Email    string
+	Password string
+}
+
+type loginResponse struct {
+	UserID      string     `json:"userId,omitempty"`
+	AccessToken string     `json:"accessToken,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+
+	MFARequired bool       `json:"mfaRequired"`
+	MFAToken    string     `json:"mfaToken,omitempty"`
+	MFAExpires  *time.Time `json:"mfaExpiresAt,omitempty"`
+}
+
+func makeLoginEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(loginRequest)
+
+		result, err := svc.Login(ctx, req.Email, req.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Challenge != nil {
+			return &loginResponse{
+				MFARequired: true,
+				MFAToken:    result.Challenge.MFAToken,
+				MFAExpires:  &result.Challenge.ExpiresAt,
+			}, nil
+		}
+
+		return &loginResponse{
+			UserID:      result.Session.UserID,
+			AccessToken: result.Session.AccessToken,
+			ExpiresAt:   &result.Session.ExpiresAt,
+		}, nil
+	}
+}
+
+type completeChallengeRequest struct {
+	MFAToken string
+	Code     string
+}
+
+type sessionResponse struct {
+	UserID      string    `json:"userId"`
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+func makeCompleteChallengeEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(completeChallengeRequest)
+
+		s, err := svc.CompleteChallenge(ctx, req.MFAToken, req.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		return &sessionResponse{
+			UserID:      s.UserID,
+			AccessToken: s.AccessToken,
+			ExpiresAt:   s.ExpiresAt,
+		}, nil
+	}
+}
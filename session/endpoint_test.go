@@ -0,0 +1,76 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestLoginEndpoint(t *testing.T) {
+	req := loginRequest{Email: mockEmail, Password: mockPassword}
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{failOnAuthenticate: true}, &mockTwoFactorService{}, tokens, Config{})
+
+		if _, err := makeLoginEndpoint(svc)(nil, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns access token when no second factor is required", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		resp, _ := makeLoginEndpoint(svc)(nil, req)
+		loginResp, ok := resp.(*loginResponse)
+		if !ok {
+			t.FailNow()
+		}
+		if loginResp.MFARequired || loginResp.AccessToken == "" || loginResp.MFAToken != "" {
+			t.Fail()
+		}
+		if strings.Compare(mockUserID, loginResp.UserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns MFA token when a second factor is required", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{twoFactorEnabled: true}, &mockTwoFactorService{}, tokens, Config{})
+
+		resp, _ := makeLoginEndpoint(svc)(nil, req)
+		loginResp, ok := resp.(*loginResponse)
+		if !ok {
+			t.FailNow()
+		}
+		if !loginResp.MFARequired || loginResp.MFAToken == "" || loginResp.AccessToken != "" {
+			t.Fail()
+		}
+	})
+}
+
+func TestCompleteChallengeEndpoint(t *testing.T) {
+	mfaToken, _, _ := tokens.Issue(mockUserID, auth.PurposeMFA, time.Minute)
+	req := completeChallengeRequest{MFAToken: mfaToken, Code: "123456"}
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{failOnVerify: true}, tokens, Config{})
+
+		if _, err := makeCompleteChallengeEndpoint(svc)(nil, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns session response when all is well", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		resp, _ := makeCompleteChallengeEndpoint(svc)(nil, req)
+		sessionResp, ok := resp.(*sessionResponse)
+		if !ok {
+			t.FailNow()
+		}
+		if sessionResp.AccessToken == "" {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/twofactor"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+const (
+	DefaultAccessTokenTTL = time.Hour
+	DefaultMFATokenTTL    = 5 * time.Minute
+)
+
+// Config represents how long the tokens issued by the service are valid.
+//
+// Zero values are replaced by their defaults.
+type Config struct {
+	AccessTokenTTL time.Duration
+	MFATokenTTL    time.Duration
+}
+
+// Session represents a logged in user's access to the API.
+type Session struct {
+	UserID      string
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// Challenge represents the second step of a login, when a user has entered the
+// right password, but has two-factor authentication enabled.
+type Challenge struct {
+	MFAToken  string
+	ExpiresAt time.Time
+}
+
+// Result represents the outcome of a login: either a session, or a challenge
+// to complete with a one-time password or recovery code.
+type Result struct {
+	Session   *Session
+	Challenge *Challenge
+}
+
+type Service interface {
+	Login(ctx context.Context, email string, password string) (*Result, error)
+	LoginWithIdentity(ctx context.Context, identity user.ExternalIdentity) (*Result, error)
+	CompleteChallenge(ctx context.Context, mfaToken string, code string) (*Session, error)
+}
+
+type service struct {
+	userSvc      user.Service
+	twoFactorSvc twofactor.Service
+	tokens       *auth.Tokens
+	conf         Config
+
+	// mu protects completedChallenges, which holds the IDs of the MFA tokens
+	// that completed a challenge until they expire, so that each one starts
+	// a single session.
+	mu                  sync.Mutex
+	completedChallenges map[string]time.Time
+}
+
+func NewService(userSvc user.Service, twoFactorSvc twofactor.Service, tokens *auth.Tokens, conf Config) (Service, error) {
+	if userSvc == nil {
+		return nil, fmt.Errorf("session.NewService: user service is required")
+	}
+
+	if twoFactorSvc == nil {
+		return nil, fmt.Errorf("session.NewService: two-factor service is required")
+	}
+
+	if tokens == nil {
+		return nil, fmt.Errorf("session.NewService: tokens are required")
+	}
+
+	if conf.AccessTokenTTL == 0 {
+		conf.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if conf.MFATokenTTL == 0 {
+		conf.MFATokenTTL = DefaultMFATokenTTL
+	}
+
+	return &service{
+		userSvc:             userSvc,
+		twoFactorSvc:        twoFactorSvc,
+		tokens:              tokens,
+		conf:                conf,
+		completedChallenges: make(map[string]time.Time),
+	}, nil
+}
+
+func (svc *service) Login(ctx context.Context, email string, password string) (*Result, error) {
+	u, err := svc.userSvc.Authenticate(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := svc.login(u)
+	if err != nil {
+		return nil, fmt.Errorf("session.Service.Login: %s", err)
+	}
+
+	return result, nil
+}
+
+// LoginWithIdentity logs in the user linked to an identity from an external
+// identity provider, which has already authenticated them.
+//
+// Users with two-factor authentication enabled are still challenged, since
+// the identity provider knows nothing about it.
+func (svc *service) LoginWithIdentity(ctx context.Context, identity user.ExternalIdentity) (*Result, error) {
+	u, err := svc.userSvc.AuthenticateWithIdentity(ctx, identity)
+	if err != nil {
+		if err == user.ErrIdentityConflict || err == user.ErrSuspended {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("session.Service.LoginWithIdentity: %s", err)
+	}
+
+	result, err := svc.login(u)
+	if err != nil {
+		return nil, fmt.Errorf("session.Service.LoginWithIdentity: %s", err)
+	}
+
+	return result, nil
+}
+
+func (svc *service) login(u *entity.User) (*Result, error) {
+	if u.TwoFactor.Enabled {
+		mfaToken, expiresAt, err := svc.tokens.Issue(u.ID, auth.PurposeMFA, svc.conf.MFATokenTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{
+			Challenge: &Challenge{
+				MFAToken:  mfaToken,
+				ExpiresAt: expiresAt,
+			},
+		}, nil
+	}
+
+	s, err := svc.start(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Session: s}, nil
+}
+
+func (svc *service) CompleteChallenge(ctx context.Context, mfaToken string, code string) (*Session, error) {
+	claims, err := svc.tokens.Parse(mfaToken, auth.PurposeMFA)
+	if err != nil {
+		return nil, err
+	}
+
+	// Challenges that were already completed are rejected before the code is
+	// verified, so that a replayed token cannot use up recovery codes.
+	if svc.isCompleted(claims.ID) {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	if err := svc.twoFactorSvc.Verify(ctx, claims.Subject, code); err != nil {
+		return nil, err
+	}
+
+	if !svc.complete(claims) {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	s, err := svc.start(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("session.Service.CompleteChallenge: %s", err)
+	}
+
+	return s, nil
+}
+
+func (svc *service) isCompleted(challengeID string) bool {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	_, ok := svc.completedChallenges[challengeID]
+	return ok
+}
+
+// complete marks the challenge of the MFA token as completed, unless a
+// concurrent request already did, in which case it returns false.
+//
+// Challenges are forgotten once their token expires, since it can no longer
+// be used anyway.
+func (svc *service) complete(claims *auth.Claims) bool {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	now := time.Now()
+	for id, expiresAt := range svc.completedChallenges {
+		if !now.Before(expiresAt) {
+			delete(svc.completedChallenges, id)
+		}
+	}
+
+	if _, ok := svc.completedChallenges[claims.ID]; ok {
+		return false
+	}
+
+	svc.completedChallenges[claims.ID] = time.Unix(claims.ExpiresAt, 0)
+
+	return true
+}
+
+func (svc *service) start(userID string) (*Session, error) {
+	accessToken, expiresAt, err := svc.tokens.Issue(userID, auth.PurposeAccess, svc.conf.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		UserID:      userID,
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
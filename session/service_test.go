@@ -0,0 +1,280 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/twofactor"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var tokens, _ = auth.NewTokens(bytes.Repeat([]byte{0x42}, auth.MinKeySize))
+
+func TestServiceConstructor(t *testing.T) {
+	userSvc := &mockUserService{}
+	twoFactorSvc := &mockTwoFactorService{}
+
+	t.Run("fails when user service is missing", func(t *testing.T) {
+		if _, err := NewService(nil, twoFactorSvc, tokens, Config{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when two-factor service is missing", func(t *testing.T) {
+		if _, err := NewService(userSvc, nil, tokens, Config{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when tokens are missing", func(t *testing.T) {
+		if _, err := NewService(userSvc, twoFactorSvc, nil, Config{}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("uses default token lifetimes when they are zero", func(t *testing.T) {
+		svc, _ := NewService(userSvc, twoFactorSvc, tokens, Config{})
+
+		conf := svc.(*service).conf
+		if conf.AccessTokenTTL != DefaultAccessTokenTTL || conf.MFATokenTTL != DefaultMFATokenTTL {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceLogin(t *testing.T) {
+	t.Run("fails when authentication fails", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{failOnAuthenticate: true}, &mockTwoFactorService{}, tokens, Config{})
+
+		if _, err := svc.Login(context.Background(), mockEmail, mockPassword); err != user.ErrInvalidCredentials {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a session with an access token when two-factor authentication is disabled", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		result, err := svc.Login(context.Background(), mockEmail, mockPassword)
+		if err != nil || result.Session == nil || result.Challenge != nil {
+			t.FailNow()
+		}
+
+		claims, err := tokens.Parse(result.Session.AccessToken, auth.PurposeAccess)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserID, claims.Subject) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a challenge with an MFA token when two-factor authentication is enabled", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{twoFactorEnabled: true}, &mockTwoFactorService{}, tokens, Config{})
+
+		result, err := svc.Login(context.Background(), mockEmail, mockPassword)
+		if err != nil || result.Challenge == nil || result.Session != nil {
+			t.FailNow()
+		}
+
+		if _, err := tokens.Parse(result.Challenge.MFAToken, auth.PurposeMFA); err != nil {
+			t.Fail()
+		}
+		if _, err := tokens.Parse(result.Challenge.MFAToken, auth.PurposeAccess); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceLoginWithIdentity(t *testing.T) {
+	identity := user.ExternalIdentity{
+		Provider:      "moosebook",
+		Subject:       "a.moosebook.subject",
+		Email:         mockEmail,
+		EmailVerified: true,
+	}
+
+	t.Run("fails with identity conflict when user service reports one", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{failOnAuthenticate: true}, &mockTwoFactorService{}, tokens, Config{})
+
+		if _, err := svc.LoginWithIdentity(context.Background(), identity); err != user.ErrIdentityConflict {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a session when two-factor authentication is disabled", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		result, err := svc.LoginWithIdentity(context.Background(), identity)
+		if err != nil || result.Session == nil {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserID, result.Session.UserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a challenge when two-factor authentication is enabled", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{twoFactorEnabled: true}, &mockTwoFactorService{}, tokens, Config{})
+
+		result, err := svc.LoginWithIdentity(context.Background(), identity)
+		if err != nil || result.Challenge == nil || result.Session != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceCompletingChallenge(t *testing.T) {
+	mfaToken, _, _ := tokens.Issue(mockUserID, auth.PurposeMFA, time.Minute)
+
+	t.Run("fails when MFA token is invalid", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+		accessToken, _, _ := tokens.Issue(mockUserID, auth.PurposeAccess, time.Minute)
+
+		if _, err := svc.CompleteChallenge(context.Background(), accessToken, "123456"); err != auth.ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when code is invalid", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{failOnVerify: true}, tokens, Config{})
+
+		if _, err := svc.CompleteChallenge(context.Background(), mfaToken, "123456"); err != twofactor.ErrInvalidCode {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a session when code is valid", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		s, err := svc.CompleteChallenge(context.Background(), mfaToken, "123456")
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserID, s.UserID) != 0 {
+			t.Fail()
+		}
+		if _, err := tokens.Parse(s.AccessToken, auth.PurposeAccess); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when challenge was already completed", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		if _, err := svc.CompleteChallenge(context.Background(), mfaToken, "123456"); err != nil {
+			t.FailNow()
+		}
+		if _, err := svc.CompleteChallenge(context.Background(), mfaToken, "654321"); err != auth.ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not complete challenge when code is invalid", func(t *testing.T) {
+		twoFactorSvc := &mockTwoFactorService{failOnVerify: true}
+		svc, _ := NewService(&mockUserService{}, twoFactorSvc, tokens, Config{})
+
+		svc.CompleteChallenge(context.Background(), mfaToken, "123456")
+		twoFactorSvc.failOnVerify = false
+
+		if _, err := svc.CompleteChallenge(context.Background(), mfaToken, "654321"); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+const (
+	mockUserID   = "mock.user.id"
+	mockEmail    = "moose@stmoosersburg.com"
+	mockPassword = "P@ssw0rd"
+)
+
+type mockUserService struct {
+	failOnAuthenticate bool
+	twoFactorEnabled   bool
+}
+
+func (mock *mockUserService) Register(_ context.Context, username string, email string, password string) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) Authenticate(_ context.Context, email string, password string) (*entity.User, error) {
+	if mock.failOnAuthenticate {
+		return nil, user.ErrInvalidCredentials
+	}
+
+	return &entity.User{
+		ID:        mockUserID,
+		Email:     email,
+		TwoFactor: entity.TwoFactor{Enabled: mock.twoFactorEnabled},
+	}, nil
+}
+
+func (mock *mockUserService) AuthenticateWithIdentity(_ context.Context, identity user.ExternalIdentity) (*entity.User, error) {
+	if mock.failOnAuthenticate {
+		return nil, user.ErrIdentityConflict
+	}
+
+	return &entity.User{
+		ID:        mockUserID,
+		Email:     identity.Email,
+		TwoFactor: entity.TwoFactor{Enabled: mock.twoFactorEnabled},
+	}, nil
+}
+
+func (mock *mockUserService) GetByID(_ context.Context, id string) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) GetByEmail(_ context.Context, email string) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) List(_ context.Context, offset int, limit int) ([]entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) Search(_ context.Context, query string, cursor string, limit int) (*user.SearchResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) ChangeRole(_ context.Context, id string, role entity.Role) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) SetSuspended(_ context.Context, id string, suspended bool) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) SetAvatar(_ context.Context, id string, avatarURL string) error {
+	return fmt.Errorf("not implemented")
+}
+
+type mockTwoFactorService struct {
+	failOnVerify bool
+}
+
+func (mock *mockTwoFactorService) Setup(_ context.Context, userID string) (*twofactor.Setup, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockTwoFactorService) Enable(_ context.Context, userID string, code string) ([]string, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockTwoFactorService) Disable(_ context.Context, userID string, code string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockTwoFactorService) Verify(_ context.Context, userID string, code string) error {
+	if mock.failOnVerify {
+		return twofactor.ErrInvalidCode
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+	"github.com/leblancjs/stmoosersburg-api/twofactor"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	loginHandler := stmhttp.NewHandler(
+		wrap("session.login")(makeLoginEndpoint(svc)),
+		decodeLoginRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	completeChallengeHandler := stmhttp.NewHandler(
+		wrap("session.completeChallenge")(makeCompleteChallengeEndpoint(svc)),
+		decodeCompleteChallengeRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/sessions", loginHandler).Methods("POST")
+	r.Handle("/v1/sessions/mfa", completeChallengeHandler).Methods("POST")
+
+	return r
+}
+
+func decodeLoginRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return loginRequest{
+		Email:    body.Email,
+		Password: body.Password,
+	}, nil
+}
+
+func decodeCompleteChallengeRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var body struct {
+		MFAToken string `json:"mfaToken"`
+		Code     string `json:"code"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return completeChallengeRequest{
+		MFAToken: body.MFAToken,
+		Code:     body.Code,
+	}, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case user.ErrInvalidCredentials, twofactor.ErrInvalidCode:
+		return http.StatusUnauthorized
+	case user.ErrSuspended:
+		return http.StatusForbidden
+	}
+
+	return 0
+})
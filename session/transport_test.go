@@ -0,0 +1,110 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+	"github.com/leblancjs/stmoosersburg-api/twofactor"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns a handler when all is well", func(t *testing.T) {
+		svc, _ := NewService(&mockUserService{}, &mockTwoFactorService{}, tokens, Config{})
+
+		if handler := MakeHandler(svc); handler == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestDecodingLoginRequest(t *testing.T) {
+	t.Run("fails when JSON decoder fails", func(t *testing.T) {
+		httpReq := stmhttptest.NewJSONRequest("POST", "/v1/sessions", "not.json.at.all")
+
+		if _, err := decodeLoginRequest(nil, httpReq); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a login request when all is well", func(t *testing.T) {
+		httpReq := httptest.NewRequest(
+			"POST",
+			"/v1/sessions",
+			bytes.NewBufferString(fmt.Sprintf(`{"email": "%s", "password": "%s"}`, mockEmail, mockPassword)),
+		)
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		req, err := decodeLoginRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		loginReq := req.(loginRequest)
+		if strings.Compare(mockEmail, loginReq.Email) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(mockPassword, loginReq.Password) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestDecodingCompleteChallengeRequest(t *testing.T) {
+	t.Run("fails when JSON decoder fails", func(t *testing.T) {
+		httpReq := stmhttptest.NewJSONRequest("POST", "/v1/sessions/mfa", "not.json.at.all")
+
+		if _, err := decodeCompleteChallengeRequest(nil, httpReq); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a complete challenge request when all is well", func(t *testing.T) {
+		httpReq := httptest.NewRequest(
+			"POST",
+			"/v1/sessions/mfa",
+			bytes.NewBufferString(`{"mfaToken": "a.token", "code": "123456"}`),
+		)
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		req, err := decodeCompleteChallengeRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		challengeReq := req.(completeChallengeRequest)
+		if strings.Compare("a.token", challengeReq.MFAToken) != 0 {
+			t.Fail()
+		}
+		if strings.Compare("123456", challengeReq.Code) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		user.ErrInvalidCredentials: http.StatusUnauthorized,
+		auth.ErrUnauthenticated:    http.StatusUnauthorized,
+		twofactor.ErrInvalidCode:   http.StatusUnauthorized,
+		user.ErrSuspended:          http.StatusForbidden,
+		fmt.Errorf("a bad error"):  http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
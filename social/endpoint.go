@@ -0,0 +1,242 @@
+package social
+
+import (
+	"context"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+const (
+	requestStatusPending  = "pending"
+	requestStatusAccepted = "accepted"
+)
+
+// userRequest represents a request about the relationships of a user.
+type userRequest struct {
+	UserID string
+}
+
+// relationshipRequest represents a request about the relationship between a
+// user and another one.
+type relationshipRequest struct {
+	UserID      string
+	OtherUserID string
+}
+
+type peerResponse struct {
+	UserID   string    `json:"userId"`
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`
+}
+
+func newPeerResponses(peers []Peer) []peerResponse {
+	responses := make([]peerResponse, 0, len(peers))
+	for _, p := range peers {
+		responses = append(responses, peerResponse{
+			UserID:   p.UserID,
+			Username: p.Username,
+			Since:    p.Since,
+		})
+	}
+
+	return responses
+}
+
+type listFriendsResponse struct {
+	Friends []peerResponse `json:"friends"`
+}
+
+func makeListFriendsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		friends, err := svc.ListFriends(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &listFriendsResponse{
+			Friends: newPeerResponses(friends),
+		}, nil
+	}
+}
+
+func makeRemoveFriendEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RemoveFriend(ctx, req.UserID, req.OtherUserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+type listRequestsResponse struct {
+	Incoming []peerResponse `json:"incoming"`
+	Outgoing []peerResponse `json:"outgoing"`
+}
+
+func makeListRequestsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		requests, err := svc.ListRequests(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &listRequestsResponse{
+			Incoming: newPeerResponses(requests.Incoming),
+			Outgoing: newPeerResponses(requests.Outgoing),
+		}, nil
+	}
+}
+
+type sendRequestResponse struct {
+	// Status is "accepted" when the other user had already sent a request,
+	// which makes both users friends, and "pending" otherwise.
+	Status string `json:"status"`
+}
+
+func makeSendRequestEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		accepted, err := svc.SendRequest(ctx, req.UserID, req.OtherUserID)
+		if err != nil {
+			return nil, err
+		}
+
+		status := requestStatusPending
+		if accepted {
+			status = requestStatusAccepted
+		}
+
+		return &sendRequestResponse{
+			Status: status,
+		}, nil
+	}
+}
+
+func makeAcceptRequestEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.AcceptRequest(ctx, req.UserID, req.OtherUserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func makeDeclineRequestEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.DeclineRequest(ctx, req.UserID, req.OtherUserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func makeCancelRequestEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.CancelRequest(ctx, req.UserID, req.OtherUserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+type listBlockedResponse struct {
+	Blocked []peerResponse `json:"blocked"`
+}
+
+func makeListBlockedEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		blocked, err := svc.ListBlocked(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &listBlockedResponse{
+			Blocked: newPeerResponses(blocked),
+		}, nil
+	}
+}
+
+func makeBlockEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.Block(ctx, req.UserID, req.OtherUserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func makeUnblockEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationshipRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.Unblock(ctx, req.UserID, req.OtherUserID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
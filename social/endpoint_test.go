@@ -0,0 +1,243 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestListFriendsEndpoint(t *testing.T) {
+	req := userRequest{UserID: mockUserID}
+
+	t.Run("fails when caller is anonymous", func(t *testing.T) {
+		endpoint := makeListFriendsEndpoint(&mockService{})
+
+		if _, err := endpoint(context.Background(), req); err != auth.ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when caller is not the user", func(t *testing.T) {
+		endpoint := makeListFriendsEndpoint(&mockService{})
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockOtherUserID})
+
+		if _, err := endpoint(ctx, req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		endpoint := makeListFriendsEndpoint(&mockService{fail: true})
+
+		if _, err := endpoint(userContext(), req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns friends when all is well", func(t *testing.T) {
+		endpoint := makeListFriendsEndpoint(&mockService{})
+
+		resp, err := endpoint(userContext(), req)
+		if err != nil {
+			t.FailNow()
+		}
+
+		friends := resp.(*listFriendsResponse).Friends
+		if len(friends) != 1 || strings.Compare(mockOtherUserID, friends[0].UserID) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestListRequestsEndpoint(t *testing.T) {
+	t.Run("returns empty lists rather than null", func(t *testing.T) {
+		endpoint := makeListRequestsEndpoint(&mockService{})
+
+		resp, err := endpoint(userContext(), userRequest{UserID: mockUserID})
+		if err != nil {
+			t.FailNow()
+		}
+
+		requests := resp.(*listRequestsResponse)
+		if requests.Incoming == nil || requests.Outgoing == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestSendRequestEndpoint(t *testing.T) {
+	req := relationshipRequest{UserID: mockUserID, OtherUserID: mockOtherUserID}
+
+	t.Run("fails when caller is not the user", func(t *testing.T) {
+		endpoint := makeSendRequestEndpoint(&mockService{})
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockOtherUserID})
+
+		if _, err := endpoint(ctx, req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		endpoint := makeSendRequestEndpoint(&mockService{fail: true})
+
+		if _, err := endpoint(userContext(), req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns pending status when request is sent", func(t *testing.T) {
+		endpoint := makeSendRequestEndpoint(&mockService{})
+
+		resp, _ := endpoint(userContext(), req)
+		if strings.Compare(requestStatusPending, resp.(*sendRequestResponse).Status) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns accepted status when request from other user is accepted", func(t *testing.T) {
+		endpoint := makeSendRequestEndpoint(&mockService{accept: true})
+
+		resp, _ := endpoint(userContext(), req)
+		if strings.Compare(requestStatusAccepted, resp.(*sendRequestResponse).Status) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestBlockEndpoint(t *testing.T) {
+	req := relationshipRequest{UserID: mockUserID, OtherUserID: mockOtherUserID}
+
+	t.Run("fails when caller is not the user", func(t *testing.T) {
+		endpoint := makeBlockEndpoint(&mockService{})
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockOtherUserID})
+
+		if _, err := endpoint(ctx, req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		endpoint := makeBlockEndpoint(&mockService{fail: true})
+
+		if _, err := endpoint(userContext(), req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns nothing when all is well", func(t *testing.T) {
+		endpoint := makeBlockEndpoint(&mockService{})
+
+		if resp, err := endpoint(userContext(), req); err != nil || resp != nil {
+			t.Fail()
+		}
+	})
+}
+
+func userContext() context.Context {
+	return auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
+}
+
+type mockService struct {
+	fail   bool
+	accept bool
+}
+
+func (mock *mockService) SendRequest(_ context.Context, fromUserID string, toUserID string) (bool, error) {
+	if mock.fail {
+		return false, ErrBlocked
+	}
+
+	return mock.accept, nil
+}
+
+func (mock *mockService) AcceptRequest(_ context.Context, userID string, fromUserID string) error {
+	if mock.fail {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (mock *mockService) DeclineRequest(_ context.Context, userID string, fromUserID string) error {
+	if mock.fail {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (mock *mockService) CancelRequest(_ context.Context, userID string, toUserID string) error {
+	if mock.fail {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (mock *mockService) ListRequests(_ context.Context, userID string) (*Requests, error) {
+	if mock.fail {
+		return nil, fmt.Errorf("failed to list requests")
+	}
+
+	return &Requests{Incoming: []Peer{}, Outgoing: []Peer{}}, nil
+}
+
+func (mock *mockService) ListFriends(_ context.Context, userID string) ([]Peer, error) {
+	if mock.fail {
+		return nil, fmt.Errorf("failed to list friends")
+	}
+
+	return []Peer{{UserID: mockOtherUserID, Username: mockOtherUsername, Since: time.Now()}}, nil
+}
+
+func (mock *mockService) AreFriends(_ context.Context, userID string, otherUserID string) (bool, error) {
+	if mock.fail {
+		return false, fmt.Errorf("failed to check friendship")
+	}
+
+	return true, nil
+}
+
+func (mock *mockService) RemoveFriend(_ context.Context, userID string, friendID string) error {
+	if mock.fail {
+		return ErrNotFriends
+	}
+
+	return nil
+}
+
+func (mock *mockService) Block(_ context.Context, userID string, blockedID string) error {
+	if mock.fail {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (mock *mockService) Unblock(_ context.Context, userID string, blockedID string) error {
+	if mock.fail {
+		return fmt.Errorf("failed to unblock")
+	}
+
+	return nil
+}
+
+func (mock *mockService) ListBlocked(_ context.Context, userID string) ([]Peer, error) {
+	if mock.fail {
+		return nil, fmt.Errorf("failed to list blocked users")
+	}
+
+	return []Peer{}, nil
+}
+
+func (mock *mockService) IsBlocked(_ context.Context, userID string, otherUserID string) (bool, error) {
+	if mock.fail {
+		return false, fmt.Errorf("failed to check blocks")
+	}
+
+	return false, nil
+}
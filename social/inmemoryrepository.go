@@ -0,0 +1,273 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inMemoryRepository struct {
+	database *db.InMemory
+}
+
+func NewInMemoryRepository(database *db.InMemory) Repository {
+	return &inMemoryRepository{database}
+}
+
+func (repo *inMemoryRepository) CreateRequest(_ context.Context, request entity.FriendRequest) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, r := range repo.database.FriendRequests {
+		if r.FromUserID == request.FromUserID && r.ToUserID == request.ToUserID {
+			return fmt.Errorf(
+				"social.InMemoryRepository.CreateRequest: user \"%s\" already sent a request to user \"%s\"",
+				request.FromUserID,
+				request.ToUserID,
+			)
+		}
+	}
+
+	repo.database.FriendRequests = append(repo.database.FriendRequests, request)
+
+	return nil
+}
+
+func (repo *inMemoryRepository) GetRequest(_ context.Context, fromUserID string, toUserID string) (*entity.FriendRequest, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, r := range repo.database.FriendRequests {
+		if r.FromUserID == fromUserID && r.ToUserID == toUserID {
+			return &r, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *inMemoryRepository) DeleteRequest(_ context.Context, fromUserID string, toUserID string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if !repo.deleteRequests(func(r entity.FriendRequest) bool {
+		return r.FromUserID == fromUserID && r.ToUserID == toUserID
+	}) {
+		return fmt.Errorf(
+			"social.InMemoryRepository.DeleteRequest: no request exists from user \"%s\" to user \"%s\"",
+			fromUserID,
+			toUserID,
+		)
+	}
+
+	return nil
+}
+
+func (repo *inMemoryRepository) ListIncomingRequests(_ context.Context, userID string) ([]entity.FriendRequest, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	requests := make([]entity.FriendRequest, 0)
+
+	for _, r := range repo.database.FriendRequests {
+		if r.ToUserID == userID {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests, nil
+}
+
+func (repo *inMemoryRepository) ListOutgoingRequests(_ context.Context, userID string) ([]entity.FriendRequest, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	requests := make([]entity.FriendRequest, 0)
+
+	for _, r := range repo.database.FriendRequests {
+		if r.FromUserID == userID {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests, nil
+}
+
+func (repo *inMemoryRepository) AcceptRequest(_ context.Context, fromUserID string, toUserID string, since time.Time) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if !repo.deleteRequests(func(r entity.FriendRequest) bool {
+		return r.FromUserID == fromUserID && r.ToUserID == toUserID
+	}) {
+		return fmt.Errorf(
+			"social.InMemoryRepository.AcceptRequest: no request exists from user \"%s\" to user \"%s\"",
+			fromUserID,
+			toUserID,
+		)
+	}
+
+	repo.database.Friendships = append(
+		repo.database.Friendships,
+		entity.Friendship{UserID: fromUserID, FriendID: toUserID, Since: since},
+		entity.Friendship{UserID: toUserID, FriendID: fromUserID, Since: since},
+	)
+
+	return nil
+}
+
+func (repo *inMemoryRepository) AreFriends(_ context.Context, userID string, otherUserID string) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, f := range repo.database.Friendships {
+		if f.UserID == userID && f.FriendID == otherUserID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (repo *inMemoryRepository) ListFriends(_ context.Context, userID string) ([]entity.Friendship, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	friendships := make([]entity.Friendship, 0)
+
+	for _, f := range repo.database.Friendships {
+		if f.UserID == userID {
+			friendships = append(friendships, f)
+		}
+	}
+
+	return friendships, nil
+}
+
+func (repo *inMemoryRepository) DeleteFriendship(_ context.Context, userID string, friendID string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if !repo.deleteFriendships(userID, friendID) {
+		return fmt.Errorf(
+			"social.InMemoryRepository.DeleteFriendship: user \"%s\" is not friends with user \"%s\"",
+			userID,
+			friendID,
+		)
+	}
+
+	return nil
+}
+
+func (repo *inMemoryRepository) Block(_ context.Context, block entity.Block) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if !repo.hasBlocked(block.BlockerID, block.BlockedID) {
+		repo.database.Blocks = append(repo.database.Blocks, block)
+	}
+
+	repo.deleteFriendships(block.BlockerID, block.BlockedID)
+	repo.deleteRequests(func(r entity.FriendRequest) bool {
+		return between(r.FromUserID, r.ToUserID, block.BlockerID, block.BlockedID)
+	})
+
+	return nil
+}
+
+func (repo *inMemoryRepository) Unblock(_ context.Context, blockerID string, blockedID string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	blocks := repo.database.Blocks[:0]
+
+	for _, b := range repo.database.Blocks {
+		if b.BlockerID != blockerID || b.BlockedID != blockedID {
+			blocks = append(blocks, b)
+		}
+	}
+
+	repo.database.Blocks = blocks
+
+	return nil
+}
+
+func (repo *inMemoryRepository) IsBlocked(_ context.Context, userID string, otherUserID string) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, b := range repo.database.Blocks {
+		if between(b.BlockerID, b.BlockedID, userID, otherUserID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (repo *inMemoryRepository) ListBlocked(_ context.Context, userID string) ([]entity.Block, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	blocks := make([]entity.Block, 0)
+
+	for _, b := range repo.database.Blocks {
+		if b.BlockerID == userID {
+			blocks = append(blocks, b)
+		}
+	}
+
+	return blocks, nil
+}
+
+func (repo *inMemoryRepository) hasBlocked(blockerID string, blockedID string) bool {
+	for _, b := range repo.database.Blocks {
+		if b.BlockerID == blockerID && b.BlockedID == blockedID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteRequests deletes the requests that match, and tells whether any did.
+func (repo *inMemoryRepository) deleteRequests(matches func(entity.FriendRequest) bool) bool {
+	requests := repo.database.FriendRequests[:0]
+
+	for _, r := range repo.database.FriendRequests {
+		if !matches(r) {
+			requests = append(requests, r)
+		}
+	}
+
+	deleted := len(requests) != len(repo.database.FriendRequests)
+	repo.database.FriendRequests = requests
+
+	return deleted
+}
+
+// deleteFriendships deletes both sides of the friendship between the users,
+// and tells whether they were friends.
+func (repo *inMemoryRepository) deleteFriendships(userID string, otherUserID string) bool {
+	friendships := repo.database.Friendships[:0]
+
+	for _, f := range repo.database.Friendships {
+		if !between(f.UserID, f.FriendID, userID, otherUserID) {
+			friendships = append(friendships, f)
+		}
+	}
+
+	deleted := len(friendships) != len(repo.database.Friendships)
+	repo.database.Friendships = friendships
+
+	return deleted
+}
+
+// between tells whether a relationship from a to b is between the two users,
+// in either direction.
+func between(a string, b string, userID string, otherUserID string) bool {
+	return (a == userID && b == otherUserID) || (a == otherUserID && b == userID)
+}
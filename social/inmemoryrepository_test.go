@@ -0,0 +1,194 @@
+package social
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func newInMemoryRepository() Repository {
+	database := &db.InMemory{}
+	database.Open()
+
+	return NewInMemoryRepository(database)
+}
+
+func TestInMemoryRepositoryRequests(t *testing.T) {
+	request := entity.FriendRequest{FromUserID: mockUserID, ToUserID: mockOtherUserID, CreatedAt: time.Now()}
+
+	t.Run("fails to create a request that already exists", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.CreateRequest(context.Background(), request)
+
+		if err := repo.CreateRequest(context.Background(), request); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns nil when getting a request that does not exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.CreateRequest(context.Background(), request)
+
+		r, err := repo.GetRequest(context.Background(), mockOtherUserID, mockUserID)
+		if err != nil || r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("lists requests by direction", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.CreateRequest(context.Background(), request)
+
+		incoming, _ := repo.ListIncomingRequests(context.Background(), mockOtherUserID)
+		outgoing, _ := repo.ListOutgoingRequests(context.Background(), mockOtherUserID)
+		if len(incoming) != 1 || len(outgoing) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to delete a request that does not exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if err := repo.DeleteRequest(context.Background(), mockUserID, mockOtherUserID); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepting a request deletes it and makes both users friends", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.CreateRequest(context.Background(), request)
+
+		if err := repo.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, time.Now()); err != nil {
+			t.FailNow()
+		}
+
+		if r, _ := repo.GetRequest(context.Background(), mockUserID, mockOtherUserID); r != nil {
+			t.Fail()
+		}
+		if friends, _ := repo.AreFriends(context.Background(), mockUserID, mockOtherUserID); !friends {
+			t.Fail()
+		}
+		if friends, _ := repo.AreFriends(context.Background(), mockOtherUserID, mockUserID); !friends {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to accept a request that does not exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if err := repo.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, time.Now()); err == nil {
+			t.Fail()
+		}
+		if friends, _ := repo.AreFriends(context.Background(), mockUserID, mockOtherUserID); friends {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryFriendships(t *testing.T) {
+	befriend := func(repo Repository) {
+		repo.CreateRequest(context.Background(), entity.FriendRequest{FromUserID: mockUserID, ToUserID: mockOtherUserID})
+		repo.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, time.Now())
+	}
+
+	t.Run("lists the user's side of friendships", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		befriend(repo)
+
+		friendships, _ := repo.ListFriends(context.Background(), mockOtherUserID)
+		if len(friendships) != 1 || friendships[0].FriendID != mockUserID {
+			t.Fail()
+		}
+	})
+
+	t.Run("deletes both sides of a friendship", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		befriend(repo)
+
+		if err := repo.DeleteFriendship(context.Background(), mockOtherUserID, mockUserID); err != nil {
+			t.FailNow()
+		}
+
+		if friendships, _ := repo.ListFriends(context.Background(), mockUserID); len(friendships) != 0 {
+			t.Fail()
+		}
+		if friendships, _ := repo.ListFriends(context.Background(), mockOtherUserID); len(friendships) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to delete a friendship that does not exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+
+		if err := repo.DeleteFriendship(context.Background(), mockUserID, mockOtherUserID); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryBlocks(t *testing.T) {
+	block := entity.Block{BlockerID: mockUserID, BlockedID: mockOtherUserID, CreatedAt: time.Now()}
+
+	t.Run("blocking ends the friendship and deletes requests in both directions", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.CreateRequest(context.Background(), entity.FriendRequest{FromUserID: mockUserID, ToUserID: mockOtherUserID})
+		repo.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, time.Now())
+		repo.CreateRequest(context.Background(), entity.FriendRequest{FromUserID: mockOtherUserID, ToUserID: mockUserID})
+
+		if err := repo.Block(context.Background(), block); err != nil {
+			t.FailNow()
+		}
+
+		if friends, _ := repo.AreFriends(context.Background(), mockUserID, mockOtherUserID); friends {
+			t.Fail()
+		}
+		if r, _ := repo.GetRequest(context.Background(), mockOtherUserID, mockUserID); r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("blocking twice keeps a single block", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Block(context.Background(), block)
+		repo.Block(context.Background(), block)
+
+		if blocks, _ := repo.ListBlocked(context.Background(), mockUserID); len(blocks) != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("tells whether either user blocked the other", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Block(context.Background(), block)
+
+		if blocked, _ := repo.IsBlocked(context.Background(), mockUserID, mockOtherUserID); !blocked {
+			t.Fail()
+		}
+		if blocked, _ := repo.IsBlocked(context.Background(), mockOtherUserID, mockUserID); !blocked {
+			t.Fail()
+		}
+		if blocks, _ := repo.ListBlocked(context.Background(), mockOtherUserID); len(blocks) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("unblocking only removes the blocker's block", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Block(context.Background(), block)
+		repo.Block(context.Background(), entity.Block{BlockerID: mockOtherUserID, BlockedID: mockUserID})
+
+		if err := repo.Unblock(context.Background(), mockUserID, mockOtherUserID); err != nil {
+			t.FailNow()
+		}
+
+		if blocks, _ := repo.ListBlocked(context.Background(), mockUserID); len(blocks) != 0 {
+			t.Fail()
+		}
+		if blocked, _ := repo.IsBlocked(context.Background(), mockUserID, mockOtherUserID); !blocked {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,325 @@
+package social
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+const (
+	createRequestQuery        = "INSERT INTO friend_requests(from_user_id, to_user_id, created_at) VALUES($1, $2, $3)"
+	getRequestQuery           = "SELECT from_user_id, to_user_id, created_at FROM friend_requests WHERE from_user_id = $1 AND to_user_id = $2"
+	deleteRequestQuery        = "DELETE FROM friend_requests WHERE from_user_id = $1 AND to_user_id = $2"
+	deleteRequestsQuery       = "DELETE FROM friend_requests WHERE (from_user_id = $1 AND to_user_id = $2) OR (from_user_id = $2 AND to_user_id = $1)"
+	listIncomingRequestsQuery = "SELECT from_user_id, to_user_id, created_at FROM friend_requests WHERE to_user_id = $1 ORDER BY created_at"
+	listOutgoingRequestsQuery = "SELECT from_user_id, to_user_id, created_at FROM friend_requests WHERE from_user_id = $1 ORDER BY created_at"
+
+	createFriendshipQuery  = "INSERT INTO friendships(user_id, friend_id, since) VALUES($1, $2, $3), ($2, $1, $3)"
+	areFriendsQuery        = "SELECT EXISTS(SELECT 1 FROM friendships WHERE user_id = $1 AND friend_id = $2)"
+	listFriendsQuery       = "SELECT user_id, friend_id, since FROM friendships WHERE user_id = $1 ORDER BY since"
+	deleteFriendshipsQuery = "DELETE FROM friendships WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)"
+
+	createBlockQuery = "INSERT INTO blocks(blocker_id, blocked_id, created_at) VALUES($1, $2, $3) ON CONFLICT DO NOTHING"
+	deleteBlockQuery = "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2"
+	isBlockedQuery   = "SELECT EXISTS(SELECT 1 FROM blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))"
+	listBlockedQuery = "SELECT blocker_id, blocked_id, created_at FROM blocks WHERE blocker_id = $1 ORDER BY created_at"
+)
+
+type postgresRepository struct {
+	database *db.Postgres
+}
+
+func NewPostgresRepository(database *db.Postgres) Repository {
+	return &postgresRepository{database}
+}
+
+func (pr *postgresRepository) CreateRequest(ctx context.Context, request entity.FriendRequest) error {
+	_, err := pr.database.ExecContext(ctx, createRequestQuery, request.FromUserID, request.ToUserID, request.CreatedAt)
+	if err != nil {
+		return fmt.Errorf(
+			"social.PostgresRepository.CreateRequest: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) GetRequest(ctx context.Context, fromUserID string, toUserID string) (*entity.FriendRequest, error) {
+	var request entity.FriendRequest
+
+	err := pr.database.QueryRowContext(ctx, getRequestQuery, fromUserID, toUserID).Scan(
+		&request.FromUserID,
+		&request.ToUserID,
+		&request.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"social.PostgresRepository.GetRequest: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return &request, nil
+}
+
+func (pr *postgresRepository) DeleteRequest(ctx context.Context, fromUserID string, toUserID string) error {
+	result, err := pr.database.ExecContext(ctx, deleteRequestQuery, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf(
+			"social.PostgresRepository.DeleteRequest: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"social.PostgresRepository.DeleteRequest: failed to count deleted rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf(
+			"social.PostgresRepository.DeleteRequest: no request exists from user \"%s\" to user \"%s\"",
+			fromUserID,
+			toUserID,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) ListIncomingRequests(ctx context.Context, userID string) ([]entity.FriendRequest, error) {
+	requests, err := pr.listRequests(ctx, listIncomingRequestsQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("social.PostgresRepository.ListIncomingRequests: %s", err)
+	}
+
+	return requests, nil
+}
+
+func (pr *postgresRepository) ListOutgoingRequests(ctx context.Context, userID string) ([]entity.FriendRequest, error) {
+	requests, err := pr.listRequests(ctx, listOutgoingRequestsQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf("social.PostgresRepository.ListOutgoingRequests: %s", err)
+	}
+
+	return requests, nil
+}
+
+func (pr *postgresRepository) listRequests(ctx context.Context, query string, userID string) ([]entity.FriendRequest, error) {
+	rows, err := pr.database.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query (%s)", err)
+	}
+	defer rows.Close()
+
+	requests := make([]entity.FriendRequest, 0)
+	for rows.Next() {
+		var request entity.FriendRequest
+		if err := rows.Scan(&request.FromUserID, &request.ToUserID, &request.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read request (%s)", err)
+		}
+
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read requests (%s)", err)
+	}
+
+	return requests, nil
+}
+
+func (pr *postgresRepository) AcceptRequest(ctx context.Context, fromUserID string, toUserID string, since time.Time) error {
+	err := pr.database.InTransactionContext(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, deleteRequestQuery, fromUserID, toUserID)
+		if err != nil {
+			return fmt.Errorf("failed to delete request (%s)", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to count deleted rows (%s)", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("no request exists from user \"%s\" to user \"%s\"", fromUserID, toUserID)
+		}
+
+		if _, err := tx.ExecContext(ctx, createFriendshipQuery, fromUserID, toUserID, since); err != nil {
+			return fmt.Errorf("failed to create friendship (%s)", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("social.PostgresRepository.AcceptRequest: %s", err)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) AreFriends(ctx context.Context, userID string, otherUserID string) (bool, error) {
+	var friends bool
+
+	err := pr.database.QueryRowContext(ctx, areFriendsQuery, userID, otherUserID).Scan(&friends)
+	if err != nil {
+		return false, fmt.Errorf(
+			"social.PostgresRepository.AreFriends: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return friends, nil
+}
+
+func (pr *postgresRepository) ListFriends(ctx context.Context, userID string) ([]entity.Friendship, error) {
+	rows, err := pr.database.QueryContext(ctx, listFriendsQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"social.PostgresRepository.ListFriends: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	friendships := make([]entity.Friendship, 0)
+	for rows.Next() {
+		var friendship entity.Friendship
+		if err := rows.Scan(&friendship.UserID, &friendship.FriendID, &friendship.Since); err != nil {
+			return nil, fmt.Errorf(
+				"social.PostgresRepository.ListFriends: failed to read friendship (%s)",
+				err,
+			)
+		}
+
+		friendships = append(friendships, friendship)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"social.PostgresRepository.ListFriends: failed to read friendships (%s)",
+			err,
+		)
+	}
+
+	return friendships, nil
+}
+
+func (pr *postgresRepository) DeleteFriendship(ctx context.Context, userID string, friendID string) error {
+	result, err := pr.database.ExecContext(ctx, deleteFriendshipsQuery, userID, friendID)
+	if err != nil {
+		return fmt.Errorf(
+			"social.PostgresRepository.DeleteFriendship: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"social.PostgresRepository.DeleteFriendship: failed to count deleted rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf(
+			"social.PostgresRepository.DeleteFriendship: user \"%s\" is not friends with user \"%s\"",
+			userID,
+			friendID,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) Block(ctx context.Context, block entity.Block) error {
+	err := pr.database.InTransactionContext(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, createBlockQuery, block.BlockerID, block.BlockedID, block.CreatedAt); err != nil {
+			return fmt.Errorf("failed to create block (%s)", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteFriendshipsQuery, block.BlockerID, block.BlockedID); err != nil {
+			return fmt.Errorf("failed to delete friendship (%s)", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteRequestsQuery, block.BlockerID, block.BlockedID); err != nil {
+			return fmt.Errorf("failed to delete requests (%s)", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("social.PostgresRepository.Block: %s", err)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) Unblock(ctx context.Context, blockerID string, blockedID string) error {
+	_, err := pr.database.ExecContext(ctx, deleteBlockQuery, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf(
+			"social.PostgresRepository.Unblock: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) IsBlocked(ctx context.Context, userID string, otherUserID string) (bool, error) {
+	var blocked bool
+
+	err := pr.database.QueryRowContext(ctx, isBlockedQuery, userID, otherUserID).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf(
+			"social.PostgresRepository.IsBlocked: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return blocked, nil
+}
+
+func (pr *postgresRepository) ListBlocked(ctx context.Context, userID string) ([]entity.Block, error) {
+	rows, err := pr.database.QueryContext(ctx, listBlockedQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"social.PostgresRepository.ListBlocked: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	blocks := make([]entity.Block, 0)
+	for rows.Next() {
+		var block entity.Block
+		if err := rows.Scan(&block.BlockerID, &block.BlockedID, &block.CreatedAt); err != nil {
+			return nil, fmt.Errorf(
+				"social.PostgresRepository.ListBlocked: failed to read block (%s)",
+				err,
+			)
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"social.PostgresRepository.ListBlocked: failed to read blocks (%s)",
+			err,
+		)
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,349 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func TestPostgresRepositoryCreation(t *testing.T) {
+	database := &db.Postgres{}
+
+	t.Run("returns a postgres repository that uses the given database", func(t *testing.T) {
+		pr, ok := NewPostgresRepository(database).(*postgresRepository)
+		if !ok {
+			t.FailNow()
+		}
+
+		if pr.database != database {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryGettingRequest(t *testing.T) {
+	queryResultColumns := []string{"from_user_id", "to_user_id", "created_at"}
+	createdAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	t.Run("returns nil when no request exists", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getRequestQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnRows(mock.NewRows(queryResultColumns))
+
+		r, err := pr.GetRequest(context.Background(), mockUserID, mockOtherUserID)
+		if err != nil || r != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getRequestQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.GetRequest(context.Background(), mockUserID, mockOtherUserID); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the request when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getRequestQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnRows(mock.NewRows(queryResultColumns).AddRow(mockUserID, mockOtherUserID, createdAt))
+
+		r, err := pr.GetRequest(context.Background(), mockUserID, mockOtherUserID)
+		if err != nil || r == nil {
+			t.FailNow()
+		}
+		if r.FromUserID != mockUserID || r.ToUserID != mockOtherUserID || !r.CreatedAt.Equal(createdAt) {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryAcceptingRequest(t *testing.T) {
+	since := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	t.Run("rolls back when no request exists", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteRequestQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		if err := pr.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, since); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("rolls back when friendship cannot be created", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteRequestQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(createFriendshipQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+		mock.ExpectRollback()
+
+		if err := pr.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, since); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("deletes the request and creates the friendship when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteRequestQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(createFriendshipQuery).
+			WithArgs(mockUserID, mockOtherUserID, since).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		if err := pr.AcceptRequest(context.Background(), mockUserID, mockOtherUserID, since); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryListingFriends(t *testing.T) {
+	queryResultColumns := []string{"user_id", "friend_id", "since"}
+	since := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listFriendsQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.ListFriends(context.Background(), mockUserID); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the friendships when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(listFriendsQuery).
+			WithArgs(mockUserID).
+			WillReturnRows(mock.NewRows(queryResultColumns).AddRow(mockUserID, mockOtherUserID, since))
+
+		friendships, err := pr.ListFriends(context.Background(), mockUserID)
+		if err != nil || len(friendships) != 1 {
+			t.FailNow()
+		}
+		if friendships[0].FriendID != mockOtherUserID {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryDeletingFriendship(t *testing.T) {
+	t.Run("fails when users are not friends", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(deleteFriendshipsQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.DeleteFriendship(context.Background(), mockUserID, mockOtherUserID); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("deletes both sides of the friendship when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(deleteFriendshipsQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		if err := pr.DeleteFriendship(context.Background(), mockUserID, mockOtherUserID); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryBlocking(t *testing.T) {
+	block := entity.Block{
+		BlockerID: mockUserID,
+		BlockedID: mockOtherUserID,
+		CreatedAt: time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+	}
+
+	t.Run("rolls back when friendship cannot be deleted", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(createBlockQuery).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteFriendshipsQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+		mock.ExpectRollback()
+
+		if err := pr.Block(context.Background(), block); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("blocks, and deletes the friendship and requests, when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(createBlockQuery).
+			WithArgs(mockUserID, mockOtherUserID, block.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteFriendshipsQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(deleteRequestsQuery).
+			WithArgs(mockUserID, mockOtherUserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		if err := pr.Block(context.Background(), block); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryCheckingBlock(t *testing.T) {
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(isBlockedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.IsBlocked(context.Background(), mockUserID, mockOtherUserID); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("tells whether either user blocked the other when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(isBlockedQuery).
+			WithArgs(mockOtherUserID, mockUserID).
+			WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+
+		if blocked, err := pr.IsBlocked(context.Background(), mockOtherUserID, mockUserID); err != nil || !blocked {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,56 @@
+// Package social lets users befriend and block each other.
+package social
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type Repository interface {
+	CreateRequest(ctx context.Context, request entity.FriendRequest) error
+	// GetRequest returns the request from one user to the other, or nil if
+	// there is none.
+	GetRequest(ctx context.Context, fromUserID string, toUserID string) (*entity.FriendRequest, error)
+	DeleteRequest(ctx context.Context, fromUserID string, toUserID string) error
+	// ListIncomingRequests returns the requests sent to the user, oldest
+	// first.
+	ListIncomingRequests(ctx context.Context, userID string) ([]entity.FriendRequest, error)
+	// ListOutgoingRequests returns the requests sent by the user, oldest
+	// first.
+	ListOutgoingRequests(ctx context.Context, userID string) ([]entity.FriendRequest, error)
+
+	// AcceptRequest deletes the request and makes both users friends, at the
+	// same time.
+	AcceptRequest(ctx context.Context, fromUserID string, toUserID string, since time.Time) error
+	AreFriends(ctx context.Context, userID string, otherUserID string) (bool, error)
+	// ListFriends returns the user's side of their friendships, oldest first.
+	ListFriends(ctx context.Context, userID string) ([]entity.Friendship, error)
+	// DeleteFriendship deletes both sides of the friendship.
+	DeleteFriendship(ctx context.Context, userID string, friendID string) error
+
+	// Block blocks the user, and deletes the friendship and the requests
+	// between both users, at the same time. Blocking a user twice does
+	// nothing.
+	Block(ctx context.Context, block entity.Block) error
+	// Unblock unblocks the user. Unblocking a user who is not blocked does
+	// nothing.
+	Unblock(ctx context.Context, blockerID string, blockedID string) error
+	// IsBlocked tells whether either user blocked the other.
+	IsBlocked(ctx context.Context, userID string, otherUserID string) (bool, error)
+	// ListBlocked returns the blocks made by the user, oldest first.
+	ListBlocked(ctx context.Context, userID string) ([]entity.Block, error)
+}
+
+func NewRepository(database db.DB) (Repository, error) {
+	if inmemory, ok := database.(*db.InMemory); ok {
+		return NewInMemoryRepository(inmemory), nil
+	} else if postgres, ok := database.(*db.Postgres); ok {
+		return NewPostgresRepository(postgres), nil
+	}
+
+	return nil, fmt.Errorf("social.NewRepository: unsupported database type")
+}
@@ -0,0 +1,365 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user does not exist")
+	ErrSelf               = errors.New("users cannot befriend or block themselves")
+	ErrAlreadyFriends     = errors.New("users are already friends")
+	ErrRequestAlreadySent = errors.New("friend request has already been sent")
+	ErrRequestNotFound    = errors.New("friend request does not exist")
+	ErrNotFriends         = errors.New("users are not friends")
+	ErrBlocked            = errors.New("one of the users has blocked the other")
+)
+
+// Peer represents another user that a user has a relationship with, such as
+// a friend, along with when the relationship started.
+type Peer struct {
+	UserID   string
+	Username string
+	Since    time.Time
+}
+
+// Requests represents a user's pending friend requests.
+type Requests struct {
+	// Incoming are the requests sent to the user, who can accept or decline
+	// them.
+	Incoming []Peer
+
+	// Outgoing are the requests sent by the user, who can cancel them.
+	Outgoing []Peer
+}
+
+type Service interface {
+	// SendRequest sends a friend request, unless the other user already sent
+	// one, in which case it is accepted instead, and tells whether the users
+	// are now friends.
+	SendRequest(ctx context.Context, fromUserID string, toUserID string) (bool, error)
+	AcceptRequest(ctx context.Context, userID string, fromUserID string) error
+	DeclineRequest(ctx context.Context, userID string, fromUserID string) error
+	CancelRequest(ctx context.Context, userID string, toUserID string) error
+	ListRequests(ctx context.Context, userID string) (*Requests, error)
+
+	// ListFriends returns the user's friends, ordered by username.
+	ListFriends(ctx context.Context, userID string) ([]Peer, error)
+	AreFriends(ctx context.Context, userID string, otherUserID string) (bool, error)
+	RemoveFriend(ctx context.Context, userID string, friendID string) error
+
+	// Block prevents both users from befriending or inviting each other,
+	// and ends their friendship, if they were friends.
+	Block(ctx context.Context, userID string, blockedID string) error
+	Unblock(ctx context.Context, userID string, blockedID string) error
+	ListBlocked(ctx context.Context, userID string) ([]Peer, error)
+
+	// IsBlocked tells whether either user blocked the other, which must be
+	// checked before users interact with each other, such as when they are
+	// invited to games.
+	IsBlocked(ctx context.Context, userID string, otherUserID string) (bool, error)
+}
+
+type service struct {
+	repo    Repository
+	userSvc user.Service
+	now     func() time.Time
+}
+
+func NewService(repo Repository, userSvc user.Service) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("social.NewService: repository is required")
+	}
+
+	if userSvc == nil {
+		return nil, fmt.Errorf("social.NewService: user service is required")
+	}
+
+	return &service{
+		repo:    repo,
+		userSvc: userSvc,
+		now:     time.Now,
+	}, nil
+}
+
+func (svc *service) SendRequest(ctx context.Context, fromUserID string, toUserID string) (bool, error) {
+	if fromUserID == toUserID {
+		return false, ErrSelf
+	}
+
+	if err := svc.requireUser(ctx, toUserID); err != nil {
+		return false, err
+	}
+
+	blocked, err := svc.repo.IsBlocked(ctx, fromUserID, toUserID)
+	if err != nil {
+		return false, fmt.Errorf("social.Service.SendRequest: %s", err)
+	}
+	if blocked {
+		return false, ErrBlocked
+	}
+
+	friends, err := svc.repo.AreFriends(ctx, fromUserID, toUserID)
+	if err != nil {
+		return false, fmt.Errorf("social.Service.SendRequest: %s", err)
+	}
+	if friends {
+		return false, ErrAlreadyFriends
+	}
+
+	sent, err := svc.repo.GetRequest(ctx, fromUserID, toUserID)
+	if err != nil {
+		return false, fmt.Errorf("social.Service.SendRequest: %s", err)
+	}
+	if sent != nil {
+		return false, ErrRequestAlreadySent
+	}
+
+	received, err := svc.repo.GetRequest(ctx, toUserID, fromUserID)
+	if err != nil {
+		return false, fmt.Errorf("social.Service.SendRequest: %s", err)
+	}
+	if received != nil {
+		if err := svc.repo.AcceptRequest(ctx, toUserID, fromUserID, svc.now()); err != nil {
+			return false, fmt.Errorf("social.Service.SendRequest: %s", err)
+		}
+
+		return true, nil
+	}
+
+	err = svc.repo.CreateRequest(ctx, entity.FriendRequest{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		CreatedAt:  svc.now(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("social.Service.SendRequest: %s", err)
+	}
+
+	return false, nil
+}
+
+func (svc *service) AcceptRequest(ctx context.Context, userID string, fromUserID string) error {
+	if err := svc.requireRequest(ctx, fromUserID, userID); err != nil {
+		return err
+	}
+
+	if err := svc.repo.AcceptRequest(ctx, fromUserID, userID, svc.now()); err != nil {
+		return fmt.Errorf("social.Service.AcceptRequest: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) DeclineRequest(ctx context.Context, userID string, fromUserID string) error {
+	if err := svc.requireRequest(ctx, fromUserID, userID); err != nil {
+		return err
+	}
+
+	if err := svc.repo.DeleteRequest(ctx, fromUserID, userID); err != nil {
+		return fmt.Errorf("social.Service.DeclineRequest: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) CancelRequest(ctx context.Context, userID string, toUserID string) error {
+	if err := svc.requireRequest(ctx, userID, toUserID); err != nil {
+		return err
+	}
+
+	if err := svc.repo.DeleteRequest(ctx, userID, toUserID); err != nil {
+		return fmt.Errorf("social.Service.CancelRequest: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) ListRequests(ctx context.Context, userID string) (*Requests, error) {
+	incoming, err := svc.repo.ListIncomingRequests(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("social.Service.ListRequests: %s", err)
+	}
+
+	outgoing, err := svc.repo.ListOutgoingRequests(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("social.Service.ListRequests: %s", err)
+	}
+
+	requests := Requests{
+		Incoming: make([]Peer, 0, len(incoming)),
+		Outgoing: make([]Peer, 0, len(outgoing)),
+	}
+
+	for _, r := range incoming {
+		peer, err := svc.peer(ctx, r.FromUserID, r.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("social.Service.ListRequests: %s", err)
+		}
+
+		requests.Incoming = append(requests.Incoming, *peer)
+	}
+
+	for _, r := range outgoing {
+		peer, err := svc.peer(ctx, r.ToUserID, r.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("social.Service.ListRequests: %s", err)
+		}
+
+		requests.Outgoing = append(requests.Outgoing, *peer)
+	}
+
+	return &requests, nil
+}
+
+func (svc *service) ListFriends(ctx context.Context, userID string) ([]Peer, error) {
+	friendships, err := svc.repo.ListFriends(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("social.Service.ListFriends: %s", err)
+	}
+
+	friends := make([]Peer, 0, len(friendships))
+	for _, f := range friendships {
+		peer, err := svc.peer(ctx, f.FriendID, f.Since)
+		if err != nil {
+			return nil, fmt.Errorf("social.Service.ListFriends: %s", err)
+		}
+
+		friends = append(friends, *peer)
+	}
+
+	sort.Slice(friends, func(i, j int) bool {
+		a, b := strings.ToLower(friends[i].Username), strings.ToLower(friends[j].Username)
+		if a != b {
+			return a < b
+		}
+
+		return friends[i].UserID < friends[j].UserID
+	})
+
+	return friends, nil
+}
+
+func (svc *service) AreFriends(ctx context.Context, userID string, otherUserID string) (bool, error) {
+	friends, err := svc.repo.AreFriends(ctx, userID, otherUserID)
+	if err != nil {
+		return false, fmt.Errorf("social.Service.AreFriends: %s", err)
+	}
+
+	return friends, nil
+}
+
+func (svc *service) RemoveFriend(ctx context.Context, userID string, friendID string) error {
+	friends, err := svc.repo.AreFriends(ctx, userID, friendID)
+	if err != nil {
+		return fmt.Errorf("social.Service.RemoveFriend: %s", err)
+	}
+	if !friends {
+		return ErrNotFriends
+	}
+
+	if err := svc.repo.DeleteFriendship(ctx, userID, friendID); err != nil {
+		return fmt.Errorf("social.Service.RemoveFriend: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) Block(ctx context.Context, userID string, blockedID string) error {
+	if userID == blockedID {
+		return ErrSelf
+	}
+
+	if err := svc.requireUser(ctx, blockedID); err != nil {
+		return err
+	}
+
+	err := svc.repo.Block(ctx, entity.Block{
+		BlockerID: userID,
+		BlockedID: blockedID,
+		CreatedAt: svc.now(),
+	})
+	if err != nil {
+		return fmt.Errorf("social.Service.Block: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) Unblock(ctx context.Context, userID string, blockedID string) error {
+	if err := svc.repo.Unblock(ctx, userID, blockedID); err != nil {
+		return fmt.Errorf("social.Service.Unblock: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) ListBlocked(ctx context.Context, userID string) ([]Peer, error) {
+	blocks, err := svc.repo.ListBlocked(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("social.Service.ListBlocked: %s", err)
+	}
+
+	blocked := make([]Peer, 0, len(blocks))
+	for _, b := range blocks {
+		peer, err := svc.peer(ctx, b.BlockedID, b.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("social.Service.ListBlocked: %s", err)
+		}
+
+		blocked = append(blocked, *peer)
+	}
+
+	return blocked, nil
+}
+
+func (svc *service) IsBlocked(ctx context.Context, userID string, otherUserID string) (bool, error) {
+	blocked, err := svc.repo.IsBlocked(ctx, userID, otherUserID)
+	if err != nil {
+		return false, fmt.Errorf("social.Service.IsBlocked: %s", err)
+	}
+
+	return blocked, nil
+}
+
+func (svc *service) requireUser(ctx context.Context, id string) error {
+	if _, err := svc.userSvc.GetByID(ctx, id); err == user.ErrNotFound {
+		return ErrUserNotFound
+	} else if err != nil {
+		return fmt.Errorf("social.Service: %s", err)
+	}
+
+	return nil
+}
+
+func (svc *service) requireRequest(ctx context.Context, fromUserID string, toUserID string) error {
+	request, err := svc.repo.GetRequest(ctx, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf("social.Service: %s", err)
+	}
+	if request == nil {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (svc *service) peer(ctx context.Context, userID string, since time.Time) (*Peer, error) {
+	u, err := svc.userSvc.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Peer{
+		UserID:   u.ID,
+		Username: u.Username,
+		Since:    since,
+	}, nil
+}
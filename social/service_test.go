@@ -0,0 +1,374 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when repository is missing", func(t *testing.T) {
+		if _, err := NewService(nil, newMockUserService()); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user service is missing", func(t *testing.T) {
+		if _, err := NewService(newInMemoryRepository(), nil); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func newService() Service {
+	svc, _ := NewService(newInMemoryRepository(), newMockUserService())
+
+	return svc
+}
+
+func TestServiceSendingRequest(t *testing.T) {
+	t.Run("fails when users are the same", func(t *testing.T) {
+		svc := newService()
+
+		if _, err := svc.SendRequest(context.Background(), mockUserID, mockUserID); err != ErrSelf {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when other user does not exist", func(t *testing.T) {
+		svc := newService()
+
+		if _, err := svc.SendRequest(context.Background(), mockUserID, "ghost"); err != ErrUserNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without blaming the other user when getting them fails", func(t *testing.T) {
+		userSvc := newMockUserService()
+		userSvc.failOnGetByID = true
+		svc, _ := NewService(newInMemoryRepository(), userSvc)
+
+		if _, err := svc.SendRequest(context.Background(), mockUserID, mockOtherUserID); err == nil || err == ErrUserNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when other user blocked the user", func(t *testing.T) {
+		svc := newService()
+		svc.Block(context.Background(), mockOtherUserID, mockUserID)
+
+		if _, err := svc.SendRequest(context.Background(), mockUserID, mockOtherUserID); err != ErrBlocked {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when request was already sent", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if _, err := svc.SendRequest(context.Background(), mockUserID, mockOtherUserID); err != ErrRequestAlreadySent {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when users are already friends", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+		svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID)
+
+		if _, err := svc.SendRequest(context.Background(), mockOtherUserID, mockUserID); err != ErrAlreadyFriends {
+			t.Fail()
+		}
+	})
+
+	t.Run("leaves request pending when all is well", func(t *testing.T) {
+		svc := newService()
+
+		accepted, err := svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+		if err != nil || accepted {
+			t.FailNow()
+		}
+
+		requests, _ := svc.ListRequests(context.Background(), mockOtherUserID)
+		if len(requests.Incoming) != 1 || strings.Compare(mockUsername, requests.Incoming[0].Username) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts request from other user instead of sending one", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockOtherUserID, mockUserID)
+
+		accepted, err := svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+		if err != nil || !accepted {
+			t.FailNow()
+		}
+
+		if friends, _ := svc.ListFriends(context.Background(), mockUserID); len(friends) != 1 {
+			t.Fail()
+		}
+		if requests, _ := svc.ListRequests(context.Background(), mockUserID); len(requests.Incoming)+len(requests.Outgoing) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceAnsweringRequest(t *testing.T) {
+	t.Run("fails to accept a request that does not exist", func(t *testing.T) {
+		svc := newService()
+
+		if err := svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID); err != ErrRequestNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to accept a request sent by the user", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if err := svc.AcceptRequest(context.Background(), mockUserID, mockOtherUserID); err != ErrRequestNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("makes both users friends when accepting", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if err := svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID); err != nil {
+			t.FailNow()
+		}
+
+		friends, _ := svc.ListFriends(context.Background(), mockUserID)
+		if len(friends) != 1 || strings.Compare(mockOtherUserID, friends[0].UserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("deletes request when declining", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if err := svc.DeclineRequest(context.Background(), mockOtherUserID, mockUserID); err != nil {
+			t.FailNow()
+		}
+
+		if friends, _ := svc.ListFriends(context.Background(), mockUserID); len(friends) != 0 {
+			t.Fail()
+		}
+		if requests, _ := svc.ListRequests(context.Background(), mockUserID); len(requests.Outgoing) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to cancel a request sent by the other user", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if err := svc.CancelRequest(context.Background(), mockOtherUserID, mockUserID); err != ErrRequestNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("deletes request when cancelling", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if err := svc.CancelRequest(context.Background(), mockUserID, mockOtherUserID); err != nil {
+			t.FailNow()
+		}
+
+		if requests, _ := svc.ListRequests(context.Background(), mockOtherUserID); len(requests.Incoming) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceFriends(t *testing.T) {
+	t.Run("lists friends ordered by username", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+		svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID)
+		svc.SendRequest(context.Background(), mockUserID, mockThirdUserID)
+		svc.AcceptRequest(context.Background(), mockThirdUserID, mockUserID)
+
+		friends, err := svc.ListFriends(context.Background(), mockUserID)
+		if err != nil || len(friends) != 2 {
+			t.FailNow()
+		}
+		if strings.Compare(mockThirdUserID, friends[0].UserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("tells whether users are friends, in either direction", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+
+		if friends, _ := svc.AreFriends(context.Background(), mockOtherUserID, mockUserID); friends {
+			t.Fail()
+		}
+
+		svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID)
+
+		if friends, _ := svc.AreFriends(context.Background(), mockOtherUserID, mockUserID); !friends {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails to remove a user who is not a friend", func(t *testing.T) {
+		svc := newService()
+
+		if err := svc.RemoveFriend(context.Background(), mockUserID, mockOtherUserID); err != ErrNotFriends {
+			t.Fail()
+		}
+	})
+
+	t.Run("removes the friendship for both users", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+		svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID)
+
+		if err := svc.RemoveFriend(context.Background(), mockOtherUserID, mockUserID); err != nil {
+			t.FailNow()
+		}
+
+		if friends, _ := svc.ListFriends(context.Background(), mockUserID); len(friends) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceBlocking(t *testing.T) {
+	t.Run("fails when users are the same", func(t *testing.T) {
+		svc := newService()
+
+		if err := svc.Block(context.Background(), mockUserID, mockUserID); err != ErrSelf {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when blocked user does not exist", func(t *testing.T) {
+		svc := newService()
+
+		if err := svc.Block(context.Background(), mockUserID, "ghost"); err != ErrUserNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("ends the friendship and blocks both users from each other", func(t *testing.T) {
+		svc := newService()
+		svc.SendRequest(context.Background(), mockUserID, mockOtherUserID)
+		svc.AcceptRequest(context.Background(), mockOtherUserID, mockUserID)
+
+		if err := svc.Block(context.Background(), mockUserID, mockOtherUserID); err != nil {
+			t.FailNow()
+		}
+
+		if friends, _ := svc.ListFriends(context.Background(), mockOtherUserID); len(friends) != 0 {
+			t.Fail()
+		}
+		if blocked, _ := svc.IsBlocked(context.Background(), mockOtherUserID, mockUserID); !blocked {
+			t.Fail()
+		}
+		if _, err := svc.SendRequest(context.Background(), mockUserID, mockOtherUserID); err != ErrBlocked {
+			t.Fail()
+		}
+
+		blocked, _ := svc.ListBlocked(context.Background(), mockUserID)
+		if len(blocked) != 1 || strings.Compare(mockOtherUsername, blocked[0].Username) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("lets users interact again once unblocked", func(t *testing.T) {
+		svc := newService()
+		svc.Block(context.Background(), mockUserID, mockOtherUserID)
+
+		if err := svc.Unblock(context.Background(), mockUserID, mockOtherUserID); err != nil {
+			t.FailNow()
+		}
+
+		if blocked, _ := svc.IsBlocked(context.Background(), mockUserID, mockOtherUserID); blocked {
+			t.Fail()
+		}
+	})
+}
+
+const (
+	mockUserID        = "mock.user.id"
+	mockUsername      = "Moose"
+	mockOtherUserID   = "mock.other.user.id"
+	mockOtherUsername = "Reindeer"
+	mockThirdUserID   = "mock.third.user.id"
+	mockThirdUsername = "elk"
+)
+
+type mockUserService struct {
+	users map[string]entity.User
+
+	failOnGetByID bool
+}
+
+func newMockUserService() *mockUserService {
+	return &mockUserService{
+		users: map[string]entity.User{
+			mockUserID:      {ID: mockUserID, Username: mockUsername},
+			mockOtherUserID: {ID: mockOtherUserID, Username: mockOtherUsername},
+			mockThirdUserID: {ID: mockThirdUserID, Username: mockThirdUsername},
+		},
+	}
+}
+
+func (mock *mockUserService) Register(_ context.Context, username string, email string, password string) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) Authenticate(_ context.Context, email string, password string) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) AuthenticateWithIdentity(_ context.Context, identity user.ExternalIdentity) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) GetByID(_ context.Context, id string) (*entity.User, error) {
+	if mock.failOnGetByID {
+		return nil, fmt.Errorf("failed to get user by ID")
+	}
+
+	u, ok := mock.users[id]
+	if !ok {
+		return nil, user.ErrNotFound
+	}
+
+	return &u, nil
+}
+
+func (mock *mockUserService) GetByEmail(_ context.Context, email string) (*entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) List(_ context.Context, offset int, limit int) ([]entity.User, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) Search(_ context.Context, query string, cursor string, limit int) (*user.SearchResult, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) ChangeRole(_ context.Context, id string, role entity.Role) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) SetSuspended(_ context.Context, id string, suspended bool) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (mock *mockUserService) SetAvatar(_ context.Context, id string, avatarURL string) error {
+	return fmt.Errorf("not implemented")
+}
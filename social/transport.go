@@ -0,0 +1,178 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	listFriendsHandler := stmhttp.NewHandler(
+		wrap("social.listFriends")(makeListFriendsEndpoint(svc)),
+		decodeUserRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	removeFriendHandler := stmhttp.NewHandler(
+		wrap("social.removeFriend")(makeRemoveFriendEndpoint(svc)),
+		decodeRelationshipRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	listRequestsHandler := stmhttp.NewHandler(
+		wrap("social.listRequests")(makeListRequestsEndpoint(svc)),
+		decodeUserRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	sendRequestHandler := stmhttp.NewHandler(
+		wrap("social.sendRequest")(makeSendRequestEndpoint(svc)),
+		decodeSendRequestRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	acceptRequestHandler := stmhttp.NewHandler(
+		wrap("social.acceptRequest")(makeAcceptRequestEndpoint(svc)),
+		decodeRelationshipRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	declineRequestHandler := stmhttp.NewHandler(
+		wrap("social.declineRequest")(makeDeclineRequestEndpoint(svc)),
+		decodeRelationshipRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	cancelRequestHandler := stmhttp.NewHandler(
+		wrap("social.cancelRequest")(makeCancelRequestEndpoint(svc)),
+		decodeRelationshipRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	listBlockedHandler := stmhttp.NewHandler(
+		wrap("social.listBlocked")(makeListBlockedEndpoint(svc)),
+		decodeUserRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	blockHandler := stmhttp.NewHandler(
+		wrap("social.block")(makeBlockEndpoint(svc)),
+		decodeRelationshipRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	unblockHandler := stmhttp.NewHandler(
+		wrap("social.unblock")(makeUnblockEndpoint(svc)),
+		decodeRelationshipRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/users/{id}/friends", listFriendsHandler).Methods("GET")
+	r.Handle("/v1/users/{id}/friends/requests", listRequestsHandler).Methods("GET")
+	r.Handle("/v1/users/{id}/friends/requests", sendRequestHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/friends/requests/{otherId}", cancelRequestHandler).Methods("DELETE")
+	r.Handle("/v1/users/{id}/friends/requests/{otherId}/accept", acceptRequestHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/friends/requests/{otherId}/decline", declineRequestHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/friends/blocked", listBlockedHandler).Methods("GET")
+	r.Handle("/v1/users/{id}/friends/blocked/{otherId}", blockHandler).Methods("PUT")
+	r.Handle("/v1/users/{id}/friends/blocked/{otherId}", unblockHandler).Methods("DELETE")
+	r.Handle("/v1/users/{id}/friends/{otherId}", removeFriendHandler).Methods("DELETE")
+
+	return r
+}
+
+func decodeUserRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return userRequest{
+		UserID: id,
+	}, nil
+}
+
+func decodeRelationshipRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+
+	id, ok := vars["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	otherID, ok := vars["otherId"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return relationshipRequest{
+		UserID:      id,
+		OtherUserID: otherID,
+	}, nil
+}
+
+func decodeSendRequestRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		UserID string `json:"userId"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return relationshipRequest{
+		UserID:      id,
+		OtherUserID: body.UserID,
+	}, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeNoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrSelf:
+		return http.StatusBadRequest
+	case ErrBlocked:
+		return http.StatusForbidden
+	case ErrUserNotFound, ErrRequestNotFound, ErrNotFriends:
+		return http.StatusNotFound
+	case ErrAlreadyFriends, ErrRequestAlreadySent:
+		return http.StatusConflict
+	}
+
+	return 0
+})
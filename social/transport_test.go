@@ -0,0 +1,126 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns a handler when all is well", func(t *testing.T) {
+		if handler := MakeHandler(&mockService{}); handler == nil {
+			t.Fail()
+		}
+	})
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/v1/users/" + mockUserID + "/friends", "", http.StatusOK},
+		{"DELETE", "/v1/users/" + mockUserID + "/friends/" + mockOtherUserID, "", http.StatusNoContent},
+		{"GET", "/v1/users/" + mockUserID + "/friends/requests", "", http.StatusOK},
+		{"POST", "/v1/users/" + mockUserID + "/friends/requests", `{"userId": "` + mockOtherUserID + `"}`, http.StatusOK},
+		{"DELETE", "/v1/users/" + mockUserID + "/friends/requests/" + mockOtherUserID, "", http.StatusNoContent},
+		{"POST", "/v1/users/" + mockUserID + "/friends/requests/" + mockOtherUserID + "/accept", "", http.StatusNoContent},
+		{"POST", "/v1/users/" + mockUserID + "/friends/requests/" + mockOtherUserID + "/decline", "", http.StatusNoContent},
+		{"GET", "/v1/users/" + mockUserID + "/friends/blocked", "", http.StatusOK},
+		{"PUT", "/v1/users/" + mockUserID + "/friends/blocked/" + mockOtherUserID, "", http.StatusNoContent},
+		{"DELETE", "/v1/users/" + mockUserID + "/friends/blocked/" + mockOtherUserID, "", http.StatusNoContent},
+	}
+
+	for _, route := range routes {
+		t.Run("routes "+route.method+" "+route.path, func(t *testing.T) {
+			handler := MakeHandler(&mockService{})
+
+			r := stmhttptest.NewJSONRequest(route.method, route.path, route.body)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r.WithContext(userContext()))
+
+			if rr.Code != route.status {
+				t.Errorf("expected status %d, got %d", route.status, rr.Code)
+			}
+		})
+	}
+}
+
+func TestDecodingSendRequestRequest(t *testing.T) {
+	t.Run("fails when JSON decoder fails", func(t *testing.T) {
+		httpReq := mux.SetURLVars(
+			stmhttptest.NewJSONRequest("POST", "/", "not.json.at.all"),
+			map[string]string{"id": mockUserID},
+		)
+
+		if _, err := decodeSendRequestRequest(nil, httpReq); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a relationship request when all is well", func(t *testing.T) {
+		httpReq := mux.SetURLVars(
+			stmhttptest.NewJSONRequest("POST", "/", `{"userId": "`+mockOtherUserID+`"}`),
+			map[string]string{"id": mockUserID},
+		)
+
+		req, err := decodeSendRequestRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		relationshipReq := req.(relationshipRequest)
+		if strings.Compare(mockUserID, relationshipReq.UserID) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(mockOtherUserID, relationshipReq.OtherUserID) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestDecodingRelationshipRequest(t *testing.T) {
+	t.Run("fails when other user is missing from route", func(t *testing.T) {
+		httpReq := mux.SetURLVars(
+			httptest.NewRequest("DELETE", "/", nil),
+			map[string]string{"id": mockUserID},
+		)
+
+		if _, err := decodeRelationshipRequest(nil, httpReq); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		auth.ErrForbidden:         http.StatusForbidden,
+		ErrSelf:                   http.StatusBadRequest,
+		ErrBlocked:                http.StatusForbidden,
+		ErrUserNotFound:           http.StatusNotFound,
+		ErrRequestNotFound:        http.StatusNotFound,
+		ErrNotFriends:             http.StatusNotFound,
+		ErrAlreadyFriends:         http.StatusConflict,
+		ErrRequestAlreadySent:     http.StatusConflict,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
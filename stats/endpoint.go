@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"context"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type getStatsRequest struct {
+	UserID string
+}
+
+type propertyCountResponse struct {
+	Property   string `json:"property"`
+	GamesOwned int    `json:"gamesOwned"`
+}
+
+type statsResponse struct {
+	UserID             string                  `json:"userId"`
+	GamesPlayed        int                     `json:"gamesPlayed"`
+	Wins               int                     `json:"wins"`
+	AverageNetWorth    float64                 `json:"averageNetWorth"`
+	FavoriteProperties []propertyCountResponse `json:"favoriteProperties"`
+	CurrentStreak      int                     `json:"currentStreak"`
+	LongestStreak      int                     `json:"longestStreak"`
+}
+
+func makeGetStatsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getStatsRequest)
+
+		// Public profiles show a summary of these statistics to anyone,
+		// but the full breakdown is kept for signed in players.
+		if _, ok := auth.FromContext(ctx); !ok {
+			return nil, auth.ErrUnauthenticated
+		}
+
+		summary, err := svc.Get(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &statsResponse{
+			UserID:             summary.UserID,
+			GamesPlayed:        summary.GamesPlayed,
+			Wins:               summary.Wins,
+			AverageNetWorth:    summary.AverageNetWorth,
+			FavoriteProperties: make([]propertyCountResponse, 0, len(summary.FavoriteProperties)),
+			CurrentStreak:      summary.CurrentStreak,
+			LongestStreak:      summary.LongestStreak,
+		}
+		for _, p := range summary.FavoriteProperties {
+			resp.FavoriteProperties = append(resp.FavoriteProperties, propertyCountResponse{
+				Property:   p.Property,
+				GamesOwned: p.GamesOwned,
+			})
+		}
+
+		return resp, nil
+	}
+}
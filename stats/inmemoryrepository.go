@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type inMemoryRepository struct {
+	database *db.InMemory
+}
+
+func NewInMemoryRepository(database *db.InMemory) Repository {
+	return &inMemoryRepository{database}
+}
+
+func (repo *inMemoryRepository) Get(userID string) (*entity.Stats, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, s := range repo.database.Stats {
+		if s.UserID == userID {
+			return copyStats(s), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *inMemoryRepository) Save(stats []entity.Stats) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, stat := range stats {
+		saved := false
+
+		for i, s := range repo.database.Stats {
+			if s.UserID == stat.UserID {
+				repo.database.Stats[i] = *copyStats(stat)
+				saved = true
+				break
+			}
+		}
+
+		if !saved {
+			repo.database.Stats = append(repo.database.Stats, *copyStats(stat))
+		}
+	}
+
+	return nil
+}
+
+// copyStats copies the statistics, so that their property counts cannot be
+// changed without saving them.
+func copyStats(s entity.Stats) *entity.Stats {
+	counts := make(map[string]int, len(s.PropertyCounts))
+	for property, count := range s.PropertyCounts {
+		counts[property] = count
+	}
+
+	s.PropertyCounts = counts
+
+	return &s
+}
@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+func newInMemoryRepository() Repository {
+	database := &db.InMemory{}
+	database.Open()
+
+	return NewInMemoryRepository(database)
+}
+
+func TestInMemoryRepositorySavingStats(t *testing.T) {
+	t.Run("returns nil when user has not finished a game", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Save([]entity.Stats{{UserID: "0", GamesPlayed: 1}})
+
+		if s, err := repo.Get("1"); err != nil || s != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("replaces the stats that already exist", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		repo.Save([]entity.Stats{{UserID: "0", GamesPlayed: 1}})
+
+		if err := repo.Save([]entity.Stats{{UserID: "0", GamesPlayed: 2}}); err != nil {
+			t.FailNow()
+		}
+
+		if s, _ := repo.Get("0"); s == nil || s.GamesPlayed != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not share property counts with callers", func(t *testing.T) {
+		repo := newInMemoryRepository()
+		counts := map[string]int{"Moose Lake": 1}
+		repo.Save([]entity.Stats{{UserID: "0", PropertyCounts: counts}})
+		counts["Moose Lake"] = 2
+
+		s, _ := repo.Get("0")
+		s.PropertyCounts["Moose Lake"] = 3
+
+		if s, _ := repo.Get("0"); s.PropertyCounts["Moose Lake"] != 1 {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+const (
+	getQuery  = "SELECT user_id, games_played, wins, total_net_worth, current_streak, longest_streak, updated_at FROM stats WHERE user_id = $1"
+	saveQuery = "INSERT INTO stats(user_id, games_played, wins, total_net_worth, current_streak, longest_streak, updated_at) VALUES($1, $2, $3, $4, $5, $6, $7) " +
+		"ON CONFLICT (user_id) DO UPDATE SET games_played = EXCLUDED.games_played, wins = EXCLUDED.wins, total_net_worth = EXCLUDED.total_net_worth, current_streak = EXCLUDED.current_streak, longest_streak = EXCLUDED.longest_streak, updated_at = EXCLUDED.updated_at"
+
+	getPropertiesQuery = "SELECT property, games_owned FROM stats_properties WHERE user_id = $1"
+	savePropertyQuery  = "INSERT INTO stats_properties(user_id, property, games_owned) VALUES($1, $2, $3) ON CONFLICT (user_id, property) DO UPDATE SET games_owned = EXCLUDED.games_owned"
+)
+
+type postgresRepository struct {
+	database *db.Postgres
+}
+
+func NewPostgresRepository(database *db.Postgres) Repository {
+	return &postgresRepository{database}
+}
+
+func (pr *postgresRepository) Get(userID string) (*entity.Stats, error) {
+	var s entity.Stats
+
+	err := pr.database.QueryRow(getQuery, userID).Scan(
+		&s.UserID,
+		&s.GamesPlayed,
+		&s.Wins,
+		&s.TotalNetWorth,
+		&s.CurrentStreak,
+		&s.LongestStreak,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"stats.PostgresRepository.Get: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rows, err := pr.database.Query(getPropertiesQuery, userID)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"stats.PostgresRepository.Get: failed to execute properties query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	s.PropertyCounts = make(map[string]int)
+	for rows.Next() {
+		var property string
+		var count int
+		if err := rows.Scan(&property, &count); err != nil {
+			return nil, fmt.Errorf(
+				"stats.PostgresRepository.Get: failed to read property (%s)",
+				err,
+			)
+		}
+
+		s.PropertyCounts[property] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"stats.PostgresRepository.Get: failed to read properties (%s)",
+			err,
+		)
+	}
+
+	return &s, nil
+}
+
+func (pr *postgresRepository) Save(stats []entity.Stats) error {
+	err := pr.database.InTransaction(func(tx *sql.Tx) error {
+		for _, s := range stats {
+			_, err := tx.Exec(saveQuery, s.UserID, s.GamesPlayed, s.Wins, s.TotalNetWorth, s.CurrentStreak, s.LongestStreak, s.UpdatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to save stats (%s)", err)
+			}
+
+			// Properties are never removed, since the number of games a
+			// player owned them in can only grow. They are saved in order,
+			// so that concurrent saves lock their rows in the same order.
+			properties := make([]string, 0, len(s.PropertyCounts))
+			for property := range s.PropertyCounts {
+				properties = append(properties, property)
+			}
+			sort.Strings(properties)
+
+			for _, property := range properties {
+				if _, err := tx.Exec(savePropertyQuery, s.UserID, property, s.PropertyCounts[property]); err != nil {
+					return fmt.Errorf("failed to save property (%s)", err)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("stats.PostgresRepository.Save: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+var statsColumns = []string{"user_id", "games_played", "wins", "total_net_worth", "current_streak", "longest_streak", "updated_at"}
+
+func TestPostgresRepositoryGettingStats(t *testing.T) {
+	t.Run("returns nil when user has not finished a game", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getQuery).
+			WithArgs("0").
+			WillReturnRows(mock.NewRows(statsColumns))
+
+		if s, err := pr.Get("0"); err != nil || s != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the stats with their property counts when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(getQuery).
+			WithArgs("0").
+			WillReturnRows(mock.NewRows(statsColumns).AddRow("0", 5, 3, 12000, 2, 3, time.Now()))
+		mock.ExpectQuery(getPropertiesQuery).
+			WithArgs("0").
+			WillReturnRows(mock.NewRows([]string{"property", "games_owned"}).AddRow("Moose Lake", 4).AddRow("Elk Harbour", 1))
+
+		s, err := pr.Get("0")
+		if err != nil || s == nil {
+			t.FailNow()
+		}
+		if s.Wins != 3 || s.TotalNetWorth != 12000 || s.PropertyCounts["Moose Lake"] != 4 || len(s.PropertyCounts) != 2 {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositorySavingStats(t *testing.T) {
+	updatedAt := time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+	stats := []entity.Stats{
+		{
+			UserID:         "0",
+			GamesPlayed:    1,
+			Wins:           1,
+			TotalNetWorth:  5000,
+			CurrentStreak:  1,
+			LongestStreak:  1,
+			PropertyCounts: map[string]int{"Moose Lake": 1, "Elk Harbour": 1},
+			UpdatedAt:      updatedAt,
+		},
+	}
+
+	t.Run("rolls back when stats cannot be saved", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(saveQuery).
+			WithArgs("0", 1, 1, int64(5000), 1, 1, updatedAt).
+			WillReturnError(fmt.Errorf("a database error"))
+		mock.ExpectRollback()
+
+		if err := pr.Save(stats); err == nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("saves the stats and their properties in order when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectBegin()
+		mock.ExpectExec(saveQuery).
+			WithArgs("0", 1, 1, int64(5000), 1, 1, updatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(savePropertyQuery).
+			WithArgs("0", "Elk Harbour", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(savePropertyQuery).
+			WithArgs("0", "Moose Lake", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := pr.Save(stats); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
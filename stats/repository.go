@@ -0,0 +1,28 @@
+// Package stats aggregates the statistics of players over the games they
+// finish.
+package stats
+
+import (
+	"fmt"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+)
+
+type Repository interface {
+	// Get returns the user's statistics, or nil if they have not finished a
+	// game yet.
+	Get(userID string) (*entity.Stats, error)
+	// Save creates or replaces the statistics, all at once.
+	Save(stats []entity.Stats) error
+}
+
+func NewRepository(database db.DB) (Repository, error) {
+	if inmemory, ok := database.(*db.InMemory); ok {
+		return NewInMemoryRepository(inmemory), nil
+	} else if postgres, ok := database.(*db.Postgres); ok {
+		return NewPostgresRepository(postgres), nil
+	}
+
+	return nil, fmt.Errorf("stats.NewRepository: unsupported database type")
+}
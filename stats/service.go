@@ -0,0 +1,198 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+// FavoritePropertiesCount is the number of favorite properties in a summary.
+const FavoritePropertiesCount = 3
+
+var ErrNotFound = errors.New("user does not exist")
+
+// PropertyCount represents the number of games a player finished owning a
+// property.
+type PropertyCount struct {
+	Property   string
+	GamesOwned int
+}
+
+// Summary represents a player's statistics, with the figures derived from
+// them.
+type Summary struct {
+	entity.Stats
+
+	// AverageNetWorth is the player's average net worth at the end of their
+	// games, or zero if they have not finished one yet.
+	AverageNetWorth float64
+	// FavoriteProperties are the properties the player finished the most
+	// games owning, from the most owned to the least.
+	FavoriteProperties []PropertyCount
+}
+
+type Service interface {
+	// Get returns a summary of the user's statistics, which are all zero if
+	// they have not finished a game yet.
+	Get(ctx context.Context, userID string) (*Summary, error)
+
+	// Update adds the results of the finished game to the statistics of its
+	// players, provided it is rated.
+	Update(game entity.Game) error
+}
+
+type service struct {
+	repo    Repository
+	userSvc user.Service
+
+	// mu serializes updates, which read each player's counters and streaks,
+	// add a game to them, and save them back whole, so that a game counted
+	// at the same time as another cannot be lost.
+	mu sync.Mutex
+}
+
+func NewService(repo Repository, userSvc user.Service) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("stats.NewService: repository is required")
+	}
+	if userSvc == nil {
+		return nil, fmt.Errorf("stats.NewService: user service is required")
+	}
+
+	return &service{
+		repo:    repo,
+		userSvc: userSvc,
+	}, nil
+}
+
+func (svc *service) Get(ctx context.Context, userID string) (*Summary, error) {
+	if _, err := svc.userSvc.GetByID(ctx, userID); err == user.ErrNotFound {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("stats.Service.Get: %s", err)
+	}
+
+	s, err := svc.get(userID)
+	if err != nil {
+		return nil, fmt.Errorf("stats.Service.Get: %s", err)
+	}
+
+	summary := &Summary{
+		Stats:              *s,
+		FavoriteProperties: favoriteProperties(s.PropertyCounts),
+	}
+	if s.GamesPlayed > 0 {
+		summary.AverageNetWorth = float64(s.TotalNetWorth) / float64(s.GamesPlayed)
+	}
+
+	return summary, nil
+}
+
+func (svc *service) Update(game entity.Game) error {
+	if !game.Rated() || len(game.Results) == 0 {
+		return nil
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	updated := make([]entity.Stats, 0, len(game.Results))
+
+	for _, result := range game.Results {
+		s, err := svc.get(result.UserID)
+		if err != nil {
+			return fmt.Errorf("stats.Service.Update: %s", err)
+		}
+
+		s.GamesPlayed++
+		s.TotalNetWorth += int64(result.NetWorth)
+
+		if result.Rank == 1 {
+			s.Wins++
+			s.CurrentStreak++
+			if s.CurrentStreak > s.LongestStreak {
+				s.LongestStreak = s.CurrentStreak
+			}
+		} else {
+			s.CurrentStreak = 0
+		}
+
+		for _, property := range result.Properties {
+			s.PropertyCounts[property]++
+		}
+
+		s.UpdatedAt = game.FinishedAt
+
+		updated = append(updated, *s)
+	}
+
+	if err := svc.repo.Save(updated); err != nil {
+		return fmt.Errorf("stats.Service.Update: %s", err)
+	}
+
+	return nil
+}
+
+// get returns the user's statistics, or empty ones if they have not finished
+// a game yet.
+func (svc *service) get(userID string) (*entity.Stats, error) {
+	s, err := svc.repo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s == nil {
+		s = &entity.Stats{UserID: userID}
+	}
+	if s.PropertyCounts == nil {
+		s.PropertyCounts = make(map[string]int)
+	}
+
+	return s, nil
+}
+
+// favoriteProperties returns the most owned properties, breaking ties by
+// name so that favorites do not change between requests.
+func favoriteProperties(counts map[string]int) []PropertyCount {
+	favorites := make([]PropertyCount, 0, len(counts))
+	for property, count := range counts {
+		favorites = append(favorites, PropertyCount{property, count})
+	}
+
+	sort.Slice(favorites, func(i, j int) bool {
+		if favorites[i].GamesOwned == favorites[j].GamesOwned {
+			return favorites[i].Property < favorites[j].Property
+		}
+		return favorites[i].GamesOwned > favorites[j].GamesOwned
+	})
+
+	if len(favorites) > FavoritePropertiesCount {
+		favorites = favorites[:FavoritePropertiesCount]
+	}
+
+	return favorites
+}
+
+// NewProfileStats returns a function that gets the stats shown on the public
+// profiles of users from the service.
+func NewProfileStats(svc Service) user.StatsFunc {
+	return func(ctx context.Context, userID string) (*user.ProfileStats, error) {
+		summary, err := svc.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &user.ProfileStats{
+			GamesPlayed:     summary.GamesPlayed,
+			Wins:            summary.Wins,
+			AverageNetWorth: summary.AverageNetWorth,
+			CurrentStreak:   summary.CurrentStreak,
+			LongestStreak:   summary.LongestStreak,
+		}, nil
+	}
+}
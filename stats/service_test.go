@@ -0,0 +1,183 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var finishedAt = time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+// newService creates a service for users whose IDs are numbered from zero.
+func newService(t *testing.T, users int) Service {
+	database := &db.InMemory{}
+	database.Open()
+
+	hashSvc, _ := hash.NewService(hash.NewBCryptProvider())
+
+	userRepo := user.NewInMemoryRepository(database)
+	for i := 0; i < users; i++ {
+		userRepo.Create(context.Background(), "Moose"+strconv.Itoa(i), strconv.Itoa(i)+"@stmoosersburg.com", "a.hashed.password")
+	}
+	userSvc, _ := user.NewService(userRepo, hashSvc)
+
+	svc, err := NewService(NewInMemoryRepository(database), userSvc)
+	if err != nil {
+		t.Fatalf("failed to create service (%s)", err)
+	}
+
+	return svc
+}
+
+// failingUserService fails to get users.
+type failingUserService struct {
+	user.Service
+}
+
+func (mock *failingUserService) GetByID(_ context.Context, _ string) (*entity.User, error) {
+	return nil, fmt.Errorf("failed to get user by ID")
+}
+
+// finishedGame returns a finished matchmade game between users "0" and "1",
+// won by the winner, who ends up owning the properties.
+func finishedGame(winner int, properties ...string) entity.Game {
+	g := entity.Game{
+		ID:         "a.game",
+		Status:     entity.GameStatusFinished,
+		Matchmade:  true,
+		PlayerIDs:  []string{"0", "1"},
+		FinishedAt: finishedAt,
+	}
+
+	for i := 0; i < 2; i++ {
+		result := entity.PlayerResult{UserID: strconv.Itoa(i), Rank: 2, NetWorth: 0}
+		if i == winner {
+			result.Rank = 1
+			result.NetWorth = 3000
+			result.Properties = properties
+		}
+
+		g.Results = append(g.Results, result)
+	}
+
+	return g
+}
+
+func TestServiceConstructor(t *testing.T) {
+	t.Run("fails when dependencies are missing", func(t *testing.T) {
+		if _, err := NewService(nil, nil); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceGettingStats(t *testing.T) {
+	t.Run("fails when user does not exist", func(t *testing.T) {
+		svc := newService(t, 1)
+
+		if _, err := svc.Get(context.Background(), "1"); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails without blaming the user when getting them fails", func(t *testing.T) {
+		svc, _ := NewService(NewInMemoryRepository(&db.InMemory{}), &failingUserService{})
+
+		if _, err := svc.Get(context.Background(), "0"); err == nil || err == ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns empty stats when user has not finished a game", func(t *testing.T) {
+		svc := newService(t, 1)
+
+		s, err := svc.Get(context.Background(), "0")
+		if err != nil || s == nil {
+			t.FailNow()
+		}
+		if s.GamesPlayed != 0 || s.AverageNetWorth != 0 || len(s.FavoriteProperties) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestProfileStats(t *testing.T) {
+	t.Run("fails when user does not exist", func(t *testing.T) {
+		if _, err := NewProfileStats(newService(t, 1))(context.Background(), "1"); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the stats shown on profiles", func(t *testing.T) {
+		s, err := NewProfileStats(newService(t, 1))(context.Background(), "0")
+		if err != nil || s == nil || s.GamesPlayed != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceUpdatingStats(t *testing.T) {
+	t.Run("ignores games that are not finished", func(t *testing.T) {
+		svc := newService(t, 2)
+		g := finishedGame(0)
+		g.Status = entity.GameStatusInProgress
+
+		svc.Update(g)
+
+		if s, _ := svc.Get(context.Background(), "0"); s.GamesPlayed != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("ignores games that are not matchmade", func(t *testing.T) {
+		svc := newService(t, 2)
+		g := finishedGame(0)
+		g.Matchmade = false
+
+		svc.Update(g)
+
+		if s, _ := svc.Get(context.Background(), "0"); s.GamesPlayed != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("aggregates the results of every game", func(t *testing.T) {
+		svc := newService(t, 2)
+
+		for _, g := range []entity.Game{
+			finishedGame(0, "Moose Lake", "Elk Harbour"),
+			finishedGame(0, "Moose Lake", "Antler Avenue"),
+			finishedGame(1),
+			finishedGame(0, "Moose Lake", "Elk Harbour", "Caribou Court"),
+		} {
+			if err := svc.Update(g); err != nil {
+				t.FailNow()
+			}
+		}
+
+		s, _ := svc.Get(context.Background(), "0")
+		if s.GamesPlayed != 4 || s.Wins != 3 || s.AverageNetWorth != 2250 {
+			t.Fail()
+		}
+		if s.CurrentStreak != 1 || s.LongestStreak != 2 {
+			t.Fail()
+		}
+		if len(s.FavoriteProperties) != FavoritePropertiesCount ||
+			s.FavoriteProperties[0] != (PropertyCount{"Moose Lake", 3}) ||
+			s.FavoriteProperties[1] != (PropertyCount{"Elk Harbour", 2}) ||
+			s.FavoriteProperties[2] != (PropertyCount{"Antler Avenue", 1}) {
+			t.Errorf("unexpected favorite properties %v", s.FavoriteProperties)
+		}
+
+		if s, _ := svc.Get(context.Background(), "1"); s.GamesPlayed != 4 || s.Wins != 1 || s.CurrentStreak != 0 || s.LongestStreak != 1 {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns the stats of any user to authenticated callers", func(t *testing.T) {
+		svc := newService(t, 2)
+		svc.Update(finishedGame(0, "Moose Lake"))
+		handler := MakeHandler(svc)
+
+		r := httptest.NewRequest("GET", "/v1/users/0/stats", nil)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "1"})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(ctx))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+
+		var body statsResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.GamesPlayed != 1 || body.Wins != 1 || len(body.FavoriteProperties) != 1 || body.FavoriteProperties[0].Property != "Moose Lake" {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails for anonymous callers", func(t *testing.T) {
+		handler := MakeHandler(newService(t, 1))
+
+		r := httptest.NewRequest("GET", "/v1/users/0/stats", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		ErrNotFound:               http.StatusNotFound,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package totp implements time-based one-time passwords, as described in RFC
+// 6238, with the parameters that authenticator apps support out of the box:
+// HMAC-SHA1, six digits, and a period of thirty seconds.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits represents the number of digits in a one-time password.
+	Digits = 6
+
+	// Period represents how long a one-time password is valid.
+	Period = 30 * time.Second
+
+	// Skew represents the number of periods before and after the current one
+	// for which one-time passwords are accepted, to account for clock drift
+	// and for the time it takes to type them.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a random secret, encoded in base 32, which is the
+// format authenticator apps expect.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("totp.GenerateSecret: failed to generate secret (%s)", err)
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// Generate generates the one-time password for the given time.
+func Generate(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", fmt.Errorf("totp.Generate: %s", err)
+	}
+
+	return generate(key, counter(t)), nil
+}
+
+// Validate tells whether the code is the one-time password for the given time,
+// or for one of the Skew periods around it.
+func Validate(secret string, code string, t time.Time) bool {
+	_, ok := Match(secret, code, t)
+	return ok
+}
+
+// Match returns the time step of the one-time password that the code matches,
+// among those of the given time and of the Skew periods around it.
+//
+// Callers that must not accept the same one-time password twice, as RFC 6238
+// recommends, remember the last step they accepted, and reject codes of that
+// step or of earlier ones.
+func Match(secret string, code string, t time.Time) (uint64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	// Later steps are tried first, so that a code that happens to match two
+	// steps is taken for the latest one.
+	c := counter(t)
+	for i := Skew; i >= -Skew; i-- {
+		step := uint64(int64(c) + int64(i))
+		expected := generate(key, step)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// ProvisioningURI returns the URI that authenticator apps use to add an
+// account, usually by scanning it as a QR code.
+//
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format.
+func ProvisioningURI(secret string, issuer string, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", Digits))
+	query.Set("period", fmt.Sprintf("%d", int(Period.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return uri.String()
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(secret, "="))
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("secret is not in base 32 (%s)", err)
+	}
+
+	return key, nil
+}
+
+func counter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(Period.Seconds()))
+}
+
+// generate implements the HOTP algorithm described in RFC 4226.
+func generate(key []byte, counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo)
+}
@@ -0,0 +1,142 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 secret used by the test vectors in appendix B of
+// RFC 6238, encoded in base 32.
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestSecretGeneration(t *testing.T) {
+	t.Run("generates a base 32 secret", func(t *testing.T) {
+		secret, err := GenerateSecret()
+		if err != nil {
+			t.FailNow()
+		}
+		if _, err := decodeSecret(secret); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("generates a unique secret every time", func(t *testing.T) {
+		first, _ := GenerateSecret()
+		second, _ := GenerateSecret()
+
+		if strings.Compare(first, second) == 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestGeneration(t *testing.T) {
+	t.Run("fails when secret is not in base 32", func(t *testing.T) {
+		if _, err := Generate("not base 32!", time.Now()); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("matches the RFC 6238 test vectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:          "287082",
+			1111111109:  "081804",
+			1111111111:  "050471",
+			1234567890:  "005924",
+			2000000000:  "279037",
+			20000000000: "353130",
+		}
+
+		for unix, expected := range vectors {
+			code, err := Generate(rfc6238Secret, time.Unix(unix, 0))
+			if err != nil {
+				t.FailNow()
+			}
+			if strings.Compare(expected, code) != 0 {
+				t.Errorf("expected %s at %d, got %s", expected, unix, code)
+			}
+		}
+	})
+}
+
+func TestValidation(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Generate(rfc6238Secret, now)
+
+	t.Run("accepts the code for the current period", func(t *testing.T) {
+		if !Validate(rfc6238Secret, code, now) {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts the code for the adjacent periods", func(t *testing.T) {
+		if !Validate(rfc6238Secret, code, now.Add(-Period)) {
+			t.Fail()
+		}
+		if !Validate(rfc6238Secret, code, now.Add(Period)) {
+			t.Fail()
+		}
+	})
+
+	t.Run("rejects the code for other periods", func(t *testing.T) {
+		if Validate(rfc6238Secret, code, now.Add(3*Period)) {
+			t.Fail()
+		}
+	})
+
+	t.Run("rejects codes with the wrong number of digits", func(t *testing.T) {
+		if Validate(rfc6238Secret, code[:Digits-1], now) {
+			t.Fail()
+		}
+	})
+
+	t.Run("rejects codes when secret is malformed", func(t *testing.T) {
+		if Validate("not base 32!", code, now) {
+			t.Fail()
+		}
+	})
+}
+
+func TestMatching(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Generate(rfc6238Secret, now)
+
+	t.Run("returns the step of the code, whatever the adjacent period", func(t *testing.T) {
+		for _, at := range []time.Time{now.Add(-Period), now, now.Add(Period)} {
+			step, ok := Match(rfc6238Secret, code, at)
+			if !ok || step != uint64(now.Unix()/int64(Period.Seconds())) {
+				t.Errorf("expected code to match step of %s at %s, got %d", now, at, step)
+			}
+		}
+	})
+
+	t.Run("does not match the code for other periods", func(t *testing.T) {
+		if _, ok := Match(rfc6238Secret, code, now.Add(3*Period)); ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestProvisioningURI(t *testing.T) {
+	t.Run("returns an otpauth URI with the secret, issuer, and account", func(t *testing.T) {
+		uri, err := url.Parse(ProvisioningURI("SECRET", "St-Moosersburg", "moose@stmoosersburg.com"))
+		if err != nil {
+			t.FailNow()
+		}
+		if uri.Scheme != "otpauth" || uri.Host != "totp" {
+			t.Fail()
+		}
+		if uri.Path != "/St-Moosersburg:moose@stmoosersburg.com" {
+			t.Fail()
+		}
+		if uri.Query().Get("secret") != "SECRET" {
+			t.Fail()
+		}
+		if uri.Query().Get("issuer") != "St-Moosersburg" {
+			t.Fail()
+		}
+	})
+}
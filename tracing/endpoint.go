@@ -0,0 +1,24 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+// NewEndpointMiddleware creates a middleware that starts a span named after
+// the endpoint, such as "user.register", around each call, as a child of the
+// span of the request.
+func NewEndpointMiddleware(name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			ctx, span := Start(ctx, name)
+			defer span.End()
+
+			response, err := next(ctx, request)
+			span.RecordError(err)
+
+			return response, err
+		}
+	}
+}
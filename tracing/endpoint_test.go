@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestEndpointMiddleware(t *testing.T) {
+	t.Run("starts a span named after the endpoint around each call", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		ctx, parent := tracer.Start(context.Background(), "GET /v1/users/{id}", SpanKindServer)
+
+		var inner *Span
+		e := NewEndpointMiddleware("user.getByID")(func(ctx context.Context, request interface{}) (interface{}, error) {
+			inner, _ = SpanFromContext(ctx)
+			return nil, fmt.Errorf("moose is loose")
+		})
+
+		if _, err := e(ctx, nil); err == nil {
+			t.FailNow()
+		}
+
+		spans := ended(tracer)
+		if len(spans) != 1 {
+			t.FailNow()
+		}
+		if spans[0].Name != "user.getByID" || spans[0].ParentSpanID != parent.SpanContext().SpanID || spans[0].Err == nil {
+			t.Fail()
+		}
+		if inner == nil || inner.SpanContext() != spans[0].SpanContext {
+			t.Fail()
+		}
+	})
+}
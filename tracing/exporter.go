@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ExporterNone only propagates traces, without recording them.
+	ExporterNone = "none"
+
+	// ExporterStdout writes spans to the standard output, for local
+	// testing.
+	ExporterStdout = "stdout"
+
+	// ExporterOTLP sends spans to an OpenTelemetry collector, with the OTLP
+	// protocol over HTTP.
+	ExporterOTLP = "otlp"
+)
+
+// ServiceName identifies the service in the spans it exports.
+const ServiceName = "stmoosersburg-api"
+
+// instrumentationScope names the library that recorded the spans.
+const instrumentationScope = "github.com/leblancjs/stmoosersburg-api/tracing"
+
+// StdoutExporter writes spans as JSON, one per line.
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+type stdoutSpan struct {
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	TraceID      string                 `json:"traceId"`
+	SpanID       string                 `json:"spanId"`
+	ParentSpanID string                 `json:"parentSpanId,omitempty"`
+	Start        time.Time              `json:"start"`
+	Duration     string                 `json:"duration"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
+
+func (e *StdoutExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, s := range spans {
+		out := stdoutSpan{
+			Name:     s.Name,
+			Kind:     s.Kind.String(),
+			TraceID:  s.SpanContext.TraceID.String(),
+			SpanID:   s.SpanContext.SpanID.String(),
+			Start:    s.Start,
+			Duration: s.End.Sub(s.Start).String(),
+		}
+		if s.ParentSpanID.IsValid() {
+			out.ParentSpanID = s.ParentSpanID.String()
+		}
+		if len(s.Attributes) > 0 {
+			out.Attributes = make(map[string]interface{}, len(s.Attributes))
+			for _, attr := range s.Attributes {
+				out.Attributes[attr.Key] = attr.Value
+			}
+		}
+		if s.Err != nil {
+			out.Error = s.Err.Error()
+		}
+
+		if err := encoder.Encode(out); err != nil {
+			return fmt.Errorf("tracing.StdoutExporter.Export: failed to write span (%s)", err)
+		}
+	}
+
+	return nil
+}
+
+// DefaultOTLPEndpoint is where OpenTelemetry collectors receive OTLP over
+// HTTP by default.
+const DefaultOTLPEndpoint = "http://localhost:4318"
+
+// OTLPExporter sends spans to an OpenTelemetry collector, encoded in JSON
+// with the OTLP protocol over HTTP.
+type OTLPExporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewOTLPExporter creates an exporter that sends spans to the collector at
+// the endpoint, such as "http://localhost:4318", to which the path of traces
+// is added.
+func NewOTLPExporter(endpoint string) *OTLPExporter {
+	if endpoint == "" {
+		endpoint = DefaultOTLPEndpoint
+	}
+
+	return &OTLPExporter{
+		url:    strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		client: &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// The types below mirror the JSON encoding of the OTLP protocol, in which
+// IDs are hexadecimal, and timestamps are nanoseconds since the epoch, as
+// strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Kinds and status codes, as numbered by OTLP.
+const (
+	otlpKindInternal = 1
+	otlpKindServer   = 2
+	otlpKindClient   = 3
+
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	scope := otlpScopeSpans{
+		Scope: otlpScope{Name: instrumentationScope},
+		Spans: make([]otlpSpan, 0, len(spans)),
+	}
+	for _, s := range spans {
+		scope.Spans = append(scope.Spans, newOTLPSpan(s))
+	}
+
+	body, err := json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{newOTLPAttribute(String("service.name", ServiceName))},
+			},
+			ScopeSpans: []otlpScopeSpans{scope},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("tracing.OTLPExporter.Export: failed to encode spans (%s)", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("tracing.OTLPExporter.Export: failed to create request (%s)", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing.OTLPExporter.Export: failed to send spans (%s)", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("tracing.OTLPExporter.Export: collector responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func newOTLPSpan(s SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.SpanContext.TraceID.String(),
+		SpanID:            s.SpanContext.SpanID.String(),
+		Name:              s.Name,
+		Kind:              otlpKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		Status:            otlpStatus{Code: otlpStatusUnset},
+	}
+
+	switch s.Kind {
+	case SpanKindServer:
+		span.Kind = otlpKindServer
+	case SpanKindClient:
+		span.Kind = otlpKindClient
+	}
+
+	if s.ParentSpanID.IsValid() {
+		span.ParentSpanID = s.ParentSpanID.String()
+	}
+
+	for _, attr := range s.Attributes {
+		span.Attributes = append(span.Attributes, newOTLPAttribute(attr))
+	}
+
+	if s.Err != nil {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Err.Error()}
+	}
+
+	return span
+}
+
+func newOTLPAttribute(attr Attribute) otlpAttribute {
+	value := make(map[string]interface{}, 1)
+
+	switch v := attr.Value.(type) {
+	case int64:
+		// 64 bit integers are strings, since JSON numbers cannot hold them
+		// all.
+		value["intValue"] = strconv.FormatInt(v, 10)
+	case float64:
+		value["doubleValue"] = v
+	case bool:
+		value["boolValue"] = v
+	default:
+		value["stringValue"] = fmt.Sprint(v)
+	}
+
+	return otlpAttribute{Key: attr.Key, Value: value}
+}
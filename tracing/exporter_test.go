@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var mockSpan = SpanData{
+	Name: "GET /v1/users/{id}",
+	Kind: SpanKindServer,
+	SpanContext: SpanContext{
+		TraceID: TraceID{0x4b, 0xf9},
+		SpanID:  SpanID{0x00, 0xf0},
+		Sampled: true,
+	},
+	ParentSpanID: SpanID{0x01},
+	Start:        time.Unix(1, 0),
+	End:          time.Unix(1, 5000000),
+	Attributes:   []Attribute{String("http.route", "/v1/users/{id}"), Int("http.response.status_code", 500)},
+	Err:          fmt.Errorf("responded with status 500"),
+}
+
+func TestStdoutExporter(t *testing.T) {
+	t.Run("writes spans as JSON, one per line", func(t *testing.T) {
+		var b bytes.Buffer
+		exporter := NewStdoutExporter(&b)
+
+		if err := exporter.Export(context.Background(), []SpanData{mockSpan, mockSpan}); err != nil {
+			t.FailNow()
+		}
+
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != 2 {
+			t.FailNow()
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+			t.FailNow()
+		}
+		if got["name"] != mockSpan.Name || got["kind"] != "server" || got["duration"] != "5ms" || got["error"] != "responded with status 500" {
+			t.Errorf("unexpected span %s", lines[0])
+		}
+		if got["traceId"] != mockSpan.SpanContext.TraceID.String() || got["parentSpanId"] != mockSpan.ParentSpanID.String() {
+			t.Errorf("unexpected span %s", lines[0])
+		}
+	})
+}
+
+func TestOTLPExporter(t *testing.T) {
+	t.Run("sends spans to the collector", func(t *testing.T) {
+		var path, contentType string
+		var body map[string]interface{}
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			contentType = r.Header.Get("Content-Type")
+			json.NewDecoder(r.Body).Decode(&body)
+		}))
+		defer collector.Close()
+
+		exporter := NewOTLPExporter(collector.URL + "/")
+		if err := exporter.Export(context.Background(), []SpanData{mockSpan}); err != nil {
+			t.FailNow()
+		}
+
+		if path != "/v1/traces" || contentType != "application/json" {
+			t.Fail()
+		}
+
+		resourceSpans := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+		scopeSpans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})
+		span := scopeSpans["spans"].([]interface{})[0].(map[string]interface{})
+
+		if span["traceId"] != mockSpan.SpanContext.TraceID.String() || span["spanId"] != mockSpan.SpanContext.SpanID.String() {
+			t.Errorf("unexpected span %v", span)
+		}
+		if span["kind"] != float64(otlpKindServer) || span["startTimeUnixNano"] != "1000000000" || span["endTimeUnixNano"] != "1005000000" {
+			t.Errorf("unexpected span %v", span)
+		}
+		if status := span["status"].(map[string]interface{}); status["code"] != float64(otlpStatusError) {
+			t.Errorf("unexpected status %v", status)
+		}
+
+		attrs := span["attributes"].([]interface{})
+		statusCode := attrs[1].(map[string]interface{})["value"].(map[string]interface{})
+		if statusCode["intValue"] != "500" {
+			t.Errorf("unexpected attribute %v", statusCode)
+		}
+	})
+
+	t.Run("fails when the collector rejects spans", func(t *testing.T) {
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer collector.Close()
+
+		if err := NewOTLPExporter(collector.URL).Export(context.Background(), []SpanData{mockSpan}); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("sends spans to the default endpoint when none is given", func(t *testing.T) {
+		if NewOTLPExporter("").url != DefaultOTLPEndpoint+"/v1/traces" {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/leblancjs/stmoosersburg-api/hash"
+)
+
+type hashService struct {
+	hash.Service
+}
+
+// InstrumentHashing wraps the hash service to start a span when it generates
+// a hash from a password, or matches a password with a hash, since they are
+// meant to be slow.
+func InstrumentHashing(svc hash.Service) hash.Service {
+	return &hashService{svc}
+}
+
+func (svc *hashService) GenerateFromPassword(ctx context.Context, password string) (string, error) {
+	ctx, span := Start(ctx, "hash.GenerateFromPassword")
+	defer span.End()
+
+	hash, err := svc.Service.GenerateFromPassword(ctx, password)
+	span.RecordError(err)
+
+	return hash, err
+}
+
+func (svc *hashService) MatchPassword(ctx context.Context, hash string, password string) bool {
+	ctx, span := Start(ctx, "hash.MatchPassword")
+	defer span.End()
+
+	matches := svc.Service.MatchPassword(ctx, hash, password)
+	span.SetAttributes(Bool("hash.matched", matches))
+
+	return matches
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+type mockHashService struct{}
+
+func (mockHashService) GenerateFromPassword(_ context.Context, password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func (mockHashService) MatchPassword(_ context.Context, hash string, password string) bool {
+	return hash == "hashed:"+password
+}
+
+func (mockHashService) NeedsRehash(hash string) bool {
+	return false
+}
+
+func TestInstrumentingHashing(t *testing.T) {
+	t.Run("starts spans around generating and matching hashes", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		ctx, _ := tracer.Start(context.Background(), "POST /v1/users", SpanKindServer)
+		svc := InstrumentHashing(mockHashService{})
+
+		hash, err := svc.GenerateFromPassword(ctx, "moose")
+		if err != nil || !svc.MatchPassword(ctx, hash, "moose") {
+			t.FailNow()
+		}
+
+		spans := ended(tracer)
+		if len(spans) != 2 || spans[0].Name != "hash.GenerateFromPassword" || spans[1].Name != "hash.MatchPassword" {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/transport/http/middleware"
+)
+
+// NewHTTPMiddleware creates a middleware that starts a server span for each
+// request, continuing the trace of the caller when it sent a valid
+// traceparent header, and carries it in the context of the request.
+//
+// Spans are named after the method and the path template of the route of the
+// router that requests match, such as "GET /v1/users/{id}".
+func NewHTTPMiddleware(tracer *Tracer, router *mux.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if remote, err := Extract(r.Header); err == nil {
+				ctx = ContextWithRemoteSpanContext(ctx, remote)
+			}
+
+			name := r.Method
+			attrs := []Attribute{
+				String("http.request.method", r.Method),
+				String("url.path", r.URL.Path),
+				String("user_agent.original", r.UserAgent()),
+			}
+			if route, ok := middleware.Route(router, r); ok {
+				name += " " + route
+				attrs = append(attrs, String("http.route", route))
+			}
+
+			ctx, span := tracer.Start(ctx, name, SpanKindServer, attrs...)
+			defer span.End()
+
+			rw := middleware.NewResponseWriter(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			span.SetAttributes(Int("http.response.status_code", rw.Status()))
+			if rw.Status() >= http.StatusInternalServerError {
+				span.RecordError(fmt.Errorf("responded with status %d", rw.Status()))
+			}
+		})
+	}
+}
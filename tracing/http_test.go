@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/v1/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := SpanFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	t.Run("starts a server span named after the route", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		handler := NewHTTPMiddleware(tracer, router)(router)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/users/1", nil))
+
+		spans := ended(tracer)
+		if len(spans) != 1 {
+			t.FailNow()
+		}
+		if spans[0].Name != "GET /v1/users/{id}" || spans[0].Kind != SpanKindServer || spans[0].Err == nil {
+			t.Fail()
+		}
+
+		attrs := make(map[string]interface{})
+		for _, attr := range spans[0].Attributes {
+			attrs[attr.Key] = attr.Value
+		}
+		if attrs["http.route"] != "/v1/users/{id}" || attrs["http.response.status_code"] != int64(http.StatusInternalServerError) {
+			t.Errorf("unexpected attributes %v", attrs)
+		}
+	})
+
+	t.Run("continues the trace of the caller", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		handler := NewHTTPMiddleware(tracer, router)(router)
+
+		r := httptest.NewRequest("GET", "/v1/users/1", nil)
+		r.Header.Set(TraceparentHeader, mockTraceparent)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := ended(tracer)
+		if len(spans) != 1 {
+			t.FailNow()
+		}
+		if spans[0].SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || spans[0].ParentSpanID.String() != "00f067aa0ba902b7" {
+			t.Fail()
+		}
+	})
+
+	t.Run("names spans of unmatched requests after their method", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		handler := NewHTTPMiddleware(tracer, router)(router)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/moose", nil))
+
+		if spans := ended(tracer); len(spans) != 1 || spans[0].Name != "GET" || spans[0].Err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("lets streamed responses be flushed", func(t *testing.T) {
+		flushed := false
+		handler := NewHTTPMiddleware(NewTracer(nil, Config{}), router)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, flushed = w.(http.Flusher)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/users/1", nil))
+
+		if !flushed {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader carries the span context of the caller, as specified by
+// W3C Trace Context.
+const TraceparentHeader = "traceparent"
+
+const (
+	traceparentVersion = "00"
+	flagSampled        = 0x01
+)
+
+type remoteKey struct{}
+
+// ContextWithRemoteSpanContext returns a context carrying the span context
+// of a caller in another service, so that the next span started with it
+// continues its trace.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func remoteFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(remoteKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Extract reads the span context of the caller from the traceparent header,
+// and fails when it is missing or invalid, in which case a new trace must be
+// started.
+func Extract(h http.Header) (SpanContext, error) {
+	value := strings.TrimSpace(h.Get(TraceparentHeader))
+	if value == "" {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: no traceparent header")
+	}
+
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: traceparent must have 4 parts")
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 || version[0] == 0xff {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: traceparent version is invalid")
+	}
+	// Later versions may add parts, but the first four cannot change.
+	if parts[0] == traceparentVersion && len(parts) != 4 {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: traceparent must have 4 parts")
+	}
+
+	var sc SpanContext
+	if err := decodeHex(parts[1], sc.TraceID[:]); err != nil || !sc.TraceID.IsValid() {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: trace ID is invalid")
+	}
+	if err := decodeHex(parts[2], sc.SpanID[:]); err != nil || !sc.SpanID.IsValid() {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: parent ID is invalid")
+	}
+
+	var flags [1]byte
+	if err := decodeHex(parts[3], flags[:]); err != nil {
+		return SpanContext{}, fmt.Errorf("tracing.Extract: trace flags are invalid")
+	}
+	sc.Sampled = flags[0]&flagSampled != 0
+
+	return sc, nil
+}
+
+// Inject writes the span context of the span carried by the context to the
+// traceparent header of a request to another service, so that it continues
+// the trace.
+func Inject(ctx context.Context, h http.Header) {
+	span, ok := SpanFromContext(ctx)
+	if !ok || !span.SpanContext().IsValid() {
+		return
+	}
+
+	sc := span.SpanContext()
+
+	var flags byte
+	if sc.Sampled {
+		flags |= flagSampled
+	}
+
+	h.Set(TraceparentHeader, fmt.Sprintf("%s-%s-%s-%02x", traceparentVersion, sc.TraceID, sc.SpanID, flags))
+}
+
+// decodeHex decodes lower case hexadecimal, which is the only case allowed
+// in the traceparent header, into the destination, which it must fill.
+func decodeHex(s string, dst []byte) error {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return fmt.Errorf("expected %d lower case hexadecimal characters", hex.EncodedLen(len(dst)))
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
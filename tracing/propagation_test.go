@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+const mockTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestExtract(t *testing.T) {
+	t.Run("reads the span context of the caller", func(t *testing.T) {
+		h := http.Header{}
+		h.Set(TraceparentHeader, mockTraceparent)
+
+		sc, err := Extract(h)
+		if err != nil {
+			t.FailNow()
+		}
+
+		if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts later versions with more parts", func(t *testing.T) {
+		h := http.Header{}
+		h.Set(TraceparentHeader, "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-moose")
+
+		sc, err := Extract(h)
+		if err != nil || sc.Sampled {
+			t.Fail()
+		}
+	})
+
+	for name, value := range map[string]string{
+		"is missing":                    "",
+		"has too few parts":             "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"has too many parts":            mockTraceparent + "-moose",
+		"has an invalid version":        "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"has a trace ID of zeros":       "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"has an upper case trace ID":    "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"has a parent ID of zeros":      "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"has a parent ID that is short": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01",
+		"has invalid flags":             "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+	} {
+		value := value
+		t.Run("fails when the header "+name, func(t *testing.T) {
+			h := http.Header{}
+			h.Set(TraceparentHeader, value)
+
+			if _, err := Extract(h); err == nil {
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	t.Run("writes the span context of the span carried by the context", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		ctx, span := tracer.Start(context.Background(), "moose", SpanKindClient)
+
+		h := http.Header{}
+		Inject(ctx, h)
+
+		sc, err := Extract(h)
+		if err != nil || sc != span.SpanContext() {
+			t.Fail()
+		}
+	})
+
+	t.Run("writes nothing when the context carries no span", func(t *testing.T) {
+		h := http.Header{}
+		Inject(context.Background(), h)
+
+		if h.Get(TraceparentHeader) != "" {
+			t.Fail()
+		}
+	})
+}
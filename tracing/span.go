@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, which is made of all the spans of a request,
+// across services.
+type TraceID [16]byte
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext represents what identifies a span across services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+
+	// Sampled tells whether the spans of the trace are recorded, which is
+	// decided once, when the trace starts.
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind tells what a span represents, such as a request served by the
+// service, or a call to another service.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// Attribute represents a key and value describing a span.
+type Attribute struct {
+	Key string
+
+	// Value is a string, an int64, a float64, or a bool.
+	Value interface{}
+}
+
+func String(key string, value string) Attribute {
+	return Attribute{key, value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{key, int64(value)}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{key, value}
+}
+
+// SpanData represents a span once it has ended, as it is exported.
+type SpanData struct {
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+
+	// Err is the error the operation failed with, if it did.
+	Err error
+}
+
+// Span represents an operation, such as serving a request, or querying the
+// database, which is recorded when it ends, if its trace is sampled.
+//
+// A span that is not recorded still carries its span context, so that it can
+// be propagated to other services.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+// IsRecording tells whether the span is recorded when it ends.
+func (s *Span) IsRecording() bool {
+	return s.tracer != nil && s.data.SpanContext.Sampled
+}
+
+// SetAttributes adds attributes to the span, replacing the ones with the same
+// keys.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attr := range attrs {
+		replaced := false
+		for i, existing := range s.data.Attributes {
+			if existing.Key == attr.Key {
+				s.data.Attributes[i] = attr
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			s.data.Attributes = append(s.data.Attributes, attr)
+		}
+	}
+}
+
+// RecordError marks the span as failed with the error, when it is not nil.
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Err = err
+}
+
+// End ends the span, and queues it to be exported if it is recorded. Ending a
+// span more than once does nothing.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+func (s *Span) String() string {
+	return fmt.Sprintf("%s (trace %s, span %s)", s.data.Name, s.data.SpanContext.TraceID, s.data.SpanContext.SpanID)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context carrying the span, so that the spans
+// started with it are its children.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span carried by the context, if any.
+func SpanFromContext(ctx context.Context) (*Span, bool) {
+	span, ok := ctx.Value(spanKey{}).(*Span)
+	return span, ok
+}
+
+// Start starts a span that is a child of the one carried by the context,
+// with the tracer that started it. When the context carries no span, such as
+// outside of requests, the span is not recorded.
+//
+// It is meant for layers that do not know about the tracer, such as
+// repositories, and the returned context must be passed to the operations
+// the span covers.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent, ok := SpanFromContext(ctx)
+	if !ok || parent.tracer == nil {
+		return ctx, &Span{}
+	}
+
+	return parent.tracer.Start(ctx, name, SpanKindInternal, attrs...)
+}
@@ -0,0 +1,210 @@
+// Package tracing records the spans of requests across the layers of the
+// service, such as the endpoint, the repository, and the database, and
+// exports them to a collector, to see where time is spent.
+//
+// Traces are propagated to and from other services with the W3C traceparent
+// header.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"log/slog"
+	"time"
+)
+
+// Defaults of the configuration.
+const (
+	DefaultSampleRatio   = 1.0
+	DefaultBatchSize     = 512
+	DefaultBatchInterval = 5 * time.Second
+	DefaultQueueSize     = 2048
+
+	// exportTimeout is how long a batch has to be exported.
+	exportTimeout = 10 * time.Second
+)
+
+// An Exporter sends spans to where they are stored, such as a collector. It
+// must not keep the spans once it returns, since they are reused.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Config represents how traces are sampled and exported. Fields that are not
+// set take their default values.
+type Config struct {
+	// SampleRatio is the ratio of traces that are recorded, between 0 and 1,
+	// when they start in this service, which is all of them by default.
+	// Traces that started elsewhere are recorded if they were there.
+	SampleRatio float64
+
+	// BatchSize is how many spans are exported at once, at most, and
+	// BatchInterval how long spans wait to be exported.
+	BatchSize     int
+	BatchInterval time.Duration
+
+	// QueueSize is how many spans can wait to be exported before new ones
+	// are dropped.
+	QueueSize int
+}
+
+// Tracer starts spans, and exports the ones that are recorded in batches,
+// while it runs.
+type Tracer struct {
+	conf     Config
+	exporter Exporter
+	queue    chan SpanData
+}
+
+// NewTracer creates a tracer that exports spans with the exporter, or that
+// only propagates traces without recording them when it is nil.
+func NewTracer(exporter Exporter, conf Config) *Tracer {
+	if conf.SampleRatio == 0 {
+		conf.SampleRatio = DefaultSampleRatio
+	}
+	if conf.BatchSize == 0 {
+		conf.BatchSize = DefaultBatchSize
+	}
+	if conf.BatchInterval == 0 {
+		conf.BatchInterval = DefaultBatchInterval
+	}
+	if conf.QueueSize == 0 {
+		conf.QueueSize = DefaultQueueSize
+	}
+
+	return &Tracer{
+		conf:     conf,
+		exporter: exporter,
+		queue:    make(chan SpanData, conf.QueueSize),
+	}
+}
+
+// Start starts a span that is a child of the one carried by the context, or
+// of the remote span from another service, if any, or the root of a new
+// trace otherwise, and returns a context carrying it.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	var parent SpanContext
+	if span, ok := SpanFromContext(ctx); ok {
+		parent = span.SpanContext()
+	} else if remote, ok := remoteFromContext(ctx); ok {
+		parent = remote
+	}
+
+	sc := SpanContext{
+		TraceID: parent.TraceID,
+		SpanID:  newSpanID(),
+		Sampled: parent.Sampled,
+	}
+	if !parent.IsValid() {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.exporter != nil && sample(t.conf.SampleRatio)
+	}
+
+	span := &Span{
+		data: SpanData{
+			Name:         name,
+			Kind:         kind,
+			SpanContext:  sc,
+			ParentSpanID: parent.SpanID,
+			Start:        time.Now(),
+		},
+	}
+	if t.exporter != nil {
+		span.tracer = t
+	}
+	span.SetAttributes(attrs...)
+
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		// Tracing must never slow requests down, so spans are dropped when
+		// the exporter cannot keep up.
+	}
+}
+
+// Run exports the spans that ended in batches, until the context is done,
+// when it exports the ones that are left.
+func (t *Tracer) Run(ctx context.Context) {
+	if t.exporter == nil {
+		return
+	}
+
+	ticker := time.NewTicker(t.conf.BatchInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, t.conf.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case data := <-t.queue:
+					batch = append(batch, data)
+					if len(batch) == t.conf.BatchSize {
+						batch = t.export(batch)
+					}
+				default:
+					t.export(batch)
+					return
+				}
+			}
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) == t.conf.BatchSize {
+				batch = t.export(batch)
+			}
+		case <-ticker.C:
+			batch = t.export(batch)
+		}
+	}
+}
+
+// export exports the batch, and returns it emptied.
+func (t *Tracer) export(batch []SpanData) []SpanData {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := t.exporter.Export(ctx, batch); err != nil {
+		slog.Error("tracing.Tracer: failed to export spans", slog.Int("spans", len(batch)), slog.String("error", err.Error()))
+	}
+
+	return batch[:0]
+}
+
+func sample(ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+
+	var b [8]byte
+	rand.Read(b[:])
+
+	return float64(binary.BigEndian.Uint64(b[:])>>11)/(1<<53) < ratio
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+
+	return id
+}
@@ -0,0 +1,282 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockExporter struct {
+	mu      sync.Mutex
+	spans   []SpanData
+	batches int
+	err     error
+}
+
+func (e *mockExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	e.batches++
+
+	return e.err
+}
+
+func (e *mockExporter) exported() ([]SpanData, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]SpanData(nil), e.spans...), e.batches
+}
+
+// ended returns the spans that ended, and are waiting to be exported.
+func ended(tracer *Tracer) []SpanData {
+	var spans []SpanData
+	for {
+		select {
+		case data := <-tracer.queue:
+			spans = append(spans, data)
+		default:
+			return spans
+		}
+	}
+}
+
+func TestTracerStart(t *testing.T) {
+	t.Run("starts a new sampled trace when there is no parent", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+
+		_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+		sc := span.SpanContext()
+
+		if !sc.IsValid() || !sc.Sampled || !span.IsRecording() {
+			t.Fail()
+		}
+	})
+
+	t.Run("continues the trace of the span carried by the context", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+
+		ctx, parent := tracer.Start(context.Background(), "parent", SpanKindServer)
+		_, child := tracer.Start(ctx, "child", SpanKindInternal)
+		child.End()
+
+		spans := ended(tracer)
+		if len(spans) != 1 {
+			t.FailNow()
+		}
+		if spans[0].SpanContext.TraceID != parent.SpanContext().TraceID || spans[0].ParentSpanID != parent.SpanContext().SpanID {
+			t.Fail()
+		}
+		if spans[0].SpanContext.SpanID == parent.SpanContext().SpanID {
+			t.Fail()
+		}
+	})
+
+	t.Run("continues the trace of a remote caller, and its sampling decision", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+		remote := SpanContext{
+			TraceID: TraceID{1},
+			SpanID:  SpanID{2},
+		}
+
+		_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "moose", SpanKindServer)
+
+		if span.SpanContext().TraceID != remote.TraceID || span.IsRecording() {
+			t.Fail()
+		}
+	})
+
+	t.Run("does not record spans without an exporter", func(t *testing.T) {
+		tracer := NewTracer(nil, Config{})
+
+		_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+		span.End()
+
+		if !span.SpanContext().IsValid() || span.IsRecording() || len(ended(tracer)) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("samples a ratio of new traces", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{SampleRatio: 0.5})
+
+		sampled := 0
+		for i := 0; i < 1000; i++ {
+			if _, span := tracer.Start(context.Background(), "moose", SpanKindServer); span.IsRecording() {
+				sampled++
+			}
+		}
+
+		if sampled < 400 || sampled > 600 {
+			t.Errorf("expected about 500 sampled traces, got %d", sampled)
+		}
+	})
+
+	t.Run("drops spans when the queue is full", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{QueueSize: 1})
+
+		for i := 0; i < 3; i++ {
+			_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+			span.End()
+		}
+
+		if len(ended(tracer)) != 1 {
+			t.Fail()
+		}
+	})
+}
+
+func TestSpan(t *testing.T) {
+	t.Run("replaces attributes with the same key", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+
+		_, span := tracer.Start(context.Background(), "moose", SpanKindServer, String("antlers", "small"))
+		span.SetAttributes(String("antlers", "large"), Int("legs", 4))
+		span.End()
+
+		attrs := ended(tracer)[0].Attributes
+		if len(attrs) != 2 || attrs[0].Value != "large" || attrs[1].Value != int64(4) {
+			t.Fail()
+		}
+	})
+
+	t.Run("records errors", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+
+		_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+		span.RecordError(nil)
+		span.RecordError(fmt.Errorf("moose is loose"))
+		span.End()
+
+		if err := ended(tracer)[0].Err; err == nil || err.Error() != "moose is loose" {
+			t.Fail()
+		}
+	})
+
+	t.Run("ends only once", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+
+		_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+		span.End()
+		span.End()
+
+		if len(ended(tracer)) != 1 {
+			t.Fail()
+		}
+	})
+}
+
+func TestStart(t *testing.T) {
+	t.Run("starts a child with the tracer of the span carried by the context", func(t *testing.T) {
+		tracer := NewTracer(&mockExporter{}, Config{})
+
+		ctx, parent := tracer.Start(context.Background(), "parent", SpanKindServer)
+		ctx, child := Start(ctx, "child", String("antlers", "large"))
+		child.End()
+
+		spans := ended(tracer)
+		if len(spans) != 1 || spans[0].Kind != SpanKindInternal || spans[0].ParentSpanID != parent.SpanContext().SpanID {
+			t.Fail()
+		}
+		if span, ok := SpanFromContext(ctx); !ok || span != child {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a span that is not recorded when the context carries none", func(t *testing.T) {
+		ctx := context.Background()
+
+		got, span := Start(ctx, "moose")
+		span.SetAttributes(String("antlers", "large"))
+		span.RecordError(fmt.Errorf("moose is loose"))
+		span.End()
+
+		if got != ctx || span.IsRecording() || span.SpanContext().IsValid() {
+			t.Fail()
+		}
+	})
+}
+
+func TestTracerRun(t *testing.T) {
+	t.Run("exports spans in batches", func(t *testing.T) {
+		exporter := &mockExporter{}
+		tracer := NewTracer(exporter, Config{BatchSize: 2, BatchInterval: time.Hour})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			tracer.Run(ctx)
+			close(done)
+		}()
+
+		for i := 0; i < 2; i++ {
+			_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+			span.End()
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			if _, batches := exporter.exported(); batches == 1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected a full batch to be exported")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel()
+		<-done
+	})
+
+	t.Run("exports the spans that are left when it stops", func(t *testing.T) {
+		exporter := &mockExporter{}
+		tracer := NewTracer(exporter, Config{BatchInterval: time.Hour})
+
+		for i := 0; i < 3; i++ {
+			_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+			span.End()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tracer.Run(ctx)
+
+		if spans, _ := exporter.exported(); len(spans) != 3 {
+			t.Fail()
+		}
+	})
+
+	t.Run("exports spans periodically", func(t *testing.T) {
+		exporter := &mockExporter{}
+		tracer := NewTracer(exporter, Config{BatchInterval: 10 * time.Millisecond})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			tracer.Run(ctx)
+			close(done)
+		}()
+
+		_, span := tracer.Start(context.Background(), "moose", SpanKindServer)
+		span.End()
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			if spans, _ := exporter.exported(); len(spans) == 1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected the span to be exported")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		cancel()
+		<-done
+	})
+}
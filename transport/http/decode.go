@@ -0,0 +1,174 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
+)
+
+// DefaultMaxBodySize is the size, in bytes, of the largest body DecodeJSON
+// reads, which is far more than any request of the service needs.
+const DefaultMaxBodySize = 1 << 20
+
+// DecodeError is returned when the body of a request cannot be decoded,
+// with the status code to respond with, such as 413 Request Entity Too Large
+// when it is too large, and the problems found with its fields, if any.
+type DecodeError struct {
+	StatusCode int
+	Message    string
+	Fields     []problem.FieldError
+}
+
+func (e *DecodeError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	problems := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		problems = append(problems, f.String())
+	}
+
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(problems, "; "))
+}
+
+// DecodeJSON decodes the body of the request, which must be a single JSON
+// value of at most DefaultMaxBodySize bytes, into v.
+func DecodeJSON(r *http.Request, v interface{}) error {
+	return DecodeJSONWithLimit(r, v, DefaultMaxBodySize)
+}
+
+// DecodeJSONWithLimit decodes the body of the request, which must be a single
+// JSON value of at most maxBodySize bytes, into v, which is usually a
+// struct.
+//
+// The request must have a JSON content type, and its body cannot have fields
+// that v does not have, so that typos are not silently ignored. It fails
+// with a DecodeError that tells callers what is wrong with their request.
+func DecodeJSONWithLimit(r *http.Request, v interface{}, maxBodySize int64) error {
+	if !isJSON(r.Header.Get("Content-Type")) {
+		return &DecodeError{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    "Content-Type must be application/json",
+		}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return newDecodeError(err, maxBodySize)
+	}
+
+	// Anything after the value, but white space, is a mistake.
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return newDecodeError(err, maxBodySize)
+		}
+
+		return &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "request body must be a single JSON value",
+		}
+	}
+
+	return nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// newDecodeError describes the error of the JSON decoder without its
+// internals, such as the Go types of fields.
+func newDecodeError(err error, maxBodySize int64) *DecodeError {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &DecodeError{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    fmt.Sprintf("request body must not be larger than %d bytes", maxBodySize),
+		}
+	case errors.Is(err, io.EOF):
+		return &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "request body is required",
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "request body is malformed JSON, which ends unexpectedly",
+		}
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("request body is malformed JSON at offset %d", syntaxErr.Offset),
+		}
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			return &DecodeError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("request body must be %s", describeKind(typeErr.Type)),
+			}
+		}
+
+		return &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "request body is invalid",
+			Fields:     []problem.FieldError{{Field: field, Problem: "must be " + describeKind(typeErr.Type)}},
+		}
+	}
+
+	// The decoder has no type for unknown fields, only its message.
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "request body is invalid",
+			Fields:     []problem.FieldError{{Field: strings.Trim(name, `"`), Problem: "is not a known field"}},
+		}
+	}
+
+	return &DecodeError{
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("request body is invalid (%s)", err),
+	}
+}
+
+// describeKind names the JSON type of values of the Go type.
+func describeKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a valid value"
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
+)
+
+type mockBody struct {
+	Username string `json:"username"`
+	Results  []struct {
+		Rank int `json:"rank"`
+	} `json:"results"`
+}
+
+func newJSONRequest(contentType string, body string) *http.Request {
+	r := httptest.NewRequest("POST", "/v1/users", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	return r
+}
+
+func TestDecodingJSON(t *testing.T) {
+	t.Run("decodes the body when all is well", func(t *testing.T) {
+		for _, contentType := range []string{"application/json", "application/json; charset=utf-8", "application/merge-patch+json"} {
+			var body mockBody
+			err := DecodeJSON(newJSONRequest(contentType, `{"username": "moose", "results": [{"rank": 1}]}`+"\n"), &body)
+
+			if err != nil || body.Username != "moose" || len(body.Results) != 1 || body.Results[0].Rank != 1 {
+				t.Errorf("expected body with content type %s to be decoded, got %v", contentType, err)
+			}
+		}
+	})
+
+	for name, test := range map[string]struct {
+		contentType string
+		body        string
+		status      int
+		fields      []problem.FieldError
+	}{
+		"the content type is missing":           {"", `{}`, http.StatusUnsupportedMediaType, nil},
+		"the content type is not JSON":          {"text/plain", `{}`, http.StatusUnsupportedMediaType, nil},
+		"the body is empty":                     {"application/json", ``, http.StatusBadRequest, nil},
+		"the body is malformed":                 {"application/json", `not.json.at.all`, http.StatusBadRequest, nil},
+		"the body ends unexpectedly":            {"application/json", `{"username": "moose"`, http.StatusBadRequest, nil},
+		"the body has more than one value":      {"application/json", `{} {}`, http.StatusBadRequest, nil},
+		"the body is not an object":             {"application/json", `[]`, http.StatusBadRequest, nil},
+		"a field has the wrong type":            {"application/json", `{"username": 42}`, http.StatusBadRequest, []problem.FieldError{{Field: "username", Problem: "must be a string"}}},
+		"a field is not known":                  {"application/json", `{"usrename": "moose"}`, http.StatusBadRequest, []problem.FieldError{{Field: "usrename", Problem: "is not a known field"}}},
+		"the body is too large":                 {"application/json", `{"username": "` + strings.Repeat("m", DefaultMaxBodySize) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		"the body is too large after its value": {"application/json", `{}` + strings.Repeat(" ", DefaultMaxBodySize), http.StatusRequestEntityTooLarge, nil},
+	} {
+		test := test
+		t.Run("fails when "+name, func(t *testing.T) {
+			var body mockBody
+			err := DecodeJSON(newJSONRequest(test.contentType, test.body), &body)
+
+			e, ok := err.(*DecodeError)
+			if !ok {
+				t.Fatalf("expected a decode error, got %v", err)
+			}
+			if e.StatusCode != test.status {
+				t.Errorf("expected status %d, got %d (%s)", test.status, e.StatusCode, e)
+			}
+			if len(e.Fields) != len(test.fields) {
+				t.Fatalf("expected fields %v, got %v", test.fields, e.Fields)
+			}
+			for i, f := range test.fields {
+				if e.Fields[i] != f {
+					t.Errorf("expected fields %v, got %v", test.fields, e.Fields)
+				}
+			}
+		})
+	}
+
+	t.Run("fails with the path of a nested field that has the wrong type", func(t *testing.T) {
+		var body mockBody
+		err := DecodeJSON(newJSONRequest("application/json", `{"results": [{"rank": "first"}]}`), &body)
+
+		// Versions of Go differ on whether the index is part of the path.
+		e, ok := err.(*DecodeError)
+		if !ok || len(e.Fields) != 1 || !strings.HasPrefix(e.Fields[0].Field, "results.") || !strings.HasSuffix(e.Fields[0].Field, ".rank") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("fails when the body is larger than the given limit", func(t *testing.T) {
+		var body mockBody
+		err := DecodeJSONWithLimit(newJSONRequest("application/json", `{"username": "moose"}`), &body, 8)
+
+		if e, ok := err.(*DecodeError); !ok || e.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fail()
+		}
+	})
+
+	t.Run("describes the problems with fields in its message", func(t *testing.T) {
+		var body mockBody
+		err := DecodeJSON(newJSONRequest("application/json", `{"username": 42}`), &body)
+
+		if err == nil || err.Error() != "request body is invalid: username must be a string" {
+			t.Errorf("unexpected message %v", err)
+		}
+	})
+}
@@ -2,11 +2,25 @@ package http
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
 
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
 	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/middleware"
 )
 
+// ErrInternal is encoded in place of panics, so that callers get a response
+// that does not tell them anything about what went wrong. It is logged where
+// it is returned, so error encoders do not log it again.
+var ErrInternal = errors.New("internal server error")
+
 type DecodeRequestFunc func(ctx context.Context, r *http.Request) (request interface{}, err error)
 type EncodeResponseFunc func(ctx context.Context, w http.ResponseWriter, response interface{}) error
 type EncodeErrorFunc func(ctx context.Context, w http.ResponseWriter, err error)
@@ -33,23 +47,87 @@ func NewHandler(
 }
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := withRequestPath(r.Context(), r)
+	rw := middleware.NewResponseWriter(w)
+
+	// Panics, such as an unexpected type of request, are recovered, so that
+	// callers still get a response, rather than a closed connection.
+	defer func() {
+		if v := recover(); v != nil {
+			// Aborting a response is how handlers close connections on
+			// purpose, and the server handles it.
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			h.recoverPanic(ctx, rw, r, v, debug.Stack())
+		}
+	}()
 
 	req, err := h.decodeRequest(ctx, r)
 	if err != nil {
-		h.encodeError(ctx, w, err)
+		logFailure(ctx, r, slog.LevelInfo, "failed to decode request", err)
+		h.encodeError(ctx, rw, err)
 		return
 	}
 
 	resp, err := h.endpoint(ctx, req)
 	if err != nil {
-		h.encodeError(ctx, w, err)
+		// Endpoints that recover from their own panics return them, and they
+		// are handled like any other panic.
+		if e, ok := err.(*endpoint.PanicError); ok {
+			h.recoverPanic(ctx, rw, r, e.Value, e.Stack)
+			return
+		}
+
+		// Most endpoint errors are the caller's, such as asking for a user
+		// that does not exist, so they are only warnings.
+		logFailure(ctx, r, slog.LevelWarn, "endpoint failed", err)
+		h.encodeError(ctx, rw, err)
 		return
 	}
 
-	err = h.encodeResponse(ctx, w, resp)
+	err = h.encodeResponse(ctx, rw, resp)
 	if err != nil {
-		h.encodeError(ctx, w, err)
+		logFailure(ctx, r, slog.LevelError, "failed to encode response", err)
+		h.encodeError(ctx, rw, err)
 		return
 	}
 }
+
+// recoverPanic logs the panic with its stack, and responds with ErrInternal,
+// unless the response was already started, in which case it is too late, so
+// the response is aborted instead, for the client to tell that it is
+// incomplete.
+func (h Handler) recoverPanic(ctx context.Context, rw *middleware.ResponseWriter, r *http.Request, v interface{}, stack []byte) {
+	logFailure(ctx, r, slog.LevelError, "recovered from panic", fmt.Errorf("%v", v), slog.String("stack", string(stack)))
+
+	if rw.Started() {
+		panic(http.ErrAbortHandler)
+	}
+
+	h.encodeError(ctx, rw, ErrInternal)
+}
+
+// logFailure logs the error with the route of the request and the caller, if
+// any, using the logger of the request, which carries its ID.
+//
+// Requests and responses are never logged, since they can hold passwords.
+func logFailure(ctx context.Context, r *http.Request, level slog.Level, msg string, err error, extra ...slog.Attr) {
+	attrs := []slog.Attr{
+		slog.String("error", err.Error()),
+	}
+	attrs = append(attrs, extra...)
+
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			attrs = append(attrs, slog.String("route", template))
+		}
+	}
+
+	if identity, ok := auth.FromContext(ctx); ok {
+		attrs = append(attrs, slog.String("userId", identity.UserID))
+	}
+
+	logging.FromContext(ctx).LogAttrs(ctx, level, msg, attrs...)
+}
@@ -1,12 +1,21 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
 	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/logging"
 )
 
 func TestHandlerConstruction(t *testing.T) {
@@ -140,6 +149,139 @@ func TestHandlerServing(t *testing.T) {
 	})
 }
 
+func TestHandlerLogging(t *testing.T) {
+	t.Run("logs failures with the request's logger, route, and caller", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil)).With(slog.String("requestId", "mock.request.id"))
+
+		handler := NewHandler(
+			newMockEndpoint(nil, fmt.Errorf("endpoint failed to process request")).endpoint,
+			newMockRequestDecoder(nil, nil).decode,
+			newMockResponseEncoder(nil).encode,
+			newMockErrorEncoder().encode,
+		)
+		router := mux.NewRouter()
+		router.Handle("/v1/users/{id}", handler)
+
+		r := httptest.NewRequest("PUT", "/v1/users/moose", strings.NewReader(`{"password": "Moose-Lake-123!"}`))
+		ctx := logging.NewContext(auth.NewContext(r.Context(), auth.Identity{UserID: "mock.user.id"}), logger)
+		router.ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("expected a single record, got %s", buf.String())
+		}
+		if record["requestId"] != "mock.request.id" || record["route"] != "/v1/users/{id}" || record["userId"] != "mock.user.id" || record["level"] != "WARN" {
+			t.Errorf("unexpected record %s", buf.String())
+		}
+		if strings.Contains(buf.String(), "Moose-Lake-123!") {
+			t.Fail()
+		}
+	})
+}
+
+func TestHandlerRecovery(t *testing.T) {
+	encodeError := func(_ context.Context, w http.ResponseWriter, err error) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+	}
+	panicking := func(_ context.Context, request interface{}) (interface{}, error) {
+		return request.(int), nil
+	}
+
+	serve := func(handler http.Handler) (*httptest.ResponseRecorder, string) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil)).With(slog.String("requestId", "mock.request.id"))
+
+		r := httptest.NewRequest("POST", "/v1/users", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(logging.NewContext(r.Context(), logger)))
+
+		return rr, buf.String()
+	}
+
+	for name, handler := range map[string]*Handler{
+		"endpoint": NewHandler(
+			panicking,
+			newMockRequestDecoder("moose", nil).decode,
+			newMockResponseEncoder(nil).encode,
+			encodeError,
+		),
+		"decoder": NewHandler(
+			newMockEndpoint(nil, nil).endpoint,
+			func(_ context.Context, _ *http.Request) (interface{}, error) {
+				panic("moose is loose")
+			},
+			newMockResponseEncoder(nil).encode,
+			encodeError,
+		),
+		"endpoint that recovered from it": NewHandler(
+			endpoint.Recover()(panicking),
+			newMockRequestDecoder("moose", nil).decode,
+			newMockResponseEncoder(nil).encode,
+			encodeError,
+		),
+	} {
+		handler := handler
+		t.Run("responds with a generic internal error when the "+name+" panics", func(t *testing.T) {
+			rr, logs := serve(handler)
+
+			if rr.Code != http.StatusInternalServerError || strings.TrimSpace(rr.Body.String()) != `{"error":"internal server error"}` {
+				t.Errorf("unexpected response %d %s", rr.Code, rr.Body.String())
+			}
+
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(logs), &record); err != nil {
+				t.Fatalf("expected a single record, got %s", logs)
+			}
+			if record["level"] != "ERROR" || record["requestId"] != "mock.request.id" || record["error"] == "" {
+				t.Errorf("unexpected record %s", logs)
+			}
+			if stack, _ := record["stack"].(string); !strings.Contains(stack, "goroutine") {
+				t.Errorf("expected a stack trace in %s", logs)
+			}
+		})
+	}
+
+	t.Run("aborts the response instead of encoding an error once it has started", func(t *testing.T) {
+		errorEncoder := newMockErrorEncoder()
+		handler := NewHandler(
+			newMockEndpoint("moose", nil).endpoint,
+			newMockRequestDecoder(nil, nil).decode,
+			func(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+				w.WriteHeader(http.StatusCreated)
+				panic("moose is loose")
+			},
+			errorEncoder.encode,
+		)
+
+		defer func() {
+			if recover() != http.ErrAbortHandler || errorEncoder.WasCalled() {
+				t.Fail()
+			}
+		}()
+		serve(handler)
+	})
+
+	t.Run("lets aborted responses be handled by the server", func(t *testing.T) {
+		handler := NewHandler(
+			func(_ context.Context, _ interface{}) (interface{}, error) {
+				panic(http.ErrAbortHandler)
+			},
+			newMockRequestDecoder(nil, nil).decode,
+			newMockResponseEncoder(nil).encode,
+			encodeError,
+		)
+
+		defer func() {
+			if recover() != http.ErrAbortHandler {
+				t.Fail()
+			}
+		}()
+		serve(handler)
+	})
+}
+
 type mockFunc struct {
 	callCount int
 }
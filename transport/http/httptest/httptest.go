@@ -0,0 +1,16 @@
+// Package httptest provides utilities for testing the HTTP transports of the
+// service, alongside those of net/http/httptest.
+package httptest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// NewJSONRequest creates a request with a JSON body, as clients send them.
+func NewJSONRequest(method string, path string, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
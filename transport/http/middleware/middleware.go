@@ -0,0 +1,85 @@
+// Package middleware holds what the HTTP middlewares of the service share,
+// such as remembering the status of responses, without depending on any
+// of them.
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ResponseWriter remembers the status code and size of the response, and
+// whether it was started, for middlewares that act once the request has been
+// served.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	bytes   int64
+	started bool
+}
+
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the status code of the response, which is 200 OK when none
+// was written.
+func (rw *ResponseWriter) Status() int {
+	return rw.status
+}
+
+// Bytes returns how many bytes of the body were written.
+func (rw *ResponseWriter) Bytes() int64 {
+	return rw.bytes
+}
+
+// Started tells whether the header of the response was written, after which
+// its status can no longer change.
+func (rw *ResponseWriter) Started() bool {
+	return rw.started
+}
+
+func (rw *ResponseWriter) WriteHeader(status int) {
+	if !rw.started {
+		rw.status = status
+		rw.started = true
+	}
+
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	rw.started = true
+
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+
+	return n, err
+}
+
+// Flush lets streamed responses, such as server-sent events, be flushed
+// through the middlewares.
+func (rw *ResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		rw.started = true
+		f.Flush()
+	}
+}
+
+// Route returns the path template of the route of the router that the
+// request matches, such as "/v1/users/{id}", which tells requests apart by
+// what they do rather than by what they are about.
+func Route(router *mux.Router, r *http.Request) (string, bool) {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return "", false
+	}
+
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+
+	return template, true
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestResponseWriter(t *testing.T) {
+	t.Run("defaults to 200 OK before the response is started", func(t *testing.T) {
+		rw := NewResponseWriter(httptest.NewRecorder())
+
+		if rw.Started() || rw.Status() != http.StatusOK {
+			t.Fail()
+		}
+	})
+
+	t.Run("remembers the first status written, and the size of the body", func(t *testing.T) {
+		rw := NewResponseWriter(httptest.NewRecorder())
+
+		rw.WriteHeader(http.StatusNotFound)
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte("moose"))
+
+		if !rw.Started() || rw.Status() != http.StatusNotFound || rw.Bytes() != 5 {
+			t.Fail()
+		}
+	})
+
+	t.Run("starts the response when it is flushed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := NewResponseWriter(rec)
+
+		rw.Flush()
+
+		if !rw.Started() || !rec.Flushed {
+			t.Fail()
+		}
+	})
+}
+
+func TestRoute(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/v1/users/{id}", http.NotFoundHandler()).Methods("GET")
+
+	t.Run("returns the path template of the route", func(t *testing.T) {
+		if route, ok := Route(router, httptest.NewRequest("GET", "/v1/users/1", nil)); !ok || route != "/v1/users/{id}" {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no route matches", func(t *testing.T) {
+		if _, ok := Route(router, httptest.NewRequest("GET", "/moose", nil)); ok {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
+)
+
+// StatusCoder is implemented by errors that know the status code to respond
+// with, such as rate limit errors.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer is implemented by errors that set headers on the response, such
+// as Retry-After.
+type Headerer interface {
+	Headers() http.Header
+}
+
+// FieldErrorer is implemented by errors about the fields of requests, such as
+// validation errors, which are listed in problem details.
+type FieldErrorer interface {
+	FieldErrors() []problem.FieldError
+}
+
+// StatusFunc returns the status code to respond with for the errors a
+// package knows about, or 0 for those it does not.
+type StatusFunc func(err error) int
+
+// NewErrorEncoder creates an EncodeErrorFunc that renders errors as problem
+// details, with the status code statusOf gives them.
+//
+// Errors that statusOf does not know about are either common to all packages,
+// such as auth.ErrUnauthenticated, or internal errors. The details of
+// internal errors, which can be queries and the like, are never sent, but
+// they are logged with the whole chain of their causes.
+func NewErrorEncoder(statusOf StatusFunc) EncodeErrorFunc {
+	return func(ctx context.Context, w http.ResponseWriter, err error) {
+		status := statusOf(err)
+		if status == 0 {
+			status = commonStatus(err)
+		}
+
+		if h, ok := err.(Headerer); ok {
+			for name, values := range h.Headers() {
+				for _, v := range values {
+					w.Header().Add(name, v)
+				}
+			}
+		}
+
+		p := NewProblem(ctx, status)
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			// ErrInternal stands in for panics, which were already logged.
+			if err != ErrInternal {
+				logging.FromContext(ctx).LogAttrs(
+					ctx,
+					slog.LevelError,
+					"responded with internal error",
+					slog.Int("status", status),
+					slog.String("error", err.Error()),
+				)
+			}
+
+			if err == endpoint.ErrTimeout {
+				p.Detail = err.Error()
+			}
+		default:
+			p.Detail = err.Error()
+
+			if e, ok := err.(*DecodeError); ok {
+				p.Detail = e.Message
+				p.Errors = e.Fields
+			} else if e, ok := err.(FieldErrorer); ok {
+				p.Errors = e.FieldErrors()
+			}
+		}
+
+		problem.Write(w, p)
+	}
+}
+
+// commonStatus returns the status code of the errors that any endpoint can
+// return, and 500 Internal Server Error for all others.
+func commonStatus(err error) int {
+	switch err {
+	case auth.ErrUnauthenticated:
+		return http.StatusUnauthorized
+	case auth.ErrForbidden:
+		return http.StatusForbidden
+	case endpoint.ErrTimeout:
+		return http.StatusServiceUnavailable
+	}
+
+	if e, ok := err.(*DecodeError); ok {
+		return e.StatusCode
+	}
+	if e, ok := err.(StatusCoder); ok {
+		return e.StatusCode()
+	}
+
+	return http.StatusInternalServerError
+}
+
+// NewProblem creates the problem details of a response with the status code,
+// for the request the context is for.
+func NewProblem(ctx context.Context, status int) problem.Problem {
+	p := problem.New(status)
+
+	if path, ok := ctx.Value(requestPathKey{}).(string); ok {
+		p.Instance = path
+	}
+	if id, ok := logging.RequestIDFromContext(ctx); ok {
+		p.RequestID = id
+	}
+
+	return p
+}
+
+type requestPathKey struct{}
+
+// withRequestPath returns a context carrying the path of the request, which is
+// the instance of the problems its handler responds with.
+func withRequestPath(ctx context.Context, r *http.Request) context.Context {
+	if r.URL == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, requestPathKey{}, r.URL.Path)
+}
@@ -0,0 +1,62 @@
+// Package problem describes errors in responses as problem details, as
+// specified by RFC 7807. It depends on nothing else in the service, so that
+// middlewares that respond before requests reach a handler, such as
+// authentication, respond like handlers do.
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ContentType is the content type of problem details.
+const ContentType = "application/problem+json"
+
+// Problem describes an error in a response.
+//
+// Problems only have the generic "about:blank" type, so their title is the
+// text of their status code, and their detail is what the caller can do
+// about them.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// RequestID identifies the request in the logs, for callers to give
+	// when they report a problem.
+	RequestID string `json:"requestId,omitempty"`
+
+	// Errors lists the problems found with the fields of the request.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError represents a problem with a field of the body of a request,
+// such as a value of the wrong type, or a field that is not known.
+type FieldError struct {
+	// Field is the path of the field, such as "results.0.rank".
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+func (e FieldError) String() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Problem)
+}
+
+// New creates the problem details of a response with the status code.
+func New(status int) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+}
+
+// Write writes the problem details as the response, with their status code.
+func Write(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
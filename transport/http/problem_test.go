@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/logging"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
+)
+
+var errMockNotFound = errors.New("thing not found")
+
+type mockRateLimitError struct{}
+
+func (mockRateLimitError) Error() string   { return "slow down" }
+func (mockRateLimitError) StatusCode() int { return http.StatusTooManyRequests }
+func (mockRateLimitError) Headers() http.Header {
+	return http.Header{"Retry-After": []string{"3"}}
+}
+
+type mockValidationError struct{}
+
+func (mockValidationError) Error() string { return "name is required" }
+func (mockValidationError) FieldErrors() []problem.FieldError {
+	return []problem.FieldError{{Field: "name", Problem: "is required"}}
+}
+
+var encodeMockError = NewErrorEncoder(func(err error) int {
+	switch err {
+	case errMockNotFound:
+		return http.StatusNotFound
+	}
+
+	if _, ok := err.(mockValidationError); ok {
+		return http.StatusBadRequest
+	}
+
+	return 0
+})
+
+// serveError responds to a request for the path with the error, through a
+// handler, as endpoints do, and returns the response and the logs.
+func serveError(err error) (*httptest.ResponseRecorder, problem.Problem, string) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := logging.NewRequestIDMiddleware()(NewHandler(
+		newMockEndpoint(nil, err).endpoint,
+		newMockRequestDecoder(nil, nil).decode,
+		newMockResponseEncoder(nil).encode,
+		encodeMockError,
+	))
+
+	r := httptest.NewRequest("GET", "/v1/things/1", nil)
+	r.Header.Set(logging.RequestIDHeader, "mock-request-id")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r.WithContext(logging.NewContext(r.Context(), logger)))
+
+	var p problem.Problem
+	json.Unmarshal(rr.Body.Bytes(), &p)
+
+	return rr, p, buf.String()
+}
+
+func TestEncodingErrorAsProblem(t *testing.T) {
+	t.Run("responds with problem details of known errors", func(t *testing.T) {
+		rr, p, _ := serveError(errMockNotFound)
+
+		if rr.Code != http.StatusNotFound || rr.Header().Get("Content-Type") != problem.ContentType {
+			t.FailNow()
+		}
+		if p.Type != "about:blank" || p.Title != "Not Found" || p.Status != http.StatusNotFound {
+			t.Errorf("unexpected problem %+v", p)
+		}
+		if p.Detail != errMockNotFound.Error() || p.Instance != "/v1/things/1" || p.RequestID != "mock-request-id" {
+			t.Errorf("unexpected problem %+v", p)
+		}
+	})
+
+	t.Run("responds with the status of errors common to all endpoints", func(t *testing.T) {
+		statuses := map[error]int{
+			auth.ErrUnauthenticated: http.StatusUnauthorized,
+			auth.ErrForbidden:       http.StatusForbidden,
+			endpoint.ErrTimeout:     http.StatusServiceUnavailable,
+			mockRateLimitError{}:    http.StatusTooManyRequests,
+		}
+
+		for err, status := range statuses {
+			rr, p, _ := serveError(err)
+
+			if rr.Code != status || p.Status != status || p.Detail != err.Error() {
+				t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+			}
+		}
+	})
+
+	t.Run("sets the headers of errors", func(t *testing.T) {
+		rr, _, _ := serveError(mockRateLimitError{})
+
+		if rr.Header().Get("Retry-After") != "3" {
+			t.Fail()
+		}
+	})
+
+	t.Run("lists the problems with the fields of requests", func(t *testing.T) {
+		decodeErr := &DecodeError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "request body is invalid",
+			Fields:     []problem.FieldError{{Field: "admin", Problem: "is not a known field"}},
+		}
+
+		for _, err := range []error{decodeErr, mockValidationError{}} {
+			rr, p, _ := serveError(err)
+
+			if rr.Code != http.StatusBadRequest || len(p.Errors) != 1 {
+				t.Errorf("expected field errors for \"%s\", got %s", err, rr.Body.String())
+			}
+		}
+
+		if _, p, _ := serveError(decodeErr); p.Detail != decodeErr.Message || p.Errors[0] != decodeErr.Fields[0] {
+			t.Errorf("unexpected problem %+v", p)
+		}
+	})
+
+	t.Run("hides the details of internal errors, but logs them", func(t *testing.T) {
+		err := errors.New("user.PostgresRepository.GetByID: failed to execute query (connection refused)")
+
+		rr, p, logs := serveError(err)
+
+		if rr.Code != http.StatusInternalServerError || p.Title != "Internal Server Error" {
+			t.FailNow()
+		}
+		if p.Detail != "" || strings.Contains(rr.Body.String(), "PostgresRepository") {
+			t.Errorf("expected internal error to be hidden, got %s", rr.Body.String())
+		}
+		if !strings.Contains(logs, `"level":"ERROR","msg":"responded with internal error"`) || !strings.Contains(logs, "connection refused") {
+			t.Errorf("expected internal error to be logged, got %s", logs)
+		}
+	})
+
+	t.Run("does not log internal server errors standing in for panics again", func(t *testing.T) {
+		var buf bytes.Buffer
+		ctx := logging.NewContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+
+		rr := httptest.NewRecorder()
+		encodeMockError(ctx, rr, ErrInternal)
+
+		if rr.Code != http.StatusInternalServerError || buf.Len() != 0 {
+			t.Fail()
+		}
+	})
+}
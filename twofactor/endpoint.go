@@ -0,0 +1,86 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+)
+
+type setupRequest struct {
+	UserID string
+}
+
+type setupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+func makeSetupEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setupRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		setup, err := svc.Setup(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &setupResponse{
+			Secret:          setup.Secret,
+			ProvisioningURI: setup.ProvisioningURI,
+		}, nil
+	}
+}
+
+type enableRequest struct {
+	UserID string
+	Code   string
+}
+
+type enableResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+func makeEnableEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(enableRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		recoveryCodes, err := svc.Enable(ctx, req.UserID, req.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		return &enableResponse{
+			RecoveryCodes: recoveryCodes,
+		}, nil
+	}
+}
+
+type disableRequest struct {
+	UserID string
+	Code   string
+}
+
+func makeDisableEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(disableRequest)
+
+		if err := auth.RequireUser(ctx, req.UserID); err != nil {
+			return nil, err
+		}
+
+		if err := svc.Disable(ctx, req.UserID, req.Code); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
@@ -0,0 +1,142 @@
+package twofactor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+)
+
+func TestSetupEndpoint(t *testing.T) {
+	req := setupRequest{UserID: mockUserID}
+
+	t.Run("fails when caller is not the user", func(t *testing.T) {
+		endpoint := makeSetupEndpoint(&mockService{})
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "someone.else"})
+
+		if _, err := endpoint(ctx, req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		endpoint := makeSetupEndpoint(&mockService{fail: true})
+
+		if _, err := endpoint(userContext(), req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns setup response when all is well", func(t *testing.T) {
+		endpoint := makeSetupEndpoint(&mockService{})
+
+		resp, _ := endpoint(userContext(), req)
+		if _, ok := resp.(*setupResponse); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestEnableEndpoint(t *testing.T) {
+	req := enableRequest{UserID: mockUserID, Code: "123456"}
+
+	t.Run("fails when caller is anonymous", func(t *testing.T) {
+		endpoint := makeEnableEndpoint(&mockService{})
+
+		if _, err := endpoint(context.Background(), req); err != auth.ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		endpoint := makeEnableEndpoint(&mockService{fail: true})
+
+		if _, err := endpoint(userContext(), req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns recovery codes when all is well", func(t *testing.T) {
+		endpoint := makeEnableEndpoint(&mockService{})
+
+		resp, _ := endpoint(userContext(), req)
+		enableResp, ok := resp.(*enableResponse)
+		if !ok {
+			t.FailNow()
+		}
+		if len(enableResp.RecoveryCodes) == 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestDisableEndpoint(t *testing.T) {
+	req := disableRequest{UserID: mockUserID, Code: "123456"}
+
+	t.Run("fails when caller is anonymous", func(t *testing.T) {
+		endpoint := makeDisableEndpoint(&mockService{})
+
+		if _, err := endpoint(context.Background(), req); err != auth.ErrUnauthenticated {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when service fails", func(t *testing.T) {
+		endpoint := makeDisableEndpoint(&mockService{fail: true})
+
+		if _, err := endpoint(userContext(), req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when all is well", func(t *testing.T) {
+		endpoint := makeDisableEndpoint(&mockService{})
+
+		if _, err := endpoint(userContext(), req); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+const mockUserID = "mock.user.id"
+
+func userContext() context.Context {
+	return auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
+}
+
+type mockService struct {
+	fail bool
+}
+
+func (mock *mockService) Setup(_ context.Context, userID string) (*Setup, error) {
+	if mock.fail {
+		return nil, fmt.Errorf("failed to set up")
+	}
+
+	return &Setup{Secret: "SECRET", ProvisioningURI: "otpauth://totp/moose"}, nil
+}
+
+func (mock *mockService) Enable(_ context.Context, userID string, code string) ([]string, error) {
+	if mock.fail {
+		return nil, ErrInvalidCode
+	}
+
+	return []string{"abcde-fghij"}, nil
+}
+
+func (mock *mockService) Disable(_ context.Context, userID string, code string) error {
+	if mock.fail {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+func (mock *mockService) Verify(_ context.Context, userID string, code string) error {
+	if mock.fail {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
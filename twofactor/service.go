@@ -0,0 +1,269 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/encryption"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/totp"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+var (
+	ErrInvalidCode    = errors.New("invalid one-time password or recovery code")
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrNotSetUp       = errors.New("two-factor authentication has not been set up")
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Setup represents what a user needs to add their account to an authenticator
+// app.
+type Setup struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type Service interface {
+	// Setup generates a new secret for the user, which is only used once
+	// two-factor authentication is enabled.
+	Setup(ctx context.Context, userID string) (*Setup, error)
+
+	// Enable enables two-factor authentication if the code was generated with
+	// the secret from the setup, and returns recovery codes in plain text.
+	Enable(ctx context.Context, userID string, code string) ([]string, error)
+
+	// Disable disables two-factor authentication if the code is a valid
+	// one-time password or recovery code.
+	Disable(ctx context.Context, userID string, code string) error
+
+	// Verify checks that the code is a valid one-time password or recovery
+	// code for the user. Both can only be used once.
+	Verify(ctx context.Context, userID string, code string) error
+}
+
+type service struct {
+	repo    user.Repository
+	hashSvc hash.Service
+	cipher  encryption.Cipher
+	issuer  string
+	now     func() time.Time
+}
+
+// NewService creates a two-factor authentication service that keeps the
+// users' secrets encrypted with the cipher, and their recovery codes hashed.
+//
+// The issuer is the name under which accounts appear in authenticator apps.
+func NewService(repo user.Repository, hashSvc hash.Service, cipher encryption.Cipher, issuer string) (Service, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("twofactor.NewService: repository is required")
+	}
+
+	if hashSvc == nil {
+		return nil, fmt.Errorf("twofactor.NewService: hash service is required")
+	}
+
+	if cipher == nil {
+		return nil, fmt.Errorf("twofactor.NewService: cipher is required")
+	}
+
+	if issuer == "" {
+		return nil, fmt.Errorf("twofactor.NewService: issuer is required")
+	}
+
+	return &service{
+		repo:    repo,
+		hashSvc: hashSvc,
+		cipher:  cipher,
+		issuer:  issuer,
+		now:     time.Now,
+	}, nil
+}
+
+func (svc *service) Setup(ctx context.Context, userID string) (*Setup, error) {
+	u, err := svc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Setup: %s", err)
+	}
+
+	if u.TwoFactor.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Setup: %s", err)
+	}
+
+	encryptedSecret, err := svc.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Setup: failed to encrypt secret (%s)", err)
+	}
+
+	err = svc.repo.UpdateTwoFactor(ctx, u.ID, entity.TwoFactor{Secret: encryptedSecret})
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Setup: failed to save secret (%s)", err)
+	}
+
+	return &Setup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, svc.issuer, u.Email),
+	}, nil
+}
+
+func (svc *service) Enable(ctx context.Context, userID string, code string) ([]string, error) {
+	u, err := svc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Enable: %s", err)
+	}
+
+	if u.TwoFactor.Enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	if u.TwoFactor.Secret == "" {
+		return nil, ErrNotSetUp
+	}
+
+	secret, err := svc.cipher.Decrypt(u.TwoFactor.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Enable: failed to decrypt secret (%s)", err)
+	}
+
+	step, ok := totp.Match(secret, code, svc.now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	recoveryCodes, hashedRecoveryCodes, err := svc.generateRecoveryCodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Enable: %s", err)
+	}
+
+	err = svc.repo.UpdateTwoFactor(ctx, u.ID, entity.TwoFactor{
+		Enabled:       true,
+		Secret:        u.TwoFactor.Secret,
+		RecoveryCodes: hashedRecoveryCodes,
+		LastUsedStep:  step,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("twofactor.Service.Enable: failed to enable two-factor authentication (%s)", err)
+	}
+
+	return recoveryCodes, nil
+}
+
+func (svc *service) Disable(ctx context.Context, userID string, code string) error {
+	if err := svc.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	err := svc.repo.UpdateTwoFactor(ctx, userID, entity.TwoFactor{})
+	if err != nil {
+		return fmt.Errorf("twofactor.Service.Disable: failed to disable two-factor authentication (%s)", err)
+	}
+
+	return nil
+}
+
+func (svc *service) Verify(ctx context.Context, userID string, code string) error {
+	u, err := svc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("twofactor.Service.Verify: %s", err)
+	}
+
+	if !u.TwoFactor.Enabled {
+		return ErrNotEnabled
+	}
+
+	secret, err := svc.cipher.Decrypt(u.TwoFactor.Secret)
+	if err != nil {
+		return fmt.Errorf("twofactor.Service.Verify: failed to decrypt secret (%s)", err)
+	}
+
+	// One-time passwords are only accepted once, even though they are valid
+	// for a few periods, so that one that was seen cannot be replayed.
+	if step, ok := totp.Match(secret, code, svc.now()); ok {
+		if step <= u.TwoFactor.LastUsedStep {
+			return ErrInvalidCode
+		}
+
+		used, err := svc.repo.UseTOTPStep(ctx, u.ID, step)
+		if err != nil {
+			return fmt.Errorf("twofactor.Service.Verify: failed to use one-time password (%s)", err)
+		}
+		if !used {
+			return ErrInvalidCode
+		}
+
+		return nil
+	}
+
+	// Recovery codes are removed only if they are still there, so that two
+	// attempts with the same code cannot both succeed.
+	normalizedCode := normalizeRecoveryCode(code)
+	for _, hashedRecoveryCode := range u.TwoFactor.RecoveryCodes {
+		if !svc.hashSvc.MatchPassword(ctx, hashedRecoveryCode, normalizedCode) {
+			continue
+		}
+
+		used, err := svc.repo.UseRecoveryCode(ctx, u.ID, hashedRecoveryCode)
+		if err != nil {
+			return fmt.Errorf("twofactor.Service.Verify: failed to use recovery code (%s)", err)
+		}
+		if !used {
+			return ErrInvalidCode
+		}
+
+		return nil
+	}
+
+	return ErrInvalidCode
+}
+
+// generateRecoveryCodes generates recovery codes formatted as two groups of
+// five characters, such as "abcde-fghij", and their hashes.
+func (svc *service) generateRecoveryCodes(ctx context.Context) ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashedCodes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		random := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code (%s)", err)
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(random))[:recoveryCodeLength]
+
+		hashedCode, err := svc.hashSvc.GenerateFromPassword(ctx, code)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash recovery code (%s)", err)
+		}
+
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashedCodes[i] = hashedCode
+	}
+
+	return codes, hashedCodes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+
+	return code
+}
@@ -0,0 +1,357 @@
+package twofactor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/encryption"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/totp"
+	"github.com/leblancjs/stmoosersburg-api/user"
+)
+
+const issuer = "St-Moosersburg"
+
+var now = time.Unix(1500000000, 0)
+
+func newTestService(t *testing.T) (*service, user.Repository, *entity.User) {
+	database := db.NewInMemory(db.Config{})
+	database.Open()
+
+	repo := user.NewInMemoryRepository(database)
+	u, _ := repo.Create(context.Background(), "Moose", "moose@stmoosersburg.com", "a.hashed.password")
+
+	cipher, _ := encryption.NewAESGCMCipher(bytes.Repeat([]byte{0x42}, encryption.KeySize))
+
+	svc, err := NewService(repo, &mockHashService{}, cipher, issuer)
+	if err != nil {
+		t.Fatalf("failed to create service (%s)", err)
+	}
+
+	s := svc.(*service)
+	s.now = func() time.Time { return now }
+
+	return s, repo, u
+}
+
+func enable(t *testing.T, svc *service, userID string) (string, []string) {
+	setup, err := svc.Setup(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("failed to set up two-factor authentication (%s)", err)
+	}
+
+	code, _ := totp.Generate(setup.Secret, now)
+
+	recoveryCodes, err := svc.Enable(context.Background(), userID, code)
+	if err != nil {
+		t.Fatalf("failed to enable two-factor authentication (%s)", err)
+	}
+
+	return setup.Secret, recoveryCodes
+}
+
+func TestServiceConstructor(t *testing.T) {
+	repo := user.NewInMemoryRepository(&db.InMemory{})
+	hashSvc := &mockHashService{}
+	cipher, _ := encryption.NewAESGCMCipher(bytes.Repeat([]byte{0x42}, encryption.KeySize))
+
+	t.Run("fails when repository is missing", func(t *testing.T) {
+		if _, err := NewService(nil, hashSvc, cipher, issuer); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when hash service is missing", func(t *testing.T) {
+		if _, err := NewService(repo, nil, cipher, issuer); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when cipher is missing", func(t *testing.T) {
+		if _, err := NewService(repo, hashSvc, nil, issuer); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when issuer is missing", func(t *testing.T) {
+		if _, err := NewService(repo, hashSvc, cipher, ""); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a service when all is well", func(t *testing.T) {
+		if svc, err := NewService(repo, hashSvc, cipher, issuer); err != nil || svc == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceSetup(t *testing.T) {
+	t.Run("fails when user does not exist", func(t *testing.T) {
+		svc, _, _ := newTestService(t)
+
+		if _, err := svc.Setup(context.Background(), "no.way.this.exists"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when two-factor authentication is already enabled", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+		enable(t, svc, u.ID)
+
+		if _, err := svc.Setup(context.Background(), u.ID); err != ErrAlreadyEnabled {
+			t.Fail()
+		}
+	})
+
+	t.Run("stores the secret encrypted without enabling two-factor authentication", func(t *testing.T) {
+		svc, repo, u := newTestService(t)
+
+		setup, err := svc.Setup(context.Background(), u.ID)
+		if err != nil {
+			t.FailNow()
+		}
+
+		stored, _ := repo.GetByID(context.Background(), u.ID)
+		if stored.TwoFactor.Enabled {
+			t.Fail()
+		}
+		if stored.TwoFactor.Secret == "" || strings.Contains(stored.TwoFactor.Secret, setup.Secret) {
+			t.Fail()
+		}
+		if !strings.HasPrefix(setup.ProvisioningURI, "otpauth://totp/") {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceEnabling(t *testing.T) {
+	t.Run("fails when two-factor authentication was not set up", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+
+		if _, err := svc.Enable(context.Background(), u.ID, "123456"); err != ErrNotSetUp {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when code is invalid", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+		setup, _ := svc.Setup(context.Background(), u.ID)
+
+		code, _ := totp.Generate(setup.Secret, now.Add(time.Hour))
+
+		if _, err := svc.Enable(context.Background(), u.ID, code); err != ErrInvalidCode {
+			t.Fail()
+		}
+	})
+
+	t.Run("enables two-factor authentication and returns recovery codes stored as hashes", func(t *testing.T) {
+		svc, repo, u := newTestService(t)
+
+		_, recoveryCodes := enable(t, svc, u.ID)
+
+		if len(recoveryCodes) != recoveryCodeCount {
+			t.FailNow()
+		}
+
+		stored, _ := repo.GetByID(context.Background(), u.ID)
+		if !stored.TwoFactor.Enabled {
+			t.Fail()
+		}
+		if len(stored.TwoFactor.RecoveryCodes) != recoveryCodeCount {
+			t.FailNow()
+		}
+		if strings.Compare(normalizeRecoveryCode(recoveryCodes[0]), stored.TwoFactor.RecoveryCodes[0]) == 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceVerification(t *testing.T) {
+	t.Run("fails when two-factor authentication is not enabled", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+
+		if err := svc.Verify(context.Background(), u.ID, "123456"); err != ErrNotEnabled {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when code is invalid", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+		enable(t, svc, u.ID)
+
+		if err := svc.Verify(context.Background(), u.ID, "not-a-code"); err != ErrInvalidCode {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts one-time passwords only once", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+		secret, _ := enable(t, svc, u.ID)
+
+		// The code of the current period was used to enable two-factor
+		// authentication, but the next one is still accepted.
+		code, _ := totp.Generate(secret, now.Add(totp.Period))
+
+		if err := svc.Verify(context.Background(), u.ID, code); err != nil {
+			t.Fail()
+		}
+		if err := svc.Verify(context.Background(), u.ID, code); err != ErrInvalidCode {
+			t.Fail()
+		}
+	})
+
+	t.Run("rejects one-time passwords of periods before the last one used", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+		secret, _ := enable(t, svc, u.ID)
+
+		used, _ := totp.Generate(secret, now)
+		earlier, _ := totp.Generate(secret, now.Add(-totp.Period))
+
+		for _, code := range []string{used, earlier} {
+			if err := svc.Verify(context.Background(), u.ID, code); err != ErrInvalidCode {
+				t.Errorf("expected code %s to be rejected, got %v", code, err)
+			}
+		}
+	})
+
+	t.Run("accepts recovery codes only once", func(t *testing.T) {
+		svc, repo, u := newTestService(t)
+		_, recoveryCodes := enable(t, svc, u.ID)
+
+		if err := svc.Verify(context.Background(), u.ID, strings.ToUpper(recoveryCodes[3])); err != nil {
+			t.Fail()
+		}
+		if err := svc.Verify(context.Background(), u.ID, recoveryCodes[3]); err != ErrInvalidCode {
+			t.Fail()
+		}
+
+		stored, _ := repo.GetByID(context.Background(), u.ID)
+		if len(stored.TwoFactor.RecoveryCodes) != recoveryCodeCount-1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("accepts recovery codes only once when verified concurrently", func(t *testing.T) {
+		svc, repo, u := newTestService(t)
+		secret, recoveryCodes := enable(t, svc, u.ID)
+
+		// Both verifications read the user before either uses the code.
+		racing := &racingRepository{Repository: repo}
+		racing.reads.Add(2)
+		svc.repo = racing
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- svc.Verify(context.Background(), u.ID, recoveryCodes[3])
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		accepted := 0
+		for err := range errs {
+			if err == nil {
+				accepted++
+			}
+		}
+		if accepted != 1 {
+			t.Errorf("expected the code to be accepted once, was accepted %d times", accepted)
+		}
+
+		stored, _ := repo.GetByID(context.Background(), u.ID)
+		if len(stored.TwoFactor.RecoveryCodes) != recoveryCodeCount-1 {
+			t.Fail()
+		}
+
+		// Using a recovery code leaves the last one-time password used alone.
+		svc.repo = repo
+		code, _ := totp.Generate(secret, now)
+		if err := svc.Verify(context.Background(), u.ID, code); err != ErrInvalidCode {
+			t.Fail()
+		}
+	})
+}
+
+// racingRepository holds back readers of users until the expected number of
+// them have read, so that they all act on the same version of the user.
+type racingRepository struct {
+	user.Repository
+	reads sync.WaitGroup
+}
+
+func (repo *racingRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	u, err := repo.Repository.GetByID(ctx, id)
+
+	repo.reads.Done()
+	repo.reads.Wait()
+
+	return u, err
+}
+
+func TestServiceDisabling(t *testing.T) {
+	t.Run("fails when code is invalid", func(t *testing.T) {
+		svc, _, u := newTestService(t)
+		enable(t, svc, u.ID)
+
+		if err := svc.Disable(context.Background(), u.ID, "not-a-code"); err != ErrInvalidCode {
+			t.Fail()
+		}
+	})
+
+	t.Run("clears the secret and recovery codes", func(t *testing.T) {
+		svc, repo, u := newTestService(t)
+		secret, _ := enable(t, svc, u.ID)
+
+		code, _ := totp.Generate(secret, now.Add(totp.Period))
+
+		if err := svc.Disable(context.Background(), u.ID, code); err != nil {
+			t.FailNow()
+		}
+
+		stored, _ := repo.GetByID(context.Background(), u.ID)
+		if stored.TwoFactor.Enabled || stored.TwoFactor.Secret != "" || len(stored.TwoFactor.RecoveryCodes) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+// mockHashService "hashes" by reversing the password, which is enough to
+// tell hashes and plain text apart.
+type mockHashService struct {
+	failOnHashGeneration bool
+}
+
+func (mock *mockHashService) GenerateFromPassword(_ context.Context, password string) (string, error) {
+	if mock.failOnHashGeneration {
+		return "", fmt.Errorf("failed to generate hash from password")
+	}
+
+	return reverse(password), nil
+}
+
+func (mock *mockHashService) MatchPassword(_ context.Context, hash string, password string) bool {
+	return strings.Compare(hash, reverse(password)) == 0
+}
+
+func (mock *mockHashService) NeedsRehash(hash string) bool {
+	return false
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
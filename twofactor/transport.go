@@ -0,0 +1,120 @@
+package twofactor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+)
+
+func MakeHandler(svc Service, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	wrap := endpoint.ChainNamed(middlewares...)
+
+	setupHandler := stmhttp.NewHandler(
+		wrap("twofactor.setup")(makeSetupEndpoint(svc)),
+		decodeSetupRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	enableHandler := stmhttp.NewHandler(
+		wrap("twofactor.enable")(makeEnableEndpoint(svc)),
+		decodeEnableRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	disableHandler := stmhttp.NewHandler(
+		wrap("twofactor.disable")(makeDisableEndpoint(svc)),
+		decodeDisableRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/v1/users/{id}/totp", setupHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/totp/enable", enableHandler).Methods("POST")
+	r.Handle("/v1/users/{id}/totp/disable", disableHandler).Methods("POST")
+
+	return r
+}
+
+func decodeSetupRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	return setupRequest{
+		UserID: id,
+	}, nil
+}
+
+func decodeEnableRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return enableRequest{
+		UserID: id,
+		Code:   body.Code,
+	}, nil
+}
+
+func decodeDisableRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return disableRequest{
+		UserID: id,
+		Code:   body.Code,
+	}, nil
+}
+
+func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeNoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
+	switch err {
+	case ErrInvalidCode:
+		return http.StatusBadRequest
+	case ErrAlreadyEnabled, ErrNotEnabled, ErrNotSetUp:
+		return http.StatusConflict
+	}
+
+	return 0
+})
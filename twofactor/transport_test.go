@@ -0,0 +1,90 @@
+package twofactor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+)
+
+func TestMakingHandler(t *testing.T) {
+	t.Run("returns a handler when all is well", func(t *testing.T) {
+		if handler := MakeHandler(&mockService{}); handler == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("routes requests to set up two-factor authentication", func(t *testing.T) {
+		handler := MakeHandler(&mockService{})
+
+		r := httptest.NewRequest("POST", "/v1/users/"+mockUserID+"/totp", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r.WithContext(userContext()))
+
+		if rr.Code != http.StatusOK {
+			t.Fail()
+		}
+	})
+}
+
+func TestDecodingEnableRequest(t *testing.T) {
+	t.Run("fails when JSON decoder fails", func(t *testing.T) {
+		httpReq := mux.SetURLVars(
+			stmhttptest.NewJSONRequest("POST", "/", "not.json.at.all"),
+			map[string]string{"id": mockUserID},
+		)
+
+		if _, err := decodeEnableRequest(nil, httpReq); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns an enable request when all is well", func(t *testing.T) {
+		httpReq := mux.SetURLVars(
+			stmhttptest.NewJSONRequest("POST", "/", `{"code": "123456"}`),
+			map[string]string{"id": mockUserID},
+		)
+
+		req, err := decodeEnableRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		enableReq := req.(enableRequest)
+		if strings.Compare(mockUserID, enableReq.UserID) != 0 {
+			t.Fail()
+		}
+		if strings.Compare("123456", enableReq.Code) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestEncodingError(t *testing.T) {
+	statuses := map[error]int{
+		auth.ErrUnauthenticated:   http.StatusUnauthorized,
+		auth.ErrForbidden:         http.StatusForbidden,
+		ErrInvalidCode:            http.StatusBadRequest,
+		ErrAlreadyEnabled:         http.StatusConflict,
+		ErrNotEnabled:             http.StatusConflict,
+		ErrNotSetUp:               http.StatusConflict,
+		fmt.Errorf("a bad error"): http.StatusInternalServerError,
+	}
+
+	for err, status := range statuses {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, err)
+
+		if rr.Code != status {
+			t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+		}
+	}
+}
@@ -2,16 +2,38 @@ package user
 
 import (
 	"context"
+	"errors"
 
+	"github.com/leblancjs/stmoosersburg-api/auth"
 	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
 )
 
+// ErrCannotManageSelf is returned when admins try to change their own role or
+// suspend themselves, which could leave no one to manage users.
+var ErrCannotManageSelf = errors.New("admins cannot change their own role or suspend themselves")
+
 type registerUserRequest struct {
 	Username string
 	Email    string
 	Password string
 }
 
+// Validate checks the username, email, and password, so that invalid requests
+// are rejected as bad requests before reaching the service, which checks them
+// again.
+func (req registerUserRequest) Validate() error {
+	if err := validateUsername(req.Username); err != nil {
+		return err
+	}
+
+	if err := validateEmail(req.Email); err != nil {
+		return err
+	}
+
+	return validatePassword(req.Password, req.Username, req.Email)
+}
+
 type registerUserResponse struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -22,7 +44,7 @@ func makeRegisterUserEndpoint(us Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(registerUserRequest)
 
-		u, err := us.Register(req.Username, req.Email, req.Password)
+		u, err := us.Register(ctx, req.Username, req.Email, req.Password)
 		if err != nil {
 			return nil, err
 		}
@@ -39,25 +61,261 @@ type getUserByIDRequest struct {
 	ID string
 }
 
-type getUserByIDResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+// publicUserResponse represents what anyone can see of a user.
+//
+// Stats are only included in profiles, and are left out of search results,
+// which would otherwise look them up for every user found.
+type publicUserResponse struct {
+	ID        string                `json:"id"`
+	Username  string                `json:"username"`
+	AvatarURL string                `json:"avatarUrl,omitempty"`
+	Stats     *profileStatsResponse `json:"stats,omitempty"`
+}
+
+type profileStatsResponse struct {
+	GamesPlayed     int     `json:"gamesPlayed"`
+	Wins            int     `json:"wins"`
+	AverageNetWorth float64 `json:"averageNetWorth"`
+	CurrentStreak   int     `json:"currentStreak"`
+	LongestStreak   int     `json:"longestStreak"`
+}
+
+// privateUserResponse represents what only the user, and those allowed to
+// view private profiles, can see of a user.
+type privateUserResponse struct {
+	publicUserResponse
+
+	Email            string      `json:"email"`
+	Role             entity.Role `json:"role"`
+	TwoFactorEnabled bool        `json:"twoFactorEnabled"`
+	Suspended        bool        `json:"suspended"`
 }
 
-func makeGetUserByIDEndpoint(us Service) endpoint.Endpoint {
+func newPublicUserResponse(u *entity.User) publicUserResponse {
+	return publicUserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		AvatarURL: u.AvatarURL,
+	}
+}
+
+func newProfileStatsResponse(stats *ProfileStats) *profileStatsResponse {
+	return &profileStatsResponse{
+		GamesPlayed:     stats.GamesPlayed,
+		Wins:            stats.Wins,
+		AverageNetWorth: stats.AverageNetWorth,
+		CurrentStreak:   stats.CurrentStreak,
+		LongestStreak:   stats.LongestStreak,
+	}
+}
+
+func newPrivateUserResponse(u *entity.User) privateUserResponse {
+	return privateUserResponse{
+		publicUserResponse: newPublicUserResponse(u),
+		Email:              u.Email,
+		Role:               u.Role,
+		TwoFactorEnabled:   u.TwoFactor.Enabled,
+		Suspended:          u.Suspended,
+	}
+}
+
+// makeGetUserByIDEndpoint makes an endpoint that gets the profile of a user,
+// along with their stats when a way to get them is given.
+func makeGetUserByIDEndpoint(us Service, stats StatsFunc) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(getUserByIDRequest)
 
-		u, err := us.GetByID(req.ID)
+		u, err := us.GetByID(ctx, req.ID)
 		if err != nil {
 			return nil, err
 		}
 
-		return &getUserByIDResponse{
-			ID:       u.ID,
-			Username: u.Username,
-			Email:    u.Email,
-		}, nil
+		var statsResp *profileStatsResponse
+		if stats != nil {
+			s, err := stats(ctx, u.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			statsResp = newProfileStatsResponse(s)
+		}
+
+		if canViewPrivateProfile(ctx, us, u.ID) {
+			resp := newPrivateUserResponse(u)
+			resp.Stats = statsResp
+			return &resp, nil
+		}
+
+		resp := newPublicUserResponse(u)
+		resp.Stats = statsResp
+		return &resp, nil
+	}
+}
+
+// canViewPrivateProfile tells whether the caller is the user, or is allowed
+// to view the private profile of any user.
+func canViewPrivateProfile(ctx context.Context, us Service, userID string) bool {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	if identity.UserID == userID {
+		return true
+	}
+
+	caller, err := us.GetByID(ctx, identity.UserID)
+	if err != nil {
+		return false
+	}
+
+	return !caller.Suspended && auth.HasPermission(caller.Role, auth.PermissionViewPrivateProfiles)
+}
+
+type searchUsersRequest struct {
+	Query  string
+	Cursor string
+	Limit  int
+}
+
+type searchUsersResponse struct {
+	Users      []publicUserResponse `json:"users"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+func makeSearchUsersEndpoint(us Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(searchUsersRequest)
+
+		result, err := us.Search(ctx, req.Query, req.Cursor, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &searchUsersResponse{
+			Users:      make([]publicUserResponse, 0, len(result.Users)),
+			NextCursor: result.NextCursor,
+		}
+		for i := range result.Users {
+			resp.Users = append(resp.Users, newPublicUserResponse(&result.Users[i]))
+		}
+
+		return resp, nil
 	}
 }
+
+type listUsersRequest struct {
+	Offset int
+	Limit  int
+}
+
+type adminUserResponse struct {
+	ID               string      `json:"id"`
+	Username         string      `json:"username"`
+	Email            string      `json:"email"`
+	Role             entity.Role `json:"role"`
+	Suspended        bool        `json:"suspended"`
+	TwoFactorEnabled bool        `json:"twoFactorEnabled"`
+}
+
+type listUsersResponse struct {
+	Users []adminUserResponse `json:"users"`
+}
+
+func makeListUsersEndpoint(us Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listUsersRequest)
+
+		users, err := us.List(ctx, req.Offset, req.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &listUsersResponse{
+			Users: make([]adminUserResponse, 0, len(users)),
+		}
+		for _, u := range users {
+			resp.Users = append(resp.Users, adminUserResponse{
+				ID:               u.ID,
+				Username:         u.Username,
+				Email:            u.Email,
+				Role:             u.Role,
+				Suspended:        u.Suspended,
+				TwoFactorEnabled: u.TwoFactor.Enabled,
+			})
+		}
+
+		return resp, nil
+	}
+}
+
+type changeRoleRequest struct {
+	ID   string
+	Role entity.Role
+}
+
+func makeChangeRoleEndpoint(us Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changeRoleRequest)
+
+		if isCaller(ctx, req.ID) {
+			return nil, ErrCannotManageSelf
+		}
+
+		if err := us.ChangeRole(ctx, req.ID, req.Role); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+type setSuspendedRequest struct {
+	ID        string
+	Suspended bool
+}
+
+func makeSetSuspendedEndpoint(us Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setSuspendedRequest)
+
+		if isCaller(ctx, req.ID) {
+			return nil, ErrCannotManageSelf
+		}
+
+		if err := us.SetSuspended(ctx, req.ID, req.Suspended); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+type setAvatarRequest struct {
+	ID        string
+	AvatarURL string
+}
+
+func makeSetAvatarEndpoint(us Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setAvatarRequest)
+
+		// Users choose their own avatar, and no one else can, not even
+		// admins.
+		if !isCaller(ctx, req.ID) {
+			return nil, auth.ErrForbidden
+		}
+
+		if err := us.SetAvatar(ctx, req.ID, req.AvatarURL); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func isCaller(ctx context.Context, userID string) bool {
+	identity, ok := auth.FromContext(ctx)
+
+	return ok && identity.UserID == userID
+}
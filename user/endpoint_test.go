@@ -1,10 +1,12 @@
 package user
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/leblancjs/stmoosersburg-api/auth"
 	"github.com/leblancjs/stmoosersburg-api/entity"
 )
 
@@ -54,27 +56,224 @@ func TestGetUserByIDEndpoint(t *testing.T) {
 	}
 
 	t.Run("fails when user service fails", func(t *testing.T) {
-		endpoint := makeGetUserByIDEndpoint(&mockService{failOnGetByID: true})
+		endpoint := makeGetUserByIDEndpoint(&mockService{failOnGetByID: true}, nil)
 
 		if _, err := endpoint(nil, req); err == nil {
 			t.Fail()
 		}
 	})
 
-	t.Run("returns get user by ID response when all is well", func(t *testing.T) {
-		endpoint := makeGetUserByIDEndpoint(&mockService{})
+	t.Run("returns public profile when caller is anonymous", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{}, nil)
 
 		resp, _ := endpoint(nil, req)
-		if resp == nil {
+
+		publicResp, ok := resp.(*publicUserResponse)
+		if !ok {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserID, publicResp.ID) != 0 {
 			t.Fail()
 		}
+	})
+
+	t.Run("returns public profile when caller is another player", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{role: entity.RolePlayer}, nil)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "another.user"})
+
+		resp, _ := endpoint(ctx, req)
+		if _, ok := resp.(*publicUserResponse); !ok {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns private profile when caller is the user", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{role: entity.RolePlayer}, nil)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
 
-		getUserResp, ok := resp.(*getUserByIDResponse)
+		resp, _ := endpoint(ctx, req)
+
+		privateResp, ok := resp.(*privateUserResponse)
 		if !ok {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserEmail, privateResp.Email) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when stats cannot be found", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{}, failingMockStats)
+
+		if _, err := endpoint(nil, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns public profile with stats when given a way to get them", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{}, mockStats)
+
+		resp, _ := endpoint(nil, req)
+
+		publicResp, ok := resp.(*publicUserResponse)
+		if !ok || publicResp.Stats == nil {
+			t.FailNow()
+		}
+		if publicResp.Stats.GamesPlayed != 4 || publicResp.Stats.Wins != 1 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns private profile with stats when caller is the user", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{role: entity.RolePlayer}, mockStats)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
+
+		resp, _ := endpoint(ctx, req)
+
+		privateResp, ok := resp.(*privateUserResponse)
+		if !ok || privateResp.Stats == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns private profile when caller is an admin", func(t *testing.T) {
+		endpoint := makeGetUserByIDEndpoint(&mockService{role: entity.RoleAdmin}, nil)
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "an.admin"})
+
+		resp, _ := endpoint(ctx, req)
+		if _, ok := resp.(*privateUserResponse); !ok {
+			t.Fail()
+		}
+	})
+}
+
+func TestSearchUsersEndpoint(t *testing.T) {
+	req := searchUsersRequest{Query: "moo", Limit: 10}
+
+	t.Run("fails when user service fails", func(t *testing.T) {
+		if _, err := makeSearchUsersEndpoint(&mockService{failOnSearch: true})(nil, req); err == nil {
 			t.Fail()
 		}
+	})
 
-		if strings.Compare(mockUserID, getUserResp.ID) != 0 {
+	t.Run("returns usernames and next cursor when all is well", func(t *testing.T) {
+		resp, err := makeSearchUsersEndpoint(&mockService{})(nil, req)
+		if err != nil {
+			t.FailNow()
+		}
+
+		searchResp := resp.(*searchUsersResponse)
+		if len(searchResp.Users) != 1 || searchResp.NextCursor != "a.cursor" {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserUsername, searchResp.Users[0].Username) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestListUsersEndpoint(t *testing.T) {
+	req := listUsersRequest{Offset: 0, Limit: 10}
+
+	t.Run("fails when user service fails", func(t *testing.T) {
+		if _, err := makeListUsersEndpoint(&mockService{failOnList: true})(nil, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns users with their role and suspension when all is well", func(t *testing.T) {
+		resp, err := makeListUsersEndpoint(&mockService{})(nil, req)
+		if err != nil {
+			t.FailNow()
+		}
+
+		listResp := resp.(*listUsersResponse)
+		if len(listResp.Users) != 1 {
+			t.FailNow()
+		}
+		if listResp.Users[0].Role != entity.RoleAdmin {
+			t.Fail()
+		}
+	})
+}
+
+func TestChangeRoleEndpoint(t *testing.T) {
+	req := changeRoleRequest{ID: "another.user", Role: entity.RoleModerator}
+
+	t.Run("fails when admins change their own role", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "another.user"})
+
+		if _, err := makeChangeRoleEndpoint(&mockService{})(ctx, req); err != ErrCannotManageSelf {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user service fails", func(t *testing.T) {
+		if _, err := makeChangeRoleEndpoint(&mockService{failOnChangeRole: true})(nil, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when all is well", func(t *testing.T) {
+		if _, err := makeChangeRoleEndpoint(&mockService{})(nil, req); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestSetSuspendedEndpoint(t *testing.T) {
+	req := setSuspendedRequest{ID: "another.user", Suspended: true}
+
+	t.Run("fails when admins suspend themselves", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "another.user"})
+
+		if _, err := makeSetSuspendedEndpoint(&mockService{})(ctx, req); err != ErrCannotManageSelf {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user service fails", func(t *testing.T) {
+		if _, err := makeSetSuspendedEndpoint(&mockService{failOnSetSuspended: true})(nil, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when all is well", func(t *testing.T) {
+		if _, err := makeSetSuspendedEndpoint(&mockService{})(nil, req); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestSetAvatarEndpoint(t *testing.T) {
+	req := setAvatarRequest{ID: mockUserID, AvatarURL: "https://cdn.stmoosersburg.com/moose.png"}
+
+	t.Run("fails when caller is anonymous", func(t *testing.T) {
+		if _, err := makeSetAvatarEndpoint(&mockService{})(context.Background(), req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when caller is another user", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: "an.admin"})
+
+		if _, err := makeSetAvatarEndpoint(&mockService{})(ctx, req); err != auth.ErrForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when user service fails", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
+
+		if _, err := makeSetAvatarEndpoint(&mockService{failOnSetAvatar: true})(ctx, req); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("succeeds when caller is the user", func(t *testing.T) {
+		ctx := auth.NewContext(context.Background(), auth.Identity{UserID: mockUserID})
+
+		if _, err := makeSetAvatarEndpoint(&mockService{})(ctx, req); err != nil {
 			t.Fail()
 		}
 	})
@@ -85,15 +284,29 @@ const (
 	mockUserUsername = "Moose"
 	mockUserEmail    = "moose@stmoosersburg.com"
 	mockUserPassword = "P@ssw0rd"
+
+	mockUserTOTPSecret = "an.encrypted.totp.secret"
+	mockUserAvatarURL  = "https://cdn.stmoosersburg.com/moose.png"
+
+	mockIdentityProvider = "moosebook"
+	mockIdentitySubject  = "a.moosebook.subject"
 )
 
 type mockService struct {
-	failOnRegister   bool
-	failOnGetByID    bool
-	failOnGetByEmail bool
+	failOnRegister     bool
+	failOnAuthenticate bool
+	failOnGetByID      bool
+	failOnGetByEmail   bool
+	failOnList         bool
+	failOnChangeRole   bool
+	failOnSetSuspended bool
+	failOnSearch       bool
+	failOnSetAvatar    bool
+
+	role entity.Role
 }
 
-func (mock *mockService) Register(username string, email string, password string) (*entity.User, error) {
+func (mock *mockService) Register(_ context.Context, username string, email string, password string) (*entity.User, error) {
 	if mock.failOnRegister {
 		return nil, fmt.Errorf("failed to register user")
 	}
@@ -106,18 +319,96 @@ func (mock *mockService) Register(username string, email string, password string
 	}, nil
 }
 
-func (mock *mockService) GetByID(id string) (*entity.User, error) {
+func (mock *mockService) Authenticate(_ context.Context, email string, password string) (*entity.User, error) {
+	if mock.failOnAuthenticate {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &entity.User{
+		ID:       mockUserID,
+		Username: mockUserUsername,
+		Email:    email,
+		Password: password,
+	}, nil
+}
+
+func (mock *mockService) AuthenticateWithIdentity(_ context.Context, identity ExternalIdentity) (*entity.User, error) {
+	if mock.failOnAuthenticate {
+		return nil, ErrIdentityConflict
+	}
+
+	return &entity.User{
+		ID:       mockUserID,
+		Username: identity.Username,
+		Email:    identity.Email,
+	}, nil
+}
+
+func (mock *mockService) GetByID(_ context.Context, id string) (*entity.User, error) {
 	if mock.failOnGetByID {
 		return nil, fmt.Errorf("failed to get user by ID")
 	}
 
-	return &entity.User{ID: id}, nil
+	return &entity.User{ID: id, Username: mockUserUsername, Email: mockUserEmail, Role: mock.role}, nil
 }
 
-func (mock *mockService) GetByEmail(email string) (*entity.User, error) {
+func (mock *mockService) GetByEmail(_ context.Context, email string) (*entity.User, error) {
 	if mock.failOnGetByEmail {
 		return nil, fmt.Errorf("failed to get user by email")
 	}
 
 	return &entity.User{Email: email}, nil
 }
+
+func (mock *mockService) List(_ context.Context, offset int, limit int) ([]entity.User, error) {
+	if mock.failOnList {
+		return nil, ErrInvalidPagination
+	}
+
+	return []entity.User{
+		{ID: mockUserID, Username: mockUserUsername, Email: mockUserEmail, Role: entity.RoleAdmin},
+	}, nil
+}
+
+func (mock *mockService) Search(_ context.Context, query string, cursor string, limit int) (*SearchResult, error) {
+	if mock.failOnSearch {
+		return nil, ErrInvalidCursor
+	}
+
+	return &SearchResult{
+		Users:      []entity.User{{ID: mockUserID, Username: mockUserUsername, Email: mockUserEmail}},
+		NextCursor: "a.cursor",
+	}, nil
+}
+
+func (mock *mockService) ChangeRole(_ context.Context, id string, role entity.Role) error {
+	if mock.failOnChangeRole {
+		return ErrInvalidRole
+	}
+
+	return nil
+}
+
+func (mock *mockService) SetSuspended(_ context.Context, id string, suspended bool) error {
+	if mock.failOnSetSuspended {
+		return fmt.Errorf("failed to suspend user")
+	}
+
+	return nil
+}
+
+func (mock *mockService) SetAvatar(_ context.Context, id string, avatarURL string) error {
+	if mock.failOnSetAvatar {
+		return fmt.Errorf("failed to set avatar")
+	}
+
+	return nil
+}
+
+func mockStats(_ context.Context, userID string) (*ProfileStats, error) {
+	return &ProfileStats{GamesPlayed: 4, Wins: 1, AverageNetWorth: 1500}, nil
+}
+
+func failingMockStats(_ context.Context, userID string) (*ProfileStats, error) {
+	return nil, fmt.Errorf("failed to get stats")
+}
@@ -1,7 +1,9 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,15 +17,19 @@ type inMemoryRepository struct {
 }
 
 func NewInMemoryRepository(database *db.InMemory) Repository {
-	return &inMemoryRepository{0, database}
+	return &inMemoryRepository{database: database}
 }
 
-func (repo *inMemoryRepository) Create(username string, email string, password string) (*entity.User, error) {
+func (repo *inMemoryRepository) Create(_ context.Context, username string, email string, password string) (*entity.User, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
 	user := entity.User{
 		ID:       strconv.Itoa(repo.nextID),
 		Username: username,
 		Email:    email,
 		Password: password,
+		Role:     entity.RolePlayer,
 	}
 
 	repo.nextID++
@@ -33,7 +39,15 @@ func (repo *inMemoryRepository) Create(username string, email string, password s
 	return &user, nil
 }
 
-func (repo *inMemoryRepository) GetByID(id string) (*entity.User, error) {
+func (repo *inMemoryRepository) GetByID(_ context.Context, id string) (*entity.User, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	return repo.getByID(id)
+}
+
+// getByID finds the user with the ID, expecting the lock to be held.
+func (repo *inMemoryRepository) getByID(id string) (*entity.User, error) {
 	var user *entity.User
 
 	for _, u := range repo.database.Users {
@@ -44,13 +58,16 @@ func (repo *inMemoryRepository) GetByID(id string) (*entity.User, error) {
 	}
 
 	if user == nil {
-		return nil, fmt.Errorf("user.InMemoryRepository.GetByID: no user exists with ID \"%s\"", id)
+		return nil, ErrNotFound
 	}
 
 	return user, nil
 }
 
-func (repo *inMemoryRepository) GetByEmail(email string) (*entity.User, error) {
+func (repo *inMemoryRepository) GetByEmail(_ context.Context, email string) (*entity.User, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
 	var user *entity.User
 
 	for _, u := range repo.database.Users {
@@ -61,8 +78,219 @@ func (repo *inMemoryRepository) GetByEmail(email string) (*entity.User, error) {
 	}
 
 	if user == nil {
-		return nil, fmt.Errorf("user.InMemoryRepository.GetByEmail: no user exists with email \"%s\"", email)
+		return nil, ErrNotFound
 	}
 
 	return user, nil
 }
+
+func (repo *inMemoryRepository) UpdatePassword(_ context.Context, id string, password string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			repo.database.Users[i].Password = password
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user.InMemoryRepository.UpdatePassword: no user exists with ID \"%s\"", id)
+}
+
+func (repo *inMemoryRepository) UpdateTwoFactor(_ context.Context, id string, twoFactor entity.TwoFactor) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			repo.database.Users[i].TwoFactor = twoFactor
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user.InMemoryRepository.UpdateTwoFactor: no user exists with ID \"%s\"", id)
+}
+
+func (repo *inMemoryRepository) UseTOTPStep(_ context.Context, id string, step uint64) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			if step <= u.TwoFactor.LastUsedStep {
+				return false, nil
+			}
+
+			repo.database.Users[i].TwoFactor.LastUsedStep = step
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("user.InMemoryRepository.UseTOTPStep: no user exists with ID \"%s\"", id)
+}
+
+func (repo *inMemoryRepository) UseRecoveryCode(_ context.Context, id string, hashedCode string) (bool, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			for j, c := range u.TwoFactor.RecoveryCodes {
+				if c != hashedCode {
+					continue
+				}
+
+				remaining := make([]string, 0, len(u.TwoFactor.RecoveryCodes)-1)
+				remaining = append(remaining, u.TwoFactor.RecoveryCodes[:j]...)
+				remaining = append(remaining, u.TwoFactor.RecoveryCodes[j+1:]...)
+
+				repo.database.Users[i].TwoFactor.RecoveryCodes = remaining
+				return true, nil
+			}
+
+			return false, nil
+		}
+	}
+
+	return false, fmt.Errorf("user.InMemoryRepository.UseRecoveryCode: no user exists with ID \"%s\"", id)
+}
+
+func (repo *inMemoryRepository) GetByIdentity(_ context.Context, provider string, subject string) (*entity.User, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for _, identity := range repo.database.LinkedIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			user, err := repo.getByID(identity.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("user.InMemoryRepository.GetByIdentity: %s", err)
+			}
+
+			return user, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (repo *inMemoryRepository) LinkIdentity(_ context.Context, id string, provider string, subject string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	if _, err := repo.getByID(id); err != nil {
+		return fmt.Errorf("user.InMemoryRepository.LinkIdentity: %s", err)
+	}
+
+	for _, identity := range repo.database.LinkedIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return fmt.Errorf(
+				"user.InMemoryRepository.LinkIdentity: identity \"%s\" from \"%s\" is already linked",
+				subject,
+				provider,
+			)
+		}
+	}
+
+	repo.database.LinkedIdentities = append(repo.database.LinkedIdentities, entity.LinkedIdentity{
+		Provider: provider,
+		Subject:  subject,
+		UserID:   id,
+	})
+
+	return nil
+}
+
+func (repo *inMemoryRepository) List(_ context.Context, offset int, limit int) ([]entity.User, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	users := make([]entity.User, len(repo.database.Users))
+	copy(users, repo.database.Users)
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Username != users[j].Username {
+			return users[i].Username < users[j].Username
+		}
+
+		return users[i].ID < users[j].ID
+	})
+
+	if offset >= len(users) {
+		return make([]entity.User, 0), nil
+	}
+	users = users[offset:]
+
+	if limit < len(users) {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+func (repo *inMemoryRepository) Search(_ context.Context, prefix string, after Cursor, limit int) ([]entity.User, error) {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	prefix = strings.ToLower(prefix)
+
+	users := make([]entity.User, 0)
+	for _, u := range repo.database.Users {
+		cursor := CursorOf(u)
+		if strings.HasPrefix(cursor.Username, prefix) && (after.IsZero() || after.Less(cursor)) {
+			users = append(users, u)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return CursorOf(users[i]).Less(CursorOf(users[j]))
+	})
+
+	if limit < len(users) {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+func (repo *inMemoryRepository) UpdateRole(_ context.Context, id string, role entity.Role) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			repo.database.Users[i].Role = role
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (repo *inMemoryRepository) UpdateSuspended(_ context.Context, id string, suspended bool) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			repo.database.Users[i].Suspended = suspended
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (repo *inMemoryRepository) UpdateAvatar(_ context.Context, id string, avatarURL string) error {
+	repo.database.Lock()
+	defer repo.database.Unlock()
+
+	for i, u := range repo.database.Users {
+		if strings.Compare(id, u.ID) == 0 {
+			repo.database.Users[i].AvatarURL = avatarURL
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"testing"
@@ -48,7 +49,7 @@ func TestInMemoryRepositoryCreation(t *testing.T) {
 
 		expectedUserID := strconv.Itoa(repo.nextID)
 
-		user, _ := repo.Create(username, email, password)
+		user, _ := repo.Create(context.Background(), username, email, password)
 
 		if strings.Compare(expectedUserID, user.ID) != 0 {
 			t.Fail()
@@ -62,6 +63,9 @@ func TestInMemoryRepositoryCreation(t *testing.T) {
 		if strings.Compare(password, user.Password) != 0 {
 			t.Fail()
 		}
+		if user.Role != entity.RolePlayer {
+			t.Fail()
+		}
 	})
 
 	t.Run("increments nextID", func(t *testing.T) {
@@ -69,7 +73,7 @@ func TestInMemoryRepositoryCreation(t *testing.T) {
 
 		initialNextID := repo.nextID
 
-		_, _ = repo.Create(username, email, password)
+		_, _ = repo.Create(context.Background(), username, email, password)
 
 		if initialNextID == repo.nextID {
 			t.Fail()
@@ -79,7 +83,7 @@ func TestInMemoryRepositoryCreation(t *testing.T) {
 	t.Run("adds a new user to the database's list of users", func(t *testing.T) {
 		repo, _ := NewInMemoryRepository(database).(*inMemoryRepository)
 
-		expectedUser, _ := repo.Create(username, email, password)
+		expectedUser, _ := repo.Create(context.Background(), username, email, password)
 
 		user := repo.database.Users[0]
 
@@ -106,14 +110,14 @@ func TestInMemoryRepositoryGettingByID(t *testing.T) {
 		database: database,
 	}
 
-	t.Run("returns error when no user is found", func(t *testing.T) {
-		if _, err := repo.GetByID("no.way.this.exists"); err == nil {
+	t.Run("returns not found when no user is found", func(t *testing.T) {
+		if _, err := repo.GetByID(context.Background(), "no.way.this.exists"); err != ErrNotFound {
 			t.Fail()
 		}
 	})
 
 	t.Run("returns user with given ID", func(t *testing.T) {
-		user, _ := repo.GetByID(id)
+		user, _ := repo.GetByID(context.Background(), id)
 		if user == nil {
 			t.FailNow()
 		}
@@ -140,14 +144,14 @@ func TestInMemoryRepositoryGettingByEmail(t *testing.T) {
 		database: database,
 	}
 
-	t.Run("returns error when no user is found", func(t *testing.T) {
-		if _, err := repo.GetByEmail("no.way.this.exists"); err == nil {
+	t.Run("returns not found when no user is found", func(t *testing.T) {
+		if _, err := repo.GetByEmail(context.Background(), "no.way.this.exists"); err != ErrNotFound {
 			t.Fail()
 		}
 	})
 
 	t.Run("returns user with given email", func(t *testing.T) {
-		user, _ := repo.GetByEmail(email)
+		user, _ := repo.GetByEmail(context.Background(), email)
 		if user == nil {
 			t.FailNow()
 		}
@@ -156,3 +160,360 @@ func TestInMemoryRepositoryGettingByEmail(t *testing.T) {
 		}
 	})
 }
+
+func TestInMemoryRepositoryUpdatingPassword(t *testing.T) {
+	user := entity.User{
+		ID:       id,
+		Username: username,
+		Email:    email,
+		Password: password,
+	}
+
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, user)
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	t.Run("returns error when no user is found", func(t *testing.T) {
+		if err := repo.UpdatePassword(context.Background(), "no.way.this.exists", "new.password"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates password of user with given ID", func(t *testing.T) {
+		if err := repo.UpdatePassword(context.Background(), id, "new.password"); err != nil {
+			t.FailNow()
+		}
+		if strings.Compare("new.password", database.Users[0].Password) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryUpdatingTwoFactor(t *testing.T) {
+	user := entity.User{
+		ID:       id,
+		Username: username,
+		Email:    email,
+		Password: password,
+	}
+
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, user)
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	twoFactor := entity.TwoFactor{
+		Enabled:       true,
+		Secret:        "an.encrypted.secret",
+		RecoveryCodes: []string{"a.hashed.recovery.code"},
+	}
+
+	t.Run("returns error when no user is found", func(t *testing.T) {
+		if err := repo.UpdateTwoFactor(context.Background(), "no.way.this.exists", twoFactor); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates two-factor state of user with given ID", func(t *testing.T) {
+		if err := repo.UpdateTwoFactor(context.Background(), id, twoFactor); err != nil {
+			t.FailNow()
+		}
+
+		updated := database.Users[0].TwoFactor
+		if !updated.Enabled {
+			t.Fail()
+		}
+		if strings.Compare(twoFactor.Secret, updated.Secret) != 0 {
+			t.Fail()
+		}
+		if len(updated.RecoveryCodes) != 1 {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryUsingTOTPStep(t *testing.T) {
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, entity.User{ID: id, TwoFactor: entity.TwoFactor{LastUsedStep: 41}})
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	t.Run("returns error when no user is found", func(t *testing.T) {
+		if _, err := repo.UseTOTPStep(context.Background(), "no.way.this.exists", 42); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when the step, or a later one, was already used", func(t *testing.T) {
+		for _, step := range []uint64{40, 41} {
+			if used, err := repo.UseTOTPStep(context.Background(), id, step); err != nil || used {
+				t.Errorf("expected step %d not to be used", step)
+			}
+		}
+	})
+
+	t.Run("records the step when it is used for the first time", func(t *testing.T) {
+		if used, err := repo.UseTOTPStep(context.Background(), id, 42); err != nil || !used {
+			t.FailNow()
+		}
+		if database.Users[0].TwoFactor.LastUsedStep != 42 {
+			t.Fail()
+		}
+		if used, _ := repo.UseTOTPStep(context.Background(), id, 42); used {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryUsingRecoveryCode(t *testing.T) {
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, entity.User{ID: id, TwoFactor: entity.TwoFactor{RecoveryCodes: []string{"a", "b", "c"}}})
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	t.Run("returns error when no user is found", func(t *testing.T) {
+		if _, err := repo.UseRecoveryCode(context.Background(), "no.way.this.exists", "b"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when the user has no such code", func(t *testing.T) {
+		if used, err := repo.UseRecoveryCode(context.Background(), id, "d"); err != nil || used {
+			t.Fail()
+		}
+	})
+
+	t.Run("removes the code when it is used for the first time", func(t *testing.T) {
+		if used, err := repo.UseRecoveryCode(context.Background(), id, "b"); err != nil || !used {
+			t.FailNow()
+		}
+		if codes := database.Users[0].TwoFactor.RecoveryCodes; len(codes) != 2 || codes[0] != "a" || codes[1] != "c" {
+			t.Fail()
+		}
+		if used, _ := repo.UseRecoveryCode(context.Background(), id, "b"); used {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryLinkingIdentity(t *testing.T) {
+	user := entity.User{
+		ID:       id,
+		Username: username,
+		Email:    email,
+	}
+
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, user)
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	t.Run("returns nil when identity is not linked", func(t *testing.T) {
+		user, err := repo.GetByIdentity(context.Background(), "moosebook", "a.subject")
+		if err != nil {
+			t.Fail()
+		}
+		if user != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns error when no user is found", func(t *testing.T) {
+		if err := repo.LinkIdentity(context.Background(), "no.way.this.exists", "moosebook", "a.subject"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the user linked to the identity", func(t *testing.T) {
+		if err := repo.LinkIdentity(context.Background(), id, "moosebook", "a.subject"); err != nil {
+			t.FailNow()
+		}
+
+		user, err := repo.GetByIdentity(context.Background(), "moosebook", "a.subject")
+		if err != nil || user == nil {
+			t.FailNow()
+		}
+		if strings.Compare(id, user.ID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns error when identity is already linked", func(t *testing.T) {
+		if err := repo.LinkIdentity(context.Background(), id, "moosebook", "a.subject"); err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryListingUsers(t *testing.T) {
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(
+		database.Users,
+		entity.User{ID: "1", Username: "Moose"},
+		entity.User{ID: "2", Username: "Elk"},
+		entity.User{ID: "3", Username: "Caribou"},
+	)
+
+	repo := inMemoryRepository{
+		nextID:   4,
+		database: database,
+	}
+
+	t.Run("returns users ordered by username", func(t *testing.T) {
+		users, err := repo.List(context.Background(), 0, 10)
+		if err != nil || len(users) != 3 {
+			t.FailNow()
+		}
+		if users[0].Username != "Caribou" || users[1].Username != "Elk" || users[2].Username != "Moose" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the requested page", func(t *testing.T) {
+		users, err := repo.List(context.Background(), 1, 1)
+		if err != nil || len(users) != 1 {
+			t.FailNow()
+		}
+		if users[0].Username != "Elk" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns no users past the last page", func(t *testing.T) {
+		users, err := repo.List(context.Background(), 3, 10)
+		if err != nil || users == nil || len(users) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryUpdatingRoleAndSuspension(t *testing.T) {
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, entity.User{ID: id, Username: username, Role: entity.RolePlayer})
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	t.Run("returns not found when no user is found", func(t *testing.T) {
+		if err := repo.UpdateRole(context.Background(), "no.way.this.exists", entity.RoleAdmin); err != ErrNotFound {
+			t.Fail()
+		}
+		if err := repo.UpdateSuspended(context.Background(), "no.way.this.exists", true); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates role and suspension of user with given ID", func(t *testing.T) {
+		if err := repo.UpdateRole(context.Background(), id, entity.RoleAdmin); err != nil {
+			t.FailNow()
+		}
+		if err := repo.UpdateSuspended(context.Background(), id, true); err != nil {
+			t.FailNow()
+		}
+		if database.Users[0].Role != entity.RoleAdmin || !database.Users[0].Suspended {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositoryUpdatingAvatar(t *testing.T) {
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(database.Users, entity.User{ID: id, Username: username})
+
+	repo := inMemoryRepository{
+		nextID:   1,
+		database: database,
+	}
+
+	t.Run("returns not found when no user is found", func(t *testing.T) {
+		if err := repo.UpdateAvatar(context.Background(), "no.way.this.exists", mockUserAvatarURL); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates avatar of user with given ID", func(t *testing.T) {
+		if err := repo.UpdateAvatar(context.Background(), id, mockUserAvatarURL); err != nil {
+			t.FailNow()
+		}
+		if database.Users[0].AvatarURL != mockUserAvatarURL {
+			t.Fail()
+		}
+	})
+}
+
+func TestInMemoryRepositorySearchingUsers(t *testing.T) {
+	database := &db.InMemory{}
+	database.Open()
+	database.Users = append(
+		database.Users,
+		entity.User{ID: "1", Username: "Moosey"},
+		entity.User{ID: "2", Username: "Elk"},
+		entity.User{ID: "3", Username: "moose"},
+		entity.User{ID: "4", Username: "MOOSE"},
+	)
+
+	repo := inMemoryRepository{
+		nextID:   5,
+		database: database,
+	}
+
+	t.Run("returns users whose username starts with the prefix, ignoring case", func(t *testing.T) {
+		users, err := repo.Search(context.Background(), "mOo", Cursor{}, 10)
+		if err != nil || len(users) != 3 {
+			t.FailNow()
+		}
+		if users[0].ID != "3" || users[1].ID != "4" || users[2].ID != "1" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns at most limit users", func(t *testing.T) {
+		users, err := repo.Search(context.Background(), "moo", Cursor{}, 2)
+		if err != nil || len(users) != 2 {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns users after the cursor", func(t *testing.T) {
+		users, err := repo.Search(context.Background(), "moo", Cursor{Username: "moose", ID: "3"}, 10)
+		if err != nil || len(users) != 2 {
+			t.FailNow()
+		}
+		if users[0].ID != "4" || users[1].ID != "1" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns everyone when prefix is empty", func(t *testing.T) {
+		users, err := repo.Search(context.Background(), "", Cursor{}, 10)
+		if err != nil || len(users) != 4 {
+			t.Fail()
+		}
+	})
+}
@@ -1,17 +1,42 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
 
 	"github.com/leblancjs/stmoosersburg-api/db"
 	"github.com/leblancjs/stmoosersburg-api/entity"
 )
 
 const (
-	createQueryFormat = "INSERT INTO users(username, email, password) VALUES('%s', '%s', '%s') RETURNING id"
-	getByIDQuery      = "SELECT id, username, email, password FROM users WHERE id = $1"
-	getByEmailQuery   = "SELECT id, username, email, password FROM users WHERE email = $1"
+	createQuery     = "INSERT INTO users(username, email, password, role) VALUES($1, $2, $3, $4) RETURNING id"
+	getByIDQuery    = "SELECT id, username, email, password, totp_enabled, totp_secret, recovery_codes, totp_last_step, role, suspended, avatar_url FROM users WHERE id = $1"
+	getByEmailQuery = "SELECT id, username, email, password, totp_enabled, totp_secret, recovery_codes, totp_last_step, role, suspended, avatar_url FROM users WHERE email = $1"
+
+	updatePasswordQuery  = "UPDATE users SET password = $1 WHERE id = $2"
+	updateTwoFactorQuery = "UPDATE users SET totp_enabled = $1, totp_secret = $2, recovery_codes = $3, totp_last_step = $4 WHERE id = $5"
+	// The step is only moved forward, so that a one-time password cannot be
+	// accepted twice, even by concurrent requests.
+	useTOTPStepQuery     = "UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1"
+	useRecoveryCodeQuery = "UPDATE users SET recovery_codes = array_remove(recovery_codes, $2) WHERE id = $1 AND $2 = ANY(recovery_codes)"
+
+	getByIdentityQuery = "SELECT u.id, u.username, u.email, u.password, u.totp_enabled, u.totp_secret, u.recovery_codes, u.totp_last_step, u.role, u.suspended, u.avatar_url FROM users u INNER JOIN linked_identities li ON li.user_id = u.id WHERE li.provider = $1 AND li.subject = $2"
+	linkIdentityQuery  = "INSERT INTO linked_identities(provider, subject, user_id) VALUES($1, $2, $3)"
+
+	listQuery = "SELECT id, username, email, password, totp_enabled, totp_secret, recovery_codes, totp_last_step, role, suspended, avatar_url FROM users ORDER BY username, id LIMIT $1 OFFSET $2"
+	// Usernames are compared with the "C" collation, byte by byte, so that the
+	// users_username_search_idx index can be used both to match prefixes and
+	// to order results, and so that the order does not depend on the locale.
+	searchQuery      = "SELECT id, username, email, password, totp_enabled, totp_secret, recovery_codes, totp_last_step, role, suspended, avatar_url FROM users WHERE lower(username) COLLATE \"C\" LIKE $1 ORDER BY lower(username) COLLATE \"C\", id LIMIT $2"
+	searchAfterQuery = "SELECT id, username, email, password, totp_enabled, totp_secret, recovery_codes, totp_last_step, role, suspended, avatar_url FROM users WHERE lower(username) COLLATE \"C\" LIKE $1 AND (lower(username) COLLATE \"C\", id) > ($2, $3) ORDER BY lower(username) COLLATE \"C\", id LIMIT $4"
+
+	updateRoleQuery      = "UPDATE users SET role = $1 WHERE id = $2"
+	updateSuspendedQuery = "UPDATE users SET suspended = $1 WHERE id = $2"
+	updateAvatarQuery    = "UPDATE users SET avatar_url = $1 WHERE id = $2"
 )
 
 type postgresRepository struct {
@@ -22,16 +47,15 @@ func NewPostgresRepository(database *db.Postgres) Repository {
 	return &postgresRepository{database}
 }
 
-func (pr *postgresRepository) Create(username string, email string, password string) (*entity.User, error) {
+func (pr *postgresRepository) Create(ctx context.Context, username string, email string, password string) (*entity.User, error) {
 	user := entity.User{
 		Username: username,
 		Email:    email,
 		Password: password,
+		Role:     entity.RolePlayer,
 	}
 
-	query := fmt.Sprintf(createQueryFormat, username, email, password)
-
-	err := pr.database.QueryRow(query).Scan(&user.ID)
+	err := pr.database.QueryRowContext(ctx, createQuery, username, email, password, user.Role).Scan(&user.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf(
@@ -48,17 +72,13 @@ func (pr *postgresRepository) Create(username string, email string, password str
 	return &user, nil
 }
 
-func (pr *postgresRepository) GetByID(id string) (*entity.User, error) {
+func (pr *postgresRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
 	var user entity.User
 
-	err := pr.database.QueryRow(getByIDQuery, id).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	err := scanUser(pr.database.QueryRowContext(ctx, getByIDQuery, id), &user)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf(
-				"user.PostgresRepository.GetByID: no user exists with ID \"%s\"",
-				id,
-			)
+			return nil, ErrNotFound
 		}
 
 		return nil, fmt.Errorf(
@@ -70,17 +90,13 @@ func (pr *postgresRepository) GetByID(id string) (*entity.User, error) {
 	return &user, nil
 }
 
-func (pr *postgresRepository) GetByEmail(email string) (*entity.User, error) {
+func (pr *postgresRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
 
-	err := pr.database.QueryRow(getByEmailQuery, email).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	err := scanUser(pr.database.QueryRowContext(ctx, getByEmailQuery, email), &user)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf(
-				"user.PostgresRepository.GetByEmail: no user exists with email \"%s\"",
-				email,
-			)
+			return nil, ErrNotFound
 		}
 
 		return nil, fmt.Errorf(
@@ -91,3 +107,301 @@ func (pr *postgresRepository) GetByEmail(email string) (*entity.User, error) {
 
 	return &user, nil
 }
+
+func (pr *postgresRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	result, err := pr.database.ExecContext(ctx, updatePasswordQuery, password, id)
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdatePassword: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdatePassword: failed to count updated rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdatePassword: no user exists with ID \"%s\"",
+			id,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) UpdateTwoFactor(ctx context.Context, id string, twoFactor entity.TwoFactor) error {
+	result, err := pr.database.ExecContext(
+		ctx,
+		updateTwoFactorQuery,
+		twoFactor.Enabled,
+		twoFactor.Secret,
+		pq.Array(twoFactor.RecoveryCodes),
+		twoFactor.LastUsedStep,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateTwoFactor: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateTwoFactor: failed to count updated rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateTwoFactor: no user exists with ID \"%s\"",
+			id,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) UseTOTPStep(ctx context.Context, id string, step uint64) (bool, error) {
+	result, err := pr.database.ExecContext(ctx, useTOTPStepQuery, step, id)
+	if err != nil {
+		return false, fmt.Errorf(
+			"user.PostgresRepository.UseTOTPStep: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf(
+			"user.PostgresRepository.UseTOTPStep: failed to count updated rows (%s)",
+			err,
+		)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (pr *postgresRepository) UseRecoveryCode(ctx context.Context, id string, hashedCode string) (bool, error) {
+	result, err := pr.database.ExecContext(ctx, useRecoveryCodeQuery, id, hashedCode)
+	if err != nil {
+		return false, fmt.Errorf(
+			"user.PostgresRepository.UseRecoveryCode: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf(
+			"user.PostgresRepository.UseRecoveryCode: failed to count updated rows (%s)",
+			err,
+		)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (pr *postgresRepository) GetByIdentity(ctx context.Context, provider string, subject string) (*entity.User, error) {
+	var user entity.User
+
+	err := scanUser(pr.database.QueryRowContext(ctx, getByIdentityQuery, provider, subject), &user)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"user.PostgresRepository.GetByIdentity: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return &user, nil
+}
+
+func (pr *postgresRepository) LinkIdentity(ctx context.Context, id string, provider string, subject string) error {
+	_, err := pr.database.ExecContext(ctx, linkIdentityQuery, provider, subject, id)
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.LinkIdentity: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) List(ctx context.Context, offset int, limit int) ([]entity.User, error) {
+	rows, err := pr.database.QueryContext(ctx, listQuery, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"user.PostgresRepository.List: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	users := make([]entity.User, 0)
+	for rows.Next() {
+		var user entity.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, fmt.Errorf(
+				"user.PostgresRepository.List: failed to read user (%s)",
+				err,
+			)
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"user.PostgresRepository.List: failed to read users (%s)",
+			err,
+		)
+	}
+
+	return users, nil
+}
+
+func (pr *postgresRepository) Search(ctx context.Context, prefix string, after Cursor, limit int) ([]entity.User, error) {
+	pattern := likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+
+	var rows *sql.Rows
+	var err error
+	if after.IsZero() {
+		rows, err = pr.database.QueryContext(ctx, searchQuery, pattern, limit)
+	} else {
+		rows, err = pr.database.QueryContext(ctx, searchAfterQuery, pattern, after.Username, after.ID, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(
+			"user.PostgresRepository.Search: failed to execute query (%s)",
+			err,
+		)
+	}
+	defer rows.Close()
+
+	users := make([]entity.User, 0)
+	for rows.Next() {
+		var user entity.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, fmt.Errorf(
+				"user.PostgresRepository.Search: failed to read user (%s)",
+				err,
+			)
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"user.PostgresRepository.Search: failed to read users (%s)",
+			err,
+		)
+	}
+
+	return users, nil
+}
+
+// likeEscaper escapes the characters that have a special meaning in LIKE
+// patterns, so that they are matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (pr *postgresRepository) UpdateRole(ctx context.Context, id string, role entity.Role) error {
+	result, err := pr.database.ExecContext(ctx, updateRoleQuery, role, id)
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateRole: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateRole: failed to count updated rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) UpdateSuspended(ctx context.Context, id string, suspended bool) error {
+	result, err := pr.database.ExecContext(ctx, updateSuspendedQuery, suspended, id)
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateSuspended: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateSuspended: failed to count updated rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (pr *postgresRepository) UpdateAvatar(ctx context.Context, id string, avatarURL string) error {
+	result, err := pr.database.ExecContext(ctx, updateAvatarQuery, avatarURL, id)
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateAvatar: failed to execute query (%s)",
+			err,
+		)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(
+			"user.PostgresRepository.UpdateAvatar: failed to count updated rows (%s)",
+			err,
+		)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// scanner is implemented by both sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row scanner, user *entity.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.TwoFactor.Enabled,
+		&user.TwoFactor.Secret,
+		pq.Array(&user.TwoFactor.RecoveryCodes),
+		&user.TwoFactor.LastUsedStep,
+		&user.Role,
+		&user.Suspended,
+		&user.AvatarURL,
+	)
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 
 	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/entity"
 )
 
 func TestPostgresRepositoryCreation(t *testing.T) {
@@ -30,7 +32,7 @@ func TestPostgresRepositoryCreation(t *testing.T) {
 
 func TestPostgresRepositoryCreatingUser(t *testing.T) {
 	queryResultColumns := []string{"id"}
-	expectedQuery := fmt.Sprintf(createQueryFormat, mockUserUsername, mockUserEmail, mockUserPassword)
+	expectedQuery := createQuery
 
 	t.Run("fails when query returns no rows (user ID can't be retrieved)", func(t *testing.T) {
 		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -44,7 +46,7 @@ func TestPostgresRepositoryCreatingUser(t *testing.T) {
 		mock.ExpectQuery(expectedQuery).
 			WillReturnRows(mock.NewRows(queryResultColumns))
 
-		if _, err := pr.Create(mockUserUsername, mockUserEmail, mockUserPassword); err == nil {
+		if _, err := pr.Create(context.Background(), mockUserUsername, mockUserEmail, mockUserPassword); err == nil {
 			t.Fail()
 		}
 	})
@@ -61,7 +63,7 @@ func TestPostgresRepositoryCreatingUser(t *testing.T) {
 		mock.ExpectQuery(expectedQuery).
 			WillReturnError(fmt.Errorf("an error occurred"))
 
-		if _, err := pr.Create(mockUserUsername, mockUserEmail, mockUserPassword); err == nil {
+		if _, err := pr.Create(context.Background(), mockUserUsername, mockUserEmail, mockUserPassword); err == nil {
 			t.Fail()
 		}
 	})
@@ -76,9 +78,10 @@ func TestPostgresRepositoryCreatingUser(t *testing.T) {
 		pr := NewPostgresRepository(&db.Postgres{DB: database})
 
 		mock.ExpectQuery(expectedQuery).
+			WithArgs(mockUserUsername, mockUserEmail, mockUserPassword, "player").
 			WillReturnRows(sqlmock.NewRows(queryResultColumns).AddRow(mockUserID))
 
-		user, err := pr.Create(mockUserUsername, mockUserEmail, mockUserPassword)
+		user, err := pr.Create(context.Background(), mockUserUsername, mockUserEmail, mockUserPassword)
 		if err != nil {
 			t.FailNow()
 		}
@@ -98,10 +101,10 @@ func TestPostgresRepositoryCreatingUser(t *testing.T) {
 }
 
 func TestPostgresRepositoryGettingUserByID(t *testing.T) {
-	queryResultColumns := []string{"id", "username", "email", "password"}
+	queryResultColumns := []string{"id", "username", "email", "password", "totp_enabled", "totp_secret", "recovery_codes", "totp_last_step", "role", "suspended", "avatar_url"}
 	expectedQuery := getByIDQuery
 
-	t.Run("fails when query returns no rows (no user with given ID exists)", func(t *testing.T) {
+	t.Run("fails with not found when query returns no rows (no user with given ID exists)", func(t *testing.T) {
 		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 		if err != nil {
 			t.Fatalf("failed to open mock database connection (%s)", err)
@@ -114,7 +117,7 @@ func TestPostgresRepositoryGettingUserByID(t *testing.T) {
 			WithArgs(mockUserID).
 			WillReturnRows(mock.NewRows(queryResultColumns))
 
-		if _, err := pr.GetByID(mockUserID); err == nil {
+		if _, err := pr.GetByID(context.Background(), mockUserID); err != ErrNotFound {
 			t.Fail()
 		}
 	})
@@ -131,7 +134,7 @@ func TestPostgresRepositoryGettingUserByID(t *testing.T) {
 		mock.ExpectQuery(expectedQuery).
 			WillReturnError(fmt.Errorf("an error occurred"))
 
-		if _, err := pr.GetByID(mockUserID); err == nil {
+		if _, err := pr.GetByID(context.Background(), mockUserID); err == nil || err == ErrNotFound {
 			t.Fail()
 		}
 	})
@@ -149,10 +152,10 @@ func TestPostgresRepositoryGettingUserByID(t *testing.T) {
 			WithArgs(mockUserID).
 			WillReturnRows(
 				sqlmock.NewRows(queryResultColumns).
-					AddRow(mockUserID, mockUserUsername, mockUserEmail, mockUserPassword),
+					AddRow(mockUserID, mockUserUsername, mockUserEmail, mockUserPassword, true, mockUserTOTPSecret, "{code}", 0, "admin", false, ""),
 			)
 
-		user, err := pr.GetByID(mockUserID)
+		user, err := pr.GetByID(context.Background(), mockUserID)
 		if err != nil {
 			t.FailNow()
 		}
@@ -168,14 +171,26 @@ func TestPostgresRepositoryGettingUserByID(t *testing.T) {
 		if strings.Compare(mockUserPassword, user.Password) != 0 {
 			t.Fail()
 		}
+		if !user.TwoFactor.Enabled {
+			t.Fail()
+		}
+		if strings.Compare(mockUserTOTPSecret, user.TwoFactor.Secret) != 0 {
+			t.Fail()
+		}
+		if len(user.TwoFactor.RecoveryCodes) != 1 {
+			t.Fail()
+		}
+		if user.Role != entity.RoleAdmin {
+			t.Fail()
+		}
 	})
 }
 
 func TestPostgresRepositoryGettingUserByEmail(t *testing.T) {
-	queryResultColumns := []string{"id", "username", "email", "password"}
+	queryResultColumns := []string{"id", "username", "email", "password", "totp_enabled", "totp_secret", "recovery_codes", "totp_last_step", "role", "suspended", "avatar_url"}
 	expectedQuery := getByEmailQuery
 
-	t.Run("fails when query returns no rows (no user with given email exists)", func(t *testing.T) {
+	t.Run("fails with not found when query returns no rows (no user with given email exists)", func(t *testing.T) {
 		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 		if err != nil {
 			t.Fatalf("failed to open mock database connection (%s)", err)
@@ -188,7 +203,7 @@ func TestPostgresRepositoryGettingUserByEmail(t *testing.T) {
 			WithArgs(mockUserEmail).
 			WillReturnRows(mock.NewRows(queryResultColumns))
 
-		if _, err := pr.GetByEmail(mockUserEmail); err == nil {
+		if _, err := pr.GetByEmail(context.Background(), mockUserEmail); err != ErrNotFound {
 			t.Fail()
 		}
 	})
@@ -205,7 +220,7 @@ func TestPostgresRepositoryGettingUserByEmail(t *testing.T) {
 		mock.ExpectQuery(expectedQuery).
 			WillReturnError(fmt.Errorf("an error occurred"))
 
-		if _, err := pr.GetByEmail(mockUserEmail); err == nil {
+		if _, err := pr.GetByEmail(context.Background(), mockUserEmail); err == nil || err == ErrNotFound {
 			t.Fail()
 		}
 	})
@@ -223,10 +238,10 @@ func TestPostgresRepositoryGettingUserByEmail(t *testing.T) {
 			WithArgs(mockUserEmail).
 			WillReturnRows(
 				sqlmock.NewRows(queryResultColumns).
-					AddRow(mockUserID, mockUserUsername, mockUserEmail, mockUserPassword),
+					AddRow(mockUserID, mockUserUsername, mockUserEmail, mockUserPassword, true, mockUserTOTPSecret, "{code}", 0, "admin", false, ""),
 			)
 
-		user, err := pr.GetByEmail(mockUserEmail)
+		user, err := pr.GetByEmail(context.Background(), mockUserEmail)
 		if err != nil {
 			t.FailNow()
 		}
@@ -244,3 +259,676 @@ func TestPostgresRepositoryGettingUserByEmail(t *testing.T) {
 		}
 	})
 }
+
+func TestPostgresRepositoryUpdatingPassword(t *testing.T) {
+	expectedQuery := updatePasswordQuery
+	newPassword := "a.new.hashed.password"
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if err := pr.UpdatePassword(context.Background(), mockUserID, newPassword); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no user exists with the given ID", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(newPassword, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.UpdatePassword(context.Background(), mockUserID, newPassword); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates the password of the user with the given ID when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(newPassword, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := pr.UpdatePassword(context.Background(), mockUserID, newPassword); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUpdatingTwoFactor(t *testing.T) {
+	expectedQuery := updateTwoFactorQuery
+	twoFactor := entity.TwoFactor{
+		Enabled:       true,
+		Secret:        mockUserTOTPSecret,
+		RecoveryCodes: []string{"a.hashed.recovery.code"},
+		LastUsedStep:  41,
+	}
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if err := pr.UpdateTwoFactor(context.Background(), mockUserID, twoFactor); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no user exists with the given ID", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.UpdateTwoFactor(context.Background(), mockUserID, twoFactor); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates the two-factor state of the user with the given ID when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(true, mockUserTOTPSecret, `{"a.hashed.recovery.code"}`, 41, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := pr.UpdateTwoFactor(context.Background(), mockUserID, twoFactor); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUsingTOTPStep(t *testing.T) {
+	expectedQuery := useTOTPStepQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("a terrible error"))
+
+		if _, err := pr.UseTOTPStep(context.Background(), mockUserID, 42); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when the step, or a later one, was already used", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(42, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if used, err := pr.UseTOTPStep(context.Background(), mockUserID, 42); err != nil || used {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns true when the step is used for the first time", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(42, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if used, err := pr.UseTOTPStep(context.Background(), mockUserID, 42); err != nil || !used {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUsingRecoveryCode(t *testing.T) {
+	expectedQuery := useRecoveryCodeQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("a terrible error"))
+
+		if _, err := pr.UseRecoveryCode(context.Background(), mockUserID, "a.hashed.code"); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns false when the code was already used", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(mockUserID, "a.hashed.code").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if used, err := pr.UseRecoveryCode(context.Background(), mockUserID, "a.hashed.code"); err != nil || used {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns true when the code is used for the first time", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(mockUserID, "a.hashed.code").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if used, err := pr.UseRecoveryCode(context.Background(), mockUserID, "a.hashed.code"); err != nil || !used {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryGettingUserByIdentity(t *testing.T) {
+	queryResultColumns := []string{"id", "username", "email", "password", "totp_enabled", "totp_secret", "recovery_codes", "totp_last_step", "role", "suspended", "avatar_url"}
+	expectedQuery := getByIdentityQuery
+
+	t.Run("returns nil when query returns no rows (identity is not linked)", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(mockIdentityProvider, mockIdentitySubject).
+			WillReturnRows(mock.NewRows(queryResultColumns))
+
+		user, err := pr.GetByIdentity(context.Background(), mockIdentityProvider, mockIdentitySubject)
+		if err != nil {
+			t.Fail()
+		}
+		if user != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.GetByIdentity(context.Background(), mockIdentityProvider, mockIdentitySubject); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the user linked to the identity when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(mockIdentityProvider, mockIdentitySubject).
+			WillReturnRows(
+				sqlmock.NewRows(queryResultColumns).
+					AddRow(mockUserID, mockUserUsername, mockUserEmail, "", false, "", "{}", 0, "player", false, ""),
+			)
+
+		user, err := pr.GetByIdentity(context.Background(), mockIdentityProvider, mockIdentitySubject)
+		if err != nil || user == nil {
+			t.FailNow()
+		}
+		if strings.Compare(mockUserID, user.ID) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryLinkingIdentity(t *testing.T) {
+	expectedQuery := linkIdentityQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if err := pr.LinkIdentity(context.Background(), mockUserID, mockIdentityProvider, mockIdentitySubject); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("links the identity to the user with the given ID when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(mockIdentityProvider, mockIdentitySubject, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := pr.LinkIdentity(context.Background(), mockUserID, mockIdentityProvider, mockIdentitySubject); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryListingUsers(t *testing.T) {
+	queryResultColumns := []string{"id", "username", "email", "password", "totp_enabled", "totp_secret", "recovery_codes", "totp_last_step", "role", "suspended", "avatar_url"}
+	expectedQuery := listQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.List(context.Background(), 0, 10); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when a row cannot be read", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(expectedQuery).
+			WillReturnRows(
+				sqlmock.NewRows(queryResultColumns).
+					AddRow(mockUserID, mockUserUsername, mockUserEmail, mockUserPassword, "not.a.boolean", "", "{}", 0, "player", false, ""),
+			)
+
+		if _, err := pr.List(context.Background(), 0, 10); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns the users of the page when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(10, 20).
+			WillReturnRows(
+				sqlmock.NewRows(queryResultColumns).
+					AddRow(mockUserID, mockUserUsername, mockUserEmail, mockUserPassword, false, "", "{}", 0, "player", false, "").
+					AddRow("another.id", "Elk", "elk@stmoosersburg.com", mockUserPassword, false, "", "{}", 0, "moderator", true, "https://cdn.stmoosersburg.com/elk.png"),
+			)
+
+		users, err := pr.List(context.Background(), 20, 10)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(users) != 2 {
+			t.FailNow()
+		}
+		if users[1].Role != entity.RoleModerator || !users[1].Suspended {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositorySearchingUsers(t *testing.T) {
+	queryResultColumns := []string{"id", "username", "email", "password", "totp_enabled", "totp_secret", "recovery_codes", "totp_last_step", "role", "suspended", "avatar_url"}
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(searchQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if _, err := pr.Search(context.Background(), "moo", Cursor{}, 10); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("searches from the start with an escaped pattern when cursor is zero", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(searchQuery).
+			WithArgs(`mo\_o\%%`, 10).
+			WillReturnRows(
+				sqlmock.NewRows(queryResultColumns).
+					AddRow(mockUserID, "Mo_o%", mockUserEmail, mockUserPassword, false, "", "{}", 0, "player", false, ""),
+			)
+
+		users, err := pr.Search(context.Background(), "Mo_o%", Cursor{}, 10)
+		if err != nil || len(users) != 1 {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("searches after the cursor when it is not zero", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectQuery(searchAfterQuery).
+			WithArgs("moo%", "moose", mockUserID, 10).
+			WillReturnRows(sqlmock.NewRows(queryResultColumns))
+
+		users, err := pr.Search(context.Background(), "moo", Cursor{Username: "moose", ID: mockUserID}, 10)
+		if err != nil || users == nil || len(users) != 0 {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUpdatingRole(t *testing.T) {
+	expectedQuery := updateRoleQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if err := pr.UpdateRole(context.Background(), mockUserID, entity.RoleAdmin); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no user exists with the given ID", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.UpdateRole(context.Background(), mockUserID, entity.RoleAdmin); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates the role of the user with the given ID when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs("admin", mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := pr.UpdateRole(context.Background(), mockUserID, entity.RoleAdmin); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUpdatingSuspension(t *testing.T) {
+	expectedQuery := updateSuspendedQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if err := pr.UpdateSuspended(context.Background(), mockUserID, true); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no user exists with the given ID", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.UpdateSuspended(context.Background(), mockUserID, true); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates the suspension of the user with the given ID when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(true, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := pr.UpdateSuspended(context.Background(), mockUserID, true); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestPostgresRepositoryUpdatingAvatar(t *testing.T) {
+	expectedQuery := updateAvatarQuery
+
+	t.Run("fails when query fails", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnError(fmt.Errorf("an error occurred"))
+
+		if err := pr.UpdateAvatar(context.Background(), mockUserID, mockUserAvatarURL); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when no user exists with the given ID", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := pr.UpdateAvatar(context.Background(), mockUserID, mockUserAvatarURL); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("updates the avatar of the user with the given ID when all is well", func(t *testing.T) {
+		database, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("failed to open mock database connection (%s)", err)
+		}
+		defer database.Close()
+
+		pr := NewPostgresRepository(&db.Postgres{DB: database})
+
+		mock.ExpectExec(expectedQuery).
+			WithArgs(mockUserAvatarURL, mockUserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := pr.UpdateAvatar(context.Background(), mockUserID, mockUserAvatarURL); err != nil {
+			t.Fail()
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fail()
+		}
+	})
+}
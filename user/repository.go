@@ -1,16 +1,77 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/leblancjs/stmoosersburg-api/db"
 	"github.com/leblancjs/stmoosersburg-api/entity"
 )
 
+// Cursor represents the position of a user in search results, which is
+// ordered by lower case username, then ID. Its zero value is the start.
+type Cursor struct {
+	Username string `json:"u"`
+	ID       string `json:"i"`
+}
+
+// IsZero tells whether the cursor points at the start of search results.
+func (c Cursor) IsZero() bool {
+	return c.Username == "" && c.ID == ""
+}
+
+// Less tells whether the cursor comes before the other one.
+func (c Cursor) Less(other Cursor) bool {
+	if c.Username != other.Username {
+		return c.Username < other.Username
+	}
+
+	return c.ID < other.ID
+}
+
+// CursorOf returns the position of the user in search results.
+func CursorOf(u entity.User) Cursor {
+	return Cursor{
+		Username: strings.ToLower(u.Username),
+		ID:       u.ID,
+	}
+}
+
 type Repository interface {
-	Create(username string, email string, password string) (*entity.User, error)
-	GetByID(id string) (*entity.User, error)
-	GetByEmail(email string) (*entity.User, error)
+	Create(ctx context.Context, username string, email string, password string) (*entity.User, error)
+	GetByID(ctx context.Context, id string) (*entity.User, error)
+	GetByEmail(ctx context.Context, email string) (*entity.User, error)
+	UpdatePassword(ctx context.Context, id string, password string) error
+	UpdateTwoFactor(ctx context.Context, id string, twoFactor entity.TwoFactor) error
+
+	// UseTOTPStep records that the one-time password of the time step was
+	// accepted, unless one of the same step or of a later one already was, in
+	// which case it returns false.
+	UseTOTPStep(ctx context.Context, id string, step uint64) (bool, error)
+
+	// UseRecoveryCode removes the hashed recovery code from the codes of the
+	// user, unless it was already removed, in which case it returns false.
+	UseRecoveryCode(ctx context.Context, id string, hashedCode string) (bool, error)
+
+	// GetByIdentity returns the user linked to the identity, or nil if the
+	// identity is not linked to any user.
+	GetByIdentity(ctx context.Context, provider string, subject string) (*entity.User, error)
+	LinkIdentity(ctx context.Context, id string, provider string, subject string) error
+
+	// List returns at most limit users, ordered by username, skipping the
+	// first offset ones.
+	List(ctx context.Context, offset int, limit int) ([]entity.User, error)
+	// Search returns at most limit users whose username starts with the
+	// prefix, ignoring case, ordered by their lower case username then ID,
+	// starting after the cursor.
+	Search(ctx context.Context, prefix string, after Cursor, limit int) ([]entity.User, error)
+
+	// UpdateRole, UpdateSuspended, and UpdateAvatar return ErrNotFound when
+	// no user exists with the ID.
+	UpdateRole(ctx context.Context, id string, role entity.Role) error
+	UpdateSuspended(ctx context.Context, id string, suspended bool) error
+	UpdateAvatar(ctx context.Context, id string, avatarURL string) error
 }
 
 func NewRepository(database db.DB) (Repository, error) {
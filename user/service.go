@@ -1,17 +1,112 @@
 package user
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
+	"strings"
 
 	"github.com/leblancjs/stmoosersburg-api/entity"
 	"github.com/leblancjs/stmoosersburg-api/hash"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
+	"github.com/leblancjs/stmoosersburg-api/passwords"
 )
 
+// ErrInvalidCredentials is returned when a user cannot be authenticated,
+// without telling whether the email or the password is wrong.
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrSuspended is returned when a suspended user tries to log in.
+var ErrSuspended = errors.New("user is suspended")
+
+// ErrNotFound is returned when getting or changing a user that does not
+// exist.
+var ErrNotFound = errors.New("user does not exist")
+
+// ErrInvalidRole is returned when changing a user's role to an unknown one.
+var ErrInvalidRole = errors.New("role is invalid")
+
+// ErrInvalidPagination is returned when listing or searching users with a
+// negative offset, or a limit out of bounds.
+var ErrInvalidPagination = pagination.NewError(MaxListLimit)
+
+// ErrInvalidCursor is returned when searching users with a cursor that was
+// not returned by a previous search.
+var ErrInvalidCursor = errors.New("cursor is invalid")
+
+// SearchResult represents a page of users found by a search.
+type SearchResult struct {
+	Users []entity.User
+
+	// NextCursor represents where the next page starts, and is empty when
+	// this page is the last one.
+	NextCursor string
+}
+
+// ErrIdentityConflict is returned when signing in with an external identity
+// whose email belongs to an existing user, but the identity provider has not
+// verified that the email belongs to whoever is signing in.
+var ErrIdentityConflict = errors.New("a user already exists with this email")
+
+// ExternalIdentity represents a user as described by an external identity
+// provider.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+}
+
+// ValidationError is returned when a field is invalid, and lists all the
+// problems found with it, rather than only the first one.
+type ValidationError struct {
+	Field    string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// MaxListLimit is the maximum number of users that can be listed at once.
+const MaxListLimit = 100
+
+// MaxAvatarURLLength is the maximum length of the URL of a user's avatar.
+const MaxAvatarURLLength = 2048
+
+// ProfileStats represents how a user has fared in the games they played, as
+// shown on their public profile.
+type ProfileStats struct {
+	GamesPlayed     int
+	Wins            int
+	AverageNetWorth float64
+	CurrentStreak   int
+	LongestStreak   int
+}
+
+// StatsFunc gets the stats shown on the public profile of the user with the
+// given ID.
+//
+// It lets the package that keeps track of stats, which depends on this one,
+// provide them without this package depending on it in turn.
+type StatsFunc func(ctx context.Context, userID string) (*ProfileStats, error)
+
 type Service interface {
-	Register(username string, email string, password string) (*entity.User, error)
-	GetByID(id string) (*entity.User, error)
-	GetByEmail(email string) (*entity.User, error)
+	Register(ctx context.Context, username string, email string, password string) (*entity.User, error)
+	Authenticate(ctx context.Context, email string, password string) (*entity.User, error)
+	AuthenticateWithIdentity(ctx context.Context, identity ExternalIdentity) (*entity.User, error)
+	GetByID(ctx context.Context, id string) (*entity.User, error)
+	GetByEmail(ctx context.Context, email string) (*entity.User, error)
+	List(ctx context.Context, offset int, limit int) ([]entity.User, error)
+	Search(ctx context.Context, query string, cursor string, limit int) (*SearchResult, error)
+	ChangeRole(ctx context.Context, id string, role entity.Role) error
+	SetSuspended(ctx context.Context, id string, suspended bool) error
+	SetAvatar(ctx context.Context, id string, avatarURL string) error
 }
 
 type service struct {
@@ -34,36 +129,33 @@ func NewService(repo Repository, hashSvc hash.Service) (Service, error) {
 	}, nil
 }
 
-func (svc *service) Register(username string, email string, password string) (*entity.User, error) {
+func (svc *service) Register(ctx context.Context, username string, email string, password string) (*entity.User, error) {
+	// Validation errors are returned as they are, so that callers can tell
+	// which field is invalid.
 	if err := validateUsername(username); err != nil {
-		return nil, fmt.Errorf("user.Service.Register: %s", err)
+		return nil, err
 	}
 
 	if err := validateEmail(email); err != nil {
-		return nil, fmt.Errorf("user.Service.Register: %s", err)
+		return nil, err
 	}
 
-	if err := validatePassword(password); err != nil {
-		return nil, fmt.Errorf("user.Service.Register: %s", err)
+	if err := validatePassword(password, username, email); err != nil {
+		return nil, err
 	}
 
-	// TODO: Fix bug where an SQL error will be interpreted as "no user exists"
-	//
-	// Either return nil upon success when no user is found, or a custom error
-	// that indicates that no user was found or that an SQL error occurred.
-	//
-	// Nil user is probably the better option... let the service here decide
-	// if it's an error or not. The repository just does its job.
-	if _, err := svc.repo.GetByEmail(email); err == nil {
+	if _, err := svc.repo.GetByEmail(ctx, email); err == nil {
 		return nil, fmt.Errorf("user.Service.Register: user already exists with email \"%s\"", email)
+	} else if err != ErrNotFound {
+		return nil, fmt.Errorf("user.Service.Register: %s", err)
 	}
 
-	hashedPassword, err := svc.hashSvc.GenerateFromPassword(password)
+	hashedPassword, err := svc.hashSvc.GenerateFromPassword(ctx, password)
 	if err != nil {
 		return nil, fmt.Errorf("user.Service.Register: failed to hash password (%s)", err)
 	}
 
-	user, err := svc.repo.Create(username, email, hashedPassword)
+	user, err := svc.repo.Create(ctx, username, email, hashedPassword)
 	if err != nil {
 		return nil, fmt.Errorf("user.Service.Register: failed to create user (%s)", err)
 	}
@@ -71,8 +163,104 @@ func (svc *service) Register(username string, email string, password string) (*e
 	return user, nil
 }
 
-func (svc *service) GetByID(id string) (*entity.User, error) {
-	user, err := svc.repo.GetByID(id)
+// Authenticate returns the user with the given email if the password matches.
+//
+// When the user's password hash was generated with an outdated algorithm or
+// outdated parameters, it is replaced by a new one, since it is the only time
+// the password is known.
+func (svc *service) Authenticate(ctx context.Context, email string, password string) (*entity.User, error) {
+	user, err := svc.repo.GetByEmail(ctx, email)
+	if err == ErrNotFound {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user.Service.Authenticate: %s", err)
+	}
+
+	if !svc.hashSvc.MatchPassword(ctx, user.Password, password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Suspension is only revealed to whoever knows the password.
+	if user.Suspended {
+		return nil, ErrSuspended
+	}
+
+	if svc.hashSvc.NeedsRehash(user.Password) {
+		// Failing to upgrade the hash is not a reason to refuse access, since
+		// the old one still works. It will be attempted again next time.
+		if hashedPassword, err := svc.hashSvc.GenerateFromPassword(ctx, password); err == nil {
+			if err := svc.repo.UpdatePassword(ctx, user.ID, hashedPassword); err == nil {
+				user.Password = hashedPassword
+			}
+		}
+	}
+
+	return user, nil
+}
+
+// AuthenticateWithIdentity returns the user linked to the external identity.
+//
+// When the identity is not linked yet, it is linked to the user with the same
+// email, provided the identity provider verified it, or to a new user without
+// a password, who can only sign in with the identity.
+func (svc *service) AuthenticateWithIdentity(ctx context.Context, identity ExternalIdentity) (*entity.User, error) {
+	user, err := svc.repo.GetByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("user.Service.AuthenticateWithIdentity: %s", err)
+	}
+	if user != nil {
+		if user.Suspended {
+			return nil, ErrSuspended
+		}
+
+		return user, nil
+	}
+
+	if err := validateEmail(identity.Email); err != nil {
+		return nil, fmt.Errorf("user.Service.AuthenticateWithIdentity: %s", err)
+	}
+
+	if user, err := svc.repo.GetByEmail(ctx, identity.Email); err == nil {
+		if !identity.EmailVerified {
+			return nil, ErrIdentityConflict
+		}
+
+		if user.Suspended {
+			return nil, ErrSuspended
+		}
+
+		if err := svc.repo.LinkIdentity(ctx, user.ID, identity.Provider, identity.Subject); err != nil {
+			return nil, fmt.Errorf("user.Service.AuthenticateWithIdentity: failed to link identity (%s)", err)
+		}
+
+		return user, nil
+	} else if err != ErrNotFound {
+		return nil, fmt.Errorf("user.Service.AuthenticateWithIdentity: %s", err)
+	}
+
+	username := identity.Username
+	if username == "" {
+		username = identity.Email[:strings.LastIndex(identity.Email, "@")]
+	}
+
+	user, err = svc.repo.Create(ctx, username, identity.Email, "")
+	if err != nil {
+		return nil, fmt.Errorf("user.Service.AuthenticateWithIdentity: failed to create user (%s)", err)
+	}
+
+	if err := svc.repo.LinkIdentity(ctx, user.ID, identity.Provider, identity.Subject); err != nil {
+		return nil, fmt.Errorf("user.Service.AuthenticateWithIdentity: failed to link identity (%s)", err)
+	}
+
+	return user, nil
+}
+
+func (svc *service) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	user, err := svc.repo.GetByID(ctx, id)
+	if err == ErrNotFound {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("user.Service.GetByID: %s", err)
 	}
@@ -80,8 +268,11 @@ func (svc *service) GetByID(id string) (*entity.User, error) {
 	return user, nil
 }
 
-func (svc *service) GetByEmail(email string) (*entity.User, error) {
-	user, err := svc.repo.GetByEmail(email)
+func (svc *service) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	user, err := svc.repo.GetByEmail(ctx, email)
+	if err == ErrNotFound {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("user.Service.GetByEmail: %s", err)
 	}
@@ -89,6 +280,113 @@ func (svc *service) GetByEmail(email string) (*entity.User, error) {
 	return user, nil
 }
 
+func (svc *service) List(ctx context.Context, offset int, limit int) ([]entity.User, error) {
+	if !pagination.Valid(offset, limit, MaxListLimit) {
+		return nil, ErrInvalidPagination
+	}
+
+	users, err := svc.repo.List(ctx, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("user.Service.List: %s", err)
+	}
+
+	return users, nil
+}
+
+// Search returns a page of users whose username starts with the query,
+// ignoring case. The cursor is empty for the first page, or the next cursor
+// of the previous page.
+func (svc *service) Search(ctx context.Context, query string, cursor string, limit int) (*SearchResult, error) {
+	if !pagination.Valid(0, limit, MaxListLimit) {
+		return nil, ErrInvalidPagination
+	}
+
+	var after Cursor
+	if cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if err := json.Unmarshal(decoded, &after); err != nil || after.IsZero() {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	// Asking for one more user than needed tells whether there is a next
+	// page.
+	users, err := svc.repo.Search(ctx, query, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("user.Service.Search: %s", err)
+	}
+
+	result := &SearchResult{Users: users}
+	if len(users) > limit {
+		result.Users = users[:limit]
+
+		encoded, err := json.Marshal(CursorOf(result.Users[limit-1]))
+		if err != nil {
+			return nil, fmt.Errorf("user.Service.Search: failed to encode cursor (%s)", err)
+		}
+		result.NextCursor = base64.RawURLEncoding.EncodeToString(encoded)
+	}
+
+	return result, nil
+}
+
+func (svc *service) ChangeRole(ctx context.Context, id string, role entity.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	if err := svc.repo.UpdateRole(ctx, id, role); err != nil {
+		if err == ErrNotFound {
+			return err
+		}
+
+		return fmt.Errorf("user.Service.ChangeRole: %s", err)
+	}
+
+	return nil
+}
+
+// SetSuspended suspends the user, or lifts their suspension.
+//
+// Suspended users cannot log in, but the access tokens they already have
+// remain valid until they expire, except for endpoints that require
+// permissions.
+func (svc *service) SetSuspended(ctx context.Context, id string, suspended bool) error {
+	if err := svc.repo.UpdateSuspended(ctx, id, suspended); err != nil {
+		if err == ErrNotFound {
+			return err
+		}
+
+		return fmt.Errorf("user.Service.SetSuspended: %s", err)
+	}
+
+	return nil
+}
+
+// SetAvatar changes the URL of the picture the user appears with, or removes
+// it when the URL is empty.
+//
+// The picture is hosted elsewhere, so the URL must be an absolute HTTPS URL,
+// which clients can load without mixed content warnings.
+func (svc *service) SetAvatar(ctx context.Context, id string, avatarURL string) error {
+	if err := validateAvatarURL(avatarURL); err != nil {
+		return err
+	}
+
+	if err := svc.repo.UpdateAvatar(ctx, id, avatarURL); err != nil {
+		if err == ErrNotFound {
+			return err
+		}
+
+		return fmt.Errorf("user.Service.SetAvatar: %s", err)
+	}
+
+	return nil
+}
+
 const (
 	// Credit for emailRegexp goes to Andy Smith.
 	// http://www.regexlib.com/REDetails.aspx?regexp_id=26
@@ -111,11 +409,30 @@ const (
 	passwordAtLeastOneUpperCaseLetterRegexp  = `^.*[A-Z].*$`
 	passwordAtLeastOneDigitRegexp            = `^.*\d.*$`
 	passwordAtLeastOneSpecialCharacterRegexp = `^.*[!@#\$%\^&\*].*$`
+
+	minPersonalInfoLength = 3
 )
 
 func validateUsername(username string) error {
 	if username == "" {
-		return fmt.Errorf("username is required")
+		return &ValidationError{"username", []string{"username is required"}}
+	}
+
+	return nil
+}
+
+func validateAvatarURL(avatarURL string) error {
+	if avatarURL == "" {
+		return nil
+	}
+
+	if len(avatarURL) > MaxAvatarURLLength {
+		return &ValidationError{"avatarUrl", []string{fmt.Sprintf("avatarUrl must be at most %d character(s) long", MaxAvatarURLLength)}}
+	}
+
+	u, err := url.Parse(avatarURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return &ValidationError{"avatarUrl", []string{"avatarUrl must be an absolute HTTPS URL"}}
 	}
 
 	return nil
@@ -123,41 +440,76 @@ func validateUsername(username string) error {
 
 func validateEmail(email string) error {
 	if email == "" {
-		return fmt.Errorf("email is required")
+		return &ValidationError{"email", []string{"email is required"}}
 	}
 
 	matched, _ := regexp.MatchString(emailRegexp, email)
 	if !matched {
-		return fmt.Errorf("email is malformed")
+		return &ValidationError{"email", []string{"email is malformed"}}
 	}
 
 	return nil
 }
 
-func validatePassword(password string) error {
+func validatePassword(password string, username string, email string) error {
 	if password == "" {
-		return fmt.Errorf("password is required")
+		return &ValidationError{"password", []string{"password is required"}}
 	}
 
+	var problems []string
+
 	if len(password) < minPasswordLength {
-		return fmt.Errorf("password must be at least %d character(s) long", minPasswordLength)
+		problems = append(problems, fmt.Sprintf("password must be at least %d character(s) long", minPasswordLength))
 	}
 	matched, _ := regexp.MatchString(passwordAtLeastOneLowerCaseLetterRegexp, password)
 	if !matched {
-		return fmt.Errorf("password is missing lower case letter (a-z)")
+		problems = append(problems, "password is missing lower case letter (a-z)")
 	}
 	matched, _ = regexp.MatchString(passwordAtLeastOneUpperCaseLetterRegexp, password)
 	if !matched {
-		return fmt.Errorf("password is missing upper case letter (A-Z)")
+		problems = append(problems, "password is missing upper case letter (A-Z)")
 	}
 	matched, _ = regexp.MatchString(passwordAtLeastOneDigitRegexp, password)
 	if !matched {
-		return fmt.Errorf("password is missing digit (0-9)")
+		problems = append(problems, "password is missing digit (0-9)")
 	}
 	matched, _ = regexp.MatchString(passwordAtLeastOneSpecialCharacterRegexp, password)
 	if !matched {
-		return fmt.Errorf("password is missing special character")
+		problems = append(problems, "password is missing special character")
+	}
+	if passwords.IsCommon(password) {
+		problems = append(problems, "password is too common")
+	}
+	if containsPersonalInfo(password, username, email) {
+		problems = append(problems, "password cannot contain username or email")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{"password", problems}
 	}
 
 	return nil
 }
+
+// containsPersonalInfo tells whether the password contains the username or
+// the local part of the email, ignoring case.
+//
+// Parts shorter than minPersonalInfoLength are ignored, since they are likely
+// to appear in passwords by chance.
+func containsPersonalInfo(password string, username string, email string) bool {
+	password = strings.ToLower(password)
+
+	localPart := email
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		localPart = email[:i]
+	}
+
+	for _, info := range []string{username, localPart} {
+		info = strings.ToLower(info)
+		if len(info) >= minPersonalInfoLength && strings.Contains(password, info) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,7 +1,9 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/leblancjs/stmoosersburg-api/entity"
@@ -45,25 +47,26 @@ func TestServiceRegistration(t *testing.T) {
 	password := "P@ssw0rd"
 
 	t.Run("fails when username validation fails", func(t *testing.T) {
-		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{})
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
 
-		if _, err := svc.Register("", email, password); err == nil {
+		if _, err := svc.Register(context.Background(), "", email, password); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when email validation fails", func(t *testing.T) {
-		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{})
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
 
-		if _, err := svc.Register(username, "", password); err == nil {
+		if _, err := svc.Register(context.Background(), username, "", password); err == nil {
 			t.Fail()
 		}
 	})
 
-	t.Run("fails when password validation fails", func(t *testing.T) {
-		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{})
+	t.Run("fails with validation error when password validation fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
 
-		if _, err := svc.Register(username, email, ""); err == nil {
+		_, err := svc.Register(context.Background(), username, email, "")
+		if _, ok := err.(*ValidationError); !ok {
 			t.Fail()
 		}
 	})
@@ -71,31 +74,43 @@ func TestServiceRegistration(t *testing.T) {
 	t.Run("fails when user already exists with email", func(t *testing.T) {
 		svc, _ := NewService(&mockRepository{failOnGetByEmail: false}, &mockHashService{})
 
-		if _, err := svc.Register(username, email, password); err == nil {
+		if _, err := svc.Register(context.Background(), username, email, password); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when getting user by email fails, rather than assuming none exists", func(t *testing.T) {
+		repo := &mockRepository{failOnGetByEmail: true}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		if _, err := svc.Register(context.Background(), username, email, password); err == nil {
+			t.Fail()
+		}
+		if repo.createCalled {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when hash generation fails", func(t *testing.T) {
-		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{true, false})
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{failOnHashGeneration: true})
 
-		if _, err := svc.Register(username, email, password); err == nil {
+		if _, err := svc.Register(context.Background(), username, email, password); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when creation in repository fails", func(t *testing.T) {
-		svc, _ := NewService(&mockRepository{failOnGetByEmail: true, failOnCreate: true}, &mockHashService{})
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true, failOnCreate: true}, &mockHashService{})
 
-		if _, err := svc.Register(username, email, password); err == nil {
+		if _, err := svc.Register(context.Background(), username, email, password); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("returns new user when all is well", func(t *testing.T) {
-		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{})
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
 
-		user, err := svc.Register(username, email, password)
+		user, err := svc.Register(context.Background(), username, email, password)
 		if err != nil {
 			t.Fail()
 		}
@@ -105,13 +120,243 @@ func TestServiceRegistration(t *testing.T) {
 	})
 }
 
+func TestServiceAuthentication(t *testing.T) {
+	email := "moose@stmoosersburg.com"
+	password := "P@ssw0rd"
+
+	t.Run("fails with invalid credentials when no user exists with email", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
+
+		if _, err := svc.Authenticate(context.Background(), email, password); err != ErrInvalidCredentials {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with another error when getting user by email fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{})
+
+		if _, err := svc.Authenticate(context.Background(), email, password); err == nil || err == ErrInvalidCredentials {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with invalid credentials when password does not match", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{failOnHashComparison: true})
+
+		if _, err := svc.Authenticate(context.Background(), email, password); err != ErrInvalidCredentials {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns user when password matches", func(t *testing.T) {
+		repo := &mockRepository{}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		user, err := svc.Authenticate(context.Background(), email, password)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(email, user.Email) != 0 {
+			t.Fail()
+		}
+		if repo.updatedPassword != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("rehashes password when hash is outdated", func(t *testing.T) {
+		repo := &mockRepository{}
+		svc, _ := NewService(repo, &mockHashService{needsRehash: true})
+
+		user, err := svc.Authenticate(context.Background(), email, password)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(password, repo.updatedPassword) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(password, user.Password) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with suspended when user is suspended", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{suspended: true}, &mockHashService{})
+
+		if _, err := svc.Authenticate(context.Background(), email, password); err != ErrSuspended {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with invalid credentials rather than suspended when password does not match", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{suspended: true}, &mockHashService{failOnHashComparison: true})
+
+		if _, err := svc.Authenticate(context.Background(), email, password); err != ErrInvalidCredentials {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns user even when rehashing fails", func(t *testing.T) {
+		repo := &mockRepository{failOnUpdatePassword: true}
+		svc, _ := NewService(repo, &mockHashService{needsRehash: true})
+
+		if _, err := svc.Authenticate(context.Background(), email, password); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceAuthenticationWithIdentity(t *testing.T) {
+	identity := ExternalIdentity{
+		Provider:      "moosebook",
+		Subject:       "a.moosebook.subject",
+		Email:         "moose@stmoosersburg.com",
+		EmailVerified: true,
+	}
+
+	t.Run("fails when getting by identity fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnGetByIdentity: true}, &mockHashService{})
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), identity); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns user linked to identity", func(t *testing.T) {
+		repo := &mockRepository{linkedToIdentity: true}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		user, err := svc.AuthenticateWithIdentity(context.Background(), identity)
+		if err != nil || user == nil {
+			t.FailNow()
+		}
+		if repo.linkedUserID != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with suspended when user linked to identity is suspended", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{linkedToIdentity: true, suspended: true}, &mockHashService{})
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), identity); err != ErrSuspended {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with suspended when user with email is suspended", func(t *testing.T) {
+		repo := &mockRepository{suspended: true}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), identity); err != ErrSuspended {
+			t.Fail()
+		}
+		if repo.linkedUserID != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when email is malformed", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
+
+		malformed := identity
+		malformed.Email = "moose"
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), malformed); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with identity conflict when email of existing user is not verified", func(t *testing.T) {
+		repo := &mockRepository{}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		unverified := identity
+		unverified.EmailVerified = false
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), unverified); err != ErrIdentityConflict {
+			t.Fail()
+		}
+		if repo.linkedUserID != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("links identity to existing user when email is verified", func(t *testing.T) {
+		repo := &mockRepository{}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		user, err := svc.AuthenticateWithIdentity(context.Background(), identity)
+		if err != nil {
+			t.FailNow()
+		}
+		if strings.Compare(user.ID, repo.linkedUserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("creates user without password when none exists with email", func(t *testing.T) {
+		repo := &mockRepository{noUserWithEmail: true}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		user, err := svc.AuthenticateWithIdentity(context.Background(), identity)
+		if err != nil {
+			t.FailNow()
+		}
+		if user.Password != "" {
+			t.Fail()
+		}
+		if strings.Compare("moose", user.Username) != 0 {
+			t.Fail()
+		}
+		if strings.Compare(user.ID, repo.linkedUserID) != 0 {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when getting user by email fails, rather than creating one", func(t *testing.T) {
+		repo := &mockRepository{failOnGetByEmail: true}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), identity); err == nil {
+			t.Fail()
+		}
+		if repo.createCalled {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when creation in repository fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true, failOnCreate: true}, &mockHashService{})
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), identity); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when linking identity fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true, failOnLinkIdentity: true}, &mockHashService{})
+
+		if _, err := svc.AuthenticateWithIdentity(context.Background(), identity); err == nil {
+			t.Fail()
+		}
+	})
+}
+
 func TestServiceGettingByID(t *testing.T) {
 	id := "a.very.unique.identifier"
 
 	t.Run("fails when getting from repository fails", func(t *testing.T) {
 		svc, _ := NewService(&mockRepository{failOnGetByID: true}, &mockHashService{})
 
-		if _, err := svc.GetByID(id); err == nil {
+		if _, err := svc.GetByID(context.Background(), id); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with not found when user does not exist", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{userNotFound: true}, &mockHashService{})
+
+		if _, err := svc.GetByID(context.Background(), id); err != ErrNotFound {
 			t.Fail()
 		}
 	})
@@ -119,7 +364,7 @@ func TestServiceGettingByID(t *testing.T) {
 	t.Run("returns user when all is well", func(t *testing.T) {
 		svc, _ := NewService(&mockRepository{}, &mockHashService{})
 
-		user, err := svc.GetByID(id)
+		user, err := svc.GetByID(context.Background(), id)
 		if err != nil {
 			t.Fail()
 		}
@@ -135,7 +380,7 @@ func TestServiceGettingByEmail(t *testing.T) {
 	t.Run("fails when getting from repository fails", func(t *testing.T) {
 		svc, _ := NewService(&mockRepository{failOnGetByEmail: true}, &mockHashService{})
 
-		if _, err := svc.GetByEmail(email); err == nil {
+		if _, err := svc.GetByEmail(context.Background(), email); err == nil {
 			t.Fail()
 		}
 	})
@@ -143,7 +388,7 @@ func TestServiceGettingByEmail(t *testing.T) {
 	t.Run("returns user when all is well", func(t *testing.T) {
 		svc, _ := NewService(&mockRepository{}, &mockHashService{})
 
-		user, err := svc.GetByEmail(email)
+		user, err := svc.GetByEmail(context.Background(), email)
 		if err != nil {
 			t.Fail()
 		}
@@ -153,6 +398,216 @@ func TestServiceGettingByEmail(t *testing.T) {
 	})
 }
 
+func TestServiceListing(t *testing.T) {
+	t.Run("fails with invalid pagination when offset is negative", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		if _, err := svc.List(context.Background(), -1, 10); err != ErrInvalidPagination {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with invalid pagination when limit is out of bounds", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		for _, limit := range []int{0, MaxListLimit + 1} {
+			if _, err := svc.List(context.Background(), 0, limit); err != ErrInvalidPagination {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("fails when listing in repository fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnList: true}, &mockHashService{})
+
+		if _, err := svc.List(context.Background(), 0, 10); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns users when all is well", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		users, err := svc.List(context.Background(), 0, 10)
+		if err != nil || len(users) != 1 {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceSearching(t *testing.T) {
+	results := []entity.User{
+		{ID: "1", Username: "Moose"},
+		{ID: "2", Username: "moosette"},
+		{ID: "3", Username: "Moosey"},
+	}
+
+	t.Run("fails with invalid pagination when limit is out of bounds", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		for _, limit := range []int{0, MaxListLimit + 1} {
+			if _, err := svc.Search(context.Background(), "moose", "", limit); err != ErrInvalidPagination {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("fails with invalid cursor when cursor is malformed", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		for _, cursor := range []string{"not base 64!", "bm90IGpzb24"} {
+			if _, err := svc.Search(context.Background(), "moose", cursor, 10); err != ErrInvalidCursor {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("fails when searching in repository fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnSearch: true}, &mockHashService{})
+
+		if _, err := svc.Search(context.Background(), "moose", "", 10); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns no next cursor on the last page", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{searchResults: results}, &mockHashService{})
+
+		result, err := svc.Search(context.Background(), "moose", "", 3)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(result.Users) != 3 || result.NextCursor != "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a next cursor that continues after the last user of the page", func(t *testing.T) {
+		repo := &mockRepository{searchResults: results}
+		svc, _ := NewService(repo, &mockHashService{})
+
+		result, err := svc.Search(context.Background(), "moose", "", 2)
+		if err != nil {
+			t.FailNow()
+		}
+		if len(result.Users) != 2 || result.NextCursor == "" {
+			t.FailNow()
+		}
+
+		if _, err := svc.Search(context.Background(), "moose", result.NextCursor, 2); err != nil {
+			t.FailNow()
+		}
+		if repo.searchedAfter != (Cursor{Username: "moosette", ID: "2"}) {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceChangingRole(t *testing.T) {
+	t.Run("fails with invalid role when role is unknown", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		if err := svc.ChangeRole(context.Background(), id, "emperor"); err != ErrInvalidRole {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when updating in repository fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnUpdateRole: true}, &mockHashService{})
+
+		if err := svc.ChangeRole(context.Background(), id, entity.RoleModerator); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with not found when user does not exist", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{userNotFound: true}, &mockHashService{})
+
+		if err := svc.ChangeRole(context.Background(), id, entity.RoleModerator); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("changes role when all is well", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		if err := svc.ChangeRole(context.Background(), id, entity.RoleModerator); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceSuspending(t *testing.T) {
+	t.Run("fails when updating in repository fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnUpdateSuspend: true}, &mockHashService{})
+
+		if err := svc.SetSuspended(context.Background(), id, true); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with not found when user does not exist", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{userNotFound: true}, &mockHashService{})
+
+		if err := svc.SetSuspended(context.Background(), id, true); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("suspends user when all is well", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		if err := svc.SetSuspended(context.Background(), id, true); err != nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestServiceSettingAvatar(t *testing.T) {
+	avatarURL := "https://cdn.stmoosersburg.com/moose.png"
+
+	t.Run("fails when avatar URL is invalid", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		for _, invalid := range []string{
+			"http://cdn.stmoosersburg.com/moose.png",
+			"javascript:alert(1)",
+			"/moose.png",
+			"https://cdn.stmoosersburg.com/" + strings.Repeat("m", MaxAvatarURLLength),
+		} {
+			if _, ok := svc.SetAvatar(context.Background(), id, invalid).(*ValidationError); !ok {
+				t.Errorf("expected validation error for \"%s\"", invalid)
+			}
+		}
+	})
+
+	t.Run("fails when updating in repository fails", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{failOnUpdateAvatar: true}, &mockHashService{})
+
+		if err := svc.SetAvatar(context.Background(), id, avatarURL); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails with not found when user does not exist", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{userNotFound: true}, &mockHashService{})
+
+		if err := svc.SetAvatar(context.Background(), id, avatarURL); err != ErrNotFound {
+			t.Fail()
+		}
+	})
+
+	t.Run("sets or removes avatar when all is well", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{}, &mockHashService{})
+
+		for _, valid := range []string{avatarURL, ""} {
+			if err := svc.SetAvatar(context.Background(), id, valid); err != nil {
+				t.Fail()
+			}
+		}
+	})
+}
+
 func TestServiceUsernameValidation(t *testing.T) {
 	t.Run("fails when username is empty", func(t *testing.T) {
 		if err := validateUsername(""); err == nil {
@@ -226,6 +681,9 @@ func TestServiceEmailValidation(t *testing.T) {
 }
 
 func TestServicePasswordValidation(t *testing.T) {
+	username := "Bullwinkle"
+	email := "rocky.squirrel@stmoosersburg.com"
+
 	validPassword := "P@ssw0rd"
 	passwordWithNoLowerCaseLetter := "NOLOWERCASE@1"
 	passwordWithNoUpperCaseLetter := "nouppercase@1"
@@ -234,55 +692,112 @@ func TestServicePasswordValidation(t *testing.T) {
 	passwordTooShort := "To0b@d"
 
 	t.Run("fails when password is empty", func(t *testing.T) {
-		if err := validatePassword(""); err == nil {
+		if err := validatePassword("", username, email); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when password does not have at least one lower case letter", func(t *testing.T) {
-		if err := validatePassword(passwordWithNoLowerCaseLetter); err == nil {
+		if err := validatePassword(passwordWithNoLowerCaseLetter, username, email); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when password does not have at least one upper case letter", func(t *testing.T) {
-		if err := validatePassword(passwordWithNoUpperCaseLetter); err == nil {
+		if err := validatePassword(passwordWithNoUpperCaseLetter, username, email); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when password does not have at least one digit", func(t *testing.T) {
-		if err := validatePassword(passwordWithNoDigit); err == nil {
+		if err := validatePassword(passwordWithNoDigit, username, email); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when password does not have at least one special character", func(t *testing.T) {
-		if err := validatePassword(passwordWithNoSpecialCharacter); err == nil {
+		if err := validatePassword(passwordWithNoSpecialCharacter, username, email); err == nil {
 			t.Fail()
 		}
 	})
 
 	t.Run("fails when password has fewer than 8 characters", func(t *testing.T) {
-		if err := validatePassword(passwordTooShort); err == nil {
+		if err := validatePassword(passwordTooShort, username, email); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when password is common", func(t *testing.T) {
+		if err := validatePassword("Password1!", username, email); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when password contains username", func(t *testing.T) {
+		if err := validatePassword("I<3bullwinkle!", username, email); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("fails when password contains email local part", func(t *testing.T) {
+		if err := validatePassword("Rocky.Squirrel#1", username, email); err == nil {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns all the problems at once", func(t *testing.T) {
+		err := validatePassword("bullwinkle", username, email)
+
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			t.FailNow()
+		}
+		if strings.Compare("password", validationErr.Field) != 0 {
+			t.Fail()
+		}
+		// Missing upper case letter, digit, special character, and contains
+		// username.
+		if len(validationErr.Problems) != 4 {
 			t.Fail()
 		}
 	})
 
 	t.Run("succeeds when password has valid format", func(t *testing.T) {
-		if err := validatePassword(validPassword); err != nil {
+		if err := validatePassword(validPassword, username, email); err != nil {
 			t.Fail()
 		}
 	})
 }
 
 type mockRepository struct {
-	failOnCreate     bool
-	failOnGetByID    bool
-	failOnGetByEmail bool
+	failOnCreate         bool
+	failOnGetByID        bool
+	failOnGetByEmail     bool
+	noUserWithEmail      bool
+	failOnUpdatePassword bool
+	failOnGetByIdentity  bool
+	failOnLinkIdentity   bool
+	failOnList           bool
+	failOnUpdateRole     bool
+	failOnUpdateSuspend  bool
+	failOnUpdateAvatar   bool
+	userNotFound         bool
+	failOnSearch         bool
+
+	linkedToIdentity bool
+	suspended        bool
+	searchResults    []entity.User
+
+	searchedAfter Cursor
+
+	createCalled    bool
+	updatedPassword string
+	linkedUserID    string
 }
 
-func (mock *mockRepository) Create(username, email, password string) (*entity.User, error) {
+func (mock *mockRepository) Create(_ context.Context, username, email, password string) (*entity.User, error) {
+	mock.createCalled = true
+
 	if mock.failOnCreate {
 		return nil, fmt.Errorf("failed to create user")
 	}
@@ -295,10 +810,13 @@ func (mock *mockRepository) Create(username, email, password string) (*entity.Us
 	}, nil
 }
 
-func (mock *mockRepository) GetByID(id string) (*entity.User, error) {
+func (mock *mockRepository) GetByID(_ context.Context, id string) (*entity.User, error) {
 	if mock.failOnGetByID {
 		return nil, fmt.Errorf("failed to get user by ID")
 	}
+	if mock.userNotFound {
+		return nil, ErrNotFound
+	}
 
 	return &entity.User{
 		ID:       id,
@@ -308,25 +826,134 @@ func (mock *mockRepository) GetByID(id string) (*entity.User, error) {
 	}, nil
 }
 
-func (mock *mockRepository) GetByEmail(email string) (*entity.User, error) {
+func (mock *mockRepository) GetByEmail(_ context.Context, email string) (*entity.User, error) {
 	if mock.failOnGetByEmail {
 		return nil, fmt.Errorf("failed to get user by email")
 	}
+	if mock.noUserWithEmail {
+		return nil, ErrNotFound
+	}
 
 	return &entity.User{
-		ID:       id,
-		Username: "username",
-		Email:    email,
-		Password: "P@ssw0rd",
+		ID:        id,
+		Username:  "username",
+		Email:     email,
+		Password:  "P@ssw0rd",
+		Suspended: mock.suspended,
 	}, nil
 }
 
+func (mock *mockRepository) UpdatePassword(_ context.Context, id string, password string) error {
+	if mock.failOnUpdatePassword {
+		return fmt.Errorf("failed to update password")
+	}
+
+	mock.updatedPassword = password
+
+	return nil
+}
+
+func (mock *mockRepository) UpdateTwoFactor(_ context.Context, id string, twoFactor entity.TwoFactor) error {
+	return nil
+}
+
+func (mock *mockRepository) UseTOTPStep(_ context.Context, id string, step uint64) (bool, error) {
+	return true, nil
+}
+
+func (mock *mockRepository) UseRecoveryCode(_ context.Context, id string, hashedCode string) (bool, error) {
+	return true, nil
+}
+
+func (mock *mockRepository) GetByIdentity(_ context.Context, provider string, subject string) (*entity.User, error) {
+	if mock.failOnGetByIdentity {
+		return nil, fmt.Errorf("failed to get user by identity")
+	}
+
+	if !mock.linkedToIdentity {
+		return nil, nil
+	}
+
+	return &entity.User{
+		ID:        id,
+		Username:  "username",
+		Email:     "email@address.com",
+		Suspended: mock.suspended,
+	}, nil
+}
+
+func (mock *mockRepository) LinkIdentity(_ context.Context, id string, provider string, subject string) error {
+	if mock.failOnLinkIdentity {
+		return fmt.Errorf("failed to link identity")
+	}
+
+	mock.linkedUserID = id
+
+	return nil
+}
+
+func (mock *mockRepository) List(_ context.Context, offset int, limit int) ([]entity.User, error) {
+	if mock.failOnList {
+		return nil, fmt.Errorf("failed to list users")
+	}
+
+	return []entity.User{{ID: id, Username: "username", Email: "email@address.com"}}, nil
+}
+
+func (mock *mockRepository) Search(_ context.Context, prefix string, after Cursor, limit int) ([]entity.User, error) {
+	if mock.failOnSearch {
+		return nil, fmt.Errorf("failed to search users")
+	}
+
+	mock.searchedAfter = after
+
+	if limit < len(mock.searchResults) {
+		return mock.searchResults[:limit], nil
+	}
+
+	return mock.searchResults, nil
+}
+
+func (mock *mockRepository) UpdateRole(_ context.Context, id string, role entity.Role) error {
+	if mock.failOnUpdateRole {
+		return fmt.Errorf("failed to update role")
+	}
+	if mock.userNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (mock *mockRepository) UpdateSuspended(_ context.Context, id string, suspended bool) error {
+	if mock.failOnUpdateSuspend {
+		return fmt.Errorf("failed to update suspension")
+	}
+	if mock.userNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (mock *mockRepository) UpdateAvatar(_ context.Context, id string, avatarURL string) error {
+	if mock.failOnUpdateAvatar {
+		return fmt.Errorf("failed to update avatar")
+	}
+	if mock.userNotFound {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 type mockHashService struct {
 	failOnHashGeneration bool
 	failOnHashComparison bool
+	needsRehash          bool
 }
 
-func (mock *mockHashService) GenerateFromPassword(password string) (string, error) {
+func (mock *mockHashService) GenerateFromPassword(_ context.Context, password string) (string, error) {
 	if mock.failOnHashGeneration {
 		return "", fmt.Errorf("failed to generate hash from password")
 	}
@@ -334,6 +961,10 @@ func (mock *mockHashService) GenerateFromPassword(password string) (string, erro
 	return password, nil
 }
 
-func (mock *mockHashService) MatchPassword(hash, password string) bool {
-	return mock.failOnHashComparison
+func (mock *mockHashService) MatchPassword(_ context.Context, hash, password string) bool {
+	return !mock.failOnHashComparison
+}
+
+func (mock *mockHashService) NeedsRehash(hash string) bool {
+	return mock.needsRehash
 }
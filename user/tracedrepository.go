@@ -0,0 +1,159 @@
+package user
+
+import (
+	"context"
+
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/tracing"
+)
+
+type tracedRepository struct {
+	repo Repository
+}
+
+// NewTracedRepository wraps the repository to start a span around each of its
+// calls, such as "user.Repository.Create", as a child of the span carried by
+// the context, so that the queries it runs are nested under it.
+func NewTracedRepository(repo Repository) Repository {
+	return &tracedRepository{repo}
+}
+
+func (tr *tracedRepository) Create(ctx context.Context, username string, email string, password string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.Create")
+	defer span.End()
+
+	user, err := tr.repo.Create(ctx, username, email, password)
+	span.RecordError(err)
+
+	return user, err
+}
+
+func (tr *tracedRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetByID")
+	defer span.End()
+
+	user, err := tr.repo.GetByID(ctx, id)
+	span.RecordError(err)
+
+	return user, err
+}
+
+func (tr *tracedRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetByEmail")
+	defer span.End()
+
+	user, err := tr.repo.GetByEmail(ctx, email)
+	span.RecordError(err)
+
+	return user, err
+}
+
+func (tr *tracedRepository) UpdatePassword(ctx context.Context, id string, password string) error {
+	ctx, span := tracing.Start(ctx, "user.Repository.UpdatePassword")
+	defer span.End()
+
+	err := tr.repo.UpdatePassword(ctx, id, password)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tr *tracedRepository) UpdateTwoFactor(ctx context.Context, id string, twoFactor entity.TwoFactor) error {
+	ctx, span := tracing.Start(ctx, "user.Repository.UpdateTwoFactor")
+	defer span.End()
+
+	err := tr.repo.UpdateTwoFactor(ctx, id, twoFactor)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tr *tracedRepository) UseTOTPStep(ctx context.Context, id string, step uint64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.UseTOTPStep")
+	defer span.End()
+
+	used, err := tr.repo.UseTOTPStep(ctx, id, step)
+	span.RecordError(err)
+
+	return used, err
+}
+
+func (tr *tracedRepository) UseRecoveryCode(ctx context.Context, id string, hashedCode string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.UseRecoveryCode")
+	defer span.End()
+
+	used, err := tr.repo.UseRecoveryCode(ctx, id, hashedCode)
+	span.RecordError(err)
+
+	return used, err
+}
+
+func (tr *tracedRepository) GetByIdentity(ctx context.Context, provider string, subject string) (*entity.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.GetByIdentity", tracing.String("identity.provider", provider))
+	defer span.End()
+
+	user, err := tr.repo.GetByIdentity(ctx, provider, subject)
+	span.RecordError(err)
+
+	return user, err
+}
+
+func (tr *tracedRepository) LinkIdentity(ctx context.Context, id string, provider string, subject string) error {
+	ctx, span := tracing.Start(ctx, "user.Repository.LinkIdentity", tracing.String("identity.provider", provider))
+	defer span.End()
+
+	err := tr.repo.LinkIdentity(ctx, id, provider, subject)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tr *tracedRepository) List(ctx context.Context, offset int, limit int) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.List")
+	defer span.End()
+
+	users, err := tr.repo.List(ctx, offset, limit)
+	span.RecordError(err)
+
+	return users, err
+}
+
+func (tr *tracedRepository) Search(ctx context.Context, prefix string, after Cursor, limit int) ([]entity.User, error) {
+	ctx, span := tracing.Start(ctx, "user.Repository.Search")
+	defer span.End()
+
+	users, err := tr.repo.Search(ctx, prefix, after, limit)
+	span.RecordError(err)
+
+	return users, err
+}
+
+func (tr *tracedRepository) UpdateRole(ctx context.Context, id string, role entity.Role) error {
+	ctx, span := tracing.Start(ctx, "user.Repository.UpdateRole")
+	defer span.End()
+
+	err := tr.repo.UpdateRole(ctx, id, role)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tr *tracedRepository) UpdateSuspended(ctx context.Context, id string, suspended bool) error {
+	ctx, span := tracing.Start(ctx, "user.Repository.UpdateSuspended")
+	defer span.End()
+
+	err := tr.repo.UpdateSuspended(ctx, id, suspended)
+	span.RecordError(err)
+
+	return err
+}
+
+func (tr *tracedRepository) UpdateAvatar(ctx context.Context, id string, avatarURL string) error {
+	ctx, span := tracing.Start(ctx, "user.Repository.UpdateAvatar")
+	defer span.End()
+
+	err := tr.repo.UpdateAvatar(ctx, id, avatarURL)
+	span.RecordError(err)
+
+	return err
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/db"
+	"github.com/leblancjs/stmoosersburg-api/tracing"
+)
+
+type mockExporter struct {
+	spans []tracing.SpanData
+}
+
+func (e *mockExporter) Export(_ context.Context, spans []tracing.SpanData) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTracedRepository(t *testing.T) {
+	t.Run("starts a span around each call, recording failures", func(t *testing.T) {
+		exporter := &mockExporter{}
+		tracer := tracing.NewTracer(exporter, tracing.Config{})
+		ctx, _ := tracer.Start(context.Background(), "POST /v1/users", tracing.SpanKindServer)
+
+		database := &db.InMemory{}
+		database.Open()
+		repo := NewTracedRepository(NewInMemoryRepository(database))
+
+		u, err := repo.Create(ctx, mockUserUsername, mockUserEmail, mockUserPassword)
+		if err != nil {
+			t.FailNow()
+		}
+		repo.GetByID(ctx, u.ID)
+		repo.GetByID(ctx, "moose")
+
+		stopped, stop := context.WithCancel(context.Background())
+		stop()
+		tracer.Run(stopped)
+
+		if len(exporter.spans) != 3 {
+			t.FailNow()
+		}
+		if exporter.spans[0].Name != "user.Repository.Create" || exporter.spans[1].Name != "user.Repository.GetByID" {
+			t.Fail()
+		}
+		if exporter.spans[1].Err != nil || exporter.spans[2].Err == nil {
+			t.Fail()
+		}
+	})
+}
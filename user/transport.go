@@ -5,31 +5,90 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
 	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
 )
 
-func MakeHandler(us Service) http.Handler {
+// MakeHandler makes a handler for the user endpoints. Profiles include the
+// stats returned by the given function, and leave them out when it is nil.
+func MakeHandler(us Service, stats StatsFunc, middlewares ...endpoint.NamedMiddleware) http.Handler {
+	// Middlewares are applied around authorization, so that looking up the
+	// caller is part of what they trace, measure, and log.
+	wrap := endpoint.ChainNamed(middlewares...)
+
 	registerUserHandler := stmhttp.NewHandler(
-		makeRegisterUserEndpoint(us),
+		wrap("user.register")(makeRegisterUserEndpoint(us)),
 		decodeRegisterUserRequest,
 		encodeRegisterUserResponse,
 		encodeError,
 	)
 
 	getUserByIDHandler := stmhttp.NewHandler(
-		makeGetUserByIDEndpoint(us),
+		wrap("user.getByID")(makeGetUserByIDEndpoint(us, stats)),
 		decodeGetUserByIDRequest,
 		encodeResponse,
 		encodeError,
 	)
 
+	// Endpoints that require authentication look up the caller on every
+	// request to check their permissions, and that they are not suspended.
+	authorize := func(permissions ...auth.Permission) endpoint.Middleware {
+		return auth.NewEndpointMiddleware(us.GetByID, permissions...)
+	}
+
+	listUsersHandler := stmhttp.NewHandler(
+		wrap("user.list")(authorize(auth.PermissionListUsers)(makeListUsersEndpoint(us))),
+		decodeListUsersRequest,
+		encodeResponse,
+		encodeError,
+	)
+
+	changeRoleHandler := stmhttp.NewHandler(
+		wrap("user.changeRole")(authorize(auth.PermissionChangeRoles)(makeChangeRoleEndpoint(us))),
+		decodeChangeRoleRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	setSuspendedHandler := stmhttp.NewHandler(
+		wrap("user.setSuspended")(authorize(auth.PermissionSuspendUsers)(makeSetSuspendedEndpoint(us))),
+		decodeSetSuspendedRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	setAvatarHandler := stmhttp.NewHandler(
+		wrap("user.setAvatar")(authorize()(makeSetAvatarEndpoint(us))),
+		decodeSetAvatarRequest,
+		encodeNoContentResponse,
+		encodeError,
+	)
+
+	searchUsersHandler := stmhttp.NewHandler(
+		wrap("user.search")(authorize()(makeSearchUsersEndpoint(us))),
+		decodeSearchUsersRequest,
+		encodeResponse,
+		encodeError,
+	)
+
 	r := mux.NewRouter()
 
 	r.Handle("/v1/users", registerUserHandler).Methods("POST")
+	r.Handle("/v1/users", searchUsersHandler).Methods("GET")
 	r.Handle("/v1/users/{id}", getUserByIDHandler).Methods("GET")
+	r.Handle("/v1/users/{id}/avatar", setAvatarHandler).Methods("PUT")
+
+	r.Handle("/v1/admin/users", listUsersHandler).Methods("GET")
+	r.Handle("/v1/admin/users/{id}/role", changeRoleHandler).Methods("PUT")
+	r.Handle("/v1/admin/users/{id}/suspension", setSuspendedHandler).Methods("PUT")
 
 	return r
 }
@@ -41,7 +100,7 @@ func decodeRegisterUserRequest(_ context.Context, r *http.Request) (interface{},
 		Password string `json:"password"`
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&body)
+	err := stmhttp.DecodeJSON(r, &body)
 	if err != nil {
 		return nil, err
 	}
@@ -71,20 +130,137 @@ func decodeGetUserByIDRequest(_ context.Context, r *http.Request) (interface{},
 	}, nil
 }
 
+func decodeSearchUsersRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	// Searches are paginated with cursors, so their offset is ignored.
+	_, limit, err := pagination.FromQuery(query, ErrInvalidPagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchUsersRequest{
+		Query:  query.Get("query"),
+		Cursor: query.Get("cursor"),
+		Limit:  limit,
+	}, nil
+}
+
+func decodeListUsersRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	offset, limit, err := pagination.FromQuery(r.URL.Query(), ErrInvalidPagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return listUsersRequest{
+		Offset: offset,
+		Limit:  limit,
+	}, nil
+}
+
+func decodeChangeRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		Role string `json:"role"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return changeRoleRequest{
+		ID:   id,
+		Role: entity.Role(body.Role),
+	}, nil
+}
+
+func decodeSetSuspendedRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		Suspended bool `json:"suspended"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return setSuspendedRequest{
+		ID:        id,
+		Suspended: body.Suspended,
+	}, nil
+}
+
+func decodeSetAvatarRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	id, ok := mux.Vars(r)["id"]
+	if !ok {
+		return nil, fmt.Errorf("bad route")
+	}
+
+	var body struct {
+		AvatarURL string `json:"avatarUrl"`
+	}
+
+	err := stmhttp.DecodeJSON(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return setAvatarRequest{
+		ID:        id,
+		AvatarURL: body.AvatarURL,
+	}, nil
+}
+
+func encodeNoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func encodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
 
-func encodeError(ctx context.Context, w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-
+var encodeError = stmhttp.NewErrorEncoder(func(err error) int {
 	switch err {
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
+	case ErrInvalidRole, ErrInvalidPagination, ErrInvalidCursor:
+		return http.StatusBadRequest
+	case ErrCannotManageSelf:
+		return http.StatusForbidden
+	case ErrNotFound:
+		return http.StatusNotFound
+	}
+
+	if _, ok := err.(*ValidationError); ok {
+		return http.StatusBadRequest
+	}
+
+	return 0
+})
+
+// FieldErrors lists the problems with the field, so that responses to
+// requests that fail validation tell callers about all of them.
+//
+// Problems start with the name of the field, which is left out, like it is
+// for the problems found when decoding requests.
+func (e *ValidationError) FieldErrors() []problem.FieldError {
+	errs := make([]problem.FieldError, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		errs = append(errs, problem.FieldError{
+			Field:   e.Field,
+			Problem: strings.TrimPrefix(p, e.Field+" "),
+		})
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
+	return errs
 }
@@ -2,22 +2,99 @@ package user
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/leblancjs/stmoosersburg-api/auth"
+	"github.com/leblancjs/stmoosersburg-api/endpoint"
+	"github.com/leblancjs/stmoosersburg-api/entity"
+	"github.com/leblancjs/stmoosersburg-api/pagination"
+	"github.com/leblancjs/stmoosersburg-api/ratelimit"
+	stmhttp "github.com/leblancjs/stmoosersburg-api/transport/http"
+	stmhttptest "github.com/leblancjs/stmoosersburg-api/transport/http/httptest"
+	"github.com/leblancjs/stmoosersburg-api/transport/http/problem"
 )
 
 func TestMakingHandler(t *testing.T) {
 	t.Run("returns a handler when all is well", func(t *testing.T) {
-		handler := MakeHandler(&mockService{})
+		handler := MakeHandler(&mockService{}, nil)
 		if handler == nil {
 			t.Fail()
 		}
 	})
 }
 
+func TestRegisteringUserThroughHandler(t *testing.T) {
+	t.Run("applies middlewares to endpoints with their names", func(t *testing.T) {
+		var names []string
+		handler := MakeHandler(&mockService{}, nil, func(name string) endpoint.Middleware {
+			names = append(names, name)
+			return func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+		})
+		if handler == nil || !strings.Contains(strings.Join(names, ","), "user.register") {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with bad request when validated request is invalid", func(t *testing.T) {
+		body := `{"username": "Moose", "email": "not an email", "password": "P@ssw0rd"}`
+
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, nil, endpoint.Unnamed(endpoint.Validate())).ServeHTTP(rr, stmhttptest.NewJSONRequest("POST", "/v1/users", body))
+
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "email is malformed") {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with bad request when service rejects request without validation middleware", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{noUserWithEmail: true}, &mockHashService{})
+		body := `{"username": "Moose", "email": "moose@stmoosersburg.com", "password": "weak"}`
+
+		rr := httptest.NewRecorder()
+		MakeHandler(svc, nil).ServeHTTP(rr, stmhttptest.NewJSONRequest("POST", "/v1/users", body))
+
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"field":"password"`) {
+			t.Errorf("expected bad request, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestRegisteringUserWithInvalidBody(t *testing.T) {
+	for name, test := range map[string]struct {
+		r      *http.Request
+		status int
+	}{
+		"unsupported media type when body is not JSON": {
+			httptest.NewRequest("POST", "/v1/users", strings.NewReader(`username=Moose`)),
+			http.StatusUnsupportedMediaType,
+		},
+		"bad request when body has unknown fields": {
+			stmhttptest.NewJSONRequest("POST", "/v1/users", `{"username": "Moose", "admin": true}`),
+			http.StatusBadRequest,
+		},
+		"request entity too large when body is too large": {
+			stmhttptest.NewJSONRequest("POST", "/v1/users", `{"username": "`+strings.Repeat("m", stmhttp.DefaultMaxBodySize)+`"}`),
+			http.StatusRequestEntityTooLarge,
+		},
+	} {
+		test := test
+		t.Run("responds with "+name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			MakeHandler(&mockService{}, nil).ServeHTTP(rr, test.r)
+
+			if rr.Code != test.status {
+				t.Errorf("expected status %d, got %d", test.status, rr.Code)
+			}
+		})
+	}
+}
+
 func TestDecodingRegisterUserRequest(t *testing.T) {
 	username := "Moose"
 	email := "moose@stmoosersburg.com"
@@ -29,6 +106,7 @@ func TestDecodingRegisterUserRequest(t *testing.T) {
 			"/users",
 			bytes.NewBuffer([]byte("not.json.at.all")),
 		)
+		httpReq.Header.Set("Content-Type", "application/json")
 
 		if _, err := decodeRegisterUserRequest(nil, httpReq); err == nil {
 			t.Fail()
@@ -50,6 +128,7 @@ func TestDecodingRegisterUserRequest(t *testing.T) {
 				password,
 			))),
 		)
+		httpReq.Header.Set("Content-Type", "application/json")
 
 		req, err := decodeRegisterUserRequest(nil, httpReq)
 		if err != nil {
@@ -75,6 +154,241 @@ func TestDecodingRegisterUserRequest(t *testing.T) {
 	})
 }
 
+func TestDecodingListUsersRequest(t *testing.T) {
+	t.Run("fails with invalid pagination when offset or limit is not a number", func(t *testing.T) {
+		for _, query := range []string{"offset=one", "limit=ten"} {
+			httpReq := httptest.NewRequest("GET", "/v1/admin/users?"+query, nil)
+
+			if _, err := decodeListUsersRequest(nil, httpReq); err != ErrInvalidPagination {
+				t.Fail()
+			}
+		}
+	})
+
+	t.Run("uses the default limit when none is given", func(t *testing.T) {
+		httpReq := httptest.NewRequest("GET", "/v1/admin/users", nil)
+
+		req, err := decodeListUsersRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		listReq := req.(listUsersRequest)
+		if listReq.Offset != 0 || listReq.Limit != pagination.DefaultLimit {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a list users request when all is well", func(t *testing.T) {
+		httpReq := httptest.NewRequest("GET", "/v1/admin/users?offset=40&limit=20", nil)
+
+		req, err := decodeListUsersRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		listReq := req.(listUsersRequest)
+		if listReq.Offset != 40 || listReq.Limit != 20 {
+			t.Fail()
+		}
+	})
+}
+
+func TestDecodingSearchUsersRequest(t *testing.T) {
+	t.Run("fails with invalid pagination when limit is not a number", func(t *testing.T) {
+		httpReq := httptest.NewRequest("GET", "/v1/users?limit=ten", nil)
+
+		if _, err := decodeSearchUsersRequest(nil, httpReq); err != ErrInvalidPagination {
+			t.Fail()
+		}
+	})
+
+	t.Run("returns a search users request when all is well", func(t *testing.T) {
+		httpReq := httptest.NewRequest("GET", "/v1/users?query=moo&cursor=a.cursor&limit=5", nil)
+
+		req, err := decodeSearchUsersRequest(nil, httpReq)
+		if err != nil {
+			t.FailNow()
+		}
+
+		searchReq := req.(searchUsersRequest)
+		if searchReq.Query != "moo" || searchReq.Cursor != "a.cursor" || searchReq.Limit != 5 {
+			t.Fail()
+		}
+	})
+}
+
+func TestSearchingUsersThroughHandler(t *testing.T) {
+	t.Run("responds with unauthorized when caller is anonymous", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, nil).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/users?query=moo", nil))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with users without their email when caller is authenticated", func(t *testing.T) {
+		httpReq := httptest.NewRequest("GET", "/v1/users?query=moo", nil)
+		httpReq = httpReq.WithContext(auth.NewContext(httpReq.Context(), auth.Identity{UserID: mockUserID}))
+
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{role: entity.RolePlayer}, nil).ServeHTTP(rr, httpReq)
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+		if strings.Contains(rr.Body.String(), mockUserEmail) {
+			t.Fail()
+		}
+	})
+}
+
+func TestGettingUserThroughHandler(t *testing.T) {
+	t.Run("responds with public profile without email to anonymous callers", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, nil).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/users/"+mockUserID, nil))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+		if strings.Contains(rr.Body.String(), "email") {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with private profile with email to the user", func(t *testing.T) {
+		httpReq := httptest.NewRequest("GET", "/v1/users/"+mockUserID, nil)
+		httpReq = httpReq.WithContext(auth.NewContext(httpReq.Context(), auth.Identity{UserID: mockUserID}))
+
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, nil).ServeHTTP(rr, httpReq)
+
+		if !strings.Contains(rr.Body.String(), `"email":"`+mockUserEmail+`"`) {
+			t.Fail()
+		}
+		if !strings.Contains(rr.Body.String(), `"username":"`+mockUserUsername+`"`) {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with not found when user does not exist", func(t *testing.T) {
+		svc, _ := NewService(&mockRepository{userNotFound: true}, &mockHashService{})
+
+		rr := httptest.NewRecorder()
+		MakeHandler(svc, nil).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/users/no.way.this.exists", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected not found, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestGettingUserWithStatsThroughHandler(t *testing.T) {
+	t.Run("responds with public profile with avatar and stats", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, mockStats).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/users/"+mockUserID, nil))
+
+		if rr.Code != http.StatusOK {
+			t.FailNow()
+		}
+		if !strings.Contains(rr.Body.String(), `"stats":{"gamesPlayed":4,"wins":1,"averageNetWorth":1500`) {
+			t.Errorf("expected stats, got %s", rr.Body.String())
+		}
+	})
+}
+
+func TestSettingAvatarThroughHandler(t *testing.T) {
+	body := `{"avatarUrl": "https://cdn.stmoosersburg.com/moose.png"}`
+
+	as := func(r *http.Request, userID string) *http.Request {
+		return r.WithContext(auth.NewContext(r.Context(), auth.Identity{UserID: userID}))
+	}
+
+	t.Run("responds with unauthorized when caller is anonymous", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, nil).ServeHTTP(rr, stmhttptest.NewJSONRequest("PUT", "/v1/users/"+mockUserID+"/avatar", body))
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with forbidden when caller is another user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{role: entity.RoleAdmin}, nil).ServeHTTP(rr, as(stmhttptest.NewJSONRequest("PUT", "/v1/users/another.user/avatar", body), mockUserID))
+
+		if rr.Code != http.StatusForbidden {
+			t.Fail()
+		}
+	})
+
+	t.Run("responds with no content when caller is the user", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		MakeHandler(&mockService{}, nil).ServeHTTP(rr, as(stmhttptest.NewJSONRequest("PUT", "/v1/users/"+mockUserID+"/avatar", body), mockUserID))
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("expected no content, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestAdminRoutes(t *testing.T) {
+	routes := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/v1/admin/users", "", http.StatusOK},
+		{"PUT", "/v1/admin/users/another.user/role", `{"role": "moderator"}`, http.StatusNoContent},
+		{"PUT", "/v1/admin/users/another.user/suspension", `{"suspended": true}`, http.StatusNoContent},
+	}
+
+	as := func(r *http.Request, userID string) *http.Request {
+		return r.WithContext(auth.NewContext(r.Context(), auth.Identity{UserID: userID}))
+	}
+
+	t.Run("responds with unauthorized when caller is anonymous", func(t *testing.T) {
+		handler := MakeHandler(&mockService{role: entity.RoleAdmin}, nil)
+
+		for _, route := range routes {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, stmhttptest.NewJSONRequest(route.method, route.path, route.body))
+
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("expected status %d for %s %s, got %d", http.StatusUnauthorized, route.method, route.path, rr.Code)
+			}
+		}
+	})
+
+	t.Run("responds with forbidden when caller is not an admin", func(t *testing.T) {
+		handler := MakeHandler(&mockService{role: entity.RoleModerator}, nil)
+
+		for _, route := range routes {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, as(stmhttptest.NewJSONRequest(route.method, route.path, route.body), mockUserID))
+
+			if rr.Code != http.StatusForbidden {
+				t.Errorf("expected status %d for %s %s, got %d", http.StatusForbidden, route.method, route.path, rr.Code)
+			}
+		}
+	})
+
+	t.Run("responds when caller is an admin", func(t *testing.T) {
+		handler := MakeHandler(&mockService{role: entity.RoleAdmin}, nil)
+
+		for _, route := range routes {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, as(stmhttptest.NewJSONRequest(route.method, route.path, route.body), mockUserID))
+
+			if rr.Code != route.status {
+				t.Errorf("expected status %d for %s %s, got %d", route.status, route.method, route.path, rr.Code)
+			}
+		}
+	})
+}
+
 func TestEncodingRegisterUserResponse(t *testing.T) {
 	t.Run("writes HTTP status created when all is well", func(t *testing.T) {
 		rr := httptest.NewRecorder()
@@ -124,10 +438,59 @@ func TestEncodingError(t *testing.T) {
 	t.Run("writes HTTP status internal server error by default", func(t *testing.T) {
 		rr := httptest.NewRecorder()
 
-		encodeError(nil, rr, fmt.Errorf("a terrible error"))
+		encodeError(context.Background(), rr, fmt.Errorf("a terrible error"))
 
 		if rr.Code != http.StatusInternalServerError {
 			t.Fail()
 		}
 	})
+
+	t.Run("writes the HTTP status matching known errors", func(t *testing.T) {
+		statuses := map[error]int{
+			ErrInvalidRole:          http.StatusBadRequest,
+			ErrInvalidPagination:    http.StatusBadRequest,
+			ErrInvalidCursor:        http.StatusBadRequest,
+			auth.ErrUnauthenticated: http.StatusUnauthorized,
+			auth.ErrForbidden:       http.StatusForbidden,
+			ErrCannotManageSelf:     http.StatusForbidden,
+			ErrNotFound:             http.StatusNotFound,
+		}
+
+		for err, status := range statuses {
+			rr := httptest.NewRecorder()
+
+			encodeError(context.Background(), rr, err)
+
+			if rr.Code != status {
+				t.Errorf("expected status %d for \"%s\", got %d", status, err, rr.Code)
+			}
+		}
+	})
+
+	t.Run("writes HTTP status too many requests with retry after when rate limit is exceeded", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, &ratelimit.Error{Result: ratelimit.Result{Limit: 1}})
+
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fail()
+		}
+		if rr.Header().Get("Retry-After") == "" {
+			t.Fail()
+		}
+	})
+
+	t.Run("lists the problems with invalid fields", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		encodeError(context.Background(), rr, &ValidationError{"password", []string{"password is too common", "password is missing digit (0-9)"}})
+
+		var p problem.Problem
+		if err := json.NewDecoder(rr.Body).Decode(&p); err != nil || rr.Code != http.StatusBadRequest {
+			t.FailNow()
+		}
+		if len(p.Errors) != 2 || p.Errors[0] != (problem.FieldError{Field: "password", Problem: "is too common"}) {
+			t.Errorf("unexpected field errors %+v", p.Errors)
+		}
+	})
 }